@@ -0,0 +1,141 @@
+package anomaly
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// Default count of previous points in baseline
+	DefaultWindow = 14
+	// Default min count of previous points which are needed for baseline
+	DefaultMinPoints = 5
+	// Default robust z-score of anomaly
+	DefaultThreshold = 3.5
+)
+
+// Scale of median absolute deviation which makes it consistent with
+// standard deviation of normal distribution
+const madScale = 1.4826
+
+// Observation of time series
+type Point struct {
+	Date  time.Time
+	Value float64
+}
+
+// Point which is far from baseline of previous points
+type Anomaly struct {
+	Point
+	// Median of previous points
+	Baseline float64
+	// Robust z-score of point, distance from baseline in scaled MADs
+	Score float64
+}
+
+// Options of detection, zero fields are replaced by defaults
+type Options struct {
+	// Count of previous points in baseline
+	Window int
+	// Min count of previous points which are needed for baseline
+	MinPoints int
+	// Min absolute score of anomaly
+	Threshold float64
+	// Lower bound of scale, so change of flat series is not infinitely abnormal
+	MinScale float64
+}
+
+// Detect flags points which robust z-score against rolling median and median
+// absolute deviation (MAD) of previous points exceeds threshold. Points should
+// be ordered by date, the first MinPoints points are never flagged
+func Detect(points []Point, opts Options) []Anomaly {
+	opts = withDefaults(opts)
+
+	var anomalies []Anomaly
+	window := make([]float64, 0, opts.Window)
+	for i, p := range points {
+		if i >= opts.MinPoints {
+			window = window[:0]
+			for _, prev := range points[max(0, i-opts.Window):i] {
+				window = append(window, prev.Value)
+			}
+			baseline := Median(window)
+			scale := madScale * MAD(window, baseline)
+			if scale < opts.MinScale {
+				scale = opts.MinScale
+			}
+
+			if scale > 0 {
+				score := (p.Value - baseline) / scale
+				if math.Abs(score) > opts.Threshold {
+					anomalies = append(anomalies, Anomaly{Point: p, Baseline: baseline, Score: score})
+				}
+			}
+		}
+	}
+
+	return anomalies
+}
+
+// Changes returns differences between consecutive points dated by the later point
+func Changes(points []Point) []Point {
+	if len(points) < 2 {
+		return nil
+	}
+	changes := make([]Point, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		changes[i-1] = Point{Date: points[i].Date, Value: points[i].Value - points[i-1].Value}
+	}
+
+	return changes
+}
+
+// Median returns median of values, values are not changed
+func Median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// MAD returns median absolute deviation of values from given median
+func MAD(values []float64, median float64) float64 {
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+
+	return Median(deviations)
+}
+
+func withDefaults(opts Options) Options {
+	if opts.Window <= 0 {
+		opts.Window = DefaultWindow
+	}
+	if opts.MinPoints <= 0 {
+		opts.MinPoints = DefaultMinPoints
+	}
+	if opts.MinPoints > opts.Window {
+		opts.MinPoints = opts.Window
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultThreshold
+	}
+	return opts
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,63 @@
+package anomaly_test
+
+import (
+	"Muromachi/anomaly"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// series returns points of values on consecutive days
+func series(values ...float64) []anomaly.Point {
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	points := make([]anomaly.Point, len(values))
+	for i, v := range values {
+		points[i] = anomaly.Point{Date: day.AddDate(0, 0, i), Value: v}
+	}
+	return points
+}
+
+func TestMedianAndMAD(t *testing.T) {
+	assert.Equal(t, 0.0, anomaly.Median(nil))
+	assert.Equal(t, 3.0, anomaly.Median([]float64{5, 1, 3}))
+	assert.Equal(t, 2.5, anomaly.Median([]float64{4, 1, 3, 2}))
+
+	values := []float64{1, 1, 2, 2, 4, 6, 9}
+	assert.Equal(t, 1.0, anomaly.MAD(values, anomaly.Median(values)))
+}
+
+func TestChanges(t *testing.T) {
+	changes := anomaly.Changes(series(10, 12, 9))
+	assert.Equal(t, []float64{2, -3}, []float64{changes[0].Value, changes[1].Value})
+	assert.Equal(t, 19, changes[0].Date.Day())
+	assert.Nil(t, anomaly.Changes(series(10)))
+}
+
+func TestDetect_ShouldFlagJumpsOfVolatileSeries(t *testing.T) {
+	// Volatile series does not flag usual changes, only a jump which is far from them
+	points := series(2, -3, 1, 4, -2, 3, -1, 2, -4, 40, 1)
+	anomalies := anomaly.Detect(points, anomaly.Options{})
+
+	assert.Len(t, anomalies, 1)
+	assert.Equal(t, 40.0, anomalies[0].Value)
+	assert.Equal(t, points[9].Date, anomalies[0].Date)
+	assert.Equal(t, 1.0, anomalies[0].Baseline)
+	assert.True(t, anomalies[0].Score > anomaly.DefaultThreshold)
+}
+
+func TestDetect_ShouldUseMinScaleForFlatSeries(t *testing.T) {
+	points := series(0, 0, 0, 0, 0, 0, 0.1, -0.5)
+
+	anomalies := anomaly.Detect(points, anomaly.Options{MinScale: 0.05})
+	assert.Len(t, anomalies, 1)
+	assert.Equal(t, -0.5, anomalies[0].Value)
+	assert.Equal(t, -10.0, anomalies[0].Score)
+
+	// Flat series without min scale has not baseline deviation
+	assert.Empty(t, anomaly.Detect(points, anomaly.Options{}))
+}
+
+func TestDetect_ShouldSkipPointsWithoutBaseline(t *testing.T) {
+	assert.Empty(t, anomaly.Detect(series(1, 100, 1, 100), anomaly.Options{MinScale: 1}))
+	assert.Len(t, anomaly.Detect(series(1, 1, 100), anomaly.Options{MinPoints: 2, MinScale: 1}), 1)
+}
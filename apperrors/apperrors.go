@@ -0,0 +1,145 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"net/http"
+)
+
+// Stable machine-readable code of error. Clients can rely on codes,
+// messages may change at any time
+type Code string
+
+const (
+	// Unexpected error of service
+	Internal Code = "internal"
+	// Request has wrong format or wrong values
+	BadRequest Code = "request.invalid"
+	// Too many requests from client
+	RateLimited Code = "request.rate_limited"
+	// Requested resource not found
+	NotFound Code = "resource.not_found"
+	// Resource already exists
+	Conflict Code = "resource.conflict"
+
+	// Request without auth token
+	NotAuthenticated Code = "auth.not_authenticated"
+	// Access token is expired
+	TokenExpired Code = "auth.token_expired"
+	// Access token is malformed or signed with another key
+	TokenInvalid Code = "auth.token_invalid"
+	// Client id or client secret is wrong
+	InvalidCredentials Code = "auth.invalid_credentials"
+	// Refresh session is in blacklist
+	SessionBanned Code = "auth.session_banned"
+	// Refresh session does not exist
+	SessionNotFound Code = "auth.session_not_found"
+	// Refresh session is expired
+	SessionExpired Code = "auth.session_expired"
+	// Client has not access to resource
+	Forbidden Code = "auth.forbidden"
+)
+
+// Http status and short human-readable summary of code
+type description struct {
+	Status int
+	Title  string
+}
+
+var descriptions = map[Code]description{
+	Internal:           {http.StatusInternalServerError, "Internal error"},
+	BadRequest:         {http.StatusBadRequest, "Invalid request"},
+	RateLimited:        {http.StatusTooManyRequests, "Too many requests"},
+	NotFound:           {http.StatusNotFound, "Resource not found"},
+	Conflict:           {http.StatusConflict, "Resource already exists"},
+	NotAuthenticated:   {http.StatusUnauthorized, "Not authenticated"},
+	TokenExpired:       {http.StatusUnauthorized, "Access token expired"},
+	TokenInvalid:       {http.StatusUnauthorized, "Invalid access token"},
+	InvalidCredentials: {http.StatusUnauthorized, "Invalid client credentials"},
+	SessionBanned:      {http.StatusUnauthorized, "Session banned"},
+	SessionNotFound:    {http.StatusBadRequest, "Session not found"},
+	SessionExpired:     {http.StatusBadRequest, "Session expired"},
+	Forbidden:          {http.StatusForbidden, "Forbidden"},
+}
+
+// Status returns http status of code
+func (c Code) Status() int {
+	if d, ok := descriptions[c]; ok {
+		return d.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// Title returns short summary of code
+func (c Code) Title() string {
+	if d, ok := descriptions[c]; ok {
+		return d.Title
+	}
+	return descriptions[Internal].Title
+}
+
+// Error of application with stable code
+//
+// Values of Error are never modified after creation, so package level
+// errors can be safely shared between requests
+type Error struct {
+	// Machine-readable code
+	Code Code
+	// Human-readable explanation of this occurrence of error
+	Detail string
+	// Cause of error
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Code.Title()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports that errors have the same code, so errors.Is(err, ErrX)
+// works for copies created with WithDetail
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetail returns copy of error with another detail
+func (e *Error) WithDetail(format string, args ...interface{}) *Error {
+	c := *e
+	c.Detail = fmt.Sprintf(format, args...)
+	return &c
+}
+
+// New creates error with given code and detail
+func New(code Code, detail string) *Error {
+	return &Error{Code: code, Detail: detail}
+}
+
+// Wrap creates error with given code and cause. Detail is taken from cause
+func Wrap(code Code, err error) *Error {
+	return &Error{Code: code, Detail: err.Error(), Err: err}
+}
+
+// From converts any error to *Error. Errors of the package are returned as is,
+// pgx.ErrNoRows becomes NotFound and every other error becomes Internal
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Error{Code: NotFound, Detail: "requested resource not found", Err: err}
+	}
+
+	return &Error{Code: Internal, Detail: err.Error(), Err: err}
+}
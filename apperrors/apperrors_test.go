@@ -0,0 +1,71 @@
+package apperrors_test
+
+import (
+	"Muromachi/apperrors"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestFrom(t *testing.T) {
+	appErr := apperrors.New(apperrors.TokenExpired, "expired access token")
+
+	var tt = []struct {
+		name           string
+		err            error
+		expectedCode   apperrors.Code
+		expectedStatus int
+	}{
+		{
+			name:           "application error should be returned as is",
+			err:            appErr,
+			expectedCode:   apperrors.TokenExpired,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "wrapped application error should be unwrapped",
+			err:            fmt.Errorf("validation: %w", appErr),
+			expectedCode:   apperrors.TokenExpired,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "no rows should become not found",
+			err:            pgx.ErrNoRows,
+			expectedCode:   apperrors.NotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "unknown error should become internal",
+			err:            errors.New("connection refused"),
+			expectedCode:   apperrors.Internal,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			e := apperrors.From(test.err)
+			assert.Equal(t, test.expectedCode, e.Code)
+			assert.Equal(t, test.expectedStatus, e.Code.Status())
+		})
+	}
+}
+
+func TestError_WithDetailShouldNotModifyOriginal(t *testing.T) {
+	original := apperrors.New(apperrors.TokenInvalid, "invalid jwt token")
+	copied := original.WithDetail("invalid jwt token, %s", "signature is invalid")
+
+	assert.Equal(t, "invalid jwt token", original.Error())
+	assert.Equal(t, "invalid jwt token, signature is invalid", copied.Error())
+	assert.True(t, errors.Is(copied, original))
+	assert.False(t, errors.Is(copied, apperrors.New(apperrors.TokenExpired, "")))
+}
+
+func TestCode_UnknownCodeShouldBeInternal(t *testing.T) {
+	code := apperrors.Code("unknown")
+	assert.Equal(t, http.StatusInternalServerError, code.Status())
+	assert.Equal(t, apperrors.Internal.Title(), code.Title())
+}
@@ -1,11 +1,11 @@
 package auth
 
 import (
+	"Muromachi/apperrors"
 	"Muromachi/config"
 	"Muromachi/store/entities"
 	"Muromachi/store/users/sessions"
 	"context"
-	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v4"
 	"time"
@@ -16,16 +16,15 @@ var (
 	SecurityCookieName = "apptwice-access-token"
 
 	// Standard authentication error
-	ErrNotAuthenticated = map[string]interface{}{
-		"status": 401,
-		"error":  "invalid auth token, please login with you credentials",
-	}
+	ErrNotAuthenticated = apperrors.New(apperrors.NotAuthenticated, "invalid auth token, please login with you credentials")
+	// Refresh session is in black list
+	ErrSessionBanned = apperrors.New(apperrors.SessionBanned, "your refresh session in blacklist")
+	// Refresh session does not exist
+	ErrSessionNotFound = apperrors.New(apperrors.SessionNotFound, "session not found")
 )
 
 type Security struct {
-	// Config for authorization
-	config    config.Authorization
-	// Generator is pointer to jwt utils
+	// Generator is pointer to jwt utils, also holds config for authorization
 	generator *securityGenerator
 	// Sessions interface which allows manipulate with user refresh
 	// sessions and blacklist
@@ -52,7 +51,7 @@ func (security *Security) StartSession(ctx *fiber.Ctx, refreshToken ...string) (
 		session, err := security.sessions.Remove(ctx.Context(), token)
 		if err != nil {
 			if err == pgx.ErrNoRows {
-				return "", fmt.Errorf("%s", "session not found")
+				return "", ErrSessionNotFound
 			}
 			return "", err
 		}
@@ -61,7 +60,7 @@ func (security *Security) StartSession(ctx *fiber.Ctx, refreshToken ...string) (
 			return "", ErrExpiredRefreshToken
 		}
 		if security.IsSessionBanned(ctx.Context(), token) {
-			return "", fmt.Errorf("%s", "your refresh session in blacklist")
+			return "", ErrSessionBanned
 		}
 		// Save userid for creating new session
 		userId = session.UserId
@@ -142,7 +141,7 @@ func (security *Security) SignAccessToken(ctx *fiber.Ctx, refreshToken string) (
 		return JWTResponse{}, ErrEmptyContext
 	}
 	jwt.TokenType = "Bearer"
-	jwt.ExpiresIn = int(security.config.JwtExpires)
+	jwt.ExpiresIn = int(security.generator.cfg().JwtExpires)
 	// If withSession additionally create an refresh session
 	if refreshToken != "" {
 		jwt.RefreshToken = refreshToken
@@ -156,6 +155,12 @@ func (security *Security) SignAccessToken(ctx *fiber.Ctx, refreshToken string) (
 	return jwt, nil
 }
 
+// Reload atomically replaces config for authorization. New config
+// is used for all tokens signed or validated after the call
+func (security *Security) Reload(cfg config.Authorization) {
+	security.generator.config.Store(cfg)
+}
+
 // Validate given jwt. Return return if token not valid
 func (security *Security) ValidateJwt(token string) (*Claims, error) {
 	return security.generator.ValidateJwt(token)
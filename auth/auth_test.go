@@ -111,7 +111,6 @@ func TestSecurity_StartSession_ShouldCreateNewRefreshSession(t *testing.T) {
 	}
 	// Change paths to db and schema
 	dbcfg := config.New("../config/dev.yml").Database
-	dbcfg.Schema = "../config/schema.sql"
 	// Create conn to real db
 	conn, cleaner := testhelpers.RealDb(dbcfg)
 	defer cleaner("refresh_sessions", "users")
@@ -157,7 +156,6 @@ func TestSecurity_StartSession_ShouldReturnErrorIfRefreshSessionNotFound(t *test
 	}
 	// Change paths to db and schema
 	dbcfg := config.New("../config/dev.yml").Database
-	dbcfg.Schema = "../config/schema.sql"
 	// Create conn to real db
 	conn, cleaner := testhelpers.RealDb(dbcfg)
 	defer cleaner("refresh_sessions")
@@ -193,7 +191,6 @@ func TestSecurity_StartSession_ShouldReturnErrorIfRefreshSessionIsExpired(t *tes
 	}
 	// Change paths to db and schema
 	dbcfg := config.New("../config/dev.yml").Database
-	dbcfg.Schema = "../config/schema.sql"
 	// Create conn to real db
 	conn, cleaner := testhelpers.RealDb(dbcfg)
 	defer cleaner("refresh_sessions", "users")
@@ -243,7 +240,6 @@ func TestSecurity_StartSession_ShouldReturnErrorIfSessionInBlackList(t *testing.
 	}
 	// Change paths to db and schema
 	dbcfg := config.New("../config/dev.yml")
-	dbcfg.Database.Schema = "../config/schema.sql"
 	// Create conn to real db
 	conn, cleaner := testhelpers.RealDb(dbcfg.Database)
 	defer cleaner("refresh_sessions", "users")
@@ -307,7 +303,6 @@ func TestSecurity_StartSession_ShouldRemoveAllUserSessionsIfLenIsMoreThen5AndRet
 	}
 	// Change paths to db and schema
 	dbcfg := config.New("../config/dev.yml").Database
-	dbcfg.Schema = "../config/schema.sql"
 	// Create conn to real db
 	conn, cleaner := testhelpers.RealDb(dbcfg)
 	defer cleaner("refresh_sessions", "users")
@@ -409,3 +404,33 @@ func TestSecurity_SignAccessToken(t *testing.T) {
 		})
 	}
 }
+
+func TestSecurity_Reload_ShouldApplyNewConfigToNextTokens(t *testing.T) {
+	cfg := config.Authorization{
+		JwtSalt:    "uuuuuuuuuuuuuuuuuuthen",
+		JwtExpires: time.Hour * 24,
+		JwtIss:     "apptwice.com",
+	}
+	security := auth.NewSecurity(cfg, nil)
+
+	app := fiber.New()
+	ctx := app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("request_user", &auth.UserClaims{ID: 123, Role: "user"})
+
+	before, err := security.SignAccessToken(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int(time.Hour*24), before.ExpiresIn)
+
+	cfg.JwtExpires = time.Hour
+	cfg.JwtSalt = "another salt"
+	security.Reload(cfg)
+
+	after, err := security.SignAccessToken(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int(time.Hour), after.ExpiresIn)
+
+	_, err = security.ValidateJwt(after.AccessToken)
+	assert.NoError(t, err)
+	_, err = security.ValidateJwt(before.AccessToken)
+	assert.Error(t, err)
+}
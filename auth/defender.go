@@ -15,11 +15,11 @@ type Defender interface {
 	BanSessions(ctx context.Context, tokens ...entities.Session) error
 	SignAccessToken(ctx *fiber.Ctx, refreshToken string) (JWTResponse, error)
 	ValidateJwt(accessToken string) (*Claims, error)
+	Reload(cfg config.Authorization)
 }
 
 func NewSecurity(config config.Authorization, usersession sessions.Session) *Security {
 	return &Security{
-		config:    config,
 		generator: newSecurityGen(config),
 		sessions:  usersession,
 	}
@@ -1,20 +1,22 @@
 package auth
 
 import (
+	"Muromachi/apperrors"
 	"Muromachi/config"
 	"Muromachi/utils"
 	"errors"
 	"github.com/dgrijalva/jwt-go"
 	"log"
+	"sync/atomic"
 	"time"
 )
 
 var (
-	ErrUnexpectedJwtError  = errors.New("unexpected jwt error")
-	ErrInvalidToken        = errors.New("invalid jwt token")
-	ErrExpiredAccessToken  = errors.New("expired access token")
-	ErrExpiredRefreshToken = errors.New("expired refresh token")
-	ErrEmptyContext        = errors.New("empty context")
+	ErrUnexpectedJwtError  = apperrors.New(apperrors.TokenInvalid, "unexpected jwt error")
+	ErrInvalidToken        = apperrors.New(apperrors.TokenInvalid, "invalid jwt token")
+	ErrExpiredAccessToken  = apperrors.New(apperrors.TokenExpired, "expired access token")
+	ErrExpiredRefreshToken = apperrors.New(apperrors.SessionExpired, "expired refresh token")
+	ErrEmptyContext        = apperrors.New(apperrors.Internal, "empty context")
 )
 
 // User data to save inside jwt
@@ -30,8 +32,14 @@ type Claims struct {
 }
 
 type securityGenerator struct {
-	// Config for authorization process
-	config config.Authorization
+	// Config for authorization process. Holds config.Authorization
+	// and can be replaced on config reload
+	config atomic.Value
+}
+
+// Current config for authorization process
+func (gen *securityGenerator) cfg() config.Authorization {
+	return gen.config.Load().(config.Authorization)
 }
 
 // Generate refresh token
@@ -52,18 +60,19 @@ func (gen *securityGenerator) Jwt(userId int64) (string, error) {
 
 // Generate jwt with given user id and refresh token
 func (gen *securityGenerator) JwtWithRefresh(userId int64, refreshToken string) (string, error) {
+	cfg := gen.cfg()
 	// if salt is not provided we should panic
-	if gen.config.JwtSalt == "" {
+	if cfg.JwtSalt == "" {
 		log.Fatal("jwt salt env not provided")
 	}
 	t := time.Now()
 	// Generate token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
 		StandardClaims: &jwt.StandardClaims{
-			Audience:  gen.config.JwtAud,
-			ExpiresAt: t.Add(gen.config.JwtExpires).Unix(),
+			Audience:  cfg.JwtAud,
+			ExpiresAt: t.Add(cfg.JwtExpires).Unix(),
 			IssuedAt:  t.Unix(),
-			Issuer:    gen.config.JwtIss,
+			Issuer:    cfg.JwtIss,
 			// Store refresh token inside jwt this will help us validate tokens in future
 			Id:        refreshToken,
 		},
@@ -73,16 +82,20 @@ func (gen *securityGenerator) JwtWithRefresh(userId int64, refreshToken string)
 		},
 	})
 
-	return token.SignedString([]byte(gen.config.JwtSalt))
+	return token.SignedString([]byte(cfg.JwtSalt))
 }
 
 // Validate given Jwt token. If token not valid return err otherwise return Jwt Claims
 func (gen *securityGenerator) ValidateJwt(token string) (*Claims, error) {
 	t, err := jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(gen.config.JwtSalt), nil
+		return []byte(gen.cfg().JwtSalt), nil
 	})
 	if err != nil {
-		return nil, err
+		var ve *jwt.ValidationError
+		if errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, ErrExpiredAccessToken
+		}
+		return nil, ErrInvalidToken.WithDetail("invalid jwt token, %v", err)
 	}
 
 	if !t.Valid {
@@ -98,7 +111,8 @@ func (gen *securityGenerator) ValidateJwt(token string) (*Claims, error) {
 }
 
 func newSecurityGen(config config.Authorization) *securityGenerator {
-	return &securityGenerator{
-		config: config,
-	}
+	gen := &securityGenerator{}
+	gen.config.Store(config)
+
+	return gen
 }
@@ -2,41 +2,32 @@ package auth
 
 import (
 	"Muromachi/httpresp"
+	"context"
 	"github.com/gofiber/fiber/v2"
+	"strings"
 )
 
 // Authentication middleware for service
 func ApplyAuthMiddleware(security Defender) func(c *fiber.Ctx) error {
 	return func(c *fiber.Ctx) error {
-		var token string
 		// CheckAndDel if token  in cookie
-		cookieToken := c.Cookies(SecurityCookieName, "")
-		if cookieToken == "" {
+		token := c.Cookies(SecurityCookieName, "")
+		if token == "" {
 			// if not in cookie then check headers
 			authToken := c.Get("Authorization", "")
 			if authToken == "" {
 				// cookie and headers empty -> return err
-				return httpresp.Error(c, 401, ErrNotAuthenticated)
-			} else {
-				token = authToken[7:]
+				return httpresp.Error(c, ErrNotAuthenticated)
+			}
+			var err error
+			if token, err = BearerToken(authToken); err != nil {
+				return httpresp.Error(c, err)
 			}
-		} else {
-			token = cookieToken
 		}
 
-		// Validate jwt
-		claims, err := security.ValidateJwt(token)
+		claims, err := Authenticate(c.Context(), security, token)
 		if err != nil {
-			ErrNotAuthenticated["additional"] = err.Error()
-			return httpresp.Error(c, 401, ErrNotAuthenticated)
-		}
-
-		// Check if refresh token is banned in redis
-		if claims.Id != "" {
-			if security.IsSessionBanned(c.Context(),claims.Id) {
-				ErrNotAuthenticated["additional"] = "your refresh token in blacklist"
-				return httpresp.Error(c, 401, ErrNotAuthenticated)
-			}
+			return httpresp.Error(c, err)
 		}
 
 		c.Locals("request_user", claims.UserClaims)
@@ -45,3 +36,41 @@ func ApplyAuthMiddleware(security Defender) func(c *fiber.Ctx) error {
 	}
 }
 
+// BearerToken extracts token from authorization value like 'Bearer {token}'
+func BearerToken(authorization string) (string, error) {
+	if authorization == "" {
+		return "", ErrNotAuthenticated
+	}
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return "", ErrInvalidToken.WithDetail("authorization header should look like 'Bearer {token}'")
+	}
+
+	return authorization[7:], nil
+}
+
+// Authenticate validates jwt and checks that refresh session of token is not banned
+func Authenticate(ctx context.Context, security Defender, token string) (*Claims, error) {
+	claims, err := security.ValidateJwt(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if refresh token is banned in redis
+	if claims.Id != "" && security.IsSessionBanned(ctx, claims.Id) {
+		return nil, ErrSessionBanned
+	}
+
+	return claims, nil
+}
+
+// ClaimsFromContext returns claims of authenticated user. Context of request
+// handlers is *fasthttp.RequestCtx, so claims saved to locals by middleware are
+// available in resolvers
+func ClaimsFromContext(ctx context.Context) (*UserClaims, error) {
+	claims, ok := ctx.Value("request_user").(*UserClaims)
+	if !ok || claims == nil {
+		return nil, ErrNotAuthenticated
+	}
+
+	return claims, nil
+}
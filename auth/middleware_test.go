@@ -1,9 +1,12 @@
 package auth_test
 
 import (
+	"Muromachi/apperrors"
 	"Muromachi/auth"
 	"Muromachi/config"
+	"Muromachi/httpresp"
 	"Muromachi/utils"
+	"encoding/json"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gofiber/fiber/v2"
@@ -38,13 +41,16 @@ func TestApplyAuthMiddleware_Mock(t *testing.T) {
 		withNotValidJwt    bool
 		withExpiredJwt     bool
 		withCookieJwt      bool
+		withoutBearer      bool
 		expectedStatusCode int
+		expectedCode       apperrors.Code
 	}{
 		{
 			name:               "401 error, request without jwt",
 			withJwt:            false,
 			withCookieJwt:      false,
 			expectedStatusCode: 401,
+			expectedCode:       apperrors.NotAuthenticated,
 		},
 		{
 			name:               "200 if jwt founded in header values",
@@ -64,12 +70,21 @@ func TestApplyAuthMiddleware_Mock(t *testing.T) {
 			withNotValidJwt:    true,
 			withCookieJwt:      true,
 			expectedStatusCode: 401,
+			expectedCode:       apperrors.TokenInvalid,
 		},
 		{
 			name:               "401 if expired jwt",
 			withJwt:            true,
 			withExpiredJwt:     true,
 			expectedStatusCode: 401,
+			expectedCode:       apperrors.TokenExpired,
+		},
+		{
+			name:               "401 if authorization header without bearer prefix",
+			withJwt:            true,
+			withoutBearer:      true,
+			expectedStatusCode: 401,
+			expectedCode:       apperrors.TokenInvalid,
 		},
 	}
 
@@ -107,6 +122,9 @@ func TestApplyAuthMiddleware_Mock(t *testing.T) {
 			if test.withJwt {
 				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 			}
+			if test.withoutBearer {
+				req.Header.Set("Authorization", "abc")
+			}
 			if test.withCookieJwt {
 				req.AddCookie(&http.Cookie{
 					Name:       auth.SecurityCookieName,
@@ -124,6 +142,14 @@ func TestApplyAuthMiddleware_Mock(t *testing.T) {
 			b, _ := ioutil.ReadAll(resp.Body)
 			assert.NotNil(t, b)
 			t.Log("msg", " ", string(b))
+
+			if test.expectedCode != "" {
+				assert.Equal(t, httpresp.ProblemContentType, resp.Header.Get("Content-Type"))
+				var problem httpresp.Problem
+				assert.NoError(t, json.Unmarshal(b, &problem))
+				assert.Equal(t, test.expectedCode, problem.Code)
+				assert.Equal(t, test.expectedStatusCode, problem.Status)
+			}
 		})
 	}
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const clientUsage = "usage: client create --company name | list | disable <client id> | rotate-secret <client id>"
+
+// clientCommand manages clients (users table) of service
+func clientCommand(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", clientUsage)
+	}
+	tables, closer, err := authTables(cfg)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("client create", flag.ExitOnError)
+		company := flags.String("company", "", "company name of new client")
+		_ = flags.Parse(args[1:])
+		if *company == "" {
+			return fmt.Errorf("%s", "company should be provided")
+		}
+
+		user := entities.User{Company: *company}
+		if err = user.GenerateSecrets(); err != nil {
+			return err
+		}
+		if user, err = tables.Users.Create(ctx, user); err != nil {
+			return err
+		}
+		printSecrets(user)
+	case "list":
+		users, err := tables.Users.List(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCLIENT ID\tCOMPANY\tADDED AT\tDISABLED")
+		for _, u := range users {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\n", u.ID, u.ClientId, u.Company, u.AddedAt.Format(time.RFC3339), u.Disabled)
+		}
+		return w.Flush()
+	case "disable":
+		if len(args) < 2 {
+			return fmt.Errorf("%s", clientUsage)
+		}
+		user, err := tables.Users.Disable(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		// Disabled client should not be able to use already issued tokens
+		userSessions, err := tables.Sessions.UserSessions(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if err = banSessions(ctx, tables.Sessions, userSessions, 0); err != nil {
+			return err
+		}
+		if err = revokeSessions(ctx, tables.Sessions, userSessions); err != nil {
+			return err
+		}
+		fmt.Printf("client %s disabled, %d sessions revoked\n", user.ClientId, len(userSessions))
+	case "rotate-secret":
+		if len(args) < 2 {
+			return fmt.Errorf("%s", clientUsage)
+		}
+		user, err := tables.Users.RotateSecret(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		printSecrets(user)
+	default:
+		return fmt.Errorf("%s", clientUsage)
+	}
+
+	return nil
+}
+
+// printSecrets prints not hashed client credentials
+func printSecrets(user entities.User) {
+	fmt.Printf("id:            %d\n", user.ID)
+	fmt.Printf("company:       %s\n", user.Company)
+	fmt.Printf("client id:     %s\n", user.ClientId)
+	fmt.Printf("client secret: %s\n", user.ClientSecret)
+	fmt.Println("store the secret now, it can not be shown again")
+}
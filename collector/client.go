@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"Muromachi/store/entities"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Page of store does not exist, for example app was removed
+var ErrNotFound = errors.New("store page is not found")
+
+// HTTP client of store, *http.Client satisfies it
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client fetches and parses pages of Google Play style store
+type Client struct {
+	HTTP HTTPClient
+	// Url of store, for example https://play.google.com
+	BaseUrl   string
+	UserAgent string
+}
+
+// App fetches details page of bundle in geo
+func (c *Client) App(ctx context.Context, bundle, geo string) (entities.Meta, error) {
+	var meta entities.Meta
+	err := c.fetch(ctx, "/store/apps/details", url.Values{"id": {bundle}}, geo, func(body io.Reader) (err error) {
+		meta, err = ParseApp(body)
+		return err
+	})
+
+	return meta, err
+}
+
+// Chart fetches top chart of category in geo and returns bundles in order of places
+func (c *Client) Chart(ctx context.Context, category, geo string) ([]string, error) {
+	var bundles []string
+	err := c.fetch(ctx, "/store/apps/category/"+url.PathEscape(category), url.Values{}, geo, func(body io.Reader) (err error) {
+		bundles, err = ParseListing(body)
+		return err
+	})
+
+	return bundles, err
+}
+
+// Search fetches search results of keyword in geo and returns bundles in order of places
+func (c *Client) Search(ctx context.Context, keyword, geo string) ([]string, error) {
+	var bundles []string
+	err := c.fetch(ctx, "/store/search", url.Values{"q": {keyword}, "c": {"apps"}}, geo, func(body io.Reader) (err error) {
+		bundles, err = ParseListing(body)
+		return err
+	})
+
+	return bundles, err
+}
+
+// fetch requests page with language and country of geo and parses body
+func (c *Client) fetch(ctx context.Context, path string, query url.Values, geo string, parse func(io.Reader) error) error {
+	// Geo is like en_US
+	if parts := strings.SplitN(geo, "_", 2); len(parts) == 2 {
+		query.Set("hl", geo)
+		query.Set("gl", strings.ToUpper(parts[1]))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(c.BaseUrl, "/")+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, req.URL)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected status %d of %s", resp.StatusCode, req.URL)
+	}
+
+	return parse(resp.Body)
+}
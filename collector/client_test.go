@@ -0,0 +1,132 @@
+package collector_test
+
+import (
+	"Muromachi/collector"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/ingest"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// startStore serves fixture pages like Google Play
+func startStore(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/store/apps/details", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") != "com.muromachi.bank" {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, "en_US", r.URL.Query().Get("hl"))
+		assert.Equal(t, "US", r.URL.Query().Get("gl"))
+		assert.Equal(t, "muromachi-test", r.Header.Get("User-Agent"))
+		http.ServeFile(w, r, "testdata/app.html")
+	})
+	mux.HandleFunc("/store/apps/category/FINANCE", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/chart.html")
+	})
+	mux.HandleFunc("/store/search", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "apps", r.URL.Query().Get("c"))
+		if r.URL.Query().Get("q") != "bank" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		http.ServeFile(w, r, "testdata/search.html")
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func newClient(server *httptest.Server) *collector.Client {
+	return &collector.Client{HTTP: server.Client(), BaseUrl: server.URL, UserAgent: "muromachi-test"}
+}
+
+// Writer which remembers records
+type mockWriter struct {
+	records []ingest.Record
+}
+
+func (m *mockWriter) Write(ctx context.Context, owner int, records []ingest.Record) (ingest.Result, error) {
+	m.records = append(m.records, records...)
+	return ingest.Result{Inserted: len(records)}, nil
+}
+
+type mockKeywords []string
+
+func (m mockKeywords) Keywords(ctx context.Context, app entities.App) ([]string, error) {
+	return m, nil
+}
+
+func TestClient_ShouldFetchPagesOfStore(t *testing.T) {
+	client := newClient(startStore(t))
+	ctx := context.Background()
+
+	meta, err := client.App(ctx, "com.muromachi.bank", "en_US")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bank of Muromachi", meta.Title)
+
+	bundles, err := client.Chart(ctx, "FINANCE", "en_US")
+	assert.NoError(t, err)
+	assert.Len(t, bundles, 3)
+
+	bundles, err = client.Search(ctx, "bank", "en_US")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"com.muromachi.bank", "com.other.bank"}, bundles)
+}
+
+func TestClient_ShouldReturnErrorsOfStatus(t *testing.T) {
+	client := newClient(startStore(t))
+
+	_, err := client.App(context.Background(), "com.removed", "en_US")
+	assert.True(t, errors.Is(err, collector.ErrNotFound))
+
+	_, err = client.Search(context.Background(), "broken", "en_US")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, collector.ErrNotFound))
+}
+
+func TestCollector_ShouldWriteMetaAndPlaces(t *testing.T) {
+	writer := &mockWriter{}
+	date := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	c := collector.Collector{
+		Client:   newClient(startStore(t)),
+		Writer:   writer,
+		Keywords: mockKeywords{"bank"},
+		Now:      func() time.Time { return date },
+	}
+
+	err := c.Collect(context.Background(), entities.App{Id: 7, Bundle: "com.muromachi.bank", Category: "FINANCE", Geo: "en_US"})
+	assert.NoError(t, err)
+
+	assert.Len(t, writer.records, 3)
+	assert.Equal(t, ingest.Meta, writer.records[0].Kind)
+	assert.Equal(t, 7, writer.records[0].Meta.BundleId)
+	assert.Equal(t, date, writer.records[0].Meta.Date)
+	assert.Equal(t, entities.Track{BundleId: 7, Type: "FINANCE", Place: 2, Date: date}, writer.records[1].Track)
+	assert.Equal(t, entities.Track{BundleId: 7, Type: "bank", Place: 1, Date: date}, writer.records[2].Track)
+}
+
+func TestCollector_ShouldReturnErrorOfMissingApp(t *testing.T) {
+	writer := &mockWriter{}
+	c := collector.Collector{Client: newClient(startStore(t)), Writer: writer}
+
+	err := c.Collect(context.Background(), entities.App{Id: 7, Bundle: "com.removed", Geo: "en_US"})
+	assert.True(t, errors.Is(err, collector.ErrNotFound))
+	assert.Empty(t, writer.records)
+}
+
+func TestCollector_ShouldRejectAppsOfAppStore(t *testing.T) {
+	writer := &mockWriter{}
+	c := collector.Collector{Client: newClient(startStore(t)), Writer: writer}
+
+	err := c.Collect(context.Background(), entities.App{Id: 7, Bundle: "com.muromachi.bank", Geo: "en_US", Store: entities.StoreAppStore})
+	assert.True(t, errors.Is(err, collector.ErrUnsupportedStore))
+	assert.Empty(t, writer.records)
+}
@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/ingest"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+)
+
+// Source of keywords which are tracked for app
+type KeywordSource interface {
+	Keywords(ctx context.Context, app entities.App) ([]string, error)
+}
+
+// Keywords which were already tracked for app
+type TrackedKeywords struct {
+	Conn connector.Conn
+}
+
+func (t *TrackedKeywords) Keywords(ctx context.Context, app entities.App) ([]string, error) {
+	var (
+		keyword  string
+		keywords []string
+	)
+	_, err := t.Conn.QueryFunc(
+		ctx,
+		"select distinct type from keyword_tracking where bundleid = $1 order by type",
+		[]interface{}{app.Id},
+		[]interface{}{&keyword},
+		func(pgx.QueryFuncRow) error {
+			keywords = append(keywords, keyword)
+			return nil
+		},
+	)
+
+	return keywords, err
+}
+
+// App is published in store which pages can not be parsed
+var ErrUnsupportedStore = errors.New("store is not supported by collector")
+
+// Collector collects meta of app, place in chart of app category and places
+// in search results of keywords and writes them as one batch. Satisfies
+// scheduler.Collector
+type Collector struct {
+	Client *Client
+	Writer ingest.Writer
+	Now    worker.Clock
+	// Keywords of app, no keywords are collected if nil
+	Keywords KeywordSource
+}
+
+// Collect fetches Google Play pages of app. Missing places are not errors, app is not
+// in chart or search results then
+func (c *Collector) Collect(ctx context.Context, app entities.App) error {
+	date := c.Now.UTC()
+	if app.StoreOrDefault() != entities.StorePlay {
+		return fmt.Errorf("%w: %s", ErrUnsupportedStore, app.StoreOrDefault())
+	}
+	apps := map[string]int{app.Bundle: app.Id}
+
+	meta, err := c.Client.App(ctx, app.Bundle, app.Geo)
+	if err != nil {
+		return err
+	}
+	meta.BundleId = app.Id
+	meta.Date = date
+	if err := meta.Validate(); err != nil {
+		return fmt.Errorf("invalid meta of %s: %v", app.Bundle, err)
+	}
+	records := []ingest.Record{{Kind: ingest.Meta, Meta: meta}}
+
+	if app.Category != "" {
+		bundles, err := c.Client.Chart(ctx, app.Category, app.Geo)
+		if err != nil {
+			return err
+		}
+		for _, track := range Tracks(bundles, apps, app.Category, date) {
+			records = append(records, ingest.Record{Kind: ingest.Category, Track: track})
+		}
+	}
+
+	if c.Keywords != nil {
+		keywords, err := c.Keywords.Keywords(ctx, app)
+		if err != nil {
+			return err
+		}
+		for _, keyword := range keywords {
+			bundles, err := c.Client.Search(ctx, keyword, app.Geo)
+			if err != nil {
+				return err
+			}
+			for _, track := range Tracks(bundles, apps, keyword, date) {
+				records = append(records, ingest.Record{Kind: ingest.Keyword, Track: track})
+			}
+		}
+	}
+
+	for i := range records {
+		records[i].Line = i + 1
+	}
+	// Apps of every client are collected, so records are written without owner
+	result, err := c.Writer.Write(ctx, 0, records)
+	if err != nil {
+		return err
+	}
+	if len(result.Rejected) > 0 {
+		return fmt.Errorf("record %d is rejected: %s", result.Rejected[0].Line, result.Rejected[0].Error)
+	}
+
+	return nil
+}
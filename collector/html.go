@@ -0,0 +1,218 @@
+package collector
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// Scripts and styles are not valid xml, so they are removed before parsing
+var scripts = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)\s*>`)
+
+// Element of parsed page
+type node struct {
+	tag      string
+	attrs    map[string]string
+	children []*node
+	// Text of node, only for text nodes
+	text   string
+	isText bool
+}
+
+// parseHTML builds tree of page by lenient xml decoder
+func parseHTML(r io.Reader) (*node, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = scripts.ReplaceAll(data, nil)
+
+	decoder := xml.NewDecoder(strings.NewReader(string(data)))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	root := &node{tag: "#root"}
+	stack := []*node{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &node{tag: strings.ToLower(t.Name.Local), attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attrs[strings.ToLower(a.Name.Local)] = a.Value
+			}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			// Unclosed elements are closed with parent
+			tag := strings.ToLower(t.Name.Local)
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == tag {
+					stack = stack[:i]
+					break
+				}
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &node{text: string(t), isText: true})
+		}
+	}
+
+	return root, nil
+}
+
+// find returns all descendants matched by predicate in document order, nil node has not descendants
+func (n *node) find(match func(*node) bool) []*node {
+	if n == nil {
+		return nil
+	}
+	var found []*node
+	for _, c := range n.children {
+		if c.isText {
+			continue
+		}
+		if match(c) {
+			found = append(found, c)
+		}
+		found = append(found, c.find(match)...)
+	}
+	return found
+}
+
+// first returns the first descendant matched by predicate or nil
+func (n *node) first(match func(*node) bool) *node {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.children {
+		if c.isText {
+			continue
+		}
+		if match(c) {
+			return c
+		}
+		if found := c.first(match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Text returns text of node and descendants with collapsed spaces.
+// Nil node has empty text
+func (n *node) Text() string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	n.writeText(&b)
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Lines returns text of node where <br> and block elements break lines
+func (n *node) Lines() string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	n.writeLines(&b)
+
+	lines := strings.Split(b.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.Join(strings.Fields(l), " ")
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func (n *node) writeText(b *strings.Builder) {
+	if n.isText {
+		b.WriteString(n.text)
+		b.WriteString(" ")
+		return
+	}
+	for _, c := range n.children {
+		c.writeText(b)
+	}
+}
+
+func (n *node) writeLines(b *strings.Builder) {
+	if n.isText {
+		b.WriteString(n.text)
+		return
+	}
+	if n.tag == "br" {
+		b.WriteString("\n")
+		return
+	}
+	for _, c := range n.children {
+		c.writeLines(b)
+	}
+	if n.tag == "p" || n.tag == "div" || n.tag == "li" {
+		b.WriteString("\n")
+	}
+}
+
+// Attr returns value of attribute, nil node has no attributes
+func (n *node) Attr(name string) string {
+	if n == nil {
+		return ""
+	}
+	return n.attrs[name]
+}
+
+// hasClass reports if class attribute of node contains all given classes
+func (n *node) hasClass(classes ...string) bool {
+	own := strings.Fields(n.attrs["class"])
+	for _, c := range classes {
+		found := false
+		for _, o := range own {
+			if o == c {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Predicates of find and first
+func byTag(tag string) func(*node) bool {
+	return func(n *node) bool { return n.tag == tag }
+}
+
+func byClass(classes ...string) func(*node) bool {
+	return func(n *node) bool { return n.hasClass(classes...) }
+}
+
+func byAttr(name, value string) func(*node) bool {
+	return func(n *node) bool {
+		v, ok := n.attrs[name]
+		return ok && (value == "" || v == value)
+	}
+}
+
+func and(predicates ...func(*node) bool) func(*node) bool {
+	return func(n *node) bool {
+		for _, p := range predicates {
+			if !p(n) {
+				return false
+			}
+		}
+		return true
+	}
+}
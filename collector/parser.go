@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"Muromachi/store/entities"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ParseApp parses app details page into entities.Meta. Bundle id and date
+// are not on page and should be set by caller. Rating histogram is ordered
+// from 5 to 1 stars
+func ParseApp(r io.Reader) (entities.Meta, error) {
+	var meta entities.Meta
+
+	root, err := parseHTML(r)
+	if err != nil {
+		return meta, err
+	}
+
+	meta.Title = root.first(and(byTag("h1"), byAttr("itemprop", "name"))).Text()
+	if meta.Title == "" {
+		return meta, fmt.Errorf("%s", "app page has no title")
+	}
+	meta.Price = root.first(and(byTag("meta"), byAttr("itemprop", "price"))).Attr("content")
+	meta.Picture = root.first(and(byTag("img"), byAttr("itemprop", "image"))).Attr("src")
+	for _, img := range root.find(and(byTag("img"), byAttr("data-screenshot-item-index", ""))) {
+		// Lazy images keep source in data-src
+		src := img.Attr("data-src")
+		if src == "" {
+			src = img.Attr("src")
+		}
+		meta.Screenshots = append(meta.Screenshots, src)
+	}
+
+	meta.Rating = root.first(byClass("BHMmbe")).Text()
+	if count := root.first(byClass("EymY4b")); count != nil {
+		meta.ReviewCount = count.first(byAttr("aria-label", "")).Text()
+	}
+	for _, bar := range root.find(byClass("L2o20d")) {
+		meta.RatingHistogram = append(meta.RatingHistogram, bar.Attr("title"))
+	}
+
+	// The first description is about app, the second one is about changes
+	descriptions := root.find(and(byTag("div"), byAttr("itemprop", "description")))
+	if len(descriptions) > 0 {
+		meta.Description = descriptions[0].Lines()
+	}
+	if len(descriptions) > 1 {
+		meta.RecentChanges = descriptions[1].Lines()
+	}
+	meta.ShortDescription = root.first(and(byTag("meta"), byAttr("name", "description"))).Attr("content")
+
+	for _, info := range root.find(byClass("hAyfc")) {
+		// Value is wrapped into several spans with the same class
+		var value *node
+		if values := info.find(byClass("htlgb")); len(values) > 0 {
+			value = values[len(values)-1]
+		}
+		switch info.first(byClass("BgcNfc")).Text() {
+		case "Updated":
+			meta.LastUpdateDate = value.Text()
+		case "Released on":
+			meta.ReleaseDate = value.Text()
+		case "Size":
+			meta.AppSize = value.Text()
+		case "Installs":
+			meta.Installs = value.Text()
+		case "Current Version":
+			meta.Version = value.Text()
+		case "Requires Android":
+			meta.AndroidVersion = value.Text()
+		case "Content Rating":
+			meta.ContentRating = value.first(byTag("div")).Text()
+		case "Developer":
+			parseDeveloper(value, &meta)
+		}
+	}
+
+	return meta, nil
+}
+
+// parseDeveloper parses email, privacy policy and address of developer
+func parseDeveloper(n *node, meta *entities.Meta) {
+	if n == nil {
+		return
+	}
+	var contacts []string
+	for _, line := range n.find(byTag("div")) {
+		link := line.first(byTag("a"))
+		switch {
+		case strings.HasPrefix(link.Attr("href"), "mailto:"):
+			meta.DeveloperContacts.Email = strings.TrimPrefix(link.Attr("href"), "mailto:")
+		case link != nil && link.Text() == "Privacy Policy":
+			meta.PrivacyPolicy = link.Attr("href")
+		case link == nil:
+			contacts = append(contacts, line.Text())
+		}
+	}
+	meta.DeveloperContacts.Contacts = strings.Join(contacts, "\n")
+}
+
+// ParseListing parses chart or search results page and returns bundles of
+// apps in order of places
+func ParseListing(r io.Reader) ([]string, error) {
+	root, err := parseHTML(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundles []string
+	seen := make(map[string]bool)
+	for _, link := range root.find(byTag("a")) {
+		href, err := url.Parse(link.Attr("href"))
+		if err != nil || !strings.HasSuffix(href.Path, "/store/apps/details") {
+			continue
+		}
+		bundle := href.Query().Get("id")
+		// Card of app has several links to details
+		if bundle == "" || seen[bundle] {
+			continue
+		}
+		seen[bundle] = true
+		bundles = append(bundles, bundle)
+	}
+
+	return bundles, nil
+}
+
+// Tracks converts listing of chart or search page into entities.Track rows of
+// tracked apps. Apps maps bundle to id of tracked app, other bundles are skipped
+func Tracks(bundles []string, apps map[string]int, typ string, date time.Time) []entities.Track {
+	var tracks []entities.Track
+	for i, bundle := range bundles {
+		id, ok := apps[bundle]
+		if !ok {
+			continue
+		}
+		tracks = append(tracks, entities.Track{
+			BundleId: id,
+			Type:     typ,
+			Place:    int32(i + 1),
+			Date:     date,
+		})
+	}
+
+	return tracks
+}
@@ -0,0 +1,83 @@
+package collector_test
+
+import (
+	"Muromachi/collector"
+	"Muromachi/store/entities"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseApp_ShouldParseFieldsOfDetailsPage(t *testing.T) {
+	page, err := os.Open("testdata/app.html")
+	assert.NoError(t, err)
+	defer page.Close()
+
+	meta, err := collector.ParseApp(page)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Bank of Muromachi", meta.Title)
+	assert.Equal(t, "0", meta.Price)
+	assert.Equal(t, "https://play-lh.googleusercontent.com/icon=s180", meta.Picture)
+	assert.Equal(t, []string{"https://play-lh.googleusercontent.com/shot1", "https://play-lh.googleusercontent.com/shot2"}, meta.Screenshots)
+	assert.Equal(t, "4.6", meta.Rating)
+	assert.Equal(t, "1,002,323", meta.ReviewCount)
+	assert.Equal(t, []string{"800,100", "120,000", "40,223", "12,000", "30,000"}, meta.RatingHistogram)
+	assert.Equal(t, "Banking in your pocket.\nTransfers without fees.\n\nSupport 24/7", meta.Description)
+	assert.Equal(t, "Pay, transfer & save in one app", meta.ShortDescription)
+	assert.Equal(t, "Fixed login.\nNew dark theme.", meta.RecentChanges)
+	assert.Equal(t, "January 1, 2020", meta.ReleaseDate)
+	assert.Equal(t, "March 3, 2020", meta.LastUpdateDate)
+	assert.Equal(t, "90M", meta.AppSize)
+	assert.Equal(t, "1,000,000+", meta.Installs)
+	assert.Equal(t, "1.3.12", meta.Version)
+	assert.Equal(t, "9.0 and up", meta.AndroidVersion)
+	assert.Equal(t, "Rated for 18+", meta.ContentRating)
+	assert.Equal(t, entities.DeveloperContacts{Email: "support@bank.example.com", Contacts: "Kyoto, Muromachi street 1"}, meta.DeveloperContacts)
+	assert.Equal(t, "https://bank.example.com/privacy", meta.PrivacyPolicy)
+}
+
+func TestParseApp_ShouldSkipRowsWithoutValue(t *testing.T) {
+	page := `<html><body><h1 itemprop="name"><span>Bank of Muromachi</span></h1>
+		<div class="hAyfc"><div class="BgcNfc">Content Rating</div></div>
+		<div class="hAyfc"><div class="BgcNfc">Developer</div></div>
+		<div class="hAyfc"><div class="BgcNfc">Size</div><span class="htlgb">90M</span></div>
+	</body></html>`
+
+	meta, err := collector.ParseApp(strings.NewReader(page))
+	assert.NoError(t, err)
+	assert.Equal(t, "", meta.ContentRating)
+	assert.Equal(t, entities.DeveloperContacts{}, meta.DeveloperContacts)
+	assert.Equal(t, "90M", meta.AppSize)
+}
+
+func TestParseApp_ShouldReturnErrorWithoutTitle(t *testing.T) {
+	_, err := collector.ParseApp(strings.NewReader("<html><body><p>Not found</p></body></html>"))
+	assert.Error(t, err)
+}
+
+func TestParseListing_ShouldReturnBundlesInOrder(t *testing.T) {
+	page, err := os.Open("testdata/chart.html")
+	assert.NoError(t, err)
+	defer page.Close()
+
+	bundles, err := collector.ParseListing(page)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"com.first.wallet", "com.muromachi.bank", "com.third.coins"}, bundles)
+}
+
+func TestTracks_ShouldKeepOnlyTrackedApps(t *testing.T) {
+	date := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+
+	tracks := collector.Tracks(
+		[]string{"com.first.wallet", "com.muromachi.bank", "com.third.coins"},
+		map[string]int{"com.muromachi.bank": 7, "com.third.coins": 8},
+		"FINANCE", date,
+	)
+	assert.Equal(t, []entities.Track{
+		{BundleId: 7, Type: "FINANCE", Place: 2, Date: date},
+		{BundleId: 8, Type: "FINANCE", Place: 3, Date: date},
+	}, tracks)
+}
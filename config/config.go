@@ -1,10 +1,9 @@
 package config
 
 import (
+	"flag"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
-	"os"
-	"strconv"
 	"time"
 )
 
@@ -13,7 +12,7 @@ type Authorization struct {
 	// Jwt salt is randomly string which will be additional added to jwt token
 	JwtSalt string `yaml:"jwt_salt"`
 	// When jwt is expired
-	JwtExpires time.Duration `yaml:"jwt_expires"`
+	JwtExpires time.Duration `yaml:"jwt_expires" default:"24h" reload:"hot"`
 	// Who created jwt token
 	JwtIss string `yaml:"jwt_iss"`
 	// Who can use the token
@@ -25,7 +24,7 @@ type Authorization struct {
 //Database config
 type DBConfig struct {
 	// Database name
-	Database string `yaml:"name"`
+	Database string `yaml:"name" default:"tracking"`
 	// Postgres user
 	//
 	// for example: postgres
@@ -41,9 +40,7 @@ type DBConfig struct {
 	// Port on which postgres
 	//
 	// for example: 5432
-	Port string `yaml:"port"`
-	// The path to the file from which the database schema will be generated
-	Schema string `yaml:"schema"`
+	Port string `yaml:"port" default:"5432"`
 	// Instance of redis config
 	Redis RedisConfig `yaml:"redis"`
 }
@@ -57,7 +54,7 @@ type RedisConfig struct {
 	// Port on which redis
 	//
 	// for example 6379
-	Port     string `yaml:"port"`
+	Port     string `yaml:"port" default:"6379"`
 	// Password to redis
 	//
 	// by default: (empty)
@@ -68,101 +65,247 @@ type RedisConfig struct {
 	Database int    `yaml:"database"`
 }
 
+// Request limits of graphql endpoint
+type Limits struct {
+	// Max number of requests from one ip within expiration
+	Max int `yaml:"max" default:"20"`
+	// Duration of limit window
+	Expiration time.Duration `yaml:"expiration" default:"1m"`
+}
+
+// Cross-origin resource sharing config
+type Cors struct {
+	// Is cors middleware enabled
+	Enabled bool `yaml:"enabled"`
+	// Comma separated list of origins
+	//
+	// for example: https://apptwice.com,https://admin.apptwice.com
+	AllowOrigins string `yaml:"allow_origins" default:"*"`
+	// Comma separated list of methods
+	AllowMethods string `yaml:"allow_methods" default:"GET,POST,HEAD,OPTIONS"`
+	// Comma separated list of request headers
+	AllowHeaders string `yaml:"allow_headers"`
+	// Can the response be exposed when credentials flag is true
+	AllowCredentials bool `yaml:"allow_credentials"`
+	// How long the results of a preflight request can be cached
+	//
+	// in seconds
+	MaxAge int `yaml:"max_age"`
+}
+
+// Log config
+type Log struct {
+	// Minimal level of written messages
+	//
+	// levels: (debug, info, warn, error)
+	Level string `yaml:"level" default:"info"`
+}
+
+// Feature toggles
+type Features struct {
+	// Serve graphql playground
+	Playground bool `yaml:"playground" default:"true"`
+	// Allow graphql introspection queries
+	Introspection bool `yaml:"introspection" default:"true"`
+}
+
+// Scheduler of collection jobs
+type Scheduler struct {
+	// Run scheduler in this instance, several instances share due apps
+	Enabled bool `yaml:"enabled"`
+	// Delay between checks of due apps
+	Interval time.Duration `yaml:"interval" default:"1m"`
+	// Count of apps leased at once
+	BatchSize int `yaml:"batch_size" default:"10"`
+	// Max duration of collection of single app
+	Timeout time.Duration `yaml:"timeout" default:"10m"`
+}
+
+// Collector of store pages
+type Collector struct {
+	// Url of store
+	BaseUrl string `yaml:"base_url" default:"https://play.google.com"`
+	// User agent of requests to store
+	UserAgent string `yaml:"user_agent"`
+	// Timeout of single request
+	Timeout time.Duration `yaml:"timeout" default:"30s"`
+}
+
+// Bulk ingestion endpoint
+type Ingest struct {
+	// Max size of request body in bytes, body is streamed, so it is not kept in memory
+	BodyLimit int `yaml:"body_limit" default:"67108864"`
+}
+
+// Alert rules and their webhooks
+type Alerts struct {
+	// Evaluate rules and send webhooks in this instance, several instances share webhooks
+	Enabled bool `yaml:"enabled"`
+	// Delay between checks of pending webhooks
+	Interval time.Duration `yaml:"interval" default:"10s"`
+	// Count of webhooks leased at once
+	BatchSize int `yaml:"batch_size" default:"20"`
+	// Timeout of single webhook request
+	Timeout time.Duration `yaml:"timeout" default:"10s"`
+	// Count of attempts before webhook is failed
+	MaxAttempts int `yaml:"max_attempts" default:"8"`
+	// Delay before the first retry, next delays are doubled
+	BaseDelay time.Duration `yaml:"base_delay" default:"30s"`
+	// Max delay between retries
+	MaxDelay time.Duration `yaml:"max_delay" default:"1h"`
+	// Delay between scans of tracking rows which were not evaluated on events
+	ScanInterval time.Duration `yaml:"scan_interval" default:"1m"`
+}
+
+// Weekly digest reports of clients
+type Reports struct {
+	// Generate reports and send their webhooks in this instance, several instances share schedules
+	Enabled bool `yaml:"enabled"`
+	// Delay between checks of due schedules and pending webhooks
+	Interval time.Duration `yaml:"interval" default:"1m"`
+	// Count of schedules or webhooks leased at once
+	BatchSize int `yaml:"batch_size" default:"10"`
+	// Max duration of generation of single report
+	Timeout time.Duration `yaml:"timeout" default:"5m"`
+	// Timeout of single webhook request
+	WebhookTimeout time.Duration `yaml:"webhook_timeout" default:"30s"`
+	// Count of webhook attempts before webhook is failed
+	MaxAttempts int `yaml:"max_attempts" default:"8"`
+	// Delay before the first retry, next delays are doubled
+	BaseDelay time.Duration `yaml:"base_delay" default:"1m"`
+	// Max delay between retries
+	MaxDelay time.Duration `yaml:"max_delay" default:"6h"`
+}
+
+// Retention of partitions of tracking table
+type Retention struct {
+	// Partitions which end before now minus retention are removed, zero keeps partitions forever
+	Retention time.Duration `yaml:"retention"`
+	// Detach expired partitions and keep them as plain tables instead of dropping
+	Detach bool `yaml:"detach"`
+}
+
+// Monthly partitions of tracking tables
+type Partitions struct {
+	// Maintain partitions in this instance, only one instance maintains them at once
+	Enabled bool `yaml:"enabled"`
+	// Delay between maintenance runs
+	Interval time.Duration `yaml:"interval" default:"6h"`
+	// Count of monthly partitions which are created ahead of current month
+	Ahead int `yaml:"ahead" default:"3"`
+	// Retention of meta_tracking
+	Meta Retention `yaml:"meta"`
+	// Retention of category_tracking
+	Categories Retention `yaml:"categories"`
+	// Retention of keyword_tracking
+	Keywords Retention `yaml:"keywords"`
+}
+
+// Daily and weekly aggregates of places
+type Rollups struct {
+	// Roll up tracking rows in this instance, several instances share sources
+	Enabled bool `yaml:"enabled"`
+	// Delay between rollup runs
+	Interval time.Duration `yaml:"interval" default:"1m"`
+	// Count of tracking rows which are rolled up at once
+	BatchSize int `yaml:"batch_size" default:"10000"`
+}
+
+// Detection of anomalies of tracking rows
+type Anomalies struct {
+	// Detect anomalies in this instance, several instances skip anomalies which are saved already
+	Enabled bool `yaml:"enabled"`
+	// Delay between detection runs
+	Interval time.Duration `yaml:"interval" default:"10m"`
+	// Count of tracking rows which are checked at once
+	BatchSize int `yaml:"batch_size" default:"10000"`
+}
+
 // Config struct of application config
+//
+// Fields and sections with reload:"hot" tag are applied without restart
+// when config is reloaded, any other changes require restart
 type Config struct {
 	// Database configs
 	Database DBConfig      `yaml:"database"`
 	// Auth config
 	Auth     Authorization `yaml:"auth"`
-
-	// Sys envs
-	Envs []string `yaml:",flow"`
+	// Request limits
+	Limits   Limits        `yaml:"limits" reload:"hot"`
+	// Cors config
+	Cors     Cors          `yaml:"cors" reload:"hot"`
+	// Log config
+	Log      Log           `yaml:"log" reload:"hot"`
+	// Feature toggles
+	Features Features      `yaml:"features" reload:"hot"`
+	// Collection scheduler
+	Scheduler Scheduler `yaml:"scheduler"`
+	// Collector of store pages
+	Collector Collector `yaml:"collector"`
+	// Bulk ingestion endpoint
+	Ingest Ingest `yaml:"ingest"`
+	// Alert rules and webhooks
+	Alerts Alerts `yaml:"alerts"`
+	// Weekly digest reports
+	Reports Reports `yaml:"reports"`
+	// Partitions of tracking tables
+	Partitions Partitions `yaml:"partitions"`
+	// Aggregates of places
+	Rollups Rollups `yaml:"rollups"`
+	// Detection of anomalies
+	Anomalies Anomalies `yaml:"anomalies"`
 }
 
-// loadEnvs load system environments with keys listed in ...e
-// then write values to map and return map[key]value
-func loadEnvs(e ...string) map[string]string {
-	envs := make(map[string]string)
-
-	for _, k := range e {
-		envs[k] = os.Getenv(k)
-	}
-
-	return envs
-}
+// Load creates config from layers, every next layer overrides previous one:
+//
+// 	1. defaults from `default` struct tags
+// 	2. yaml file with given path
+// 	3. environment variables like MUROMACHI_DATABASE_PASSWORD. Values of
+// 	   variables with _FILE suffix are read from file with given path. Legacy
+// 	   variables like db_pass are read if new ones are not set
+// 	4. flags which were registered with RegisterFlags and set by user
+//
+// Load does not validate config, use Config.Validate for it
+func Load(path string, flags ...*flag.FlagSet) (Config, error) {
+	config := Config{}
 
-// New Create new instance of apprepo config with given path to (../config.yml)
-func New(p ...string) Config {
-	path := "./dev.yml"
-	if len(p) > 0 {
-		path = p[0]
+	if err := applyDefaults(&config); err != nil {
+		return Config{}, err
 	}
 
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		panic(err)
+		return Config{}, err
 	}
-
-	config := Config{}
 	if err = yaml.Unmarshal(data, &config); err != nil {
-		panic(err)
+		return Config{}, err
 	}
 
-	envs := loadEnvs(config.Envs...)
-
-	v, ok := envs["db_pass"]
-	if ok && v != "" {
-		config.Database.Password = v
-	}
-	v, ok = envs["db_user"]
-	if ok && v != "" {
-		config.Database.User = v
-	}
-	v, ok = envs["db_address"]
-	if ok && v != "" {
-		config.Database.Address = v
-	}
-	v, ok = envs["db_port"]
-	if ok && v != "" {
-		config.Database.Port = v
-	}
-	v, ok = envs["r_address"]
-	if ok && v != "" {
-		config.Database.Redis.Address = v
+	if err = applyEnvs(&config); err != nil {
+		return Config{}, err
 	}
-	v, ok = envs["r_port"]
-	if ok && v != "" {
-		config.Database.Redis.Port = v
-	}
-	v, ok = envs["r_pass"]
-	if ok && v != "" {
-		config.Database.Redis.Password = v
-	}
-	v, ok = envs["r_database"]
-	if ok && v != "" {
-		i, err := strconv.Atoi(v)
-		if err != nil {
-			panic(err)
-		}
-		config.Database.Redis.Database = i
-	}
-	v, ok = envs["jwt_salt"]
-	if ok && v != "" {
-		config.Auth.JwtSalt = v
-	}
-	v, ok = envs["jwt_exp"]
-	if ok && v != "" {
-		d, err := time.ParseDuration(v)
-		if err != nil {
-			panic(err)
+
+	for _, f := range flags {
+		if err = applyFlags(&config, f); err != nil {
+			return Config{}, err
 		}
-		config.Auth.JwtExpires = d
 	}
-	v, ok = envs["jwt_iss"]
-	if ok && v != "" {
-		config.Auth.JwtIss = v
+
+	return config, nil
+}
+
+// New loads config like Load from given path (./dev.yml by default)
+// and panics on error. Useful in tests and tools
+func New(p ...string) Config {
+	path := "./dev.yml"
+	if len(p) > 0 {
+		path = p[0]
 	}
 
+	config, err := Load(path)
+	if err != nil {
+		panic(err)
+	}
 
 	return config
 }
@@ -2,8 +2,12 @@ package config_test
 
 import (
 	"Muromachi/config"
+	"flag"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 )
 
 func TestConfig_ShouldCreateValidInstanceOfDatabaseConfig_Dev(t *testing.T) {
@@ -12,7 +16,6 @@ func TestConfig_ShouldCreateValidInstanceOfDatabaseConfig_Dev(t *testing.T) {
 	assert.NotEmpty(t, cfg.Database.Database)
 	assert.NotEmpty(t, cfg.Database.User)
 	assert.NotEmpty(t, cfg.Database.Password)
-	assert.NotEmpty(t, cfg.Database.Schema)
 	assert.NotEmpty(t, cfg.Database.Address)
 	assert.NotEmpty(t, cfg.Database.Port)
 }
@@ -21,10 +24,116 @@ func TestConfig_ShouldCreateValidInstanceOfDatabaseConfig_Prod(t *testing.T) {
 	cfg := config.New("./prod.yml")
 
 	assert.NotEmpty(t, cfg.Database.Database)
-	assert.NotEmpty(t, cfg.Database.Schema)
 	assert.NotEmpty(t, cfg.Database.Port)
 
 	assert.Empty(t, cfg.Database.User)
 	assert.Empty(t, cfg.Database.Password)
 	assert.Empty(t, cfg.Database.Address)
-}
\ No newline at end of file
+}
+func TestLoad_ShouldApplyDefaults(t *testing.T) {
+	cfg, err := config.Load("./prod.yml")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "6379", cfg.Database.Redis.Port)
+	assert.Equal(t, time.Hour*24, cfg.Auth.JwtExpires)
+	// Bulk ingestion is not limited by default 4MB of fiber
+	assert.Equal(t, 64<<20, cfg.Ingest.BodyLimit)
+}
+
+func TestLoad_ShouldOverrideValuesWithEnvs(t *testing.T) {
+	_ = os.Setenv("MUROMACHI_DATABASE_ADDRESS", "db.local")
+	_ = os.Setenv("MUROMACHI_DATABASE_REDIS_DATABASE", "3")
+	_ = os.Setenv("MUROMACHI_AUTH_JWT_EXPIRES", "1h")
+	defer os.Unsetenv("MUROMACHI_DATABASE_ADDRESS")
+	defer os.Unsetenv("MUROMACHI_DATABASE_REDIS_DATABASE")
+	defer os.Unsetenv("MUROMACHI_AUTH_JWT_EXPIRES")
+
+	cfg, err := config.Load("./dev.yml")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "db.local", cfg.Database.Address)
+	assert.Equal(t, 3, cfg.Database.Redis.Database)
+	assert.Equal(t, time.Hour, cfg.Auth.JwtExpires)
+	assert.Equal(t, "postgres", cfg.Database.User)
+}
+
+func TestLoad_ShouldReadLegacyEnvs(t *testing.T) {
+	_ = os.Setenv("db_pass", "legacy secret")
+	_ = os.Setenv("db_address", "legacy.local")
+	_ = os.Setenv("MUROMACHI_DATABASE_ADDRESS", "db.local")
+	_ = os.Setenv("r_database", "2")
+	defer os.Unsetenv("db_pass")
+	defer os.Unsetenv("db_address")
+	defer os.Unsetenv("MUROMACHI_DATABASE_ADDRESS")
+	defer os.Unsetenv("r_database")
+
+	cfg, err := config.Load("./dev.yml")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "legacy secret", cfg.Database.Password)
+	assert.Equal(t, 2, cfg.Database.Redis.Database)
+	// New variables win over legacy ones
+	assert.Equal(t, "db.local", cfg.Database.Address)
+}
+
+func TestLoad_ShouldReadSecretsFromFiles(t *testing.T) {
+	f, err := ioutil.TempFile("", "secret")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString("very secret\n")
+	_ = f.Close()
+
+	_ = os.Setenv("MUROMACHI_DATABASE_PASSWORD_FILE", f.Name())
+	defer os.Unsetenv("MUROMACHI_DATABASE_PASSWORD_FILE")
+
+	cfg, err := config.Load("./dev.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, "very secret", cfg.Database.Password)
+}
+
+func TestLoad_ShouldReturnErrorIfEnvHasWrongFormat(t *testing.T) {
+	_ = os.Setenv("MUROMACHI_AUTH_JWT_EXPIRES", "tomorrow")
+	_ = os.Setenv("MUROMACHI_DATABASE_REDIS_DATABASE", "first")
+	defer os.Unsetenv("MUROMACHI_AUTH_JWT_EXPIRES")
+	defer os.Unsetenv("MUROMACHI_DATABASE_REDIS_DATABASE")
+
+	_, err := config.Load("./dev.yml")
+	assert.Error(t, err)
+	assert.Equal(t, 2, len(err.(config.ValidationError)))
+}
+
+func TestLoad_FlagsShouldOverrideEnvs(t *testing.T) {
+	_ = os.Setenv("MUROMACHI_DATABASE_ADDRESS", "db.local")
+	defer os.Unsetenv("MUROMACHI_DATABASE_ADDRESS")
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	config.RegisterFlags(flags)
+	assert.NoError(t, flags.Parse([]string{"--database.address", "db.remote", "--auth.jwt_iss=me"}))
+
+	cfg, err := config.Load("./dev.yml", flags)
+	assert.NoError(t, err)
+	assert.Equal(t, "db.remote", cfg.Database.Address)
+	assert.Equal(t, "me", cfg.Auth.JwtIss)
+}
+
+func TestLoad_ShouldReturnErrorIfFileNotFound(t *testing.T) {
+	_, err := config.Load("./nothing.yml")
+	assert.Error(t, err)
+}
+
+func TestConfig_Validate_ShouldReturnAllProblems(t *testing.T) {
+	cfg := config.New("./prod.yml")
+	cfg.Auth.JwtExpires = -time.Second
+	cfg.Database.Port = "port"
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+
+	problems, ok := err.(config.ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, 5, len(problems))
+}
+
+func TestConfig_Validate_DevConfigShouldBeValid(t *testing.T) {
+	assert.NoError(t, config.New().Validate())
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"Muromachi/logging"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prefix of environment variables which override config values
+const EnvPrefix = "MUROMACHI"
+
+// Environment variables of previous releases by keys of config values. They are still
+// read when new variables are not set, so deployments can move to new names
+var legacyEnvs = map[string]string{
+	"database.user":           "db_user",
+	"database.password":       "db_pass",
+	"database.address":        "db_address",
+	"database.port":           "db_port",
+	"database.redis.address":  "r_address",
+	"database.redis.port":     "r_port",
+	"database.redis.password": "r_pass",
+	"database.redis.database": "r_database",
+	"auth.jwt_salt":           "jwt_salt",
+	"auth.jwt_expires":        "jwt_exp",
+	"auth.jwt_iss":            "jwt_iss",
+}
+
+// Single value of config
+type field struct {
+	// Dotted path of yaml keys, for example: database.redis.port
+	Key string
+	// Struct tags of field
+	Tag reflect.StructTag
+	// Settable value of field
+	Value reflect.Value
+	// Can field be changed without restart
+	Hot bool
+}
+
+// Name of environment variable of field, for example: MUROMACHI_DATABASE_REDIS_PORT
+func (f field) Env() string {
+	name := strings.NewReplacer(".", "_", "-", "_").Replace(f.Key)
+	return EnvPrefix + "_" + strings.ToUpper(name)
+}
+
+// Set parses string and writes it to field value
+func (f field) Set(s string) error {
+	if err := setValue(f.Value, s); err != nil {
+		return fmt.Errorf("%s: %v", f.Key, err)
+	}
+	return nil
+}
+
+// fields walks through config struct and returns all leaf values
+// with keys built from yaml tags
+func fields(config *Config) []field {
+	return walk(reflect.ValueOf(config).Elem(), "", false)
+}
+
+func walk(v reflect.Value, prefix string, hot bool) []field {
+	var list []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" || sf.PkgPath != "" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		value := v.Field(i)
+		fieldHot := hot || sf.Tag.Get("reload") == "hot"
+		if value.Kind() == reflect.Struct && value.Type() != reflect.TypeOf(time.Time{}) {
+			list = append(list, walk(value, key, fieldHot)...)
+			continue
+		}
+		list = append(list, field{Key: key, Tag: sf.Tag, Value: value, Hot: fieldHot})
+	}
+
+	return list
+}
+
+// setValue parses s according to kind of v and writes result to v
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// applyDefaults sets values from `default` struct tags
+func applyDefaults(config *Config) error {
+	for _, f := range fields(config) {
+		if d, ok := f.Tag.Lookup("default"); ok {
+			if err := f.Set(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyEnvs overrides config values with environment variables.
+// If variable NAME_FILE is set then value is read from file with this path,
+// this is handy for docker and kubernetes secrets. Legacy variables of previous
+// releases are read if new ones are not set
+func applyEnvs(config *Config) error {
+	var problems ValidationError
+	for _, f := range fields(config) {
+		name := f.Env()
+		value, ok := os.LookupEnv(name)
+		if path, fileOk := os.LookupEnv(name + "_FILE"); fileOk && path != "" {
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s_FILE: %v", name, err))
+				continue
+			}
+			value, ok = strings.TrimRight(string(data), "\r\n"), true
+		}
+		if legacy, found := legacyEnvs[f.Key]; found && (!ok || value == "") {
+			if value, ok = os.LookupEnv(legacy); ok && value != "" {
+				logging.Warnf("env %s is deprecated, use %s", legacy, name)
+				name = legacy
+			}
+		}
+		if !ok || value == "" {
+			continue
+		}
+		if err := setValue(f.Value, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+
+	return nil
+}
+
+// RegisterFlags adds flag for every config value to given flag set.
+// Flags are named by dotted keys, for example: --database.redis.port
+func RegisterFlags(fs *flag.FlagSet) {
+	var config Config
+	for _, f := range fields(&config) {
+		fs.String(f.Key, "", fmt.Sprintf("overrides %s (env %s)", f.Key, f.Env()))
+	}
+}
+
+// applyFlags overrides config values with flags which were set by user
+func applyFlags(config *Config, fs *flag.FlagSet) error {
+	byKey := make(map[string]field)
+	for _, f := range fields(config) {
+		byKey[f.Key] = f
+	}
+
+	var problems ValidationError
+	fs.Visit(func(fl *flag.Flag) {
+		f, ok := byKey[fl.Name]
+		if !ok {
+			return
+		}
+		if err := f.Set(fl.Value.String()); err != nil {
+			problems = append(problems, "--"+err.Error())
+		}
+	})
+	if len(problems) > 0 {
+		return problems
+	}
+
+	return nil
+}
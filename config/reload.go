@@ -0,0 +1,159 @@
+package config
+
+import (
+	"Muromachi/logging"
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Changed config value
+type Change struct {
+	// Dotted key of value, for example: limits.max
+	Key string
+	// If true the value is applied without restart
+	Hot bool
+}
+
+// Diff returns list of values which are different in old and new configs
+func Diff(old, new Config) []Change {
+	var changes []Change
+	oldFields, newFields := fields(&old), fields(&new)
+	for i, f := range oldFields {
+		if !reflect.DeepEqual(f.Value.Interface(), newFields[i].Value.Interface()) {
+			changes = append(changes, Change{Key: f.Key, Hot: f.Hot})
+		}
+	}
+
+	return changes
+}
+
+// Store holds current config and reloads it from the same layers
+// which were used for the first loading
+type Store struct {
+	path  string
+	flags []*flag.FlagSet
+
+	mu          sync.Mutex
+	value       atomic.Value
+	subscribers []func(Config)
+}
+
+// Get returns current config. Safe for concurrent use
+func (s *Store) Get() Config {
+	return s.value.Load().(Config)
+}
+
+// Subscribe adds function which is called with new config every time
+// when hot values were changed
+func (s *Store) Subscribe(f func(Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, f)
+}
+
+// Reload loads and validates config again. Only hot values are applied to
+// current config, other changes are returned with Hot = false and
+// require restart of application
+func (s *Store) Reload() ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loaded, err := Load(s.path, s.flags...)
+	if err != nil {
+		return nil, err
+	}
+	if err = loaded.Validate(); err != nil {
+		return nil, err
+	}
+
+	current := s.Get()
+	changes := Diff(current, loaded)
+
+	next := current
+	nextFields, loadedFields := fields(&next), fields(&loaded)
+	applied := false
+	for i, f := range nextFields {
+		if f.Hot && !reflect.DeepEqual(f.Value.Interface(), loadedFields[i].Value.Interface()) {
+			f.Value.Set(loadedFields[i].Value)
+			applied = true
+		}
+	}
+	if !applied {
+		return changes, nil
+	}
+
+	s.value.Store(next)
+	for _, f := range s.subscribers {
+		f(next)
+	}
+
+	return changes, nil
+}
+
+// Watch reloads config when SIGHUP is received or when config file is modified.
+// File is checked with given interval. Blocks until ctx is done
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	modified := modTime(s.path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logging.Infof("SIGHUP received, reloading config %s", s.path)
+		case <-ticker.C:
+			t := modTime(s.path)
+			if t.Equal(modified) {
+				continue
+			}
+			modified = t
+			logging.Infof("config %s modified, reloading", s.path)
+		}
+
+		changes, err := s.Reload()
+		if err != nil {
+			logging.Errorf("config is not reloaded, %v", err)
+			continue
+		}
+		for _, c := range changes {
+			if c.Hot {
+				logging.Infof("config %s reloaded", c.Key)
+			} else {
+				logging.Warnf("config %s changed, requires restart", c.Key)
+			}
+		}
+	}
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// NewStore creates store with already loaded config. Path and flags
+// should be the same which were passed to Load
+func NewStore(config Config, path string, flags ...*flag.FlagSet) *Store {
+	s := &Store{
+		path:  path,
+		flags: flags,
+	}
+	s.value.Store(config)
+
+	return s
+}
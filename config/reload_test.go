@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"Muromachi/config"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiff_ShouldReturnChangedKeys(t *testing.T) {
+	old := config.New()
+	updated := old
+	updated.Limits.Max = old.Limits.Max + 1
+	updated.Database.Address = "another"
+
+	changes := config.Diff(old, updated)
+	assert.Equal(t, []config.Change{
+		{Key: "database.address", Hot: false},
+		{Key: "limits.max", Hot: true},
+	}, changes)
+}
+
+func TestStore_Reload_ShouldApplyOnlyHotValues(t *testing.T) {
+	data, err := ioutil.ReadFile("./dev.yml")
+	assert.NoError(t, err)
+
+	f, err := ioutil.TempFile("", "config*.yml")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	assert.NoError(t, ioutil.WriteFile(f.Name(), data, 0644))
+
+	cfg, err := config.Load(f.Name())
+	assert.NoError(t, err)
+	store := config.NewStore(cfg, f.Name())
+
+	var notified []config.Config
+	store.Subscribe(func(c config.Config) {
+		notified = append(notified, c)
+	})
+
+	updated := strings.Replace(string(data), "max: 20", "max: 50", 1)
+	updated = strings.Replace(updated, "jwt_expires: 24h", "jwt_expires: 1h", 1)
+	updated = strings.Replace(updated, "address: localhost", "address: db.remote", 1)
+	assert.NoError(t, ioutil.WriteFile(f.Name(), []byte(updated), 0644))
+
+	changes, err := store.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(changes))
+
+	current := store.Get()
+	assert.Equal(t, 50, current.Limits.Max)
+	assert.Equal(t, time.Hour, current.Auth.JwtExpires)
+	assert.Equal(t, "localhost", current.Database.Address)
+	assert.Equal(t, 1, len(notified))
+}
+
+func TestStore_Reload_ShouldKeepConfigIfNewOneIsInvalid(t *testing.T) {
+	data, err := ioutil.ReadFile("./dev.yml")
+	assert.NoError(t, err)
+
+	f, err := ioutil.TempFile("", "config*.yml")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	assert.NoError(t, ioutil.WriteFile(f.Name(), data, 0644))
+
+	cfg, err := config.Load(f.Name())
+	assert.NoError(t, err)
+	store := config.NewStore(cfg, f.Name())
+
+	updated := strings.Replace(string(data), "max: 20", "max: -1", 1)
+	assert.NoError(t, ioutil.WriteFile(f.Name(), []byte(updated), 0644))
+
+	_, err = store.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, 20, store.Get().Limits.Max)
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"Muromachi/logging"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// List of problems found in config
+type ValidationError []string
+
+func (v ValidationError) Error() string {
+	return "invalid config: " + strings.Join(v, "; ")
+}
+
+// Validate checks config values and returns ValidationError with
+// every found problem or nil if config is valid
+func (c Config) Validate() error {
+	var problems ValidationError
+	check := func(ok bool, problem string) {
+		if !ok {
+			problems = append(problems, problem)
+		}
+	}
+
+	check(c.Database.Address != "", "database.address is empty")
+	check(isPort(c.Database.Port), "database.port should be a number from 1 to 65535")
+	check(c.Database.Redis.Address != "", "database.redis.address is empty")
+	check(isPort(c.Database.Redis.Port), "database.redis.port should be a number from 1 to 65535")
+	check(c.Database.Redis.Database >= 0, "database.redis.database should not be negative")
+
+	check(c.Auth.JwtSalt != "", "auth.jwt_salt is empty")
+	check(c.Auth.JwtExpires > 0, "auth.jwt_expires should be positive duration")
+
+	check(c.Limits.Max > 0, "limits.max should be positive")
+	check(c.Limits.Expiration > 0, "limits.expiration should be positive duration")
+	check(c.Cors.MaxAge >= 0, "cors.max_age should not be negative")
+	_, err := logging.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level should be one of debug, info, warn, error")
+	if c.Scheduler.Enabled {
+		check(c.Scheduler.Interval > 0, "scheduler.interval should be positive duration")
+		check(c.Scheduler.BatchSize > 0, "scheduler.batch_size should be positive")
+		check(c.Scheduler.Timeout > 0, "scheduler.timeout should be positive duration")
+		check(c.Collector.BaseUrl != "", "collector.base_url is empty")
+		check(c.Collector.Timeout > 0, "collector.timeout should be positive duration")
+	}
+	check(c.Ingest.BodyLimit > 0, "ingest.body_limit should be positive")
+	if c.Alerts.Enabled {
+		check(c.Alerts.Interval > 0, "alerts.interval should be positive duration")
+		check(c.Alerts.BatchSize > 0, "alerts.batch_size should be positive")
+		check(c.Alerts.Timeout > 0, "alerts.timeout should be positive duration")
+		check(c.Alerts.MaxAttempts > 0, "alerts.max_attempts should be positive")
+		check(c.Alerts.BaseDelay > 0 && c.Alerts.BaseDelay <= c.Alerts.MaxDelay, "alerts.base_delay should be positive and not greater than alerts.max_delay")
+		check(c.Alerts.ScanInterval > 0, "alerts.scan_interval should be positive duration")
+	}
+	if c.Reports.Enabled {
+		check(c.Reports.Interval > 0, "reports.interval should be positive duration")
+		check(c.Reports.BatchSize > 0, "reports.batch_size should be positive")
+		check(c.Reports.Timeout > 0, "reports.timeout should be positive duration")
+		check(c.Reports.WebhookTimeout > 0, "reports.webhook_timeout should be positive duration")
+		check(c.Reports.MaxAttempts > 0, "reports.max_attempts should be positive")
+		check(c.Reports.BaseDelay > 0 && c.Reports.BaseDelay <= c.Reports.MaxDelay, "reports.base_delay should be positive and not greater than reports.max_delay")
+	}
+	if c.Partitions.Enabled {
+		check(c.Partitions.Interval > 0, "partitions.interval should be positive duration")
+		check(c.Partitions.Ahead > 0, "partitions.ahead should be positive")
+		check(c.Partitions.Meta.Retention >= 0, "partitions.meta.retention should not be negative")
+		check(c.Partitions.Categories.Retention >= 0, "partitions.categories.retention should not be negative")
+		check(c.Partitions.Keywords.Retention >= 0, "partitions.keywords.retention should not be negative")
+	}
+	if c.Rollups.Enabled {
+		// Readers ignore rollups which were not updated for an hour
+		check(c.Rollups.Interval > 0 && c.Rollups.Interval < time.Hour, "rollups.interval should be positive duration shorter than 1h")
+		check(c.Rollups.BatchSize > 0, "rollups.batch_size should be positive")
+	}
+	if c.Anomalies.Enabled {
+		check(c.Anomalies.Interval > 0, "anomalies.interval should be positive duration")
+		check(c.Anomalies.BatchSize > 0, "anomalies.batch_size should be positive")
+	}
+
+	if len(problems) > 0 {
+		return problems
+	}
+
+	return nil
+}
+
+func isPort(s string) bool {
+	port, err := strconv.Atoi(s)
+	return err == nil && port > 0 && port <= 65535
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"Muromachi/config"
+	"fmt"
+)
+
+// configCommand checks loaded config and prints every found problem
+func configCommand(cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return fmt.Errorf("%s", "usage: config check")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		if problems, ok := err.(config.ValidationError); ok {
+			for _, p := range problems {
+				fmt.Println(p)
+			}
+			return fmt.Errorf("config has %d problems", len(problems))
+		}
+		return err
+	}
+	fmt.Println("config is valid")
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/connector"
+	"Muromachi/store/users"
+	"Muromachi/store/users/sessions"
+	"Muromachi/store/users/sessions/blacklist"
+	"Muromachi/store/users/sessions/tokens"
+	"Muromachi/store/users/userstore"
+)
+
+// authTables opens connections to postgres and redis and returns users tables
+// with function which closes all opened connections
+func authTables(cfg config.Config) (*users.Tables, func(), error) {
+	url, err := connector.ConnectionUrl(cfg.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := connector.Connect(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	redisConn := connector.EstablishRedisConnection(cfg.Database.Redis)
+
+	session := sessions.New(tokens.New(conn), blacklist.New(redisConn))
+	tables := users.NewAuthTables(session, userstore.NewUserRepo(conn))
+
+	return tables, func() {
+		_ = redisConn.Close()
+		conn.Close()
+	}, nil
+}
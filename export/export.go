@@ -0,0 +1,163 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format of exported rows
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	XLSX   Format = "xlsx"
+)
+
+// Layout of exported dates
+const dateLayout = "2006-01-02 15:04:05"
+
+// ParseFormat returns format of name, empty name means CSV
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case "":
+		return CSV, nil
+	case CSV, NDJSON, XLSX:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("unknown export format %q, supported formats are csv, ndjson and xlsx", name)
+}
+
+// Content type of response with format
+func (f Format) ContentType() string {
+	switch f {
+	case NDJSON:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer encodes rows one by one, so rows are never collected in memory.
+// Values of row are in order of columns
+type Writer interface {
+	Write(values []interface{}) error
+	// Close writes end of document, underlying writer is not closed
+	Close() error
+}
+
+// New returns writer of format which writes header of columns to w
+func New(format Format, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case CSV:
+		c := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(columns))}
+		if err := c.w.Write(columns); err != nil {
+			return nil, err
+		}
+		return c, nil
+	case NDJSON:
+		return &ndjsonWriter{w: w, columns: columns}, nil
+	case XLSX:
+		return newXlsxWriter(w, columns)
+	}
+
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (c *csvWriter) Write(values []interface{}) error {
+	for i, v := range values {
+		c.record[i], _ = text(v)
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Writer of json objects keyed by columns, one object per line
+type ndjsonWriter struct {
+	w       io.Writer
+	columns []string
+	buf     []byte
+}
+
+func (n *ndjsonWriter) Write(values []interface{}) error {
+	n.buf = append(n.buf[:0], '{')
+	for i, v := range values {
+		if i > 0 {
+			n.buf = append(n.buf, ',')
+		}
+		n.buf = strconv.AppendQuote(n.buf, n.columns[i])
+		n.buf = append(n.buf, ':')
+		if t, ok := v.(time.Time); ok {
+			v = t.UTC().Format(time.RFC3339)
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		n.buf = append(n.buf, value...)
+	}
+	n.buf = append(n.buf, '}', '\n')
+
+	_, err := n.w.Write(n.buf)
+	return err
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// text formats value for text cells, numeric is true for numbers
+func text(v interface{}) (s string, numeric bool) {
+	switch value := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, false
+	case int:
+		return strconv.Itoa(value), true
+	case int32:
+		return strconv.FormatInt(int64(value), 10), true
+	case int64:
+		return strconv.FormatInt(value, 10), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case *int64:
+		if value == nil {
+			return "", false
+		}
+		return strconv.FormatInt(*value, 10), true
+	case *float64:
+		if value == nil {
+			return "", false
+		}
+		return strconv.FormatFloat(*value, 'f', -1, 64), true
+	case time.Time:
+		return value.UTC().Format(dateLayout), false
+	case []string:
+		return strings.Join(value, "; "), false
+	case []int64:
+		parts := make([]string, len(value))
+		for i, n := range value {
+			parts[i] = strconv.FormatInt(n, 10)
+		}
+		return strings.Join(parts, "; "), false
+	}
+
+	return fmt.Sprint(v), false
+}
@@ -0,0 +1,105 @@
+package export_test
+
+import (
+	"Muromachi/export"
+	"Muromachi/store/entities"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+// write encodes rows by writer of format
+func write(t *testing.T, format export.Format, columns []string, rows ...[]interface{}) []byte {
+	var buf bytes.Buffer
+	w, err := export.New(format, &buf, columns)
+	assert.NoError(t, err)
+	for _, row := range rows {
+		assert.NoError(t, w.Write(row))
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := export.ParseFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, export.CSV, format)
+	format, err = export.ParseFormat("XLSX")
+	assert.NoError(t, err)
+	assert.Equal(t, export.XLSX, format)
+	_, err = export.ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestWriter_CSV(t *testing.T) {
+	rating := 4.5
+	date := time.Date(2021, 1, 18, 10, 0, 0, 0, time.UTC)
+	out := write(t, export.CSV, []string{"id", "title", "rating", "reviews", "date", "screenshots"},
+		[]interface{}{1, "Bank, the best", &rating, (*int64)(nil), date, []string{"a.png", "b.png"}},
+	)
+
+	assert.Equal(t, "id,title,rating,reviews,date,screenshots\n1,\"Bank, the best\",4.5,,2021-01-18 10:00:00,a.png; b.png\n", string(out))
+}
+
+func TestWriter_NDJSON(t *testing.T) {
+	date := time.Date(2021, 1, 18, 10, 0, 0, 0, time.UTC)
+	out := write(t, export.NDJSON, []string{"id", "type", "place", "date"},
+		[]interface{}{1, "bank", int32(3), date},
+		[]interface{}{2, "loan", int32(7), date},
+	)
+
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, `{"id":1,"type":"bank","place":3,"date":"2021-01-18T10:00:00Z"}`, lines[0])
+	var row map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	assert.Equal(t, "loan", row["type"])
+}
+
+func TestWriter_XLSX(t *testing.T) {
+	out := write(t, export.XLSX, []string{"id", "title"},
+		[]interface{}{1, "Bank & <Loans>"},
+		[]interface{}{2, strings.Repeat("ж", 20000)},
+	)
+
+	archive, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	assert.NoError(t, err)
+	var names []string
+	var sheet string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			r, err := f.Open()
+			assert.NoError(t, err)
+			content, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			sheet = string(content)
+		}
+	}
+	assert.Equal(t, []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"}, names)
+	assert.Contains(t, sheet, `<row r="1"><c t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`)
+	assert.Contains(t, sheet, `<row r="2"><c><v>1</v></c><c t="inlineStr"><is><t xml:space="preserve">Bank &amp; &lt;Loans&gt;</t></is></c></row>`)
+	// Long text is cut by whole characters
+	assert.NotContains(t, sheet, "�")
+	assert.True(t, strings.HasSuffix(sheet, "</sheetData></worksheet>"))
+}
+
+func TestRows(t *testing.T) {
+	app := entities.App{Bundle: "com.bank", Geo: "en_us", Store: entities.StorePlay}
+	values, err := export.TrackRow(entities.Track{Id: 1, BundleId: 7, Type: "bank", Place: 3, App: app})
+	assert.NoError(t, err)
+	assert.Len(t, values, len(export.TrackColumns))
+	assert.Equal(t, "com.bank", values[2])
+
+	values, err = export.MetaRow(entities.Meta{Id: 1, BundleId: 7, Title: "Bank", App: app})
+	assert.NoError(t, err)
+	assert.Len(t, values, len(export.MetaColumns))
+
+	_, err = export.TrackRow(entities.Meta{})
+	assert.Error(t, err)
+}
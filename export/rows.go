@@ -0,0 +1,44 @@
+package export
+
+import (
+	"Muromachi/store/entities"
+	"fmt"
+)
+
+// Columns of exported meta snapshots
+var MetaColumns = []string{
+	"id", "bundleId", "bundle", "geo", "store", "date", "title", "price", "rating", "ratingValue",
+	"reviewCount", "reviewCountValue", "ratingHistogram", "installs", "installsMin", "installsMax",
+	"appSize", "sizeBytes", "version", "androidVersion", "iosVersion", "contentRating", "ageRating",
+	"releaseDate", "lastUpdateDate", "shortDescription", "description", "recentChanges",
+	"privacyPolicy", "picture", "screenshots", "supportedDevices", "inAppPurchases",
+}
+
+// Columns of exported category or keyword places
+var TrackColumns = []string{"id", "bundleId", "bundle", "geo", "store", "date", "type", "place"}
+
+// MetaRow returns values of snapshot in order of MetaColumns
+func MetaRow(dbo entities.DBO) ([]interface{}, error) {
+	m, ok := dbo.(entities.Meta)
+	if !ok {
+		return nil, fmt.Errorf("%T is not meta", dbo)
+	}
+
+	return []interface{}{
+		m.Id, m.BundleId, m.App.Bundle, m.App.Geo, string(m.App.Store), m.Date, m.Title, m.Price, m.Rating, m.RatingValue,
+		m.ReviewCount, m.ReviewCountValue, m.RatingHistogramValues, m.Installs, m.InstallsMin, m.InstallsMax,
+		m.AppSize, m.SizeBytes, m.Version, m.AndroidVersion, m.IosVersion, m.ContentRating, m.AgeRating,
+		m.ReleaseDate, m.LastUpdateDate, m.ShortDescription, m.Description, m.RecentChanges,
+		m.PrivacyPolicy, m.Picture, m.Screenshots, m.SupportedDevices, m.InAppPurchases,
+	}, nil
+}
+
+// TrackRow returns values of place in order of TrackColumns
+func TrackRow(dbo entities.DBO) ([]interface{}, error) {
+	t, ok := dbo.(entities.Track)
+	if !ok {
+		return nil, fmt.Errorf("%T is not track", dbo)
+	}
+
+	return []interface{}{t.Id, t.BundleId, t.App.Bundle, t.App.Geo, string(t.App.Store), t.Date, t.Type, t.Place}, nil
+}
@@ -0,0 +1,192 @@
+package graph
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/auth"
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/alerts"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/jackc/pgx/v4"
+	"strings"
+)
+
+const (
+	// Default count of deliveries of rule
+	defaultDeliveries = 20
+	// Max count of deliveries of rule
+	maxDeliveries = 100
+	// Size of random secret of webhook signature
+	secretSize = 32
+)
+
+// alertKind converts kind of graphql arguments
+func alertKind(kind model.AlertKind) entities.AlertKind {
+	return entities.AlertKind(strings.ToLower(string(kind)))
+}
+
+// alertCondition converts condition of graphql arguments
+func alertCondition(condition model.AlertCondition) entities.AlertCondition {
+	return entities.AlertCondition(strings.ToLower(string(condition)))
+}
+
+// createAlertRule creates rule of request client on app
+func (r *Resolver) createAlertRule(ctx context.Context, rule entities.AlertRule) (*model.AlertRule, error) {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rule.OwnerId = int(claims.ID)
+	rule.Type = strings.TrimSpace(rule.Type)
+	if rule.Kind == entities.AlertMeta {
+		rule.Type = strings.ToLower(rule.Type)
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, apperrors.New(apperrors.BadRequest, err.Error())
+	}
+	if err := validateWebhook(rule.WebhookUrl); err != nil {
+		return nil, err
+	}
+	if _, err := r.Tables.App.ById(ctx, rule.AppId); err != nil {
+		return nil, err
+	}
+
+	if rule.Secret, err = newSecret(); err != nil {
+		return nil, err
+	}
+	created, err := r.Tables.Alerts.CreateRule(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return toModelAlertRule(created)
+}
+
+// alertRules returns rules of request client, nil bundleId means rules of all apps
+func (r *Resolver) alertRules(ctx context.Context, bundleId *int) ([]*model.AlertRule, error) {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	appId := 0
+	if bundleId != nil {
+		appId = *bundleId
+	}
+
+	dbo, err := r.Tables.Alerts.Rules(ctx, int(claims.ID), appId)
+	if err == pgx.ErrNoRows {
+		return []*model.AlertRule{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*model.AlertRule, len(dbo))
+	if err := dbo.To(rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// ownedAlertRule returns rule of request client
+func (r *Resolver) ownedAlertRule(ctx context.Context, id int) (entities.AlertRule, error) {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return entities.AlertRule{}, err
+	}
+	rule, err := r.Tables.Alerts.RuleById(ctx, id)
+	if err != nil {
+		return rule, err
+	}
+	if rule.OwnerId != int(claims.ID) {
+		return rule, apperrors.New(apperrors.Forbidden, "alert rule belongs to another client")
+	}
+
+	return rule, nil
+}
+
+// setAlertRuleEnabled enables or disables rule of request client
+func (r *Resolver) setAlertRuleEnabled(ctx context.Context, id int, enabled bool) (*model.AlertRule, error) {
+	if _, err := r.ownedAlertRule(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rule, err := r.Tables.Alerts.SetRuleEnabled(ctx, id, enabled)
+	if err != nil {
+		return nil, err
+	}
+
+	return toModelAlertRule(rule)
+}
+
+// deleteAlertRule deletes rule of request client
+func (r *Resolver) deleteAlertRule(ctx context.Context, id int) (bool, error) {
+	if _, err := r.ownedAlertRule(ctx, id); err != nil {
+		return false, err
+	}
+	if err := r.Tables.Alerts.DeleteRule(ctx, id); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// alertDeliveries returns last deliveries of rule
+func (r *Resolver) alertDeliveries(ctx context.Context, ruleId int, last *int) ([]*model.AlertDelivery, error) {
+	limit := defaultDeliveries
+	if last != nil {
+		limit = *last
+	}
+	if limit <= 0 || limit > maxDeliveries {
+		return nil, apperrors.New(apperrors.BadRequest, "last should be from 1 to 100")
+	}
+
+	dbo, err := r.Tables.Alerts.Deliveries(ctx, ruleId, limit)
+	if err == pgx.ErrNoRows {
+		return []*model.AlertDelivery{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*model.AlertDelivery, len(dbo))
+	if err := dbo.To(deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// validateWebhook checks that webhook url is absolute http url of external host
+func validateWebhook(webhook string) error {
+	switch err := alerts.ValidateWebhook(webhook); err {
+	case nil:
+		return nil
+	case alerts.ErrWebhookAddress:
+		return apperrors.New(apperrors.BadRequest, "webhookUrl should not point to loopback, private or link-local address")
+	default:
+		return apperrors.New(apperrors.BadRequest, "webhookUrl should be absolute http or https url")
+	}
+}
+
+// newSecret returns random hex secret of webhook signature
+func newSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// toModelAlertRule converts rule to graphql model
+func toModelAlertRule(rule entities.AlertRule) (*model.AlertRule, error) {
+	m := &model.AlertRule{}
+	if err := rule.To(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,166 @@
+package graph_test
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Repository which keeps alert rules in memory
+type mockAlertRepo struct {
+	rules      []entities.AlertRule
+	deliveries []entities.AlertDelivery
+}
+
+func (m *mockAlertRepo) Rules(ctx context.Context, ownerId, appId int) (entities.DboSlice, error) {
+	var dbo entities.DboSlice
+	for _, rule := range m.rules {
+		if rule.OwnerId == ownerId && (appId == 0 || rule.AppId == appId) {
+			dbo = append(dbo, rule)
+		}
+	}
+	if len(dbo) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return dbo, nil
+}
+
+func (m *mockAlertRepo) RuleById(ctx context.Context, id int) (entities.AlertRule, error) {
+	for _, rule := range m.rules {
+		if rule.Id == id {
+			return rule, nil
+		}
+	}
+	return entities.AlertRule{}, pgx.ErrNoRows
+}
+
+func (m *mockAlertRepo) CreateRule(ctx context.Context, rule entities.AlertRule) (entities.AlertRule, error) {
+	rule.Id = len(m.rules) + 1
+	rule.Enabled = true
+	m.rules = append(m.rules, rule)
+	return rule, nil
+}
+
+func (m *mockAlertRepo) SetRuleEnabled(ctx context.Context, id int, enabled bool) (entities.AlertRule, error) {
+	for i := range m.rules {
+		if m.rules[i].Id == id {
+			m.rules[i].Enabled = enabled
+			return m.rules[i], nil
+		}
+	}
+	return entities.AlertRule{}, pgx.ErrNoRows
+}
+
+func (m *mockAlertRepo) DeleteRule(ctx context.Context, id int) error {
+	for i := range m.rules {
+		if m.rules[i].Id == id {
+			m.rules = append(m.rules[:i], m.rules[i+1:]...)
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *mockAlertRepo) Deliveries(ctx context.Context, ruleId, limit int) (entities.DboSlice, error) {
+	var dbo entities.DboSlice
+	for _, d := range m.deliveries {
+		if d.RuleId == ruleId && len(dbo) < limit {
+			dbo = append(dbo, d)
+		}
+	}
+	if len(dbo) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return dbo, nil
+}
+
+func TestCreateAlertRule_ShouldValidateRuleAndGenerateSecret(t *testing.T) {
+	resolver, _ := newAppsResolver()
+	repo := &mockAlertRepo{}
+	resolver.Tables.Alerts = repo
+	ctx := withClient(7)
+
+	var tt = []struct {
+		name      string
+		bundleId  int
+		kind      model.AlertKind
+		typ       string
+		condition model.AlertCondition
+		threshold int
+		webhook   string
+	}{
+		{name: "empty keyword", bundleId: 1, kind: model.AlertKindKeyword, typ: " ", condition: model.AlertConditionBelow, threshold: 10, webhook: "https://hook"},
+		{name: "place without threshold", bundleId: 1, kind: model.AlertKindKeyword, typ: "bank", condition: model.AlertConditionBelow, webhook: "https://hook"},
+		{name: "unknown meta field", bundleId: 1, kind: model.AlertKindMeta, typ: "NAME", condition: model.AlertConditionChanged, webhook: "https://hook"},
+		{name: "place condition of meta", bundleId: 1, kind: model.AlertKindMeta, typ: "TITLE", condition: model.AlertConditionAbove, threshold: 1, webhook: "https://hook"},
+		{name: "relative webhook", bundleId: 1, kind: model.AlertKindMeta, typ: "TITLE", condition: model.AlertConditionChanged, webhook: "/hook"},
+		{name: "ftp webhook", bundleId: 1, kind: model.AlertKindMeta, typ: "TITLE", condition: model.AlertConditionChanged, webhook: "ftp://hook"},
+		{name: "loopback webhook", bundleId: 1, kind: model.AlertKindMeta, typ: "TITLE", condition: model.AlertConditionChanged, webhook: "http://127.0.0.1:5432"},
+		{name: "metadata webhook", bundleId: 1, kind: model.AlertKindMeta, typ: "TITLE", condition: model.AlertConditionChanged, webhook: "http://169.254.169.254/latest"},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := resolver.Mutation().CreateAlertRule(ctx, test.bundleId, test.kind, test.typ, test.condition, test.threshold, test.webhook)
+			assert.True(t, errors.Is(err, apperrors.New(apperrors.BadRequest, "")), err)
+		})
+	}
+
+	_, err := resolver.Mutation().CreateAlertRule(ctx, 100, model.AlertKindMeta, "TITLE", model.AlertConditionChanged, 0, "https://hook")
+	assert.Equal(t, pgx.ErrNoRows, err)
+
+	rule, err := resolver.Mutation().CreateAlertRule(ctx, 1, model.AlertKindMeta, "TITLE", model.AlertConditionChanged, 0, "https://hook")
+	assert.NoError(t, err)
+	assert.True(t, rule.Enabled)
+	assert.Equal(t, "title", rule.Type)
+	assert.Len(t, rule.Secret, 64)
+	assert.Equal(t, 7, repo.rules[0].OwnerId)
+
+	_, err = resolver.Mutation().CreateAlertRule(context.Background(), 1, model.AlertKindMeta, "TITLE", model.AlertConditionChanged, 0, "https://hook")
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.NotAuthenticated, "")))
+}
+
+func TestAlertRules_ShouldBeChangedOnlyByOwner(t *testing.T) {
+	resolver, _ := newAppsResolver()
+	repo := &mockAlertRepo{
+		rules: []entities.AlertRule{
+			{Id: 1, OwnerId: 7, AppId: 1, Kind: entities.AlertKeyword, Type: "bank", Condition: entities.AlertBelow, Threshold: 10, Enabled: true},
+			{Id: 2, OwnerId: 8, AppId: 1, Kind: entities.AlertKeyword, Type: "bank", Condition: entities.AlertBelow, Threshold: 10, Enabled: true},
+		},
+		deliveries: []entities.AlertDelivery{{Id: 1, RuleId: 1, Payload: []byte(`{}`), Status: entities.DeliveryFailed, ResponseStatus: 502}},
+	}
+	resolver.Tables.Alerts = repo
+	ctx := withClient(7)
+
+	rules, err := resolver.Query().AlertRules(ctx, nil)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+	other := 2
+	rules, err = resolver.Query().AlertRules(ctx, &other)
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+
+	deliveries, err := resolver.AlertRule().Deliveries(ctx, &model.AlertRule{ID: 1}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, model.DeliveryStatusFailed, deliveries[0].Status)
+	assert.Equal(t, 502, *deliveries[0].ResponseStatus)
+
+	rule, err := resolver.Mutation().SetAlertRuleEnabled(ctx, 1, false)
+	assert.NoError(t, err)
+	assert.False(t, rule.Enabled)
+
+	_, err = resolver.Mutation().SetAlertRuleEnabled(ctx, 2, false)
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.Forbidden, "")))
+	_, err = resolver.Mutation().DeleteAlertRule(ctx, 2)
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.Forbidden, "")))
+
+	deleted, err := resolver.Mutation().DeleteAlertRule(ctx, 1)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	assert.Len(t, repo.rules, 1)
+}
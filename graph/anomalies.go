@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/auth"
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"strings"
+)
+
+// anomalies returns anomalies of app within range with their review status. Anomalies
+// are detected and saved by background job, so query only reads them
+func (r *Resolver) anomalies(ctx context.Context, bundleId int, rng *model.DateRange) ([]*model.Anomaly, error) {
+	if _, err := r.app(ctx, bundleId); err != nil {
+		return nil, err
+	}
+	start, end := dateRange(rng)
+
+	dbo, err := r.Tables.Anomalies.Anomalies(ctx, bundleId, start, end)
+	if err == pgx.ErrNoRows {
+		return []*model.Anomaly{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Anomaly, len(dbo))
+	if err := dbo.To(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// reviewAnomaly changes review status of anomaly of app which can be changed by request client
+func (r *Resolver) reviewAnomaly(ctx context.Context, id int, status model.AnomalyStatus) (*model.Anomaly, error) {
+	if !status.IsValid() {
+		return nil, apperrors.New(apperrors.BadRequest, "unknown status of anomaly")
+	}
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	anomaly, err := r.Tables.Anomalies.ById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Anomalies of stopped apps are still reviewed, so only owner of app is checked
+	app, err := r.Tables.App.ById(ctx, anomaly.BundleId)
+	if err != nil {
+		return nil, err
+	}
+	if app.OwnerId != 0 && app.OwnerId != int(claims.ID) {
+		return nil, apperrors.New(apperrors.Forbidden, "app is tracked by another client")
+	}
+
+	anomaly, err = r.Tables.Anomalies.SetStatus(ctx, id, entities.AnomalyStatus(strings.ToLower(string(status))))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &model.Anomaly{}
+	if err := anomaly.To(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,127 @@
+package graph_test
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/graph/model"
+	"Muromachi/graph/scalar"
+	"Muromachi/store/entities"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Repository which keeps anomalies in memory
+type mockAnomalyRepo struct {
+	anomalies []entities.Anomaly
+	// Count of Save calls
+	saves int
+}
+
+func (m *mockAnomalyRepo) Anomalies(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
+	var dbo entities.DboSlice
+	for _, a := range m.anomalies {
+		if a.BundleId == bundleId && !a.Date.Before(start) && (end.IsZero() || !a.Date.After(end)) {
+			dbo = append(dbo, a)
+		}
+	}
+	if len(dbo) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return dbo, nil
+}
+
+func (m *mockAnomalyRepo) Save(ctx context.Context, anomalies []entities.Anomaly) error {
+	m.saves++
+	for _, a := range anomalies {
+		saved := false
+		for _, v := range m.anomalies {
+			saved = saved || (v.BundleId == a.BundleId && v.Metric == a.Metric && v.Type == a.Type && v.Date.Equal(a.Date))
+		}
+		if !saved {
+			a.Id = int64(len(m.anomalies) + 1)
+			m.anomalies = append(m.anomalies, a)
+		}
+	}
+	return nil
+}
+
+func (m *mockAnomalyRepo) ById(ctx context.Context, id int) (entities.Anomaly, error) {
+	for _, a := range m.anomalies {
+		if a.Id == int64(id) {
+			return a, nil
+		}
+	}
+	return entities.Anomaly{}, pgx.ErrNoRows
+}
+
+func (m *mockAnomalyRepo) SetStatus(ctx context.Context, id int, status entities.AnomalyStatus) (entities.Anomaly, error) {
+	for i := range m.anomalies {
+		if m.anomalies[i].Id == int64(id) {
+			m.anomalies[i].Status = status
+			return m.anomalies[i], nil
+		}
+	}
+	return entities.Anomaly{}, pgx.ErrNoRows
+}
+
+func TestAnomalies_ShouldReadSavedAnomaliesOfRange_Mock(t *testing.T) {
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	resolver, _ := newAppsResolver()
+	repo := &mockAnomalyRepo{anomalies: []entities.Anomaly{
+		{Id: 1, BundleId: 1, Metric: entities.AnomalyKeyword, Type: "bank", Date: day, Change: 29, Status: entities.AnomalyNew},
+		{Id: 2, BundleId: 1, Metric: entities.AnomalyRating, Date: day.AddDate(0, 0, -7), Change: -0.6, Status: entities.AnomalyNew},
+	}}
+	resolver.Tables.Anomalies = repo
+
+	start := scalar.FormattedDate(day)
+	anomalies, err := resolver.Query().Anomalies(context.Background(), 1, &model.DateRange{Start: &start})
+	assert.NoError(t, err)
+	assert.Len(t, anomalies, 1)
+	assert.Equal(t, model.AnomalyMetricKeyword, anomalies[0].Metric)
+	assert.Equal(t, "bank", anomalies[0].Type)
+	assert.Equal(t, 29.0, anomalies[0].Change)
+	assert.Equal(t, model.AnomalyStatusNew, anomalies[0].Status)
+
+	// Query does not detect and save anomalies
+	assert.Equal(t, 0, repo.saves)
+
+	_, err = resolver.Query().Anomalies(context.Background(), 100, nil)
+	assert.Equal(t, pgx.ErrNoRows, err)
+}
+
+func TestAnomalies_ShouldReturnEmptyListWithoutAnomalies_Mock(t *testing.T) {
+	resolver, _ := newAppsResolver()
+	resolver.Tables.Anomalies = &mockAnomalyRepo{}
+
+	anomalies, err := resolver.Query().Anomalies(context.Background(), 1, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, anomalies)
+	assert.Empty(t, anomalies)
+}
+
+func TestReviewAnomaly_ShouldChangeStatusOfOwnedApps_Mock(t *testing.T) {
+	resolver, apps := newAppsResolver()
+	apps.apps[1].OwnerId = 8
+	repo := &mockAnomalyRepo{anomalies: []entities.Anomaly{
+		{Id: 1, BundleId: 1, Metric: entities.AnomalyRating, Status: entities.AnomalyNew},
+		{Id: 2, BundleId: 2, Metric: entities.AnomalyRating, Status: entities.AnomalyNew},
+	}}
+	resolver.Tables.Anomalies = repo
+
+	_, err := resolver.Mutation().ReviewAnomaly(context.Background(), 1, model.AnomalyStatusConfirmed)
+	assert.Error(t, err)
+	_, err = resolver.Mutation().ReviewAnomaly(withClient(7), 1, model.AnomalyStatus("UNKNOWN"))
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.BadRequest, "")), err)
+	_, err = resolver.Mutation().ReviewAnomaly(withClient(7), 2, model.AnomalyStatusConfirmed)
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.Forbidden, "")), err)
+	_, err = resolver.Mutation().ReviewAnomaly(withClient(7), 3, model.AnomalyStatusConfirmed)
+	assert.Equal(t, pgx.ErrNoRows, err)
+
+	anomaly, err := resolver.Mutation().ReviewAnomaly(withClient(7), 1, model.AnomalyStatusDismissed)
+	assert.NoError(t, err)
+	assert.Equal(t, model.AnomalyStatusDismissed, anomaly.Status)
+	assert.Equal(t, entities.AnomalyDismissed, repo.anomalies[0].Status)
+}
@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// appFilter converts graphql filter of apps to repository filter
+func appFilter(filter *model.AppFilter) entities.AppFilter {
+	var f entities.AppFilter
+	if filter == nil {
+		return f
+	}
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	f.Bundle = value(filter.Bundle)
+	f.Developer = value(filter.Developer)
+	f.DeveloperId = value(filter.DeveloperID)
+	f.Category = value(filter.Category)
+	f.Geo = value(filter.Geo)
+	if filter.StartedAfter != nil {
+		f.StartedAfter = *filter.StartedAfter
+	}
+	if filter.Status != nil {
+		f.Status = entities.AppStatus(strings.ToLower(string(*filter.Status)))
+	}
+	if filter.Store != nil {
+		f.Store = appStore(filter.Store)
+	}
+
+	return f
+}
+
+// appOrder converts graphql order of apps to repository order, apps are ordered by id by default
+func appOrder(order *model.AppOrder) entities.AppOrder {
+	o := entities.AppOrder{Field: entities.AppOrderId}
+	if order == nil {
+		return o
+	}
+
+	switch order.Field {
+	case model.AppOrderFieldBundle:
+		o.Field = entities.AppOrderBundle
+	case model.AppOrderFieldStartAt:
+		o.Field = entities.AppOrderStartAt
+	}
+	o.Desc = order.Direction != nil && *order.Direction == model.OrderDirectionDesc
+
+	return o
+}
+
+// EncodeAppCursor returns opaque cursor of app position in given order
+func EncodeAppCursor(app entities.App, order entities.AppOrder) string {
+	var value string
+	switch order.Field {
+	case entities.AppOrderBundle:
+		value = app.Bundle
+	case entities.AppOrderStartAt:
+		value = strconv.FormatInt(app.StartAt.UTC().UnixNano(), 10)
+	}
+	raw := fmt.Sprintf("%s:%d:%s", order.Field, app.Id, value)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeAppCursor parses cursor created by EncodeAppCursor. Cursor
+// should be created for the same order
+func DecodeAppCursor(cursor string, order entities.AppOrder) (*entities.AppCursor, error) {
+	invalid := apperrors.New(apperrors.BadRequest, fmt.Sprintf("invalid cursor %q", cursor))
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 3 || entities.AppOrderField(parts[0]) != order.Field {
+		return nil, invalid
+	}
+
+	c := &entities.AppCursor{}
+	if c.Id, err = strconv.Atoi(parts[1]); err != nil {
+		return nil, invalid
+	}
+	switch order.Field {
+	case entities.AppOrderBundle:
+		c.Bundle = parts[2]
+	case entities.AppOrderStartAt:
+		nano, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		c.StartAt = time.Unix(0, nano).UTC()
+	}
+
+	return c, nil
+}
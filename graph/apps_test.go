@@ -0,0 +1,316 @@
+package graph_test
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/graph"
+	"Muromachi/graph/model"
+	"Muromachi/graph/scalar"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// App repository which remembers last search query
+type mockSearchRepo struct {
+	mockMemoryRepo
+	apps  []entities.App
+	query entities.AppQuery
+}
+
+func (m *mockSearchRepo) ById(ctx context.Context, id int) (entities.App, error) {
+	for _, app := range m.apps {
+		if app.Id == id {
+			return app, nil
+		}
+	}
+	return entities.App{}, pgx.ErrNoRows
+}
+
+func (m *mockSearchRepo) ByBundleIds(ctx context.Context, bundleIds []int, start, end time.Time) (entities.DboSlice, error) {
+	var dbo entities.DboSlice
+	for _, id := range bundleIds {
+		if app, err := m.ById(ctx, id); err == nil {
+			dbo = append(dbo, app)
+		}
+	}
+	return dbo, nil
+}
+
+func (m *mockSearchRepo) ByBundle(ctx context.Context, store entities.AppStore, bundle, geo string) (entities.App, error) {
+	for _, app := range m.apps {
+		if app.StoreOrDefault() == store && app.Bundle == bundle && app.Geo == geo {
+			return app, nil
+		}
+	}
+	return entities.App{}, pgx.ErrNoRows
+}
+
+func (m *mockSearchRepo) Search(ctx context.Context, query entities.AppQuery) (entities.DboSlice, error) {
+	m.query = query
+	var dbo entities.DboSlice
+	for _, app := range m.apps {
+		if query.After != nil && app.Id <= query.After.Id {
+			continue
+		}
+		if len(dbo) == query.Limit {
+			break
+		}
+		dbo = append(dbo, app)
+	}
+	if len(dbo) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return dbo, nil
+}
+
+func (m *mockSearchRepo) CountApps(ctx context.Context, filter entities.AppFilter) (int, error) {
+	return len(m.apps), nil
+}
+
+func (m *mockSearchRepo) Create(ctx context.Context, app entities.App) (entities.App, error) {
+	for _, v := range m.apps {
+		if v.StoreOrDefault() == app.StoreOrDefault() && v.Bundle == app.Bundle && v.Geo == app.Geo && v.Status != entities.AppStopped {
+			return app, apperrors.New(apperrors.Conflict, "already tracked")
+		}
+	}
+	app.Id = len(m.apps) + 1
+	app.Status = entities.AppActive
+	m.apps = append(m.apps, app)
+	return app, nil
+}
+
+func (m *mockSearchRepo) Update(ctx context.Context, app entities.App) (entities.App, error) {
+	for i, v := range m.apps {
+		if v.Id == app.Id {
+			m.apps[i] = app
+			return app, nil
+		}
+	}
+	return app, pgx.ErrNoRows
+}
+
+func (m *mockSearchRepo) SetStatus(ctx context.Context, id int, status entities.AppStatus) (entities.App, error) {
+	for i, v := range m.apps {
+		if v.Id == id {
+			m.apps[i].Status = status
+			return m.apps[i], nil
+		}
+	}
+	return entities.App{}, pgx.ErrNoRows
+}
+
+func newAppsResolver() (*graph.Resolver, *mockSearchRepo) {
+	repo := &mockSearchRepo{}
+	for i := 1; i <= 5; i++ {
+		repo.apps = append(repo.apps, entities.App{Id: i, Bundle: "com.app", Geo: "ru_RU", StartAt: time.Now()})
+	}
+	repo.apps[4].Geo = "en_US"
+
+	return &graph.Resolver{Tables: &tracking.Tables{App: repo}}, repo
+}
+
+func TestAppCursor_ShouldBeDecodedOnlyWithTheSameOrder(t *testing.T) {
+	app := entities.App{
+		Id:      7,
+		Bundle:  "com:app",
+		StartAt: time.Date(2021, 1, 18, 1, 2, 3, 4000, time.UTC),
+	}
+	for _, field := range []entities.AppOrderField{entities.AppOrderId, entities.AppOrderBundle, entities.AppOrderStartAt} {
+		order := entities.AppOrder{Field: field}
+		cursor := graph.EncodeAppCursor(app, order)
+
+		decoded, err := graph.DecodeAppCursor(cursor, order)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, decoded.Id)
+		if field == entities.AppOrderBundle {
+			assert.Equal(t, "com:app", decoded.Bundle)
+		}
+		if field == entities.AppOrderStartAt {
+			assert.True(t, app.StartAt.Equal(decoded.StartAt))
+		}
+	}
+
+	cursor := graph.EncodeAppCursor(app, entities.AppOrder{Field: entities.AppOrderBundle})
+	_, err := graph.DecodeAppCursor(cursor, entities.AppOrder{Field: entities.AppOrderId})
+	assert.Error(t, err)
+}
+
+func TestApps_ShouldPassFilterAndOrderToRepository(t *testing.T) {
+	resolver, repo := newAppsResolver()
+	ctx := context.Background()
+
+	bundle, geo := "app", "ru_RU"
+	desc := model.OrderDirectionDesc
+	conn, err := resolver.Query().Apps(ctx, &model.AppFilter{
+		Bundle: &bundle,
+		Geo:    &geo,
+	}, &model.AppOrder{
+		Field:     model.AppOrderFieldStartAt,
+		Direction: &desc,
+	}, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, conn.Edges, 5)
+	assert.False(t, conn.PageInfo.HasNextPage)
+
+	assert.Equal(t, "app", repo.query.Filter.Bundle)
+	assert.Equal(t, "ru_RU", repo.query.Filter.Geo)
+	assert.Equal(t, "", repo.query.Filter.Category)
+	assert.Equal(t, entities.AppOrderStartAt, repo.query.Order.Field)
+	assert.True(t, repo.query.Order.Desc)
+
+	count, err := resolver.AppConnection().TotalCount(ctx, conn)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+func TestApps_ShouldWalkThroughAllPages(t *testing.T) {
+	resolver, _ := newAppsResolver()
+	ctx := context.Background()
+
+	var (
+		after *string
+		ids   []int
+	)
+	for {
+		conn, err := resolver.Query().Apps(ctx, nil, nil, intPtr(2), after)
+		assert.NoError(t, err)
+		for _, edge := range conn.Edges {
+			ids = append(ids, edge.Node.ID)
+		}
+		if !conn.PageInfo.HasNextPage {
+			break
+		}
+		after = conn.PageInfo.EndCursor
+	}
+
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+}
+
+func TestApp_ShouldReturnNilIfAppNotFound(t *testing.T) {
+	resolver, _ := newAppsResolver()
+	ctx := context.Background()
+
+	app, err := resolver.Query().App(ctx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, app.ID)
+
+	app, err = resolver.Query().App(ctx, 100)
+	assert.NoError(t, err)
+	assert.Nil(t, app)
+
+	app, err = resolver.Query().AppByBundle(ctx, "com.app", "en_US", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, app.ID)
+
+	app, err = resolver.Query().AppByBundle(ctx, "com.app", "de_DE", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, app)
+}
+
+func TestApp_ShouldResolveTrackingHistory(t *testing.T) {
+	date, _ := time.Parse("2006-01-02", "2021-01-18")
+	keys := mockMemoryRepo{}
+	for i, keyword := range []string{"bank", "money", "bank"} {
+		keys.rows = append(keys.rows, entities.Track{Id: i + 1, BundleId: 1, Type: keyword, Date: date})
+	}
+	meta := mockMemoryRepo{rows: entities.DboSlice{
+		entities.Meta{Id: 1, BundleId: 1, Title: "old", Date: date},
+		entities.Meta{Id: 2, BundleId: 1, Title: "new", Date: date.AddDate(0, 0, 1)},
+	}}
+	resolver := &graph.Resolver{Tables: &tracking.Tables{
+		App:  &mockSearchRepo{},
+		Meta: meta,
+		Cat:  mockMemoryRepo{},
+		Keys: keys,
+	}}
+	app := &model.App{ID: 1}
+	ctx := context.Background()
+
+	metaModels, err := resolver.App().Meta(ctx, app, nil)
+	assert.NoError(t, err)
+	assert.Len(t, metaModels, 2)
+
+	// Repository returns ErrNoRows for empty table
+	cats, err := resolver.App().Categories(ctx, app, &model.DateRange{}, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, cats)
+	assert.Empty(t, cats)
+
+	keyword := "bank"
+	keywords, err := resolver.App().Keywords(ctx, app, nil, &keyword, nil)
+	assert.NoError(t, err)
+	assert.Len(t, keywords, 2)
+	for _, k := range keywords {
+		assert.Equal(t, "bank", k.Type)
+	}
+
+	all, err := resolver.App().Keywords(ctx, app, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func TestApp_ShouldResolveHistoryByResolution(t *testing.T) {
+	date, _ := time.Parse("2006-01-02", "2021-01-18")
+	keys := mockMemoryRepo{
+		rows: entities.DboSlice{
+			entities.Track{Id: 1, BundleId: 1, Type: "bank", Place: 3, Date: date},
+			entities.Track{Id: 2, BundleId: 1, Type: "bank", Place: 2, Date: date.AddDate(0, 0, 1)},
+		},
+		buckets: entities.DboSlice{
+			entities.Track{BundleId: 1, Type: "bank", Place: 2, Date: date},
+		},
+	}
+	resolver := &graph.Resolver{Tables: &tracking.Tables{
+		App:  &mockSearchRepo{},
+		Meta: mockMemoryRepo{},
+		Cat:  mockMemoryRepo{},
+		Keys: keys,
+	}}
+	app := &model.App{ID: 1}
+	ctx := context.Background()
+
+	start, end := scalar.FormattedDate(date.AddDate(-2, 0, 0)), scalar.FormattedDate(date)
+	wide := &model.DateRange{Start: &start, End: &end}
+	keywords, err := resolver.App().Keywords(ctx, app, wide, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, keywords, 1)
+
+	raw := model.ResolutionRaw
+	keywords, err = resolver.App().Keywords(ctx, app, wide, nil, &raw)
+	assert.NoError(t, err)
+	assert.Len(t, keywords, 2)
+
+	week := model.ResolutionWeek
+	narrow := scalar.FormattedDate(date.AddDate(0, 0, -7))
+	keywords, err = resolver.Query().Keys(ctx, 1, nil, &narrow, &end, &week)
+	assert.NoError(t, err)
+	assert.Len(t, keywords, 1)
+
+	keywords, err = resolver.Query().Keys(ctx, 1, nil, &narrow, &end, nil)
+	assert.NoError(t, err)
+	assert.Len(t, keywords, 2)
+}
+
+func TestRankStats_ShouldReturnEmptyListWithoutRows(t *testing.T) {
+	resolver := &graph.Resolver{Tables: &tracking.Tables{
+		Cat: mockMemoryRepo{},
+		Keys: mockMemoryRepo{stats: entities.DboSlice{
+			entities.RankStats{Type: "bank", Min: 1, Max: 3, Count: 2},
+		}},
+	}}
+	ctx := context.Background()
+
+	stats, err := resolver.Query().KeywordRankStats(ctx, 1, "bank", nil, model.BucketWeek)
+	assert.NoError(t, err)
+	assert.Len(t, stats, 1)
+	assert.Equal(t, 3, stats[0].Max)
+
+	stats, err = resolver.Query().CategoryRankStats(ctx, 1, nil, &model.DateRange{}, model.BucketDay)
+	assert.NoError(t, err)
+	assert.NotNil(t, stats)
+	assert.Empty(t, stats)
+}
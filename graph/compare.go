@@ -0,0 +1,101 @@
+package graph
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Max count of apps in one comparison
+const maxCompareBundles = 20
+
+// compare aggregates places of apps by keyword or category and aligns series
+// of every app by buckets, so missing points of series are null
+func (r *Resolver) compare(ctx context.Context, bundleIds []int, keyword, category *string, rng *model.DateRange, bucket model.Bucket) (*model.Comparison, error) {
+	var (
+		repo tracking.TrackRepository
+		typ  string
+	)
+	switch {
+	case keyword != nil && category == nil:
+		repo, typ = r.Tables.Keys, *keyword
+	case category != nil && keyword == nil:
+		repo, typ = r.Tables.Cat, *category
+	default:
+		return nil, apperrors.New(apperrors.BadRequest, "exactly one of keyword or category should be given")
+	}
+	if typ == "" {
+		return nil, apperrors.New(apperrors.BadRequest, "keyword or category should not be empty")
+	}
+
+	ids := make([]int, 0, len(bundleIds))
+	seen := make(map[int]bool, len(bundleIds))
+	for _, id := range bundleIds {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > maxCompareBundles {
+		return nil, apperrors.New(apperrors.BadRequest, fmt.Sprintf("comparison is limited by %d apps", maxCompareBundles))
+	}
+
+	comparison := &model.Comparison{Buckets: []*time.Time{}, Series: make([]*model.ComparisonSeries, len(ids))}
+	if len(ids) == 0 {
+		return comparison, nil
+	}
+
+	start, end := dateRange(rng)
+	dbo, err := repo.RankStatsByBundleIds(ctx, ids, typ, entities.Bucket(strings.ToLower(string(bucket))), start, end)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, err
+	}
+
+	points := make(map[int]map[int64]*model.RankPoint, len(ids))
+	buckets := make(map[int64]time.Time)
+	for _, v := range dbo {
+		var stats entities.RankStats
+		if err := v.To(&stats); err != nil {
+			return nil, err
+		}
+		key := stats.Bucket.UnixNano()
+		buckets[key] = stats.Bucket
+		if points[stats.BundleId] == nil {
+			points[stats.BundleId] = make(map[int64]*model.RankPoint)
+		}
+		points[stats.BundleId][key] = &model.RankPoint{
+			Bucket: stats.Bucket,
+			Min:    int(stats.Min),
+			Max:    int(stats.Max),
+			Avg:    stats.Avg,
+			Last:   int(stats.Last),
+		}
+	}
+
+	keys := make([]int64, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, key := range keys {
+		b := buckets[key]
+		comparison.Buckets = append(comparison.Buckets, &b)
+	}
+
+	for i, id := range ids {
+		series := &model.ComparisonSeries{BundleID: id, Points: make([]*model.RankPoint, len(keys))}
+		for j, key := range keys {
+			series.Points[j] = points[id][key]
+		}
+		comparison.Series[i] = series
+	}
+
+	return comparison, nil
+}
@@ -0,0 +1,53 @@
+package graph_test
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/graph"
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCompare_ShouldAlignSeriesByBuckets(t *testing.T) {
+	day, _ := time.Parse("2006-01-02", "2021-01-18")
+	repo := mockMemoryRepo{stats: entities.DboSlice{
+		entities.RankStats{BundleId: 1, Bucket: day, Type: "bank", Min: 1, Max: 3, Avg: 2, Last: 3},
+		entities.RankStats{BundleId: 1, Bucket: day.AddDate(0, 0, 1), Type: "bank", Min: 2, Max: 2, Avg: 2, Last: 2},
+		entities.RankStats{BundleId: 2, Bucket: day.AddDate(0, 0, 2), Type: "bank", Min: 5, Max: 7, Avg: 6, Last: 5},
+	}}
+	resolver := &graph.Resolver{Tables: &tracking.Tables{Keys: repo, Cat: mockMemoryRepo{}}}
+	keyword := "bank"
+
+	comparison, err := resolver.Query().Compare(context.Background(), []int{2, 1, 3, 2}, &keyword, nil, nil, model.BucketDay)
+	assert.NoError(t, err)
+	assert.Len(t, comparison.Buckets, 3)
+	assert.True(t, day.Equal(*comparison.Buckets[0]))
+
+	assert.Len(t, comparison.Series, 3)
+	assert.Equal(t, 2, comparison.Series[0].BundleID)
+	assert.Equal(t, []*model.RankPoint{nil, nil, {Bucket: day.AddDate(0, 0, 2), Min: 5, Max: 7, Avg: 6, Last: 5}}, comparison.Series[0].Points)
+	assert.Equal(t, 1, comparison.Series[1].BundleID)
+	assert.Equal(t, 3, comparison.Series[1].Points[0].Last)
+	assert.Nil(t, comparison.Series[1].Points[2])
+	assert.Equal(t, []*model.RankPoint{nil, nil, nil}, comparison.Series[2].Points)
+}
+
+func TestCompare_ShouldRequireExactlyOneOfKeywordOrCategory(t *testing.T) {
+	resolver := &graph.Resolver{Tables: &tracking.Tables{Keys: mockMemoryRepo{}, Cat: mockMemoryRepo{}}}
+	value := "bank"
+
+	for _, args := range [][2]*string{{nil, nil}, {&value, &value}} {
+		_, err := resolver.Query().Compare(context.Background(), []int{1}, args[0], args[1], nil, model.BucketDay)
+		assert.True(t, errors.Is(err, apperrors.New(apperrors.BadRequest, "")))
+	}
+
+	comparison, err := resolver.Query().Compare(context.Background(), []int{1}, nil, &value, nil, model.BucketWeek)
+	assert.NoError(t, err)
+	assert.Empty(t, comparison.Buckets)
+	assert.Empty(t, comparison.Series[0].Points)
+}
@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/graph/model"
+	"Muromachi/graph/scalar"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Size of page if first and last are not provided
+	defaultPageSize = 20
+	// Max size of page
+	maxPageSize = 100
+)
+
+// EncodeCursor returns opaque cursor of tracking row position
+func EncodeCursor(c entities.Cursor) string {
+	raw := strconv.FormatInt(c.Date.UTC().UnixNano(), 10) + ":" + strconv.Itoa(c.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses cursor created by EncodeCursor
+func DecodeCursor(cursor string) (*entities.Cursor, error) {
+	invalid := apperrors.New(apperrors.BadRequest, fmt.Sprintf("invalid cursor %q", cursor))
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return nil, invalid
+	}
+	nano, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, invalid
+	}
+
+	return &entities.Cursor{Date: time.Unix(0, nano).UTC(), Id: id}, nil
+}
+
+// newPage validates connection arguments and converts them to page request.
+// Forward pagination uses first and after, backward pagination uses last and before
+func newPage(first *int, after *string, last *int, before *string, start, end *scalar.FormattedDate) (entities.Page, error) {
+	var (
+		page entities.Page
+		err  error
+	)
+	if first != nil && last != nil {
+		return page, apperrors.New(apperrors.BadRequest, "first and last can not be used together")
+	}
+
+	page.Limit = defaultPageSize
+	switch {
+	case first != nil:
+		page.Limit = *first
+	case last != nil:
+		page.Limit = *last
+		page.Backward = true
+	}
+	if page.Limit < 0 || page.Limit > maxPageSize {
+		return page, apperrors.New(apperrors.BadRequest, fmt.Sprintf("page size should be from 0 to %d", maxPageSize))
+	}
+
+	if after != nil {
+		if page.After, err = DecodeCursor(*after); err != nil {
+			return page, err
+		}
+	}
+	if before != nil {
+		if page.Before, err = DecodeCursor(*before); err != nil {
+			return page, err
+		}
+	}
+	if start != nil {
+		page.Start = time.Time(*start)
+	}
+	if end != nil {
+		page.End = time.Time(*end)
+	}
+
+	return page, nil
+}
+
+// loadPage selects page of rows from repository and returns rows with
+// their cursors and page info
+func loadPage(ctx context.Context, repo tracking.Repository, id int, page entities.Page) (entities.DboSlice, []string, *model.PageInfo, error) {
+	info := &model.PageInfo{}
+	if page.Limit == 0 {
+		return nil, nil, info, nil
+	}
+
+	// One more row shows if there is next (or previous for backward pagination) page
+	limit := page.Limit
+	page.Limit++
+	dbo, err := repo.Page(ctx, id, page)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, nil, nil, err
+	}
+
+	more := len(dbo) > limit
+	if more {
+		if page.Backward {
+			dbo = dbo[1:]
+		} else {
+			dbo = dbo[:limit]
+		}
+	}
+	if page.Backward {
+		info.HasPreviousPage = more
+		info.HasNextPage = page.Before != nil
+	} else {
+		info.HasNextPage = more
+		info.HasPreviousPage = page.After != nil
+	}
+
+	cursors := make([]string, len(dbo))
+	for i, v := range dbo {
+		c, ok := entities.CursorOf(v)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("row %T has not cursor", v)
+		}
+		cursors[i] = EncodeCursor(c)
+	}
+	if len(cursors) > 0 {
+		info.StartCursor = &cursors[0]
+		info.EndCursor = &cursors[len(cursors)-1]
+	}
+
+	return dbo, cursors, info, nil
+}
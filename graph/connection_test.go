@@ -0,0 +1,236 @@
+package graph_test
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/graph"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Repository which keeps tracking rows in memory
+type mockMemoryRepo struct {
+	rows entities.DboSlice
+	// Returned by RankStats
+	stats entities.DboSlice
+	// Returned by ByResolution for day and week resolutions, rows are returned if it is empty
+	buckets entities.DboSlice
+	// Streamed by Stream of last rows, rows are streamed if it is empty
+	previous entities.DboSlice
+}
+
+func (m mockMemoryRepo) ProducerFunc(ctx context.Context, sql string, params ...interface{}) (entities.DboSlice, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (m mockMemoryRepo) ByBundleId(ctx context.Context, bundleId int) (entities.DboSlice, error) {
+	return m.rows, nil
+}
+
+func (m mockMemoryRepo) TimeRange(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
+	return m.rows, nil
+}
+
+func (m mockMemoryRepo) ByBundleIds(ctx context.Context, bundleIds []int, start, end time.Time) (entities.DboSlice, error) {
+	return m.rows, nil
+}
+
+func (m mockMemoryRepo) ByResolution(ctx context.Context, bundleIds []int, resolution entities.Resolution, start, end time.Time) (entities.DboSlice, error) {
+	if resolution.Pick(start, end) == entities.ResolutionRaw || len(m.buckets) == 0 {
+		return m.rows, nil
+	}
+	return m.buckets, nil
+}
+
+func (m mockMemoryRepo) LastUpdates(ctx context.Context, bundleId, count int) (entities.DboSlice, error) {
+	return m.rows, nil
+}
+
+func (m mockMemoryRepo) Count(ctx context.Context, bundleId int, start, end time.Time) (int, error) {
+	return len(m.rows), nil
+}
+
+func (m mockMemoryRepo) Stream(ctx context.Context, bundleId, last int, start, end time.Time, f func(entities.DBO) error) error {
+	rows := m.rows
+	if last > 0 && len(m.previous) > 0 {
+		rows = m.previous
+	}
+	for _, v := range rows {
+		if err := f(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m mockMemoryRepo) RankStats(ctx context.Context, bundleId int, typ string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	if len(m.stats) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return m.stats, nil
+}
+
+// RankStatsByBundleIds returns stats of given bundles
+func (m mockMemoryRepo) RankStatsByBundleIds(ctx context.Context, bundleIds []int, typ string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	var matched entities.DboSlice
+	for _, v := range m.stats {
+		for _, id := range bundleIds {
+			if v.(entities.RankStats).BundleId == id {
+				matched = append(matched, v)
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return matched, nil
+}
+
+// Page emulates keyset query, rows are already ordered by date and id
+func (m mockMemoryRepo) Page(ctx context.Context, bundleId int, page entities.Page) (entities.DboSlice, error) {
+	less := func(a, b entities.Cursor) bool {
+		return a.Date.Before(b.Date) || (a.Date.Equal(b.Date) && a.Id < b.Id)
+	}
+
+	var matched entities.DboSlice
+	for _, v := range m.rows {
+		c, _ := entities.CursorOf(v)
+		if page.After != nil && !less(*page.After, c) {
+			continue
+		}
+		if page.Before != nil && !less(c, *page.Before) {
+			continue
+		}
+		matched = append(matched, v)
+	}
+	if len(matched) > page.Limit {
+		if page.Backward {
+			matched = matched[len(matched)-page.Limit:]
+		} else {
+			matched = matched[:page.Limit]
+		}
+	}
+	if len(matched) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return matched, nil
+}
+
+func newConnectionResolver(count int) *graph.Resolver {
+	t, _ := time.Parse("2006-01-02", "2021-01-18")
+	repo := mockMemoryRepo{}
+	for i := 0; i < count; i++ {
+		// Every two rows have the same date
+		repo.rows = append(repo.rows, entities.Track{
+			Id:       i + 1,
+			BundleId: 1,
+			Type:     "key",
+			Date:     t.AddDate(0, 0, i/2),
+			Place:    int32(i),
+		})
+	}
+
+	return &graph.Resolver{
+		Tables: &tracking.Tables{Cat: repo, Keys: repo},
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestCursor_ShouldBeDecodedToTheSamePosition(t *testing.T) {
+	date := time.Date(2021, 1, 18, 10, 11, 12, 13000, time.UTC)
+	cursor := graph.EncodeCursor(entities.Cursor{Date: date, Id: 42})
+
+	decoded, err := graph.DecodeCursor(cursor)
+	assert.NoError(t, err)
+	assert.True(t, date.Equal(decoded.Date))
+	assert.Equal(t, 42, decoded.Id)
+
+	for _, invalid := range []string{"", "abc", "MTIz", "YWJjOjE"} {
+		_, err = graph.DecodeCursor(invalid)
+		assert.True(t, errors.Is(err, apperrors.New(apperrors.BadRequest, "")), invalid)
+	}
+}
+
+func TestCatsConnection_ShouldWalkForwardThroughAllRows(t *testing.T) {
+	r := newConnectionResolver(7).Query()
+	ctx := context.Background()
+
+	var (
+		after *string
+		ids   []int
+		pages int
+	)
+	for {
+		conn, err := r.CatsConnection(ctx, 1, intPtr(3), after, nil, nil, nil, nil)
+		assert.NoError(t, err)
+		pages++
+		for _, edge := range conn.Edges {
+			ids = append(ids, edge.Node.ID)
+		}
+		assert.Equal(t, after != nil, conn.PageInfo.HasPreviousPage)
+		if !conn.PageInfo.HasNextPage {
+			break
+		}
+		after = conn.PageInfo.EndCursor
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, ids)
+}
+
+func TestKeysConnection_ShouldWalkBackwardThroughAllRows(t *testing.T) {
+	r := newConnectionResolver(5).Query()
+	ctx := context.Background()
+
+	conn, err := r.KeysConnection(ctx, 1, nil, nil, intPtr(2), nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, conn.Edges, 2)
+	assert.Equal(t, 4, conn.Edges[0].Node.ID)
+	assert.Equal(t, 5, conn.Edges[1].Node.ID)
+	assert.True(t, conn.PageInfo.HasPreviousPage)
+	assert.False(t, conn.PageInfo.HasNextPage)
+
+	conn, err = r.KeysConnection(ctx, 1, nil, nil, intPtr(10), conn.PageInfo.StartCursor, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, conn.Edges, 3)
+	assert.Equal(t, 1, conn.Edges[0].Node.ID)
+	assert.False(t, conn.PageInfo.HasPreviousPage)
+	assert.True(t, conn.PageInfo.HasNextPage)
+}
+
+func TestCatsConnection_ShouldReturnEmptyPageAndTotalCount(t *testing.T) {
+	resolver := newConnectionResolver(0)
+	conn, err := resolver.Query().CatsConnection(context.Background(), 1, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, conn.Edges)
+	assert.False(t, conn.PageInfo.HasNextPage)
+	assert.Nil(t, conn.PageInfo.EndCursor)
+
+	count, err := resolver.CategoriesConnection().TotalCount(context.Background(), conn)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestCatsConnection_ShouldValidateArguments(t *testing.T) {
+	r := newConnectionResolver(3).Query()
+	ctx := context.Background()
+	invalid := "invalid"
+
+	_, err := r.CatsConnection(ctx, 1, intPtr(1), nil, intPtr(1), nil, nil, nil)
+	assert.Error(t, err)
+	_, err = r.CatsConnection(ctx, 1, intPtr(1000), nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	_, err = r.CatsConnection(ctx, 1, intPtr(-1), nil, nil, nil, nil, nil)
+	assert.Error(t, err)
+	_, err = r.CatsConnection(ctx, 1, nil, &invalid, nil, nil, nil, nil)
+	assert.Error(t, err)
+}
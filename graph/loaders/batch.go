@@ -0,0 +1,104 @@
+package loaders
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Fetches values of several keys with one query. Returned slices
+// should have the same length and order as keys
+type fetchFunc func(ctx context.Context, keys []interface{}) ([]interface{}, []error)
+
+// Result of loading single key
+type result struct {
+	value interface{}
+	err   error
+	// Closed when value and err are ready
+	done chan struct{}
+}
+
+// batch collects keys during wait time and fetches them together
+type batch struct {
+	keys    []interface{}
+	results []*result
+	// Closed when batch is full, so it should be fetched without waiting
+	full chan struct{}
+}
+
+// loader batches and caches loading of keys. Results are cached for the
+// whole life of loader, so it should be created for every request
+type loader struct {
+	fetch    fetchFunc
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[interface{}]*result
+	batch *batch
+}
+
+func newLoader(fetch fetchFunc, wait time.Duration, maxBatch int) *loader {
+	return &loader{
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		cache:    make(map[interface{}]*result),
+	}
+}
+
+// load returns value of key. Keys requested within wait time are
+// fetched with one call of fetch func
+func (l *loader) load(ctx context.Context, key interface{}) (interface{}, error) {
+	l.mu.Lock()
+	res, ok := l.cache[key]
+	if !ok {
+		res = &result{done: make(chan struct{})}
+		l.cache[key] = res
+
+		if l.batch == nil {
+			l.batch = &batch{full: make(chan struct{})}
+			go l.run(ctx, l.batch)
+		}
+		b := l.batch
+		b.keys = append(b.keys, key)
+		b.results = append(b.results, res)
+		if l.maxBatch > 0 && len(b.keys) >= l.maxBatch {
+			l.batch = nil
+			close(b.full)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-res.done:
+		return res.value, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run waits for more keys and fetches batch
+func (l *loader) run(ctx context.Context, b *batch) {
+	select {
+	case <-b.full:
+	case <-time.After(l.wait):
+	}
+
+	l.mu.Lock()
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	values, errs := l.fetch(ctx, b.keys)
+	for i, res := range b.results {
+		if i < len(values) {
+			res.value = values[i]
+		}
+		if i < len(errs) {
+			res.err = errs[i]
+		}
+		close(res.done)
+	}
+}
@@ -0,0 +1,228 @@
+package loaders
+
+import (
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+const (
+	// How long loader waits for other keys before query
+	wait = 2 * time.Millisecond
+	// Max count of keys in one query
+	maxBatch = 100
+)
+
+type contextKey struct{}
+
+// Key of tracking rows. Zero Start or End means that range is
+// not bounded from this side, Resolution is ignored by meta loader
+type TrackKey struct {
+	BundleId   int
+	Start      time.Time
+	End        time.Time
+	Resolution entities.Resolution
+}
+
+// Loads apps by id
+type AppLoader struct {
+	loader *loader
+}
+
+// Load returns app with given id. Apps requested at the same time
+// are selected with one query
+func (a *AppLoader) Load(ctx context.Context, id int) (entities.App, error) {
+	v, err := a.loader.load(ctx, id)
+	if err != nil {
+		return entities.App{}, err
+	}
+	return v.(entities.App), nil
+}
+
+// Loads tracking rows by bundle id and time range
+type TrackLoader struct {
+	loader *loader
+}
+
+// Load returns tracking rows of key. Keys requested at the same time
+// are selected with one query for every distinct time range and resolution
+func (t *TrackLoader) Load(ctx context.Context, key TrackKey) (entities.DboSlice, error) {
+	v, err := t.loader.load(ctx, TrackKey{
+		BundleId: key.BundleId,
+		// Times with different locations should be the same key
+		Start:      key.Start.UTC(),
+		End:        key.End.UTC(),
+		Resolution: key.Resolution,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(entities.DboSlice), nil
+}
+
+// Loaders of single request
+type Loaders struct {
+	App  *AppLoader
+	Meta *TrackLoader
+	Cat  *TrackLoader
+	Keys *TrackLoader
+}
+
+// New creates loaders for one request
+func New(tables *tracking.Tables) *Loaders {
+	return &Loaders{
+		App: &AppLoader{
+			loader: newLoader(fetchApps(tables.App), wait, maxBatch),
+		},
+		Meta: &TrackLoader{
+			loader: newLoader(fetchTracks(byBundleIds(tables.Meta)), wait, maxBatch),
+		},
+		Cat: &TrackLoader{
+			loader: newLoader(fetchTracks(byResolution(tables.Cat)), wait, maxBatch),
+		},
+		Keys: &TrackLoader{
+			loader: newLoader(fetchTracks(byResolution(tables.Keys)), wait, maxBatch),
+		},
+	}
+}
+
+// WithLoaders returns copy of ctx with loaders
+func WithLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, contextKey{}, loaders)
+}
+
+// For returns loaders of request or nil if context has not loaders
+func For(ctx context.Context) *Loaders {
+	loaders, _ := ctx.Value(contextKey{}).(*Loaders)
+	return loaders
+}
+
+// fetchApps selects apps of all keys with one query
+func fetchApps(repo tracking.Repository) fetchFunc {
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		ids := make([]int, len(keys))
+		for i, key := range keys {
+			ids[i] = key.(int)
+		}
+		values := make([]interface{}, len(keys))
+		errs := make([]error, len(keys))
+
+		dbo, err := repo.ByBundleIds(ctx, ids, time.Time{}, time.Time{})
+		if err != nil && err != pgx.ErrNoRows {
+			for i := range errs {
+				errs[i] = err
+			}
+			return values, errs
+		}
+
+		byId := make(map[int]entities.App, len(dbo))
+		for _, value := range dbo {
+			var app entities.App
+			if err := value.To(&app); err == nil {
+				byId[app.Id] = app
+			}
+		}
+		for i, id := range ids {
+			app, ok := byId[id]
+			if !ok {
+				errs[i] = pgx.ErrNoRows
+				continue
+			}
+			values[i] = app
+		}
+
+		return values, errs
+	}
+}
+
+// Query of tracking rows of several bundles within time range by resolution
+type tracksQuery func(ctx context.Context, bundleIds []int, resolution entities.Resolution, start, end time.Time) (entities.DboSlice, error)
+
+// byBundleIds returns query of rows of repository, resolution is ignored
+func byBundleIds(repo tracking.Repository) tracksQuery {
+	return func(ctx context.Context, bundleIds []int, _ entities.Resolution, start, end time.Time) (entities.DboSlice, error) {
+		return repo.ByBundleIds(ctx, bundleIds, start, end)
+	}
+}
+
+// byResolution returns query of places of repository by resolution
+func byResolution(repo tracking.TrackRepository) tracksQuery {
+	return func(ctx context.Context, bundleIds []int, resolution entities.Resolution, start, end time.Time) (entities.DboSlice, error) {
+		return repo.ByResolution(ctx, bundleIds, resolution, start, end)
+	}
+}
+
+// fetchTracks selects rows of all keys with one query for every distinct time range and resolution
+func fetchTracks(query tracksQuery) fetchFunc {
+	type bounds struct {
+		start, end time.Time
+		resolution entities.Resolution
+	}
+
+	return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+		values := make([]interface{}, len(keys))
+		errs := make([]error, len(keys))
+
+		// Group keys with the same range
+		groups := make(map[bounds][]int)
+		var order []bounds
+		for i, key := range keys {
+			k := key.(TrackKey)
+			b := bounds{k.Start, k.End, k.Resolution}
+			if _, ok := groups[b]; !ok {
+				order = append(order, b)
+			}
+			groups[b] = append(groups[b], i)
+		}
+
+		for _, b := range order {
+			indexes := groups[b]
+			ids := make([]int, len(indexes))
+			for j, i := range indexes {
+				ids[j] = keys[i].(TrackKey).BundleId
+			}
+
+			dbo, err := query(ctx, ids, b.resolution, b.start, b.end)
+			if err != nil && err != pgx.ErrNoRows {
+				for _, i := range indexes {
+					errs[i] = err
+				}
+				continue
+			}
+
+			byBundle := make(map[int]entities.DboSlice)
+			for _, value := range dbo {
+				id, ok := bundleId(value)
+				if ok {
+					byBundle[id] = append(byBundle[id], value)
+				}
+			}
+			for j, i := range indexes {
+				rows, ok := byBundle[ids[j]]
+				if !ok {
+					// The same error which is returned by repositories for empty result
+					errs[i] = pgx.ErrNoRows
+					continue
+				}
+				values[i] = rows
+			}
+		}
+
+		return values, errs
+	}
+}
+
+// bundleId returns bundle id of tracking row
+func bundleId(value entities.DBO) (int, bool) {
+	switch v := value.(type) {
+	case entities.Meta:
+		return v.BundleId, true
+	case entities.Track:
+		return v.BundleId, true
+	case entities.App:
+		return v.Id, true
+	}
+	return 0, false
+}
@@ -0,0 +1,279 @@
+package loaders_test
+
+import (
+	"Muromachi/graph/loaders"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Mock repository which counts queries and returns two rows for every bundle id except 0
+type mockCountingRepo struct {
+	calls int32
+	// Resolutions of ByResolution queries
+	mu          sync.Mutex
+	resolutions []entities.Resolution
+}
+
+func (m *mockCountingRepo) ProducerFunc(ctx context.Context, sql string, params ...interface{}) (entities.DboSlice, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (m *mockCountingRepo) ByBundleId(ctx context.Context, bundleId int) (entities.DboSlice, error) {
+	return m.ByBundleIds(ctx, []int{bundleId}, time.Time{}, time.Time{})
+}
+
+func (m *mockCountingRepo) TimeRange(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
+	return m.ByBundleIds(ctx, []int{bundleId}, start, end)
+}
+
+func (m *mockCountingRepo) LastUpdates(ctx context.Context, bundleId, count int) (entities.DboSlice, error) {
+	return m.ByBundleIds(ctx, []int{bundleId}, time.Time{}, time.Time{})
+}
+
+func (m *mockCountingRepo) Page(ctx context.Context, bundleId int, page entities.Page) (entities.DboSlice, error) {
+	return m.ByBundleIds(ctx, []int{bundleId}, page.Start, page.End)
+}
+
+func (m *mockCountingRepo) Count(ctx context.Context, bundleId int, start, end time.Time) (int, error) {
+	return 2, nil
+}
+
+func (m *mockCountingRepo) Stream(ctx context.Context, bundleId, last int, start, end time.Time, f func(entities.DBO) error) error {
+	return nil
+}
+
+func (m *mockCountingRepo) RankStats(ctx context.Context, bundleId int, typ string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (m *mockCountingRepo) RankStatsByBundleIds(ctx context.Context, bundleIds []int, typ string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (m *mockCountingRepo) ByResolution(ctx context.Context, bundleIds []int, resolution entities.Resolution, start, end time.Time) (entities.DboSlice, error) {
+	m.mu.Lock()
+	m.resolutions = append(m.resolutions, resolution)
+	m.mu.Unlock()
+	return m.ByBundleIds(ctx, bundleIds, start, end)
+}
+
+func (m *mockCountingRepo) ByBundleIds(ctx context.Context, bundleIds []int, start, end time.Time) (entities.DboSlice, error) {
+	atomic.AddInt32(&m.calls, 1)
+
+	var dbo entities.DboSlice
+	for _, id := range bundleIds {
+		if id == 0 {
+			continue
+		}
+		for i := 0; i < 2; i++ {
+			dbo = append(dbo, entities.Track{
+				Id:       id*10 + i,
+				BundleId: id,
+				Type:     "key",
+				Date:     start,
+				App:      entities.App{Id: id},
+			})
+		}
+	}
+	if len(dbo) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return dbo, nil
+}
+
+// Mock repository of apps
+type mockAppRepo struct {
+	mockCountingRepo
+}
+
+func (m *mockAppRepo) ByBundleIds(ctx context.Context, bundleIds []int, start, end time.Time) (entities.DboSlice, error) {
+	atomic.AddInt32(&m.calls, 1)
+
+	var dbo entities.DboSlice
+	for _, id := range bundleIds {
+		if id != 0 {
+			dbo = append(dbo, entities.App{Id: id, Bundle: "com.bundle"})
+		}
+	}
+
+	return dbo, nil
+}
+
+func (m *mockAppRepo) ById(ctx context.Context, id int) (entities.App, error) {
+	return entities.App{Id: id}, nil
+}
+
+func (m *mockAppRepo) ByBundle(ctx context.Context, store entities.AppStore, bundle, geo string) (entities.App, error) {
+	return entities.App{Bundle: bundle, Geo: geo}, nil
+}
+
+func (m *mockAppRepo) Search(ctx context.Context, query entities.AppQuery) (entities.DboSlice, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (m *mockAppRepo) CountApps(ctx context.Context, filter entities.AppFilter) (int, error) {
+	return 0, nil
+}
+
+func (m *mockAppRepo) Create(ctx context.Context, app entities.App) (entities.App, error) {
+	return app, nil
+}
+
+func (m *mockAppRepo) Update(ctx context.Context, app entities.App) (entities.App, error) {
+	return app, nil
+}
+
+func (m *mockAppRepo) SetStatus(ctx context.Context, id int, status entities.AppStatus) (entities.App, error) {
+	return entities.App{Id: id, Status: status}, nil
+}
+
+func newTables() (*tracking.Tables, *mockAppRepo, *mockCountingRepo) {
+	apps, cats := &mockAppRepo{}, &mockCountingRepo{}
+	return &tracking.Tables{
+		App:  apps,
+		Meta: &mockCountingRepo{},
+		Cat:  cats,
+		Keys: &mockCountingRepo{},
+	}, apps, cats
+}
+
+func TestAppLoader_ShouldSelectAppsRequestedAtTheSameTimeWithOneQuery(t *testing.T) {
+	tables, apps, _ := newTables()
+	l := loaders.New(tables)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	ids := []int{1, 2, 1, 3, 2, 1}
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			app, err := l.App.Load(ctx, id)
+			assert.NoError(t, err)
+			assert.Equal(t, id, app.Id)
+		}(id)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&apps.calls))
+
+	// Loaded apps are cached
+	app, err := l.App.Load(ctx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, app.Id)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&apps.calls))
+}
+
+func TestAppLoader_ShouldReturnErrNoRowsForUnknownApp(t *testing.T) {
+	tables, _, _ := newTables()
+	l := loaders.New(tables)
+
+	_, err := l.App.Load(context.Background(), 0)
+	assert.Equal(t, pgx.ErrNoRows, err)
+}
+
+func TestTrackLoader_ShouldSelectRowsWithOneQueryForEveryRange(t *testing.T) {
+	tables, _, cats := newTables()
+	l := loaders.New(tables)
+	ctx := context.Background()
+
+	start, _ := time.Parse("2006-01-02", "2021-01-18")
+	end := start.AddDate(0, 0, 7)
+	keys := []loaders.TrackKey{
+		{BundleId: 1, Start: start, End: end},
+		{BundleId: 2, Start: start, End: end},
+		{BundleId: 1, Start: start, End: end},
+		{BundleId: 1},
+		{BundleId: 2},
+	}
+
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key loaders.TrackKey) {
+			defer wg.Done()
+			dbo, err := l.Cat.Load(ctx, key)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, len(dbo))
+
+			var track entities.Track
+			for _, v := range dbo {
+				assert.NoError(t, v.To(&track))
+				assert.Equal(t, key.BundleId, track.BundleId)
+				assert.True(t, track.Date.Equal(key.Start))
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	// Two distinct ranges
+	assert.Equal(t, int32(2), atomic.LoadInt32(&cats.calls))
+}
+
+func TestTrackLoader_ShouldSelectRowsWithOneQueryForEveryResolution(t *testing.T) {
+	tables, _, cats := newTables()
+	l := loaders.New(tables)
+	ctx := context.Background()
+
+	start, _ := time.Parse("2006-01-02", "2020-01-18")
+	end := start.AddDate(1, 0, 0)
+	keys := []loaders.TrackKey{
+		{BundleId: 1, Start: start, End: end},
+		{BundleId: 2, Start: start, End: end},
+		{BundleId: 1, Start: start, End: end, Resolution: entities.ResolutionRaw},
+		{BundleId: 2, Start: start, End: end, Resolution: entities.ResolutionRaw},
+	}
+
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key loaders.TrackKey) {
+			defer wg.Done()
+			dbo, err := l.Cat.Load(ctx, key)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, len(dbo))
+		}(key)
+	}
+	wg.Wait()
+
+	// The same range with two resolutions
+	assert.Equal(t, int32(2), atomic.LoadInt32(&cats.calls))
+	assert.ElementsMatch(t, []entities.Resolution{entities.ResolutionAuto, entities.ResolutionRaw}, cats.resolutions)
+}
+
+func TestTrackLoader_ShouldReturnErrNoRowsForBundleWithoutRows(t *testing.T) {
+	tables, _, _ := newTables()
+	l := loaders.New(tables)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for _, id := range []int{0, 1} {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			dbo, err := l.Keys.Load(ctx, loaders.TrackKey{BundleId: id})
+			if id == 0 {
+				assert.Equal(t, pgx.ErrNoRows, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 2, len(dbo))
+		}(id)
+	}
+	wg.Wait()
+}
+
+func TestFor_ShouldReturnLoadersOfContext(t *testing.T) {
+	tables, _, _ := newTables()
+	l := loaders.New(tables)
+
+	assert.Nil(t, loaders.For(context.Background()))
+	assert.Equal(t, l, loaders.For(loaders.WithLoaders(context.Background(), l)))
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"context"
+	"strings"
+	"time"
+)
+
+// metaChanges compares consecutive meta snapshots of app within range. The last snapshot
+// before start of range is compared with the first one within range, so change made at
+// start of range is reported too
+func (r *Resolver) metaChanges(ctx context.Context, bundleId int, rng *model.DateRange, fields []model.MetaField) ([]*model.MetaChange, error) {
+	dbo, err := r.history(ctx, r.loaders(ctx).Meta, bundleId, rng, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]entities.Meta, len(dbo))
+	for i, v := range dbo {
+		if err := v.To(&snapshots[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if start, _ := dateRange(rng); !start.IsZero() && len(snapshots) > 0 {
+		// End of stream is inclusive and dates are stored with microseconds
+		err := r.Tables.Meta.Stream(ctx, bundleId, 1, time.Time{}, start.Add(-time.Microsecond), func(dbo entities.DBO) error {
+			var baseline entities.Meta
+			if err := dbo.To(&baseline); err != nil {
+				return err
+			}
+			snapshots = append(snapshots, baseline)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	metaFields := make([]entities.MetaField, len(fields))
+	for i, field := range fields {
+		metaFields[i] = entities.MetaField(strings.ToLower(string(field)))
+	}
+
+	changes, err := entities.MetaChanges(snapshots, metaFields)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.MetaChange, len(changes))
+	if err := changes.To(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package graph_test
+
+import (
+	"Muromachi/graph"
+	"Muromachi/graph/model"
+	"Muromachi/graph/scalar"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMetaChanges_ShouldReturnChangesOfRequestedFields(t *testing.T) {
+	date, _ := time.Parse("2006-01-02", "2021-01-18")
+	meta := entities.Meta{Id: 1, BundleId: 1, Title: "Bank", Version: "1.0", Description: "Best bank", Date: date}
+	updated := meta
+	updated.Id, updated.Date = 2, date.AddDate(0, 0, 1)
+	updated.Version, updated.Description = "1.1", "Best bank\nCashback"
+
+	resolver := &graph.Resolver{Tables: &tracking.Tables{
+		Meta: mockMemoryRepo{rows: entities.DboSlice{updated, meta}},
+	}}
+
+	changes, err := resolver.Query().MetaChanges(context.Background(), 1, nil, []model.MetaField{
+		model.MetaFieldTitle, model.MetaFieldVersion, model.MetaFieldDescription,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+
+	assert.Equal(t, model.MetaFieldVersion, changes[0].Field)
+	assert.Equal(t, "1.0", changes[0].OldValue)
+	assert.Equal(t, "1.1", changes[0].NewValue)
+	assert.True(t, date.Equal(changes[0].PreviousDate))
+	assert.Nil(t, changes[0].Diff)
+
+	assert.Equal(t, model.MetaFieldDescription, changes[1].Field)
+	assert.Equal(t, []*model.DiffLine{
+		{Op: model.DiffOpEqual, Text: "Best bank"},
+		{Op: model.DiffOpInsert, Text: "Cashback"},
+	}, changes[1].Diff)
+}
+
+func TestMetaChanges_ShouldReturnEmptyListWithoutSnapshots(t *testing.T) {
+	resolver := &graph.Resolver{Tables: &tracking.Tables{Meta: mockMemoryRepo{}}}
+
+	changes, err := resolver.Query().MetaChanges(context.Background(), 1, nil, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, changes)
+	assert.Empty(t, changes)
+}
+
+func TestMetaChanges_ShouldCompareFirstSnapshotWithSnapshotBeforeRange(t *testing.T) {
+	date, _ := time.Parse("2006-01-02", "2021-01-18")
+	before := entities.Meta{Id: 1, BundleId: 1, Title: "Bank", Version: "1.0", Date: date.AddDate(0, 0, -1)}
+	first := before
+	first.Id, first.Date, first.Version = 2, date, "1.1"
+
+	resolver := &graph.Resolver{Tables: &tracking.Tables{
+		Meta: mockMemoryRepo{rows: entities.DboSlice{first}, previous: entities.DboSlice{before}},
+	}}
+
+	start := scalar.FormattedDate(date)
+	changes, err := resolver.Query().MetaChanges(context.Background(), 1, &model.DateRange{Start: &start}, []model.MetaField{
+		model.MetaFieldTitle, model.MetaFieldVersion,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+
+	assert.Equal(t, model.MetaFieldVersion, changes[0].Field)
+	assert.Equal(t, "1.0", changes[0].OldValue)
+	assert.Equal(t, "1.1", changes[0].NewValue)
+	assert.True(t, before.Date.Equal(changes[0].PreviousDate))
+}
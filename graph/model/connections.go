@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+)
+
+// Parameters of connection which are needed to count all its rows
+type ConnectionQuery struct {
+	BundleID int
+	Start    time.Time
+	End      time.Time
+}
+
+type MetaConnection struct {
+	Edges    []*MetaEdge     `json:"edges"`
+	PageInfo *PageInfo       `json:"pageInfo"`
+	Query    ConnectionQuery `json:"-"`
+}
+
+type CategoriesConnection struct {
+	Edges    []*CategoriesEdge `json:"edges"`
+	PageInfo *PageInfo         `json:"pageInfo"`
+	Query    ConnectionQuery   `json:"-"`
+}
+
+type KeywordsConnection struct {
+	Edges    []*KeywordsEdge `json:"edges"`
+	PageInfo *PageInfo       `json:"pageInfo"`
+	Query    ConnectionQuery `json:"-"`
+}
+
+type AppConnection struct {
+	Edges    []*AppEdge `json:"edges"`
+	PageInfo *PageInfo  `json:"pageInfo"`
+	Filter   *AppFilter `json:"-"`
+}
@@ -3,20 +3,153 @@
 package model
 
 import (
+	"Muromachi/graph/scalar"
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 )
 
+// Snapshot of store page of app, fields are common for all stores
+type Meta interface {
+	IsMeta()
+}
+
+// Webhook of triggered alert rule
+type AlertDelivery struct {
+	ID     int            `json:"id"`
+	Status DeliveryStatus `json:"status"`
+	// JSON body of webhook
+	Payload  string `json:"payload"`
+	Attempts int    `json:"attempts"`
+	// Http status of last attempt
+	ResponseStatus *int       `json:"responseStatus"`
+	LastError      string     `json:"lastError"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+}
+
+type AlertRule struct {
+	ID       int       `json:"id"`
+	BundleID int       `json:"bundleId"`
+	App      *App      `json:"app"`
+	Kind     AlertKind `json:"kind"`
+	// Keyword, category or meta field
+	Type       string         `json:"type"`
+	Condition  AlertCondition `json:"condition"`
+	Threshold  int            `json:"threshold"`
+	WebhookURL string         `json:"webhookUrl"`
+	// Key of HMAC-SHA256 signature of webhook body which is sent in X-Muromachi-Signature header
+	Secret    string    `json:"secret"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Last deliveries of rule, newest first
+	Deliveries []*AlertDelivery `json:"deliveries"`
+}
+
+// Abnormal jump of metric, change is far from rolling median of previous changes
+type Anomaly struct {
+	ID       int           `json:"id"`
+	BundleID int           `json:"bundleId"`
+	App      *App          `json:"app"`
+	Metric   AnomalyMetric `json:"metric"`
+	// Keyword or category of place metrics, empty for rating and review count
+	Type string    `json:"type"`
+	Date time.Time `json:"date"`
+	// Observed value
+	Value float64 `json:"value"`
+	// Change from previous observation
+	Change float64 `json:"change"`
+	// Median of previous changes
+	Baseline float64 `json:"baseline"`
+	// Distance of change from baseline in scaled median absolute deviations
+	Score      float64       `json:"score"`
+	Status     AnomalyStatus `json:"status"`
+	DetectedAt time.Time     `json:"detectedAt"`
+}
+
 type App struct {
-	ID          int       `json:"id"`
-	Bundle      string    `json:"bundle"`
-	Category    string    `json:"category"`
-	DeveloperID string    `json:"developerId"`
-	Developer   string    `json:"developer"`
-	Geo         string    `json:"geo"`
-	StartAt     time.Time `json:"startAt"`
-	Period      int       `json:"period"`
+	ID          int            `json:"id"`
+	Bundle      string         `json:"bundle"`
+	Category    string         `json:"category"`
+	DeveloperID string         `json:"developerId"`
+	Developer   string         `json:"developer"`
+	Geo         string         `json:"geo"`
+	StartAt     time.Time      `json:"startAt"`
+	Period      int            `json:"period"`
+	Status      TrackingStatus `json:"status"`
+	Store       Store          `json:"store"`
+	Meta        []Meta         `json:"meta"`
+	LatestMeta  Meta           `json:"latestMeta"`
+	// Omitted resolution is picked by width of range
+	Categories []*Categories `json:"categories"`
+	// Omitted resolution is picked by width of range
+	Keywords []*Keywords `json:"keywords"`
+}
+
+type AppEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *App   `json:"node"`
+}
+
+type AppFilter struct {
+	// Part of bundle, case insensitive
+	Bundle *string `json:"bundle"`
+	// Part of developer name, case insensitive
+	Developer   *string `json:"developer"`
+	DeveloperID *string `json:"developerId"`
+	Category    *string `json:"category"`
+	Geo         *string `json:"geo"`
+	// Apps which tracking was started at this time or later
+	StartedAfter *time.Time      `json:"startedAfter"`
+	Status       *TrackingStatus `json:"status"`
+	Store        *Store          `json:"store"`
 }
 
+type AppOrder struct {
+	Field     AppOrderField   `json:"field"`
+	Direction *OrderDirection `json:"direction"`
+}
+
+// Snapshot of App Store page
+type AppStoreMeta struct {
+	ID              int      `json:"id"`
+	BundleID        int      `json:"bundleId"`
+	Title           string   `json:"title"`
+	Price           string   `json:"price"`
+	Picture         string   `json:"picture"`
+	Screenshots     []string `json:"screenshots"`
+	Rating          string   `json:"rating"`
+	ReviewCount     string   `json:"reviewCount"`
+	RatingHistogram []string `json:"ratingHistogram"`
+	// Rating parsed from rating string, null if it can not be parsed
+	RatingValue      *float64 `json:"ratingValue"`
+	ReviewCountValue *int     `json:"reviewCountValue"`
+	// Counts of ratings from one to five stars, empty if histogram can not be parsed
+	RatingHistogramValues []int  `json:"ratingHistogramValues"`
+	Description           string `json:"description"`
+	ShortDescription      string `json:"shortDescription"`
+	RecentChanges         string `json:"recentChanges"`
+	ReleaseDate           string `json:"releaseDate"`
+	LastUpdateDate        string `json:"lastUpdateDate"`
+	Appsize               string `json:"appsize"`
+	// Size of app in bytes, null if size varies with device
+	SizeBytes *int   `json:"sizeBytes"`
+	Version   string `json:"version"`
+	// Required iOS version
+	IosVersion       string             `json:"iosVersion"`
+	SupportedDevices []string           `json:"supportedDevices"`
+	InAppPurchases   []string           `json:"inAppPurchases"`
+	AgeRating        string             `json:"ageRating"`
+	DevContacts      *DeveloperContacts `json:"devContacts"`
+	PrivacyPolicy    string             `json:"privacyPolicy"`
+	Date             time.Time          `json:"date"`
+	App              *App               `json:"app"`
+}
+
+func (AppStoreMeta) IsMeta() {}
+
 type Categories struct {
 	ID       int       `json:"id"`
 	BundleID int       `json:"bundleId"`
@@ -26,11 +159,42 @@ type Categories struct {
 	App      *App      `json:"app"`
 }
 
+type CategoriesEdge struct {
+	Cursor string      `json:"cursor"`
+	Node   *Categories `json:"node"`
+}
+
+type Comparison struct {
+	// Buckets of all series in ascending order
+	Buckets []*time.Time        `json:"buckets"`
+	Series  []*ComparisonSeries `json:"series"`
+}
+
+// Places of one app aligned by buckets of comparison
+type ComparisonSeries struct {
+	BundleID int  `json:"bundleId"`
+	App      *App `json:"app"`
+	// Point of every bucket of comparison, null if app has no rows in bucket
+	Points []*RankPoint `json:"points"`
+}
+
+// Range of dates, omitted start or end means that range is not bounded from this side
+type DateRange struct {
+	Start *scalar.FormattedDate `json:"start"`
+	End   *scalar.FormattedDate `json:"end"`
+}
+
 type DeveloperContacts struct {
 	Email    string `json:"email"`
 	Contacts string `json:"contacts"`
 }
 
+// Line of text diff
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
 type Keywords struct {
 	ID       int       `json:"id"`
 	BundleID int       `json:"bundleId"`
@@ -40,28 +204,795 @@ type Keywords struct {
 	App      *App      `json:"app"`
 }
 
-type Meta struct {
-	ID               int                `json:"id"`
-	BundleID         int                `json:"bundleId"`
-	Title            string             `json:"title"`
-	Price            string             `json:"price"`
-	Picture          string             `json:"picture"`
-	Screenshots      []string           `json:"screenshots"`
-	Rating           string             `json:"rating"`
-	ReviewCount      string             `json:"reviewCount"`
-	RatingHistogram  []string           `json:"ratingHistogram"`
-	Description      string             `json:"description"`
-	ShortDescription string             `json:"shortDescription"`
-	RecentChanges    string             `json:"recentChanges"`
-	ReleaseDate      string             `json:"releaseDate"`
-	LastUpdateDate   string             `json:"lastUpdateDate"`
-	Appsize          string             `json:"appsize"`
-	Installs         string             `json:"installs"`
-	Version          string             `json:"version"`
-	OsVersion        string             `json:"osVersion"`
-	ContentRating    string             `json:"contentRating"`
-	DevContacts      *DeveloperContacts `json:"devContacts"`
-	PrivacyPolicy    string             `json:"privacyPolicy"`
-	Date             time.Time          `json:"date"`
-	App              *App               `json:"app"`
+type KeywordsEdge struct {
+	Cursor string    `json:"cursor"`
+	Node   *Keywords `json:"node"`
+}
+
+// Change of one field between two consecutive meta snapshots
+type MetaChange struct {
+	BundleID int       `json:"bundleId"`
+	Field    MetaField `json:"field"`
+	// Date of snapshot with new value
+	Date time.Time `json:"date"`
+	// Date of previous snapshot
+	PreviousDate time.Time `json:"previousDate"`
+	// List values are joined by new line
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+	// Line diff of old and new value, only for long text fields
+	Diff []*DiffLine `json:"diff"`
+}
+
+type MetaEdge struct {
+	Cursor string `json:"cursor"`
+	Node   Meta   `json:"node"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+// Snapshot of Google Play page
+type PlayMeta struct {
+	ID              int      `json:"id"`
+	BundleID        int      `json:"bundleId"`
+	Title           string   `json:"title"`
+	Price           string   `json:"price"`
+	Picture         string   `json:"picture"`
+	Screenshots     []string `json:"screenshots"`
+	Rating          string   `json:"rating"`
+	ReviewCount     string   `json:"reviewCount"`
+	RatingHistogram []string `json:"ratingHistogram"`
+	// Rating parsed from rating string, null if it can not be parsed
+	RatingValue      *float64 `json:"ratingValue"`
+	ReviewCountValue *int     `json:"reviewCountValue"`
+	// Counts of ratings from one to five stars, empty if histogram can not be parsed
+	RatingHistogramValues []int  `json:"ratingHistogramValues"`
+	Description           string `json:"description"`
+	ShortDescription      string `json:"shortDescription"`
+	RecentChanges         string `json:"recentChanges"`
+	ReleaseDate           string `json:"releaseDate"`
+	LastUpdateDate        string `json:"lastUpdateDate"`
+	Appsize               string `json:"appsize"`
+	// Size of app in bytes, null if size varies with device
+	SizeBytes *int `json:"sizeBytes"`
+	// Bucket of installs like 1,000,000+
+	Installs string `json:"installs"`
+	// Lower bound of installs
+	InstallsMin *int `json:"installsMin"`
+	// Upper bound of installs, the next threshold of Google Play buckets
+	InstallsMax *int   `json:"installsMax"`
+	Version     string `json:"version"`
+	// Required Android version
+	OsVersion     string             `json:"osVersion"`
+	ContentRating string             `json:"contentRating"`
+	DevContacts   *DeveloperContacts `json:"devContacts"`
+	PrivacyPolicy string             `json:"privacyPolicy"`
+	Date          time.Time          `json:"date"`
+	App           *App               `json:"app"`
+}
+
+func (PlayMeta) IsMeta() {}
+
+// Places of app within one time bucket
+type RankPoint struct {
+	Bucket time.Time `json:"bucket"`
+	Min    int       `json:"min"`
+	Max    int       `json:"max"`
+	Avg    float64   `json:"avg"`
+	// Place of the latest row in bucket
+	Last int `json:"last"`
+}
+
+// Statistics of places within one time bucket
+type RankStats struct {
+	BundleID int `json:"bundleId"`
+	// Start of bucket
+	Bucket time.Time `json:"bucket"`
+	// Keyword or category
+	Type   string  `json:"type"`
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+	Avg    float64 `json:"avg"`
+	Median float64 `json:"median"`
+	// Place of the earliest row in bucket
+	First int `json:"first"`
+	// Place of the latest row in bucket
+	Last int `json:"last"`
+	// Count of rows in bucket
+	Count int `json:"count"`
+}
+
+// Digest of rank movements, meta changes and rating trends of tracked apps of client
+type Report struct {
+	ID          int       `json:"id"`
+	PeriodStart time.Time `json:"periodStart"`
+	PeriodEnd   time.Time `json:"periodEnd"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// Status of webhook, null if report was not sent
+	Delivery    *DeliveryStatus `json:"delivery"`
+	DeliveredAt *time.Time      `json:"deliveredAt"`
+	// Authenticated path of html body
+	HTMLURL string `json:"htmlUrl"`
+	// Authenticated path of json body
+	JSONURL string `json:"jsonUrl"`
+}
+
+// Weekly digest schedule of client
+type ReportSchedule struct {
+	// Day of week from 0 (Sunday) to 6
+	Weekday int `json:"weekday"`
+	// Hour in UTC
+	Hour       int     `json:"hour"`
+	WebhookURL *string `json:"webhookUrl"`
+	// Key of HMAC signature of webhooks in X-Muromachi-Signature header
+	Secret    string    `json:"secret"`
+	Enabled   bool      `json:"enabled"`
+	NextRunAt time.Time `json:"nextRunAt"`
+}
+
+// Inserted or updated tracking row
+type TrackingUpdate struct {
+	Kind     TrackingKind `json:"kind"`
+	BundleID int          `json:"bundleId"`
+	// Id of row in tracking table of kind
+	ID   int       `json:"id"`
+	Date time.Time `json:"date"`
+	App  *App      `json:"app"`
+}
+
+type AlertCondition string
+
+const (
+	// Place becomes worse than threshold
+	AlertConditionBelow AlertCondition = "BELOW"
+	// Place becomes threshold or better
+	AlertConditionAbove AlertCondition = "ABOVE"
+	// Place changes by more than threshold within a day
+	AlertConditionChangedBy AlertCondition = "CHANGED_BY"
+	// Meta field changes
+	AlertConditionChanged AlertCondition = "CHANGED"
+)
+
+var AllAlertCondition = []AlertCondition{
+	AlertConditionBelow,
+	AlertConditionAbove,
+	AlertConditionChangedBy,
+	AlertConditionChanged,
+}
+
+func (e AlertCondition) IsValid() bool {
+	switch e {
+	case AlertConditionBelow, AlertConditionAbove, AlertConditionChangedBy, AlertConditionChanged:
+		return true
+	}
+	return false
+}
+
+func (e AlertCondition) String() string {
+	return string(e)
+}
+
+func (e *AlertCondition) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AlertCondition(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AlertCondition", str)
+	}
+	return nil
+}
+
+func (e AlertCondition) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type AlertKind string
+
+const (
+	AlertKindKeyword  AlertKind = "KEYWORD"
+	AlertKindCategory AlertKind = "CATEGORY"
+	AlertKindMeta     AlertKind = "META"
+)
+
+var AllAlertKind = []AlertKind{
+	AlertKindKeyword,
+	AlertKindCategory,
+	AlertKindMeta,
+}
+
+func (e AlertKind) IsValid() bool {
+	switch e {
+	case AlertKindKeyword, AlertKindCategory, AlertKindMeta:
+		return true
+	}
+	return false
+}
+
+func (e AlertKind) String() string {
+	return string(e)
+}
+
+func (e *AlertKind) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AlertKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AlertKind", str)
+	}
+	return nil
+}
+
+func (e AlertKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type AnomalyMetric string
+
+const (
+	// Place by keyword
+	AnomalyMetricKeyword AnomalyMetric = "KEYWORD"
+	// Place in category
+	AnomalyMetricCategory    AnomalyMetric = "CATEGORY"
+	AnomalyMetricRating      AnomalyMetric = "RATING"
+	AnomalyMetricReviewCount AnomalyMetric = "REVIEW_COUNT"
+)
+
+var AllAnomalyMetric = []AnomalyMetric{
+	AnomalyMetricKeyword,
+	AnomalyMetricCategory,
+	AnomalyMetricRating,
+	AnomalyMetricReviewCount,
+}
+
+func (e AnomalyMetric) IsValid() bool {
+	switch e {
+	case AnomalyMetricKeyword, AnomalyMetricCategory, AnomalyMetricRating, AnomalyMetricReviewCount:
+		return true
+	}
+	return false
+}
+
+func (e AnomalyMetric) String() string {
+	return string(e)
+}
+
+func (e *AnomalyMetric) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AnomalyMetric(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AnomalyMetric", str)
+	}
+	return nil
+}
+
+func (e AnomalyMetric) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type AnomalyStatus string
+
+const (
+	AnomalyStatusNew       AnomalyStatus = "NEW"
+	AnomalyStatusConfirmed AnomalyStatus = "CONFIRMED"
+	AnomalyStatusDismissed AnomalyStatus = "DISMISSED"
+)
+
+var AllAnomalyStatus = []AnomalyStatus{
+	AnomalyStatusNew,
+	AnomalyStatusConfirmed,
+	AnomalyStatusDismissed,
+}
+
+func (e AnomalyStatus) IsValid() bool {
+	switch e {
+	case AnomalyStatusNew, AnomalyStatusConfirmed, AnomalyStatusDismissed:
+		return true
+	}
+	return false
+}
+
+func (e AnomalyStatus) String() string {
+	return string(e)
+}
+
+func (e *AnomalyStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AnomalyStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AnomalyStatus", str)
+	}
+	return nil
+}
+
+func (e AnomalyStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type AppOrderField string
+
+const (
+	AppOrderFieldID      AppOrderField = "ID"
+	AppOrderFieldBundle  AppOrderField = "BUNDLE"
+	AppOrderFieldStartAt AppOrderField = "START_AT"
+)
+
+var AllAppOrderField = []AppOrderField{
+	AppOrderFieldID,
+	AppOrderFieldBundle,
+	AppOrderFieldStartAt,
+}
+
+func (e AppOrderField) IsValid() bool {
+	switch e {
+	case AppOrderFieldID, AppOrderFieldBundle, AppOrderFieldStartAt:
+		return true
+	}
+	return false
+}
+
+func (e AppOrderField) String() string {
+	return string(e)
+}
+
+func (e *AppOrderField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AppOrderField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AppOrderField", str)
+	}
+	return nil
+}
+
+func (e AppOrderField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Bucket string
+
+const (
+	BucketHour  Bucket = "HOUR"
+	BucketDay   Bucket = "DAY"
+	BucketWeek  Bucket = "WEEK"
+	BucketMonth Bucket = "MONTH"
+)
+
+var AllBucket = []Bucket{
+	BucketHour,
+	BucketDay,
+	BucketWeek,
+	BucketMonth,
+}
+
+func (e Bucket) IsValid() bool {
+	switch e {
+	case BucketHour, BucketDay, BucketWeek, BucketMonth:
+		return true
+	}
+	return false
+}
+
+func (e Bucket) String() string {
+	return string(e)
+}
+
+func (e *Bucket) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Bucket(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Bucket", str)
+	}
+	return nil
+}
+
+func (e Bucket) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "PENDING"
+	DeliveryStatusDelivered DeliveryStatus = "DELIVERED"
+	DeliveryStatusFailed    DeliveryStatus = "FAILED"
+)
+
+var AllDeliveryStatus = []DeliveryStatus{
+	DeliveryStatusPending,
+	DeliveryStatusDelivered,
+	DeliveryStatusFailed,
+}
+
+func (e DeliveryStatus) IsValid() bool {
+	switch e {
+	case DeliveryStatusPending, DeliveryStatusDelivered, DeliveryStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e DeliveryStatus) String() string {
+	return string(e)
+}
+
+func (e *DeliveryStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DeliveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DeliveryStatus", str)
+	}
+	return nil
+}
+
+func (e DeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type DiffOp string
+
+const (
+	DiffOpEqual  DiffOp = "EQUAL"
+	DiffOpInsert DiffOp = "INSERT"
+	DiffOpDelete DiffOp = "DELETE"
+)
+
+var AllDiffOp = []DiffOp{
+	DiffOpEqual,
+	DiffOpInsert,
+	DiffOpDelete,
+}
+
+func (e DiffOp) IsValid() bool {
+	switch e {
+	case DiffOpEqual, DiffOpInsert, DiffOpDelete:
+		return true
+	}
+	return false
+}
+
+func (e DiffOp) String() string {
+	return string(e)
+}
+
+func (e *DiffOp) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DiffOp(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DiffOp", str)
+	}
+	return nil
+}
+
+func (e DiffOp) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Field of meta snapshot
+type MetaField string
+
+const (
+	MetaFieldTitle             MetaField = "TITLE"
+	MetaFieldPrice             MetaField = "PRICE"
+	MetaFieldPicture           MetaField = "PICTURE"
+	MetaFieldScreenshots       MetaField = "SCREENSHOTS"
+	MetaFieldRating            MetaField = "RATING"
+	MetaFieldReviewCount       MetaField = "REVIEW_COUNT"
+	MetaFieldRatingHistogram   MetaField = "RATING_HISTOGRAM"
+	MetaFieldDescription       MetaField = "DESCRIPTION"
+	MetaFieldShortDescription  MetaField = "SHORT_DESCRIPTION"
+	MetaFieldRecentChanges     MetaField = "RECENT_CHANGES"
+	MetaFieldReleaseDate       MetaField = "RELEASE_DATE"
+	MetaFieldLastUpdateDate    MetaField = "LAST_UPDATE_DATE"
+	MetaFieldAppSize           MetaField = "APP_SIZE"
+	MetaFieldInstalls          MetaField = "INSTALLS"
+	MetaFieldVersion           MetaField = "VERSION"
+	MetaFieldAndroidVersion    MetaField = "ANDROID_VERSION"
+	MetaFieldContentRating     MetaField = "CONTENT_RATING"
+	MetaFieldDeveloperContacts MetaField = "DEVELOPER_CONTACTS"
+	MetaFieldPrivacyPolicy     MetaField = "PRIVACY_POLICY"
+	MetaFieldIosVersion        MetaField = "IOS_VERSION"
+	MetaFieldSupportedDevices  MetaField = "SUPPORTED_DEVICES"
+	MetaFieldInAppPurchases    MetaField = "IN_APP_PURCHASES"
+	MetaFieldAgeRating         MetaField = "AGE_RATING"
+)
+
+var AllMetaField = []MetaField{
+	MetaFieldTitle,
+	MetaFieldPrice,
+	MetaFieldPicture,
+	MetaFieldScreenshots,
+	MetaFieldRating,
+	MetaFieldReviewCount,
+	MetaFieldRatingHistogram,
+	MetaFieldDescription,
+	MetaFieldShortDescription,
+	MetaFieldRecentChanges,
+	MetaFieldReleaseDate,
+	MetaFieldLastUpdateDate,
+	MetaFieldAppSize,
+	MetaFieldInstalls,
+	MetaFieldVersion,
+	MetaFieldAndroidVersion,
+	MetaFieldContentRating,
+	MetaFieldDeveloperContacts,
+	MetaFieldPrivacyPolicy,
+	MetaFieldIosVersion,
+	MetaFieldSupportedDevices,
+	MetaFieldInAppPurchases,
+	MetaFieldAgeRating,
+}
+
+func (e MetaField) IsValid() bool {
+	switch e {
+	case MetaFieldTitle, MetaFieldPrice, MetaFieldPicture, MetaFieldScreenshots, MetaFieldRating, MetaFieldReviewCount, MetaFieldRatingHistogram, MetaFieldDescription, MetaFieldShortDescription, MetaFieldRecentChanges, MetaFieldReleaseDate, MetaFieldLastUpdateDate, MetaFieldAppSize, MetaFieldInstalls, MetaFieldVersion, MetaFieldAndroidVersion, MetaFieldContentRating, MetaFieldDeveloperContacts, MetaFieldPrivacyPolicy, MetaFieldIosVersion, MetaFieldSupportedDevices, MetaFieldInAppPurchases, MetaFieldAgeRating:
+		return true
+	}
+	return false
+}
+
+func (e MetaField) String() string {
+	return string(e)
+}
+
+func (e *MetaField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = MetaField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid MetaField", str)
+	}
+	return nil
+}
+
+func (e MetaField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrderDirection string
+
+const (
+	OrderDirectionAsc  OrderDirection = "ASC"
+	OrderDirectionDesc OrderDirection = "DESC"
+)
+
+var AllOrderDirection = []OrderDirection{
+	OrderDirectionAsc,
+	OrderDirectionDesc,
+}
+
+func (e OrderDirection) IsValid() bool {
+	switch e {
+	case OrderDirectionAsc, OrderDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e OrderDirection) String() string {
+	return string(e)
+}
+
+func (e *OrderDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderDirection", str)
+	}
+	return nil
+}
+
+func (e OrderDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Granularity of rank history, DAY and WEEK return the latest place of every bucket with start of bucket as date
+type Resolution string
+
+const (
+	ResolutionRaw  Resolution = "RAW"
+	ResolutionDay  Resolution = "DAY"
+	ResolutionWeek Resolution = "WEEK"
+)
+
+var AllResolution = []Resolution{
+	ResolutionRaw,
+	ResolutionDay,
+	ResolutionWeek,
+}
+
+func (e Resolution) IsValid() bool {
+	switch e {
+	case ResolutionRaw, ResolutionDay, ResolutionWeek:
+		return true
+	}
+	return false
+}
+
+func (e Resolution) String() string {
+	return string(e)
+}
+
+func (e *Resolution) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Resolution(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Resolution", str)
+	}
+	return nil
+}
+
+func (e Resolution) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Store string
+
+const (
+	StorePlay     Store = "PLAY"
+	StoreAppStore Store = "APP_STORE"
+)
+
+var AllStore = []Store{
+	StorePlay,
+	StoreAppStore,
+}
+
+func (e Store) IsValid() bool {
+	switch e {
+	case StorePlay, StoreAppStore:
+		return true
+	}
+	return false
+}
+
+func (e Store) String() string {
+	return string(e)
+}
+
+func (e *Store) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Store(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Store", str)
+	}
+	return nil
+}
+
+func (e Store) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TrackingKind string
+
+const (
+	TrackingKindMeta     TrackingKind = "META"
+	TrackingKindCategory TrackingKind = "CATEGORY"
+	TrackingKindKeyword  TrackingKind = "KEYWORD"
+)
+
+var AllTrackingKind = []TrackingKind{
+	TrackingKindMeta,
+	TrackingKindCategory,
+	TrackingKindKeyword,
+}
+
+func (e TrackingKind) IsValid() bool {
+	switch e {
+	case TrackingKindMeta, TrackingKindCategory, TrackingKindKeyword:
+		return true
+	}
+	return false
+}
+
+func (e TrackingKind) String() string {
+	return string(e)
+}
+
+func (e *TrackingKind) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TrackingKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TrackingKind", str)
+	}
+	return nil
+}
+
+func (e TrackingKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TrackingStatus string
+
+const (
+	TrackingStatusActive  TrackingStatus = "ACTIVE"
+	TrackingStatusPaused  TrackingStatus = "PAUSED"
+	TrackingStatusStopped TrackingStatus = "STOPPED"
+)
+
+var AllTrackingStatus = []TrackingStatus{
+	TrackingStatusActive,
+	TrackingStatusPaused,
+	TrackingStatusStopped,
+}
+
+func (e TrackingStatus) IsValid() bool {
+	switch e {
+	case TrackingStatusActive, TrackingStatusPaused, TrackingStatusStopped:
+		return true
+	}
+	return false
+}
+
+func (e TrackingStatus) String() string {
+	return string(e)
+}
+
+func (e *TrackingStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TrackingStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TrackingStatus", str)
+	}
+	return nil
+}
+
+func (e TrackingStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/auth"
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// Package name of android app like com.example.app
+	bundlePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*(\.[a-zA-Z][a-zA-Z0-9_]*)+$`)
+	// Bundle id of iOS app like com.example.my-app
+	iosBundlePattern = regexp.MustCompile(`^[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)+$`)
+	// Language and country like ru_ru
+	geoPattern = regexp.MustCompile(`^[a-zA-Z]{2}_[a-zA-Z]{2}$`)
+)
+
+const (
+	maxBundleLength   = 255
+	maxCategoryLength = 128
+	// Max period of tracking in days
+	maxPeriod = 365
+)
+
+// validateApp checks values of tracked app
+func validateApp(app entities.App) error {
+	invalid := func(format string, args ...interface{}) error {
+		return apperrors.New(apperrors.BadRequest, fmt.Sprintf(format, args...))
+	}
+
+	pattern := bundlePattern
+	switch app.StoreOrDefault() {
+	case entities.StorePlay:
+	case entities.StoreAppStore:
+		pattern = iosBundlePattern
+	default:
+		return invalid("unknown store %q", app.Store)
+	}
+	if len(app.Bundle) > maxBundleLength || !pattern.MatchString(app.Bundle) {
+		return invalid("bundle %q should look like com.example.app", app.Bundle)
+	}
+	if !geoPattern.MatchString(app.Geo) {
+		return invalid("geo %q should look like ru_ru", app.Geo)
+	}
+	if app.Category == "" || len(app.Category) > maxCategoryLength {
+		return invalid("category should not be empty and longer than %d symbols", maxCategoryLength)
+	}
+	if app.Period == 0 || app.Period > maxPeriod {
+		return invalid("period should be from 1 to %d days", maxPeriod)
+	}
+
+	return nil
+}
+
+// trackingPeriod converts period of graphql arguments, negative period is invalid
+func trackingPeriod(p int) (uint32, error) {
+	if p <= 0 || p > maxPeriod {
+		return 0, apperrors.New(apperrors.BadRequest, fmt.Sprintf("period should be from 1 to %d days", maxPeriod))
+	}
+	return uint32(p), nil
+}
+
+// appStore converts store of graphql arguments, omitted store is Google Play
+func appStore(store *model.Store) entities.AppStore {
+	if store == nil {
+		return entities.StorePlay
+	}
+	return entities.AppStore(strings.ToLower(string(*store)))
+}
+
+// trackApp registers new app owned by request client
+func (r *Resolver) trackApp(ctx context.Context, app entities.App) (*model.App, error) {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	app.OwnerId = int(claims.ID)
+	app.Bundle = strings.TrimSpace(app.Bundle)
+	app.Category = strings.TrimSpace(app.Category)
+	if app.StartAt.IsZero() {
+		app.StartAt = time.Now().UTC()
+	}
+	if err := validateApp(app); err != nil {
+		return nil, err
+	}
+
+	created, err := r.Tables.App.Create(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	return toModelApp(created)
+}
+
+// ownedApp returns app which can be changed by request client. Apps without
+// owner were added by sql and can be changed by any client
+func (r *Resolver) ownedApp(ctx context.Context, id int) (entities.App, error) {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return entities.App{}, err
+	}
+	app, err := r.Tables.App.ById(ctx, id)
+	if err != nil {
+		return app, err
+	}
+	if app.OwnerId != 0 && app.OwnerId != int(claims.ID) {
+		return app, apperrors.New(apperrors.Forbidden, "app is tracked by another client")
+	}
+	if app.Status == entities.AppStopped {
+		return app, apperrors.New(apperrors.Conflict, "tracking of app is stopped")
+	}
+
+	return app, nil
+}
+
+// updateTrackedApp changes given values of app tracking
+func (r *Resolver) updateTrackedApp(ctx context.Context, id int, category *string, p *int, startAt *time.Time) (*model.App, error) {
+	app, err := r.ownedApp(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if category != nil {
+		app.Category = strings.TrimSpace(*category)
+	}
+	if p != nil {
+		if app.Period, err = trackingPeriod(*p); err != nil {
+			return nil, err
+		}
+	}
+	if startAt != nil {
+		app.StartAt = *startAt
+	}
+	if err := validateApp(app); err != nil {
+		return nil, err
+	}
+
+	updated, err := r.Tables.App.Update(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	return toModelApp(updated)
+}
+
+// setTrackingStatus changes status of app tracking
+func (r *Resolver) setTrackingStatus(ctx context.Context, id int, status entities.AppStatus) (*model.App, error) {
+	if _, err := r.ownedApp(ctx, id); err != nil {
+		return nil, err
+	}
+
+	app, err := r.Tables.App.SetStatus(ctx, id, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return toModelApp(app)
+}
+
+// toModelApp converts app to graphql model
+func toModelApp(app entities.App) (*model.App, error) {
+	m := &model.App{}
+	if err := app.To(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,95 @@
+package graph_test
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/auth"
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// withClient returns context of authenticated client request
+func withClient(id int64) context.Context {
+	return context.WithValue(context.Background(), "request_user", &auth.UserClaims{ID: id, Role: "user"})
+}
+
+func TestTrackApp_ShouldValidateAndRecordOwner(t *testing.T) {
+	resolver, repo := newAppsResolver()
+	ctx := withClient(7)
+
+	var tt = []struct {
+		name     string
+		bundle   string
+		geo      string
+		category string
+		period   int
+	}{
+		{name: "invalid bundle", bundle: "not a bundle", geo: "ru_ru", category: "FINANCE", period: 7},
+		{name: "invalid geo", bundle: "com.test", geo: "russia", category: "FINANCE", period: 7},
+		{name: "empty category", bundle: "com.test", geo: "ru_ru", category: " ", period: 7},
+		{name: "negative period", bundle: "com.test", geo: "ru_ru", category: "FINANCE", period: -1},
+		{name: "too long period", bundle: "com.test", geo: "ru_ru", category: "FINANCE", period: 366},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := resolver.Mutation().TrackApp(ctx, test.bundle, test.geo, test.category, test.period, nil, nil, nil, nil)
+			assert.True(t, errors.Is(err, apperrors.New(apperrors.BadRequest, "")), err)
+		})
+	}
+
+	app, err := resolver.Mutation().TrackApp(ctx, "com.test.bank", "ru_ru", "FINANCE", 7, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TrackingStatusActive, app.Status)
+	assert.False(t, app.StartAt.IsZero())
+	assert.Equal(t, 7, repo.apps[len(repo.apps)-1].OwnerId)
+
+	_, err = resolver.Mutation().TrackApp(ctx, "com.test.bank", "ru_ru", "FINANCE", 7, nil, nil, nil, nil)
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.Conflict, "")))
+
+	// The same bundle is tracked once in every store
+	appStore := model.StoreAppStore
+	app, err = resolver.Mutation().TrackApp(ctx, "com.test.bank", "ru_ru", "FINANCE", 7, nil, nil, nil, &appStore)
+	assert.NoError(t, err)
+	assert.Equal(t, model.StoreAppStore, app.Store)
+
+	_, err = resolver.Mutation().TrackApp(ctx, "com.test.my-bank", "ru_ru", "FINANCE", 7, nil, nil, nil, &appStore)
+	assert.NoError(t, err)
+
+	_, err = resolver.Mutation().TrackApp(context.Background(), "com.test.other", "ru_ru", "FINANCE", 7, nil, nil, nil, nil)
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.NotAuthenticated, "")))
+}
+
+func TestTrackingMutations_ShouldChangeOnlyOwnApps(t *testing.T) {
+	resolver, repo := newAppsResolver()
+	repo.apps[0].OwnerId, repo.apps[0].Period, repo.apps[0].Category = 7, 7, "FINANCE"
+	repo.apps[1].OwnerId = 8
+	ctx := withClient(7)
+
+	period, category := 30, "BUSINESS"
+	app, err := resolver.Mutation().UpdateTrackedApp(ctx, 1, &category, &period, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, app.Period)
+	assert.Equal(t, "BUSINESS", app.Category)
+
+	_, err = resolver.Mutation().UpdateTrackedApp(ctx, 2, &category, nil, nil)
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.Forbidden, "")))
+
+	app, err = resolver.Mutation().PauseTracking(ctx, 1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TrackingStatusPaused, app.Status)
+
+	app, err = resolver.Mutation().PauseTracking(ctx, 1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TrackingStatusActive, app.Status)
+
+	app, err = resolver.Mutation().StopTracking(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TrackingStatusStopped, app.Status)
+	assert.Equal(t, entities.AppStopped, repo.apps[0].Status)
+
+	_, err = resolver.Mutation().PauseTracking(ctx, 1, true)
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.Conflict, "")))
+}
@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/auth"
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"strings"
+	"time"
+)
+
+// Max count of reports in list
+const maxReports = 100
+
+// reportSchedule returns digest schedule of request client, nil if client has not schedule
+func (r *Resolver) reportSchedule(ctx context.Context) (*model.ReportSchedule, error) {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := r.Tables.Reports.Schedule(ctx, int(claims.ID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toModelReportSchedule(schedule)
+}
+
+// setReportSchedule creates or changes digest schedule of request client
+func (r *Resolver) setReportSchedule(ctx context.Context, weekday, hour int, webhookUrl *string, enabled bool) (*model.ReportSchedule, error) {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedule := entities.ReportSchedule{
+		OwnerId: int(claims.ID),
+		Weekday: time.Weekday(weekday),
+		Hour:    hour,
+		Enabled: enabled,
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, apperrors.New(apperrors.BadRequest, err.Error())
+	}
+	if webhookUrl != nil {
+		schedule.WebhookUrl = strings.TrimSpace(*webhookUrl)
+	}
+	if schedule.WebhookUrl != "" {
+		if err := validateWebhook(schedule.WebhookUrl); err != nil {
+			return nil, err
+		}
+	}
+
+	// Secret is generated for every call, but secret of existing schedule is kept by repository
+	if schedule.Secret, err = newSecret(); err != nil {
+		return nil, err
+	}
+	schedule.NextRunAt = schedule.NextRun(time.Now())
+	saved, err := r.Tables.Reports.SaveSchedule(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	return toModelReportSchedule(saved)
+}
+
+// reports returns last reports of request client
+func (r *Resolver) reports(ctx context.Context, last *int) ([]*model.Report, error) {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	limit := 10
+	if last != nil {
+		limit = *last
+	}
+	if limit <= 0 || limit > maxReports {
+		return nil, apperrors.New(apperrors.BadRequest, "last should be from 1 to 100")
+	}
+
+	dbo, err := r.Tables.Reports.Reports(ctx, int(claims.ID), limit)
+	if err == pgx.ErrNoRows {
+		return []*model.Report{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*model.Report, len(dbo))
+	if err := dbo.To(reports); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// toModelReportSchedule converts schedule to graphql model
+func toModelReportSchedule(schedule entities.ReportSchedule) (*model.ReportSchedule, error) {
+	m := &model.ReportSchedule{}
+	if err := schedule.To(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
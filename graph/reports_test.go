@@ -0,0 +1,144 @@
+package graph_test
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Repository which keeps schedules and reports in memory
+type mockReportRepo struct {
+	schedules map[int]entities.ReportSchedule
+	reports   []entities.Report
+}
+
+func (m *mockReportRepo) Schedule(ctx context.Context, ownerId int) (entities.ReportSchedule, error) {
+	schedule, ok := m.schedules[ownerId]
+	if !ok {
+		return schedule, pgx.ErrNoRows
+	}
+	return schedule, nil
+}
+
+func (m *mockReportRepo) SaveSchedule(ctx context.Context, schedule entities.ReportSchedule) (entities.ReportSchedule, error) {
+	if m.schedules == nil {
+		m.schedules = make(map[int]entities.ReportSchedule)
+	}
+	if saved, ok := m.schedules[schedule.OwnerId]; ok {
+		schedule.Secret = saved.Secret
+	}
+	m.schedules[schedule.OwnerId] = schedule
+	return schedule, nil
+}
+
+func (m *mockReportRepo) Reports(ctx context.Context, ownerId, limit int) (entities.DboSlice, error) {
+	var dbo entities.DboSlice
+	for i := len(m.reports) - 1; i >= 0 && len(dbo) < limit; i-- {
+		if m.reports[i].OwnerId == ownerId {
+			dbo = append(dbo, m.reports[i])
+		}
+	}
+	if len(dbo) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return dbo, nil
+}
+
+func (m *mockReportRepo) ReportById(ctx context.Context, id int64) (entities.Report, error) {
+	for _, report := range m.reports {
+		if report.Id == id {
+			return report, nil
+		}
+	}
+	return entities.Report{}, pgx.ErrNoRows
+}
+
+func TestSetReportSchedule_ShouldValidateAndKeepSecret_Mock(t *testing.T) {
+	resolver, _ := newAppsResolver()
+	resolver.Tables.Reports = &mockReportRepo{}
+	ctx := withClient(7)
+	hook := "https://example.com/hook"
+	bad := "ftp://example.com"
+	internal := "http://192.168.0.10:8080/hook"
+
+	var tt = []struct {
+		name    string
+		weekday int
+		hour    int
+		webhook *string
+	}{
+		{name: "weekday", weekday: 7, hour: 9},
+		{name: "hour", weekday: 1, hour: 24},
+		{name: "webhook", weekday: 1, hour: 9, webhook: &bad},
+		{name: "internal webhook", weekday: 1, hour: 9, webhook: &internal},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := resolver.Mutation().SetReportSchedule(ctx, test.weekday, test.hour, test.webhook, true)
+			assert.True(t, errors.Is(err, apperrors.New(apperrors.BadRequest, "")))
+		})
+	}
+
+	_, err := resolver.Mutation().SetReportSchedule(context.Background(), 1, 9, nil, true)
+	assert.Error(t, err)
+
+	schedule, err := resolver.Query().ReportSchedule(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, schedule)
+
+	created, err := resolver.Mutation().SetReportSchedule(ctx, 1, 9, &hook, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created.Weekday)
+	assert.Equal(t, hook, *created.WebhookURL)
+	assert.NotEmpty(t, created.Secret)
+	assert.Equal(t, time.Monday, created.NextRunAt.Weekday())
+	assert.Equal(t, 9, created.NextRunAt.Hour())
+	assert.True(t, created.NextRunAt.After(time.Now()))
+
+	changed, err := resolver.Mutation().SetReportSchedule(ctx, 5, 0, nil, false)
+	assert.NoError(t, err)
+	assert.Nil(t, changed.WebhookURL)
+	assert.False(t, changed.Enabled)
+	assert.Equal(t, created.Secret, changed.Secret)
+
+	schedule, err = resolver.Query().ReportSchedule(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, changed, schedule)
+}
+
+func TestReports_ShouldReturnReportsOfClient_Mock(t *testing.T) {
+	end := time.Date(2021, 1, 18, 9, 0, 0, 0, time.UTC)
+	resolver, _ := newAppsResolver()
+	resolver.Tables.Reports = &mockReportRepo{reports: []entities.Report{
+		{Id: 1, OwnerId: 7, PeriodStart: end.AddDate(0, 0, -14), PeriodEnd: end.AddDate(0, 0, -7)},
+		{Id: 2, OwnerId: 8, PeriodStart: end.AddDate(0, 0, -7), PeriodEnd: end},
+		{Id: 3, OwnerId: 7, PeriodStart: end.AddDate(0, 0, -7), PeriodEnd: end, Delivery: entities.DeliveryDelivered},
+	}}
+
+	reports, err := resolver.Query().Reports(withClient(7), nil)
+	assert.NoError(t, err)
+	assert.Len(t, reports, 2)
+	assert.Equal(t, 3, reports[0].ID)
+	assert.Equal(t, model.DeliveryStatusDelivered, *reports[0].Delivery)
+	assert.Equal(t, "/reports/3/html", reports[0].HTMLURL)
+	assert.Nil(t, reports[1].Delivery)
+
+	last := 1
+	reports, err = resolver.Query().Reports(withClient(7), &last)
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+
+	reports, err = resolver.Query().Reports(withClient(9), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, reports)
+
+	last = 101
+	_, err = resolver.Query().Reports(withClient(7), &last)
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.BadRequest, "")))
+}
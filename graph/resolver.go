@@ -1,7 +1,15 @@
 package graph
 
 import (
+	"Muromachi/graph/loaders"
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
 	"Muromachi/store/tracking"
+	"Muromachi/store/tracking/events"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"strings"
+	"time"
 )
 
 //go:generate go run github.com/99designs/gqlgen
@@ -12,4 +20,66 @@ import (
 
 type Resolver struct{
 	Tables *tracking.Tables
+	// Hub of tracking events for subscriptions
+	Events *events.Hub
+}
+
+// loaders returns loaders of request. If request has not loaders (resolver is
+// called without server.Graphql middleware) then new loaders are created
+func (r *Resolver) loaders(ctx context.Context) *loaders.Loaders {
+	if l := loaders.For(ctx); l != nil {
+		return l
+	}
+	return loaders.New(r.Tables)
+}
+
+// app loads app with given id through loader of request
+func (r *Resolver) app(ctx context.Context, id int) (*model.App, error) {
+	app, err := r.loaders(ctx).App.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &model.App{}
+	if err := app.To(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// history loads tracking rows of app within range by resolution through loader of request.
+// App without rows has empty history
+func (r *Resolver) history(ctx context.Context, loader *loaders.TrackLoader, id int, rng *model.DateRange, resolution *model.Resolution) (entities.DboSlice, error) {
+	key := loaders.TrackKey{BundleId: id, Resolution: resolutionOf(resolution)}
+	key.Start, key.End = dateRange(rng)
+
+	dbo, err := loader.Load(ctx, key)
+	if err == pgx.ErrNoRows {
+		return entities.DboSlice{}, nil
+	}
+
+	return dbo, err
+}
+
+// dateRange returns bounds of range, omitted bounds are zero
+func dateRange(rng *model.DateRange) (start, end time.Time) {
+	if rng == nil {
+		return
+	}
+	if rng.Start != nil {
+		start = time.Time(*rng.Start)
+	}
+	if rng.End != nil {
+		end = time.Time(*rng.End)
+	}
+	return
+}
+
+// resolutionOf returns resolution of argument, omitted resolution is auto
+func resolutionOf(resolution *model.Resolution) entities.Resolution {
+	if resolution == nil {
+		return entities.ResolutionAuto
+	}
+	return entities.Resolution(strings.ToLower(string(*resolution)))
 }
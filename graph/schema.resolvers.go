@@ -4,21 +4,221 @@ package graph
 // will be copied through when generating and any unknown code will be moved to the end.
 
 import (
+	"Muromachi/apperrors"
 	"Muromachi/graph/generated"
+	"Muromachi/graph/loaders"
 	"Muromachi/graph/model"
 	"Muromachi/graph/scalar"
 	"Muromachi/store/entities"
 	"context"
+	"fmt"
 	"time"
+
+	pgx "github.com/jackc/pgx/v4"
 )
 
-func (r *queryResolver) Meta(ctx context.Context, id int, last *int, start *scalar.FormattedDate, end *scalar.FormattedDate) ([]*model.Meta, error) {
+func (r *alertRuleResolver) App(ctx context.Context, obj *model.AlertRule) (*model.App, error) {
+	return r.app(ctx, obj.BundleID)
+}
+
+func (r *alertRuleResolver) Deliveries(ctx context.Context, obj *model.AlertRule, last *int) ([]*model.AlertDelivery, error) {
+	return r.alertDeliveries(ctx, obj.ID, last)
+}
+
+func (r *anomalyResolver) App(ctx context.Context, obj *model.Anomaly) (*model.App, error) {
+	return r.app(ctx, obj.BundleID)
+}
+
+func (r *appResolver) Meta(ctx context.Context, obj *model.App, rangeArg *model.DateRange) ([]model.Meta, error) {
+	dbo, err := r.history(ctx, r.loaders(ctx).Meta, obj.ID, rangeArg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	metaModels := make([]model.Meta, len(dbo))
+	if err := dbo.To(metaModels); err != nil {
+		return nil, err
+	}
+
+	return metaModels, nil
+}
+
+func (r *appResolver) LatestMeta(ctx context.Context, obj *model.App) (model.Meta, error) {
+	dbo, err := r.Tables.Meta.LastUpdates(ctx, obj.ID, 1)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var meta model.Meta
+	if err := dbo[0].To(&meta); err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+func (r *appResolver) Categories(ctx context.Context, obj *model.App, rangeArg *model.DateRange, resolution *model.Resolution) ([]*model.Categories, error) {
+	dbo, err := r.history(ctx, r.loaders(ctx).Cat, obj.ID, rangeArg, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	catModels := make([]*model.Categories, len(dbo))
+	if err := dbo.To(catModels); err != nil {
+		return nil, err
+	}
+
+	return catModels, nil
+}
+
+func (r *appResolver) Keywords(ctx context.Context, obj *model.App, rangeArg *model.DateRange, keyword *string, resolution *model.Resolution) ([]*model.Keywords, error) {
+	dbo, err := r.history(ctx, r.loaders(ctx).Keys, obj.ID, rangeArg, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows of all keywords are loaded once and shared between fields with different keywords
+	if keyword != nil {
+		var filtered entities.DboSlice
+		for _, v := range dbo {
+			var track entities.Track
+			if err := v.To(&track); err != nil {
+				return nil, err
+			}
+			if track.Type == *keyword {
+				filtered = append(filtered, v)
+			}
+		}
+		dbo = filtered
+	}
+
+	keyModels := make([]*model.Keywords, len(dbo))
+	if err := dbo.To(keyModels); err != nil {
+		return nil, err
+	}
+
+	return keyModels, nil
+}
+
+func (r *appConnectionResolver) TotalCount(ctx context.Context, obj *model.AppConnection) (int, error) {
+	return r.Tables.App.CountApps(ctx, appFilter(obj.Filter))
+}
+
+func (r *appStoreMetaResolver) App(ctx context.Context, obj *model.AppStoreMeta) (*model.App, error) {
+	return r.app(ctx, obj.BundleID)
+}
+
+func (r *categoriesResolver) App(ctx context.Context, obj *model.Categories) (*model.App, error) {
+	return r.app(ctx, obj.BundleID)
+}
+
+func (r *categoriesConnectionResolver) TotalCount(ctx context.Context, obj *model.CategoriesConnection) (int, error) {
+	return r.Tables.Cat.Count(ctx, obj.Query.BundleID, obj.Query.Start, obj.Query.End)
+}
+
+func (r *comparisonSeriesResolver) App(ctx context.Context, obj *model.ComparisonSeries) (*model.App, error) {
+	app, err := r.app(ctx, obj.BundleID)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+
+	return app, err
+}
+
+func (r *keywordsResolver) App(ctx context.Context, obj *model.Keywords) (*model.App, error) {
+	return r.app(ctx, obj.BundleID)
+}
+
+func (r *keywordsConnectionResolver) TotalCount(ctx context.Context, obj *model.KeywordsConnection) (int, error) {
+	return r.Tables.Keys.Count(ctx, obj.Query.BundleID, obj.Query.Start, obj.Query.End)
+}
+
+func (r *metaConnectionResolver) TotalCount(ctx context.Context, obj *model.MetaConnection) (int, error) {
+	return r.Tables.Meta.Count(ctx, obj.Query.BundleID, obj.Query.Start, obj.Query.End)
+}
+
+func (r *mutationResolver) TrackApp(ctx context.Context, bundle string, geo string, category string, period int, startAt *time.Time, developer *string, developerID *string, store *model.Store) (*model.App, error) {
+	app := entities.App{Bundle: bundle, Geo: geo, Category: category, Store: appStore(store)}
+	if startAt != nil {
+		app.StartAt = *startAt
+	}
+	if developer != nil {
+		app.Developer = *developer
+	}
+	if developerID != nil {
+		app.DeveloperId = *developerID
+	}
+	p, err := trackingPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	app.Period = p
+
+	return r.trackApp(ctx, app)
+}
+
+func (r *mutationResolver) UpdateTrackedApp(ctx context.Context, id int, category *string, period *int, startAt *time.Time) (*model.App, error) {
+	return r.updateTrackedApp(ctx, id, category, period, startAt)
+}
+
+func (r *mutationResolver) PauseTracking(ctx context.Context, id int, paused bool) (*model.App, error) {
+	status := entities.AppActive
+	if paused {
+		status = entities.AppPaused
+	}
+
+	return r.setTrackingStatus(ctx, id, status)
+}
+
+func (r *mutationResolver) StopTracking(ctx context.Context, id int) (*model.App, error) {
+	return r.setTrackingStatus(ctx, id, entities.AppStopped)
+}
+
+func (r *mutationResolver) CreateAlertRule(ctx context.Context, bundleID int, kind model.AlertKind, typeArg string, condition model.AlertCondition, threshold int, webhookURL string) (*model.AlertRule, error) {
+	return r.createAlertRule(ctx, entities.AlertRule{
+		AppId:      bundleID,
+		Kind:       alertKind(kind),
+		Type:       typeArg,
+		Condition:  alertCondition(condition),
+		Threshold:  threshold,
+		WebhookUrl: webhookURL,
+	})
+}
+
+func (r *mutationResolver) SetAlertRuleEnabled(ctx context.Context, id int, enabled bool) (*model.AlertRule, error) {
+	return r.setAlertRuleEnabled(ctx, id, enabled)
+}
+
+func (r *mutationResolver) DeleteAlertRule(ctx context.Context, id int) (bool, error) {
+	return r.deleteAlertRule(ctx, id)
+}
+
+func (r *mutationResolver) ReviewAnomaly(ctx context.Context, id int, status model.AnomalyStatus) (*model.Anomaly, error) {
+	return r.reviewAnomaly(ctx, id, status)
+}
+
+func (r *mutationResolver) SetReportSchedule(ctx context.Context, weekday int, hour int, webhookURL *string, enabled bool) (*model.ReportSchedule, error) {
+	return r.setReportSchedule(ctx, weekday, hour, webhookURL, enabled)
+}
+
+func (r *playMetaResolver) App(ctx context.Context, obj *model.PlayMeta) (*model.App, error) {
+	return r.app(ctx, obj.BundleID)
+}
+
+func (r *queryResolver) Meta(ctx context.Context, id int, last *int, start *scalar.FormattedDate, end *scalar.FormattedDate) ([]model.Meta, error) {
 	var (
 		dbo entities.DboSlice
 		err error
 	)
 	if start != nil && end != nil {
-		dbo, err = r.Tables.Meta.TimeRange(ctx, id, time.Time(*start), time.Time(*end))
+		dbo, err = r.loaders(ctx).Meta.Load(ctx, loaders.TrackKey{
+			BundleId: id,
+			Start:    time.Time(*start),
+			End:      time.Time(*end),
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -28,13 +228,13 @@ func (r *queryResolver) Meta(ctx context.Context, id int, last *int, start *scal
 			return nil, err
 		}
 	} else {
-		dbo, err = r.Tables.Meta.ByBundleId(ctx, id)
+		dbo, err = r.loaders(ctx).Meta.Load(ctx, loaders.TrackKey{BundleId: id})
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	metaModels := make([]*model.Meta, len(dbo))
+	metaModels := make([]model.Meta, len(dbo))
 	if err := dbo.To(metaModels); err != nil {
 		return nil, err
 	}
@@ -42,13 +242,18 @@ func (r *queryResolver) Meta(ctx context.Context, id int, last *int, start *scal
 	return metaModels, nil
 }
 
-func (r *queryResolver) Cats(ctx context.Context, id int, last *int, start *scalar.FormattedDate, end *scalar.FormattedDate) ([]*model.Categories, error) {
+func (r *queryResolver) Cats(ctx context.Context, id int, last *int, start *scalar.FormattedDate, end *scalar.FormattedDate, resolution *model.Resolution) ([]*model.Categories, error) {
 	var (
 		dbo entities.DboSlice
 		err error
 	)
 	if start != nil && end != nil {
-		dbo, err = r.Tables.Cat.TimeRange(ctx, id, time.Time(*start), time.Time(*end))
+		dbo, err = r.loaders(ctx).Cat.Load(ctx, loaders.TrackKey{
+			BundleId:   id,
+			Start:      time.Time(*start),
+			End:        time.Time(*end),
+			Resolution: resolutionOf(resolution),
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -58,7 +263,7 @@ func (r *queryResolver) Cats(ctx context.Context, id int, last *int, start *scal
 			return nil, err
 		}
 	} else {
-		dbo, err = r.Tables.Cat.ByBundleId(ctx, id)
+		dbo, err = r.loaders(ctx).Cat.Load(ctx, loaders.TrackKey{BundleId: id, Resolution: resolutionOf(resolution)})
 		if err != nil {
 			return nil, err
 		}
@@ -72,13 +277,18 @@ func (r *queryResolver) Cats(ctx context.Context, id int, last *int, start *scal
 	return metaModels, nil
 }
 
-func (r *queryResolver) Keys(ctx context.Context, id int, last *int, start *scalar.FormattedDate, end *scalar.FormattedDate) ([]*model.Keywords, error) {
+func (r *queryResolver) Keys(ctx context.Context, id int, last *int, start *scalar.FormattedDate, end *scalar.FormattedDate, resolution *model.Resolution) ([]*model.Keywords, error) {
 	var (
 		dbo entities.DboSlice
 		err error
 	)
 	if start != nil && end != nil {
-		dbo, err = r.Tables.Keys.TimeRange(ctx, id, time.Time(*start), time.Time(*end))
+		dbo, err = r.loaders(ctx).Keys.Load(ctx, loaders.TrackKey{
+			BundleId:   id,
+			Start:      time.Time(*start),
+			End:        time.Time(*end),
+			Resolution: resolutionOf(resolution),
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -88,7 +298,7 @@ func (r *queryResolver) Keys(ctx context.Context, id int, last *int, start *scal
 			return nil, err
 		}
 	} else {
-		dbo, err = r.Tables.Keys.ByBundleId(ctx, id)
+		dbo, err = r.loaders(ctx).Keys.Load(ctx, loaders.TrackKey{BundleId: id, Resolution: resolutionOf(resolution)})
 		if err != nil {
 			return nil, err
 		}
@@ -102,7 +312,306 @@ func (r *queryResolver) Keys(ctx context.Context, id int, last *int, start *scal
 	return metaModels, nil
 }
 
+func (r *queryResolver) Apps(ctx context.Context, filter *model.AppFilter, orderBy *model.AppOrder, first *int, after *string) (*model.AppConnection, error) {
+	query := entities.AppQuery{
+		Filter: appFilter(filter),
+		Order:  appOrder(orderBy),
+		Limit:  defaultPageSize,
+	}
+	if first != nil {
+		query.Limit = *first
+	}
+	if query.Limit < 0 || query.Limit > maxPageSize {
+		return nil, apperrors.New(apperrors.BadRequest, fmt.Sprintf("page size should be from 0 to %d", maxPageSize))
+	}
+	if after != nil {
+		cursor, err := DecodeAppCursor(*after, query.Order)
+		if err != nil {
+			return nil, err
+		}
+		query.After = cursor
+	}
+
+	conn := &model.AppConnection{
+		Edges:    []*model.AppEdge{},
+		PageInfo: &model.PageInfo{HasPreviousPage: after != nil},
+		Filter:   filter,
+	}
+	if query.Limit == 0 {
+		return conn, nil
+	}
+
+	// One more app shows if there is next page
+	limit := query.Limit
+	query.Limit++
+	dbo, err := r.Tables.App.Search(ctx, query)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, err
+	}
+	if len(dbo) > limit {
+		dbo = dbo[:limit]
+		conn.PageInfo.HasNextPage = true
+	}
+
+	for _, v := range dbo {
+		var app entities.App
+		if err := v.To(&app); err != nil {
+			return nil, err
+		}
+		node := &model.App{}
+		if err := app.To(node); err != nil {
+			return nil, err
+		}
+		conn.Edges = append(conn.Edges, &model.AppEdge{
+			Cursor: EncodeAppCursor(app, query.Order),
+			Node:   node,
+		})
+	}
+	if len(conn.Edges) > 0 {
+		conn.PageInfo.StartCursor = &conn.Edges[0].Cursor
+		conn.PageInfo.EndCursor = &conn.Edges[len(conn.Edges)-1].Cursor
+	}
+
+	return conn, nil
+}
+
+func (r *queryResolver) App(ctx context.Context, id int) (*model.App, error) {
+	app, err := r.app(ctx, id)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+
+	return app, err
+}
+
+func (r *queryResolver) AppByBundle(ctx context.Context, bundle string, geo string, store *model.Store) (*model.App, error) {
+	app, err := r.Tables.App.ByBundle(ctx, appStore(store), bundle, geo)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := &model.App{}
+	if err := app.To(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *queryResolver) KeywordRankStats(ctx context.Context, bundleID int, keyword string, rangeArg *model.DateRange, bucket model.Bucket) ([]*model.RankStats, error) {
+	return rankStats(ctx, r.Tables.Keys, bundleID, keyword, rangeArg, bucket)
+}
+
+func (r *queryResolver) CategoryRankStats(ctx context.Context, bundleID int, category *string, rangeArg *model.DateRange, bucket model.Bucket) ([]*model.RankStats, error) {
+	var typ string
+	if category != nil {
+		typ = *category
+	}
+
+	return rankStats(ctx, r.Tables.Cat, bundleID, typ, rangeArg, bucket)
+}
+
+func (r *queryResolver) Compare(ctx context.Context, bundleIds []int, keyword *string, category *string, rangeArg *model.DateRange, bucket model.Bucket) (*model.Comparison, error) {
+	return r.compare(ctx, bundleIds, keyword, category, rangeArg, bucket)
+}
+
+func (r *queryResolver) MetaChanges(ctx context.Context, bundleID int, rangeArg *model.DateRange, fields []model.MetaField) ([]*model.MetaChange, error) {
+	return r.metaChanges(ctx, bundleID, rangeArg, fields)
+}
+
+func (r *queryResolver) MetaConnection(ctx context.Context, id int, first *int, after *string, last *int, before *string, start *scalar.FormattedDate, end *scalar.FormattedDate) (*model.MetaConnection, error) {
+	page, err := newPage(first, after, last, before, start, end)
+	if err != nil {
+		return nil, err
+	}
+	dbo, cursors, info, err := loadPage(ctx, r.Tables.Meta, id, page)
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]*model.MetaEdge, len(dbo))
+	for i, v := range dbo {
+		var node model.Meta
+		if err := v.To(&node); err != nil {
+			return nil, err
+		}
+		edges[i] = &model.MetaEdge{Cursor: cursors[i], Node: node}
+	}
+
+	return &model.MetaConnection{
+		Edges:    edges,
+		PageInfo: info,
+		Query: model.ConnectionQuery{
+			BundleID: id,
+			Start:    page.Start,
+			End:      page.End,
+		},
+	}, nil
+}
+
+func (r *queryResolver) CatsConnection(ctx context.Context, id int, first *int, after *string, last *int, before *string, start *scalar.FormattedDate, end *scalar.FormattedDate) (*model.CategoriesConnection, error) {
+	page, err := newPage(first, after, last, before, start, end)
+	if err != nil {
+		return nil, err
+	}
+	dbo, cursors, info, err := loadPage(ctx, r.Tables.Cat, id, page)
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]*model.CategoriesEdge, len(dbo))
+	for i, v := range dbo {
+		node := &model.Categories{}
+		if err := v.To(node); err != nil {
+			return nil, err
+		}
+		edges[i] = &model.CategoriesEdge{Cursor: cursors[i], Node: node}
+	}
+
+	return &model.CategoriesConnection{
+		Edges:    edges,
+		PageInfo: info,
+		Query: model.ConnectionQuery{
+			BundleID: id,
+			Start:    page.Start,
+			End:      page.End,
+		},
+	}, nil
+}
+
+func (r *queryResolver) KeysConnection(ctx context.Context, id int, first *int, after *string, last *int, before *string, start *scalar.FormattedDate, end *scalar.FormattedDate) (*model.KeywordsConnection, error) {
+	page, err := newPage(first, after, last, before, start, end)
+	if err != nil {
+		return nil, err
+	}
+	dbo, cursors, info, err := loadPage(ctx, r.Tables.Keys, id, page)
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]*model.KeywordsEdge, len(dbo))
+	for i, v := range dbo {
+		node := &model.Keywords{}
+		if err := v.To(node); err != nil {
+			return nil, err
+		}
+		edges[i] = &model.KeywordsEdge{Cursor: cursors[i], Node: node}
+	}
+
+	return &model.KeywordsConnection{
+		Edges:    edges,
+		PageInfo: info,
+		Query: model.ConnectionQuery{
+			BundleID: id,
+			Start:    page.Start,
+			End:      page.End,
+		},
+	}, nil
+}
+
+func (r *queryResolver) Anomalies(ctx context.Context, bundleID int, rangeArg *model.DateRange) ([]*model.Anomaly, error) {
+	return r.anomalies(ctx, bundleID, rangeArg)
+}
+
+func (r *queryResolver) AlertRules(ctx context.Context, bundleID *int) ([]*model.AlertRule, error) {
+	return r.alertRules(ctx, bundleID)
+}
+
+func (r *queryResolver) ReportSchedule(ctx context.Context) (*model.ReportSchedule, error) {
+	return r.reportSchedule(ctx)
+}
+
+func (r *queryResolver) Reports(ctx context.Context, last *int) ([]*model.Report, error) {
+	return r.reports(ctx, last)
+}
+
+func (r *subscriptionResolver) TrackingUpdated(ctx context.Context, bundleIds []int, kinds []model.TrackingKind) (<-chan *model.TrackingUpdate, error) {
+	return r.trackingUpdated(ctx, bundleIds, kinds)
+}
+
+func (r *trackingUpdateResolver) App(ctx context.Context, obj *model.TrackingUpdate) (*model.App, error) {
+	app, err := r.app(ctx, obj.BundleID)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+
+	return app, err
+}
+
+// AlertRule returns generated.AlertRuleResolver implementation.
+func (r *Resolver) AlertRule() generated.AlertRuleResolver { return &alertRuleResolver{r} }
+
+// Anomaly returns generated.AnomalyResolver implementation.
+func (r *Resolver) Anomaly() generated.AnomalyResolver { return &anomalyResolver{r} }
+
+// App returns generated.AppResolver implementation.
+func (r *Resolver) App() generated.AppResolver { return &appResolver{r} }
+
+// AppConnection returns generated.AppConnectionResolver implementation.
+func (r *Resolver) AppConnection() generated.AppConnectionResolver { return &appConnectionResolver{r} }
+
+// AppStoreMeta returns generated.AppStoreMetaResolver implementation.
+func (r *Resolver) AppStoreMeta() generated.AppStoreMetaResolver { return &appStoreMetaResolver{r} }
+
+// Categories returns generated.CategoriesResolver implementation.
+func (r *Resolver) Categories() generated.CategoriesResolver { return &categoriesResolver{r} }
+
+// CategoriesConnection returns generated.CategoriesConnectionResolver implementation.
+func (r *Resolver) CategoriesConnection() generated.CategoriesConnectionResolver {
+	return &categoriesConnectionResolver{r}
+}
+
+// ComparisonSeries returns generated.ComparisonSeriesResolver implementation.
+func (r *Resolver) ComparisonSeries() generated.ComparisonSeriesResolver {
+	return &comparisonSeriesResolver{r}
+}
+
+// Keywords returns generated.KeywordsResolver implementation.
+func (r *Resolver) Keywords() generated.KeywordsResolver { return &keywordsResolver{r} }
+
+// KeywordsConnection returns generated.KeywordsConnectionResolver implementation.
+func (r *Resolver) KeywordsConnection() generated.KeywordsConnectionResolver {
+	return &keywordsConnectionResolver{r}
+}
+
+// MetaConnection returns generated.MetaConnectionResolver implementation.
+func (r *Resolver) MetaConnection() generated.MetaConnectionResolver {
+	return &metaConnectionResolver{r}
+}
+
+// Mutation returns generated.MutationResolver implementation.
+func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
+
+// PlayMeta returns generated.PlayMetaResolver implementation.
+func (r *Resolver) PlayMeta() generated.PlayMetaResolver { return &playMetaResolver{r} }
+
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+// Subscription returns generated.SubscriptionResolver implementation.
+func (r *Resolver) Subscription() generated.SubscriptionResolver { return &subscriptionResolver{r} }
+
+// TrackingUpdate returns generated.TrackingUpdateResolver implementation.
+func (r *Resolver) TrackingUpdate() generated.TrackingUpdateResolver {
+	return &trackingUpdateResolver{r}
+}
+
+type alertRuleResolver struct{ *Resolver }
+type anomalyResolver struct{ *Resolver }
+type appResolver struct{ *Resolver }
+type appConnectionResolver struct{ *Resolver }
+type appStoreMetaResolver struct{ *Resolver }
+type categoriesResolver struct{ *Resolver }
+type categoriesConnectionResolver struct{ *Resolver }
+type comparisonSeriesResolver struct{ *Resolver }
+type keywordsResolver struct{ *Resolver }
+type keywordsConnectionResolver struct{ *Resolver }
+type metaConnectionResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
+type playMetaResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type trackingUpdateResolver struct{ *Resolver }
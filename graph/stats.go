@@ -0,0 +1,30 @@
+package graph
+
+import (
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"strings"
+)
+
+// rankStats aggregates places of keyword or category in repository. Empty
+// typ means all types of bundle
+func rankStats(ctx context.Context, repo tracking.TrackRepository, bundleId int, typ string, rng *model.DateRange, bucket model.Bucket) ([]*model.RankStats, error) {
+	start, end := dateRange(rng)
+	dbo, err := repo.RankStats(ctx, bundleId, typ, entities.Bucket(strings.ToLower(string(bucket))), start, end)
+	if err == pgx.ErrNoRows {
+		return []*model.RankStats{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]*model.RankStats, len(dbo))
+	if err := dbo.To(stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/graph/model"
+	"Muromachi/store/tracking/events"
+	"context"
+	"strings"
+)
+
+// trackingUpdated subscribes on events of given apps. Subscription is
+// closed when client stops operation or connection is closed
+func (r *Resolver) trackingUpdated(ctx context.Context, bundleIds []int, kinds []model.TrackingKind) (<-chan *model.TrackingUpdate, error) {
+	if r.Events == nil {
+		return nil, apperrors.New(apperrors.Internal, "tracking events are not configured")
+	}
+	if len(bundleIds) == 0 {
+		return nil, apperrors.New(apperrors.BadRequest, "bundle ids should not be empty")
+	}
+
+	filter := events.Filter{BundleIds: bundleIds}
+	for _, kind := range kinds {
+		filter.Kinds = append(filter.Kinds, events.Kind(strings.ToLower(string(kind))))
+	}
+	sub := r.Events.Subscribe(filter)
+
+	updates := make(chan *model.TrackingUpdate)
+	go func() {
+		defer close(updates)
+		defer sub.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				update := &model.TrackingUpdate{
+					Kind:     model.TrackingKind(strings.ToUpper(string(event.Kind))),
+					BundleID: event.BundleId,
+					ID:       event.Id,
+					Date:     event.Date,
+				}
+				select {
+				case updates <- update:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return updates, nil
+}
@@ -1,20 +1,89 @@
 package httpresp
 
 import (
-	"fmt"
+	"Muromachi/apperrors"
+	"errors"
 	"github.com/gofiber/fiber/v2"
 )
 
-// Push error to context for response
-func Error(ctx *fiber.Ctx, status int, resp interface{}) error {
-	ctx.Status(status)
-	switch v := resp.(type) {
-	case error, string:
-		return ctx.JSON(map[string]interface{}{
-			"error": fmt.Sprintf("%v", v),
-			"status": status,
-		})
-	default:
-		return ctx.JSON(resp)
+// Content type of error responses
+const ProblemContentType = "application/problem+json"
+
+// Error response according to RFC 7807 (problem details for http apis)
+type Problem struct {
+	// Uri which identifies the problem type
+	Type string `json:"type"`
+	// Short summary of problem type
+	Title string `json:"title"`
+	// Http status code
+	Status int `json:"status"`
+	// Explanation of this occurrence of problem
+	Detail string `json:"detail,omitempty"`
+	// Path of request
+	Instance string `json:"instance,omitempty"`
+	// Stable machine-readable code of problem
+	Code apperrors.Code `json:"code"`
+}
+
+// NewProblem converts error to problem details
+func NewProblem(err error, instance string) Problem {
+	e := apperrors.From(err)
+	detail := e.Error()
+	// Details of unexpected errors may contain internals of service
+	if e.Code == apperrors.Internal {
+		detail = ""
+	}
+
+	return Problem{
+		Type:     "urn:problem:" + string(e.Code),
+		Title:    e.Code.Title(),
+		Status:   e.Code.Status(),
+		Detail:   detail,
+		Instance: instance,
+		Code:     e.Code,
+	}
+}
+
+// Push error to context for response as application/problem+json
+func Error(ctx *fiber.Ctx, err error) error {
+	problem := NewProblem(err, ctx.Path())
+	ctx.Status(problem.Status)
+	if err = ctx.JSON(problem); err != nil {
+		return err
+	}
+	ctx.Set(fiber.HeaderContentType, ProblemContentType)
+
+	return nil
+}
+
+// Error handler for fiber app. Converts errors returned from handlers
+// (including *fiber.Error) to problem details
+func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		err = apperrors.New(codeOfStatus(fe.Code), fe.Message)
 	}
+
+	return Error(ctx, err)
+}
+
+// codeOfStatus returns code for http status of fiber errors
+func codeOfStatus(status int) apperrors.Code {
+	switch status {
+	case fiber.StatusNotFound:
+		return apperrors.NotFound
+	case fiber.StatusTooManyRequests:
+		return apperrors.RateLimited
+	case fiber.StatusUnauthorized:
+		return apperrors.NotAuthenticated
+	case fiber.StatusForbidden:
+		return apperrors.Forbidden
+	case fiber.StatusConflict:
+		return apperrors.Conflict
+	}
+	if status >= 400 && status < 500 {
+		return apperrors.BadRequest
+	}
+
+	return apperrors.Internal
 }
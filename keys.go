@@ -0,0 +1,55 @@
+package main
+
+import (
+	"Muromachi/utils"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"time"
+)
+
+const keysUsage = "usage: keys rotate [--write]"
+
+// Line of config file with jwt salt
+var saltLine = regexp.MustCompile(`(?m)^(\s*jwt_salt:).*$`)
+
+// keysCommand generates new jwt salt. With --write flag the salt
+// is saved to config file with given path
+//
+// All access tokens signed with the previous salt become invalid after restart,
+// refresh sessions stay valid, so clients with sessions only need to refresh tokens
+func keysCommand(path string, args []string) error {
+	if len(args) == 0 || args[0] != "rotate" {
+		return fmt.Errorf("%s", keysUsage)
+	}
+	flags := flag.NewFlagSet("keys rotate", flag.ExitOnError)
+	write := flags.Bool("write", false, "save new salt to config file")
+	_ = flags.Parse(args[1:])
+
+	uuid, err := utils.UUID()
+	if err != nil {
+		return err
+	}
+	salt := utils.Hash(uuid, time.Now().UnixNano())
+
+	if !*write {
+		fmt.Println(salt)
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !saltLine.Match(data) {
+		return fmt.Errorf("jwt_salt not found in %s", path)
+	}
+	data = saltLine.ReplaceAll(data, []byte("${1} "+salt))
+	if err = ioutil.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("new jwt salt saved to %s, restart server to apply it\n", path)
+
+	return nil
+}
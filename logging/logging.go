@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level of log messages
+type Level int32
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var names = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < Debug || l > Error {
+		return fmt.Sprintf("level(%d)", l)
+	}
+	return names[l]
+}
+
+// Current level of logger. Messages with lower level are skipped
+var level = int32(Info)
+
+// ParseLevel converts name of level (debug, info, warn, error) to Level
+func ParseLevel(s string) (Level, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return Info, fmt.Errorf("unknown log level %q", s)
+}
+
+// SetLevel changes level of logger. Safe for concurrent use
+func SetLevel(l Level) {
+	atomic.StoreInt32(&level, int32(l))
+}
+
+// GetLevel returns current level of logger
+func GetLevel() Level {
+	return Level(atomic.LoadInt32(&level))
+}
+
+// Enabled reports whether messages with given level will be written
+func Enabled(l Level) bool {
+	return l >= GetLevel()
+}
+
+func logf(l Level, format string, args ...interface{}) {
+	if !Enabled(l) {
+		return
+	}
+	_ = log.Output(3, "["+strings.ToUpper(l.String())+"] "+fmt.Sprintf(format, args...))
+}
+
+// Debugf writes message with debug level
+func Debugf(format string, args ...interface{}) {
+	logf(Debug, format, args...)
+}
+
+// Infof writes message with info level
+func Infof(format string, args ...interface{}) {
+	logf(Info, format, args...)
+}
+
+// Warnf writes message with warn level
+func Warnf(format string, args ...interface{}) {
+	logf(Warn, format, args...)
+}
+
+// Errorf writes message with error level
+func Errorf(format string, args ...interface{}) {
+	logf(Error, format, args...)
+}
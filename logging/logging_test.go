@@ -0,0 +1,41 @@
+package logging_test
+
+import (
+	"Muromachi/logging"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestParseLevel_ShouldParseKnownLevels(t *testing.T) {
+	var tt = map[string]logging.Level{
+		"debug": logging.Debug,
+		"INFO":  logging.Info,
+		"Warn":  logging.Warn,
+		"error": logging.Error,
+	}
+	for name, expected := range tt {
+		l, err := logging.ParseLevel(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, l)
+	}
+
+	_, err := logging.ParseLevel("loud")
+	assert.Error(t, err)
+}
+
+func TestSetLevel_ShouldSkipMessagesWithLowerLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+	defer logging.SetLevel(logging.GetLevel())
+
+	logging.SetLevel(logging.Warn)
+	logging.Infof("hidden %d", 1)
+	logging.Errorf("visible %d", 2)
+
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "[ERROR] visible 2")
+}
@@ -2,15 +2,11 @@ package main
 
 import (
 	"Muromachi/config"
-	"Muromachi/server"
-	"log"
+	"flag"
+	"fmt"
 	"os"
-	"os/signal"
 )
 
-// Даталоадер, агрегация постоянных одинаковых запросов
-// https://gqlgen.com/reference/dataloaders/
-//
 // Показывать или нет ендпоинты https://gqlgen.com/reference/introspection/
 //
 // Сделать тулзу позволит лимитировать реквесты
@@ -20,29 +16,71 @@ import (
 // Check db connection before server started
 // Логирование всех запросов в бд
 
-const defaultPort = "8080"
+const (
+	defaultPort   = "8080"
+	defaultConfig = "./config/dev.yml"
+)
+
+const usage = `usage: muromachi [--config path] [--key value ...] <command> [args]
+
+Config values are loaded from defaults, then from config file, then from
+environment variables (MUROMACHI_DATABASE_PASSWORD, or MUROMACHI_DATABASE_PASSWORD_FILE
+to read the value from file) and then from flags named by config keys (--database.password).
+
+commands:
+  serve [--port port]                                 start http server (default command)
+  migrate up | down N | status                        manage database schema
+  client create --company name                        register new client
+  client list                                         print all clients
+  client disable <client id>                          disable client and revoke his sessions
+  client rotate-secret <client id>                    generate new client secret
+  sessions list --user id                             print refresh sessions of user
+  sessions revoke --user id | --token token           remove refresh sessions
+  sessions ban --user id | --token token [--ttl 24h]  add refresh sessions to blacklist
+  sessions unban --user id | --token token            remove refresh sessions from blacklist
+  keys rotate [--write]                               generate new jwt salt
+  config check                                        validate config file
+`
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	flags := flag.NewFlagSet("muromachi", flag.ExitOnError)
+	path := flags.String("config", defaultConfig, "path to config file")
+	config.RegisterFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
 	}
+	_ = flags.Parse(os.Args[1:])
 
-	cfg := config.New("./config/dev.yml")
-	cfg.Database.Schema = "./config/schema.sql"
-
-	serv := server.New(defaultPort, cfg)
+	command, args := "serve", flags.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
 
-	go func() {
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, os.Interrupt)
-		_ = <-c
-		_ = serv.Shutdown()
-	}()
+	cfg, err := config.Load(*path, flags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-	if err := serv.Listen(); err != nil {
-		log.Fatal(err)
+	switch command {
+	case "serve":
+		err = serveCommand(config.NewStore(cfg, *path, flags), args)
+	case "migrate":
+		err = migrateCommand(cfg, args)
+	case "client":
+		err = clientCommand(cfg, args)
+	case "sessions":
+		err = sessionsCommand(cfg, args)
+	case "keys":
+		err = keysCommand(*path, args)
+	case "config":
+		err = configCommand(cfg, args)
+	default:
+		err = fmt.Errorf("unknown command %q\n\n%s", command, usage)
 	}
 
-	log.Println("Shutdown")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
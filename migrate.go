@@ -0,0 +1,84 @@
+package main
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/connector"
+	"Muromachi/store/migrations"
+	"context"
+	"fmt"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down N | status"
+
+// migrateCommand runs migrate subcommand with given args
+//
+//	migrate up       apply all pending migrations
+//	migrate down N   revert last N applied migrations
+//	migrate status   print state of every known migration
+func migrateCommand(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	url, err := connector.ConnectionUrl(cfg.Database)
+	if err != nil {
+		return err
+	}
+	conn, err := connector.Connect(url)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	migrator, err := migrations.New(conn)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied  %06d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("nothing to apply")
+		}
+	case "down":
+		if len(args) < 2 {
+			return fmt.Errorf("%s", migrateUsage)
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("wrong number of migrations %q", args[1])
+		}
+		reverted, err := migrator.Down(ctx, n)
+		for _, m := range reverted {
+			fmt.Printf("reverted %06d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied at " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%06d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	default:
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	return nil
+}
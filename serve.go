@@ -0,0 +1,54 @@
+package main
+
+import (
+	"Muromachi/config"
+	"Muromachi/server"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// How often config file is checked for changes
+const configWatchInterval = time.Second * 5
+
+// serveCommand starts http server. Port is taken from --port flag, then
+// from PORT env and then defaultPort is used
+//
+// While server is running config is reloaded on SIGHUP or when config file is modified
+func serveCommand(store *config.Store, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	port := flags.String("port", os.Getenv("PORT"), "port for listening")
+	_ = flags.Parse(args)
+
+	if *port == "" {
+		*port = defaultPort
+	}
+	cfg := store.Get()
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	serv := server.New(*port, cfg)
+	store.Subscribe(serv.Reload)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.Watch(ctx, configWatchInterval)
+
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt)
+		_ = <-c
+		_ = serv.Shutdown()
+	}()
+
+	if err := serv.Listen(); err != nil {
+		return err
+	}
+
+	log.Println("Shutdown")
+	return nil
+}
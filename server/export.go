@@ -0,0 +1,129 @@
+package server
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/export"
+	"Muromachi/httpresp"
+	"Muromachi/logging"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"time"
+)
+
+// Parameters of export, they are the same with arguments of meta, cats and keys queries
+type exportParams struct {
+	id         int
+	last       int
+	start, end time.Time
+	format     export.Format
+}
+
+// Export endpoint of tracking history, kind is meta, cats or keys. Rows are streamed
+// from database straight to response, so history of any length needs constant memory
+func Export(tables *tracking.Tables) func(*fiber.Ctx) error {
+	return func(ctx *fiber.Ctx) error {
+		var (
+			source  tracking.Streamer
+			columns []string
+			row     func(entities.DBO) ([]interface{}, error)
+		)
+		kind := ctx.Params("kind")
+		switch kind {
+		case "meta":
+			source, columns, row = tables.Meta, export.MetaColumns, export.MetaRow
+		case "cats":
+			source, columns, row = tables.Cat, export.TrackColumns, export.TrackRow
+		case "keys":
+			source, columns, row = tables.Keys, export.TrackColumns, export.TrackRow
+		default:
+			return fiber.ErrNotFound
+		}
+
+		params, err := parseExportParams(ctx)
+		if err != nil {
+			return httpresp.Error(ctx, err)
+		}
+		if _, err := tables.App.ById(ctx.Context(), params.id); err != nil {
+			return httpresp.Error(ctx, err)
+		}
+
+		ctx.Set(fiber.HeaderContentType, params.format.ContentType())
+		ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-%d.%s"`, kind, params.id, params.format))
+		// Status and headers are sent before the first row, so errors of query are only logged
+		// and response is cut
+		ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			if err := writeExport(context.Background(), w, source, params, columns, row); err != nil {
+				logging.Errorf("export of %s of app %d: %v", kind, params.id, err)
+			}
+		})
+
+		return nil
+	}
+}
+
+// parseExportParams validates query of export. Range is used when both start and end
+// are given, otherwise last updates or the whole history are exported
+func parseExportParams(ctx *fiber.Ctx) (exportParams, error) {
+	var (
+		params exportParams
+		err    error
+	)
+	if params.format, err = export.ParseFormat(ctx.Query("format")); err != nil {
+		return params, apperrors.New(apperrors.BadRequest, err.Error())
+	}
+	if params.id, err = strconv.Atoi(ctx.Query("id")); err != nil || params.id <= 0 {
+		return params, apperrors.New(apperrors.BadRequest, "id of app should be positive integer")
+	}
+
+	var start, end time.Time
+	if value := ctx.Query("start"); value != "" {
+		if start, err = time.Parse("2006-01-02", value); err != nil {
+			return params, apperrors.New(apperrors.BadRequest, "start should be date like 2006-01-02")
+		}
+	}
+	if value := ctx.Query("end"); value != "" {
+		if end, err = time.Parse("2006-01-02", value); err != nil {
+			return params, apperrors.New(apperrors.BadRequest, "end should be date like 2006-01-02")
+		}
+	}
+	if value := ctx.Query("last"); value != "" {
+		if params.last, err = strconv.Atoi(value); err != nil || params.last <= 0 {
+			return params, apperrors.New(apperrors.BadRequest, "last should be positive integer")
+		}
+	}
+
+	if !start.IsZero() && !end.IsZero() {
+		params.start, params.end, params.last = start, end, 0
+	}
+
+	return params, nil
+}
+
+// writeExport streams rows of source to w in format of params
+func writeExport(ctx context.Context, w *bufio.Writer, source tracking.Streamer, params exportParams, columns []string, row func(entities.DBO) ([]interface{}, error)) error {
+	writer, err := export.New(params.format, w, columns)
+	if err != nil {
+		return err
+	}
+
+	err = source.Stream(ctx, params.id, params.last, params.start, params.end, func(dbo entities.DBO) error {
+		values, err := row(dbo)
+		if err != nil {
+			return err
+		}
+		return writer.Write(values)
+	})
+	if err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
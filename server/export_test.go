@@ -0,0 +1,77 @@
+package server_test
+
+import (
+	"Muromachi/httpresp"
+	"Muromachi/server"
+	"Muromachi/store/tracking"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExport_ShouldStreamRowsInRequestedFormat_Mock(t *testing.T) {
+	meta := &mockCountingRepo{}
+	app := fiber.New(fiber.Config{ErrorHandler: httpresp.ErrorHandler})
+	app.Get("/export/:kind", server.Export(&tracking.Tables{App: &mockCountingRepo{apps: true}, Meta: meta}))
+	request := func(url string) (*http.Response, string) {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp, err := app.Test(req, 1000*60)
+		assert.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return resp, string(body)
+	}
+
+	resp, body := request("/export/meta?id=1")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="meta-1.csv"`, resp.Header.Get("Content-Disposition"))
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "id,bundleId,bundle,geo,store,date,title"))
+	assert.True(t, strings.HasPrefix(lines[1], "1,1,com.bundle,"))
+
+	resp, body = request("/export/meta?id=1&format=ndjson&start=2021-01-01&end=2021-02-01")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	assert.Equal(t, 2, strings.Count(body, "\n"))
+	assert.True(t, strings.HasPrefix(body, `{"id":1,"bundleId":1,"bundle":"com.bundle"`))
+
+	resp, body = request("/export/meta?id=1&format=xlsx&last=5")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(body, "PK"))
+}
+
+func TestExport_ShouldValidateRequest_Mock(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: httpresp.ErrorHandler})
+	app.Get("/export/:kind", server.Export(&tracking.Tables{
+		App:  mockRepoError{err: pgx.ErrNoRows},
+		Meta: &mockCountingRepo{},
+	}))
+
+	var tt = []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{name: "unknown kind", url: "/export/apps?id=1", status: http.StatusNotFound},
+		{name: "without id", url: "/export/meta", status: http.StatusBadRequest},
+		{name: "unknown format", url: "/export/meta?id=1&format=xml", status: http.StatusBadRequest},
+		{name: "wrong date", url: "/export/cats?id=1&start=18.01.2021", status: http.StatusBadRequest},
+		{name: "negative last", url: "/export/keys?id=1&last=-1", status: http.StatusBadRequest},
+		{name: "unknown app", url: "/export/meta?id=100", status: http.StatusNotFound},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.url, nil)
+			resp, err := app.Test(req, 1000*60)
+			assert.NoError(t, err)
+			assert.Equal(t, test.status, resp.StatusCode)
+			assert.Equal(t, httpresp.ProblemContentType, resp.Header.Get("Content-Type"))
+		})
+	}
+}
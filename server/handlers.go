@@ -1,19 +1,37 @@
 package server
 
 import (
+	"Muromachi/apperrors"
 	"Muromachi/auth"
+	"Muromachi/graph"
 	"Muromachi/graph/generated"
+	"Muromachi/graph/loaders"
 	"Muromachi/httpresp"
 	"Muromachi/server/requests"
 	"Muromachi/store/entities"
+	"Muromachi/store/tracking/ingest"
 	"Muromachi/store/users"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/fasthttp/websocket"
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v4"
+	"github.com/valyala/fasthttp"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"io"
+	"io/ioutil"
 	"time"
 )
 
+// Wrong client id or client secret
+var ErrInvalidCredentials = apperrors.New(apperrors.InvalidCredentials, "client id or client secret is wrong")
+
 // Handler for graphql testground
 func Testground() func(ctx *fiber.Ctx) error {
 	play := playground.Handler("GraphQL playground", "/query")
@@ -25,19 +43,180 @@ func Testground() func(ctx *fiber.Ctx) error {
 }
 
 // Graphql handler
-func Graphql(resolver generated.ResolverRoot) func(ctx *fiber.Ctx) error {
+//
+// Every operation gets its own dataloaders, so the same apps and tracking rows
+// requested by several fields are selected once. Introspection queries are
+// allowed only while introspection func returns true
+func Graphql(resolver *graph.Resolver, introspection func() bool) func(ctx *fiber.Ctx) error {
 	srv := handler.NewDefaultServer(
 		generated.NewExecutableSchema(
 			generated.Config{Resolvers: resolver},
 		),
-	).Handler()
+	)
+	srv.AroundOperations(func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		if !introspection() {
+			graphql.GetOperationContext(ctx).DisableIntrospection = true
+		}
+		return next(loaders.WithLoaders(ctx, loaders.New(resolver.Tables)))
+	})
+	srv.SetErrorPresenter(presentError)
+	handle := srv.Handler()
+
+	return func(ctx *fiber.Ctx) error {
+		handle(ctx.Context())
+		return nil
+	}
+}
+
+// Graphql subscriptions handler, serves graphql-ws protocol
+//
+// Browsers can't set headers of websocket handshake, so access token is taken from
+// Authorization value of connection init payload like {"Authorization": "Bearer {token}"}
+func Subscriptions(resolver *graph.Resolver, security auth.Defender) func(ctx *fiber.Ctx) error {
+	srv := handler.New(
+		generated.NewExecutableSchema(
+			generated.Config{Resolvers: resolver},
+		),
+	)
+	srv.AddTransport(transport.Websocket{
+		InitFunc: func(ctx *fasthttp.RequestCtx, payload transport.InitPayload) (*fasthttp.RequestCtx, error) {
+			token, err := auth.BearerToken(payload.Authorization())
+			if err != nil {
+				return nil, err
+			}
+			claims, err := auth.Authenticate(ctx, security, token)
+			if err != nil {
+				return nil, err
+			}
+			ctx.SetUserValue("request_user", claims.UserClaims)
+
+			return ctx, nil
+		},
+		KeepAlivePingInterval: 10 * time.Second,
+	})
+	srv.AroundOperations(func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		return next(loaders.WithLoaders(ctx, loaders.New(resolver.Tables)))
+	})
+	srv.SetErrorPresenter(presentError)
+	handle := srv.Handler()
 
 	return func(ctx *fiber.Ctx) error {
-		srv(ctx.Context())
+		if !websocket.FastHTTPIsWebSocketUpgrade(ctx.Context()) {
+			return httpresp.Error(ctx, apperrors.New(apperrors.BadRequest, "websocket upgrade expected"))
+		}
+		handle(ctx.Context())
 		return nil
 	}
 }
 
+// presentError adds stable code and http status of error to extensions
+// of graphql error. Messages of unexpected errors are hidden from clients
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	// Errors of parsing and validation are created by gqlgen itself
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) && gqlErr.Unwrap() == nil {
+		appErr = apperrors.New(apperrors.BadRequest, gqlErr.Message)
+	} else {
+		appErr = apperrors.From(err)
+	}
+
+	if appErr.Code == apperrors.Internal {
+		gqlErr.Message = appErr.Code.Title()
+	} else {
+		gqlErr.Message = appErr.Error()
+	}
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = make(map[string]interface{})
+	}
+	gqlErr.Extensions["code"] = appErr.Code
+	gqlErr.Extensions["status"] = appErr.Code.Status()
+
+	return gqlErr
+}
+
+// Ingestion endpoint, body is NDJSON with one observation per line. Body is read
+// from stream by batches, so it is not kept in memory and is limited by given size
+func Ingest(ingester *ingest.Ingester, limit int) func(*fiber.Ctx) error {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Request().Header.ContentLength() > limit {
+			return rejectBody(ctx, bodyTooLarge(limit))
+		}
+		claims, err := auth.ClaimsFromContext(ctx.Context())
+		if err != nil {
+			return httpresp.Error(ctx, err)
+		}
+		body := ctx.Context().RequestBodyStream()
+		if body == nil {
+			body = bytes.NewReader(ctx.Body())
+		}
+
+		report, err := ingester.Ingest(ctx.Context(), int(claims.ID), &limitedReader{r: body, limit: limit})
+		if err != nil {
+			return rejectBody(ctx, err)
+		}
+
+		return ctx.JSON(report)
+	}
+}
+
+// Middleware which rejects bodies longer than limit. Server streams bodies, so bodies
+// of all routes except streamed ones are read here before handlers
+func LimitBody(limit int, streamed ...string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		for _, path := range streamed {
+			if ctx.Path() == path {
+				return ctx.Next()
+			}
+		}
+		if ctx.Request().Header.ContentLength() > limit {
+			return rejectBody(ctx, bodyTooLarge(limit))
+		}
+
+		if stream := ctx.Context().RequestBodyStream(); stream != nil {
+			body, err := ioutil.ReadAll(io.LimitReader(stream, int64(limit)+1))
+			if err != nil {
+				return rejectBody(ctx, apperrors.Wrap(apperrors.BadRequest, err))
+			}
+			if len(body) > limit {
+				return rejectBody(ctx, bodyTooLarge(limit))
+			}
+			ctx.Request().SetBodyRaw(body)
+		}
+
+		return ctx.Next()
+	}
+}
+
+// Reader which fails when more than n bytes are read. Chunked bodies have no length,
+// so their size is checked while they are read
+type limitedReader struct {
+	r     io.Reader
+	read  int
+	limit int
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += n
+	if l.read > l.limit {
+		return n, bodyTooLarge(l.limit)
+	}
+	return n, err
+}
+
+// rejectBody responds with error and closes connection. Rest of body is not read,
+// so it can't be reused for next request
+func rejectBody(ctx *fiber.Ctx, err error) error {
+	ctx.Context().SetConnectionClose()
+	return httpresp.Error(ctx, err)
+}
+
+// bodyTooLarge returns error of body longer than limit
+func bodyTooLarge(limit int) error {
+	return apperrors.New(apperrors.BadRequest, fmt.Sprintf("body should not be longer than %d bytes", limit))
+}
+
 // Auth endpoint
 func Authorize(sec auth.Defender, sessions *users.Tables) func(*fiber.Ctx) error {
 	return func(ctx *fiber.Ctx) error {
@@ -46,18 +225,21 @@ func Authorize(sec auth.Defender, sessions *users.Tables) func(*fiber.Ctx) error
 		)
 		// parse body as JWTRequest
 		if err := ctx.BodyParser(&request); err != nil {
-			return httpresp.Error(ctx, 400, err.Error())
+			return httpresp.Error(ctx, apperrors.Wrap(apperrors.BadRequest, err))
 		}
 		if request.ClientId == "" || request.ClientSecret == "" {
-			return httpresp.Error(ctx, 400, fmt.Errorf("%s", "client id or client secret not provided"))
+			return httpresp.Error(ctx, apperrors.New(apperrors.BadRequest, "client id or client secret not provided"))
 		}
 		// CheckAndDel if user with this client id and secret exists
 		user, err := sessions.Users.Approve(ctx.Context(), request.ClientId)
+		if err == pgx.ErrNoRows {
+			return httpresp.Error(ctx, ErrInvalidCredentials)
+		}
 		if err != nil {
-			return httpresp.Error(ctx, 403, err.Error())
+			return httpresp.Error(ctx, err)
 		}
 		if err = user.CompareSecret(request.ClientSecret); err != nil {
-			return httpresp.Error(ctx, 401, auth.ErrNotAuthenticated)
+			return httpresp.Error(ctx, ErrInvalidCredentials)
 		}
 		// Pass user to request context
 		ctx.Locals("request_user", &auth.UserClaims{
@@ -74,21 +256,21 @@ func Authorize(sec auth.Defender, sessions *users.Tables) func(*fiber.Ctx) error
 		case "session":
 			refreshToken, err = sec.StartSession(ctx)
 			if err != nil {
-				return httpresp.Error(ctx, 400, err)
+				return httpresp.Error(ctx, err)
 			}
 		case "refresh_token":
 			refreshToken, err = sec.StartSession(ctx, request.RefreshToken)
 			if err != nil {
-				return httpresp.Error(ctx, 400, err)
+				return httpresp.Error(ctx, err)
 			}
 		default:
-			return httpresp.Error(ctx, 400, "need to provide access type for request")
+			return httpresp.Error(ctx, apperrors.New(apperrors.BadRequest, "need to provide access type for request"))
 		}
 
 		// Create jwt object
 		accesstoken, err := sec.SignAccessToken(ctx, refreshToken)
 		if err != nil {
-			return httpresp.Error(ctx, 500, err.Error())
+			return httpresp.Error(ctx, err)
 		}
 
 		// return json depending of the type of Access type
@@ -102,7 +284,7 @@ func NewCompany(sessions *users.Tables) func(*fiber.Ctx) error {
 		var err error
 		company := ctx.Query("c")
 		if company == "" {
-			return httpresp.Error(ctx, 400, "empty company query")
+			return httpresp.Error(ctx, apperrors.New(apperrors.BadRequest, "empty company query"))
 		}
 
 		user := entities.User{
@@ -110,11 +292,11 @@ func NewCompany(sessions *users.Tables) func(*fiber.Ctx) error {
 		}
 		err = user.GenerateSecrets()
 		if err != nil {
-			return httpresp.Error(ctx, 500, "can not generate secrets")
+			return httpresp.Error(ctx, apperrors.Wrap(apperrors.Internal, err))
 		}
 		user, err = sessions.Users.Create(ctx.Context(), user)
 		if err != nil {
-			return httpresp.Error(ctx, 500, err)
+			return httpresp.Error(ctx, err)
 		}
 
 		return ctx.JSON(user)
@@ -129,7 +311,7 @@ func Ban(collection *users.Tables) func(*fiber.Ctx) error {
 			forBan []entities.Session
 		)
 		if err := ctx.BodyParser(&list); err != nil {
-			return httpresp.Error(ctx, 400, "can not parse to token list")
+			return httpresp.Error(ctx, apperrors.New(apperrors.BadRequest, "can not parse to token list"))
 		}
 
 		// If user id is presented
@@ -144,7 +326,7 @@ func Ban(collection *users.Tables) func(*fiber.Ctx) error {
 		for _, token := range list.Tokens {
 			session, err := collection.Sessions.Get(ctx.Context(), token)
 			if err != nil {
-				return httpresp.Error(ctx, 400, err.Error())
+				return httpresp.Error(ctx, err)
 			}
 			forBan = append(forBan, session)
 		}
@@ -157,7 +339,7 @@ func Ban(collection *users.Tables) func(*fiber.Ctx) error {
 				s.ID,
 				time.Duration(list.Ttl),
 			); err != nil {
-				return httpresp.Error(ctx, 400, err.Error())
+				return httpresp.Error(ctx, err)
 			}
 		}
 
@@ -178,7 +360,7 @@ func Unban(collection *users.Tables) func(*fiber.Ctx) error {
 			antiBan []string
 		)
 		if err := ctx.BodyParser(&list); err != nil {
-			return httpresp.Error(ctx, 400, "can not parse to token list")
+			return httpresp.Error(ctx, apperrors.New(apperrors.BadRequest, "can not parse to token list"))
 		}
 
 		// if user id is presented
@@ -197,7 +379,7 @@ func Unban(collection *users.Tables) func(*fiber.Ctx) error {
 		if len(antiBan) > 0 {
 			n, err := collection.Sessions.Del(ctx.Context(), antiBan...)
 			if err != nil || int(n) != len(antiBan) {
-				return httpresp.Error(ctx, 500, "unexpected error while deletion")
+				return httpresp.Error(ctx, apperrors.New(apperrors.Internal, "unexpected error while deletion"))
 			}
 		}
 
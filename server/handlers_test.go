@@ -3,10 +3,12 @@ package server_test
 import (
 	"Muromachi/auth"
 	"Muromachi/config"
+	"Muromachi/graph"
 	"Muromachi/server"
 	"Muromachi/server/requests"
 	"Muromachi/store/entities"
 	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking"
 	"Muromachi/store/users"
 	"Muromachi/store/users/sessions"
 	"Muromachi/store/users/sessions/blacklist"
@@ -17,12 +19,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -75,7 +79,7 @@ func TestAuthorize_Mock(t *testing.T) {
 			expectedCode: 200,
 		},
 		{
-			name:          "request with wrong user client id, should return 404 error",
+			name:          "request with wrong user client id, should return 401 error",
 			withJson:      true,
 			withUrlValues: false,
 			request: auth.JWTRequest{
@@ -83,7 +87,7 @@ func TestAuthorize_Mock(t *testing.T) {
 				ClientId:     "1234",
 				ClientSecret: "123",
 			},
-			expectedCode: 403,
+			expectedCode: 401,
 		},
 		{
 			name:          "request with wrong user client secret, should return 401 error",
@@ -192,8 +196,6 @@ func TestAuthorize_WithWrongRequestDataType(t *testing.T) {
 func TestAuthorize(t *testing.T) {
 	// Load config from file
 	cfg := config.New("../config/dev.yml")
-	// Change path to database schema
-	cfg.Database.Schema = "../config/schema.sql"
 	// Conn to real db
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users", "refresh_sessions")
@@ -282,7 +284,7 @@ func TestAuthorize(t *testing.T) {
 				ClientId:     clientId + "123123123",
 				ClientSecret: clientSecret,
 			},
-			expectedCode: 403,
+			expectedCode: 401,
 		},
 		{
 			name: "should return error if client secret not valid",
@@ -439,8 +441,6 @@ func TestBan_Mock(t *testing.T) {
 func TestBan(t *testing.T) {
 	// Load config
 	cfg := config.New("../config/dev.yml")
-	// Change path to database schema
-	cfg.Database.Schema = "../config/schema.sql"
 	// Conn to real db
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users", "refresh_sessions")
@@ -673,8 +673,6 @@ func TestUnban_Mock(t *testing.T) {
 func TestUnban(t *testing.T) {
 	// Load config
 	cfg := config.New("../config/dev.yml")
-	// Change path to database schema
-	cfg.Database.Schema = "../config/schema.sql"
 	// Conn to real db
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users", "refresh_sessions")
@@ -818,3 +816,127 @@ func TestUnban(t *testing.T) {
 		})
 	}
 }
+
+func TestGraphql_ShouldDisableIntrospectionWithToggle_Mock(t *testing.T) {
+	enabled := true
+	app := fiber.New()
+	app.Post("/query", server.Graphql(&graph.Resolver{
+		Tables: tracking.NewTrackingTables(nil),
+	}, func() bool {
+		return enabled
+	}))
+
+	query := func() map[string]interface{} {
+		req, _ := http.NewRequest("POST", "/query", strings.NewReader(`{"query":"{ __schema { queryType { name } } }"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, 1000*60)
+		assert.NoError(t, err)
+
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	body := query()
+	assert.Nil(t, body["errors"])
+	assert.NotNil(t, body["data"])
+
+	enabled = false
+	body = query()
+	assert.NotNil(t, body["errors"])
+}
+
+func TestGraphql_ShouldPresentErrorsWithCode_Mock(t *testing.T) {
+	var tt = []struct {
+		name            string
+		query           string
+		err             error
+		expectedCode    string
+		expectedStatus  float64
+		expectedMessage string
+	}{
+		{
+			name:            "not found rows",
+			query:           `{"query":"{ meta(id: 1, last: 1) { id } }"}`,
+			err:             pgx.ErrNoRows,
+			expectedCode:    "resource.not_found",
+			expectedStatus:  404,
+			expectedMessage: "requested resource not found",
+		},
+		{
+			name:            "unexpected error should hide message",
+			query:           `{"query":"{ meta(id: 1, last: 1) { id } }"}`,
+			err:             fmt.Errorf("connection refused to 10.0.0.1"),
+			expectedCode:    "internal",
+			expectedStatus:  500,
+			expectedMessage: "Internal error",
+		},
+		{
+			name:           "query validation error",
+			query:          `{"query":"{ meta(id: 1, last: 1) { unknownField } }"}`,
+			expectedCode:   "request.invalid",
+			expectedStatus: 400,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			repo := mockRepoError{err: test.err}
+			app := fiber.New()
+			app.Post("/query", server.Graphql(&graph.Resolver{
+				Tables: &tracking.Tables{App: repo, Meta: repo, Cat: repo, Keys: repo},
+			}, func() bool {
+				return true
+			}))
+
+			req, _ := http.NewRequest("POST", "/query", strings.NewReader(test.query))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req, 1000*60)
+			assert.NoError(t, err)
+
+			var body struct {
+				Errors []struct {
+					Message    string                 `json:"message"`
+					Extensions map[string]interface{} `json:"extensions"`
+				} `json:"errors"`
+			}
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			if assert.Len(t, body.Errors, 1) {
+				assert.Equal(t, test.expectedCode, body.Errors[0].Extensions["code"])
+				assert.Equal(t, test.expectedStatus, body.Errors[0].Extensions["status"])
+				if test.expectedMessage != "" {
+					assert.Equal(t, test.expectedMessage, body.Errors[0].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestGraphql_ShouldLoadSameAppAndRowsOnce_Mock(t *testing.T) {
+	apps, meta := &mockCountingRepo{apps: true}, &mockCountingRepo{}
+	app := fiber.New()
+	app.Post("/query", server.Graphql(&graph.Resolver{
+		Tables: &tracking.Tables{App: apps, Meta: meta, Cat: meta, Keys: meta},
+	}, func() bool {
+		return true
+	}))
+
+	query := `{"query":"{ a: meta(id: 1) { id app { bundle } } b: meta(id: 1) { app { id } } c: meta(id: 1, start: \"2021-01-01\", end: \"2021-02-01\") { app { geo } } }"}`
+	req, _ := http.NewRequest("POST", "/query", strings.NewReader(query))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, 1000*60)
+	assert.NoError(t, err)
+
+	var body struct {
+		Data   map[string][]map[string]interface{} `json:"data"`
+		Errors []interface{}                       `json:"errors"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Empty(t, body.Errors)
+	assert.Len(t, body.Data["a"], 2)
+	assert.Len(t, body.Data["c"], 2)
+
+	// One query for app and one query for every distinct range of meta
+	assert.Equal(t, int32(1), atomic.LoadInt32(&apps.calls))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&meta.calls))
+}
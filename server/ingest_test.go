@@ -0,0 +1,121 @@
+package server_test
+
+import (
+	"Muromachi/auth"
+	"Muromachi/config"
+	"Muromachi/httpresp"
+	"Muromachi/server"
+	"Muromachi/store/tracking/ingest"
+	"context"
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Writer which inserts every record
+type mockIngestWriter struct {
+	records int
+}
+
+func (m *mockIngestWriter) Write(ctx context.Context, owner int, records []ingest.Record) (ingest.Result, error) {
+	m.records += len(records)
+	return ingest.Result{Inserted: len(records)}, nil
+}
+
+func TestIngest_ShouldRequireTokenAndReportBatches_Mock(t *testing.T) {
+	cfg := config.Authorization{
+		JwtSalt:    "nunetprivet",
+		JwtExpires: time.Hour * 24,
+		JwtIss:     "apptwice.com",
+	}
+	writer := &mockIngestWriter{}
+	app := fiber.New(fiber.Config{ErrorHandler: httpresp.ErrorHandler, StreamRequestBody: true})
+	app.Post("/ingest", auth.ApplyAuthMiddleware(auth.NewSecurity(cfg, mockSession{})), server.Ingest(&ingest.Ingester{Writer: writer}, 1<<20))
+
+	body := strings.Join([]string{
+		`{"kind": "keyword", "bundleId": 1, "type": "bank", "place": 3, "date": "2021-01-18T00:00:00Z"}`,
+		`{"kind": "category", "bundleId": 1, "type": "finance", "place": 0, "date": "2021-01-18T00:00:00Z"}`,
+	}, "\n")
+	request := func(token string) *http.Response {
+		req, _ := http.NewRequest("POST", "/ingest", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := app.Test(req, 1000*60)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp := request("")
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 0, writer.records)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.Claims{
+		StandardClaims: &jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+			Issuer:    cfg.JwtIss,
+		},
+		UserClaims: &auth.UserClaims{ID: 123, Role: "user"},
+	}).SignedString([]byte(cfg.JwtSalt))
+	assert.NoError(t, err)
+
+	resp = request(token)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report ingest.Report
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, 2, report.Received)
+	assert.Equal(t, 1, report.Inserted)
+	assert.Equal(t, 1, report.Rejected)
+	assert.Equal(t, 2, report.Batches[0].Rejected[0].Line)
+	assert.Equal(t, 1, writer.records)
+}
+
+func TestIngest_ShouldStreamBodyOnlyOfIngestion_Mock(t *testing.T) {
+	writer := &mockIngestWriter{}
+	app := fiber.New(fiber.Config{ErrorHandler: httpresp.ErrorHandler, StreamRequestBody: true, BodyLimit: 64})
+	app.Use(server.LimitBody(64, "/ingest"))
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("request_user", &auth.UserClaims{ID: 123, Role: "user"})
+		return ctx.Next()
+	})
+	app.Post("/ingest", server.Ingest(&ingest.Ingester{Writer: writer, BatchSize: 1}, 1<<10))
+	app.Post("/authorize", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(string(ctx.Body()))
+	})
+
+	line := `{"kind": "keyword", "bundleId": 1, "type": "bank", "place": 3, "date": "2021-01-18T00:00:00Z"}`
+	request := func(path, body string, chunked bool) *http.Response {
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		if chunked {
+			// Length of chunked body is not known before it is read
+			req.ContentLength = -1
+			req.TransferEncoding = []string{"chunked"}
+		}
+		resp, err := app.Test(req, 1000*60)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	// Bodies of other routes are limited by body limit
+	resp := request("/authorize", "small", true)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	echo, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "small", string(echo))
+	assert.Equal(t, http.StatusBadRequest, request("/authorize", strings.Repeat("a", 65), false).StatusCode)
+	assert.Equal(t, http.StatusBadRequest, request("/authorize", strings.Repeat("a", 65), true).StatusCode)
+
+	// Ingestion bodies are longer than body limit of server, but they are limited by limit of ingestion
+	resp = request("/ingest", strings.Repeat(line+"\n", 5), false)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 5, writer.records)
+	assert.Equal(t, http.StatusBadRequest, request("/ingest", strings.Repeat(line+"\n", 20), false).StatusCode)
+	assert.Equal(t, http.StatusBadRequest, request("/ingest", strings.Repeat(line+"\n", 20), true).StatusCode)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
+	"sync/atomic"
 	"time"
 )
 
@@ -129,3 +130,186 @@ type mockRowError struct {
 func (m mockRowError) Scan(dest ...interface{}) error {
 	return pgx.ErrNoRows
 }
+
+type mockRepoError struct {
+	err error
+}
+
+func (m mockRepoError) ProducerFunc(ctx context.Context, sql string, params ...interface{}) (entities.DboSlice, error) {
+	return nil, m.err
+}
+
+func (m mockRepoError) ByBundleId(ctx context.Context, bundleId int) (entities.DboSlice, error) {
+	return nil, m.err
+}
+
+func (m mockRepoError) TimeRange(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
+	return nil, m.err
+}
+
+func (m mockRepoError) LastUpdates(ctx context.Context, bundleId, count int) (entities.DboSlice, error) {
+	return nil, m.err
+}
+
+func (m mockRepoError) Page(ctx context.Context, bundleId int, page entities.Page) (entities.DboSlice, error) {
+	return nil, m.err
+}
+
+func (m mockRepoError) Count(ctx context.Context, bundleId int, start, end time.Time) (int, error) {
+	return 0, m.err
+}
+
+func (m mockRepoError) ById(ctx context.Context, id int) (entities.App, error) {
+	return entities.App{}, m.err
+}
+
+func (m mockRepoError) ByBundle(ctx context.Context, store entities.AppStore, bundle, geo string) (entities.App, error) {
+	return entities.App{}, m.err
+}
+
+func (m mockRepoError) Search(ctx context.Context, query entities.AppQuery) (entities.DboSlice, error) {
+	return nil, m.err
+}
+
+func (m mockRepoError) CountApps(ctx context.Context, filter entities.AppFilter) (int, error) {
+	return 0, m.err
+}
+
+func (m mockRepoError) Create(ctx context.Context, app entities.App) (entities.App, error) {
+	return app, m.err
+}
+
+func (m mockRepoError) Update(ctx context.Context, app entities.App) (entities.App, error) {
+	return app, m.err
+}
+
+func (m mockRepoError) SetStatus(ctx context.Context, id int, status entities.AppStatus) (entities.App, error) {
+	return entities.App{}, m.err
+}
+
+func (m mockRepoError) RankStats(ctx context.Context, bundleId int, typ string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	return nil, m.err
+}
+
+func (m mockRepoError) RankStatsByBundleIds(ctx context.Context, bundleIds []int, typ string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	return nil, m.err
+}
+
+func (m mockRepoError) ByResolution(ctx context.Context, bundleIds []int, resolution entities.Resolution, start, end time.Time) (entities.DboSlice, error) {
+	return nil, m.err
+}
+
+func (m mockRepoError) ByBundleIds(ctx context.Context, bundleIds []int, start, end time.Time) (entities.DboSlice, error) {
+	return nil, m.err
+}
+
+func (m mockRepoError) Stream(ctx context.Context, bundleId, last int, start, end time.Time, f func(entities.DBO) error) error {
+	return m.err
+}
+
+// Mock repository which counts queries. Returns apps if apps is true
+// otherwise returns two meta rows for every bundle id
+type mockCountingRepo struct {
+	apps  bool
+	calls int32
+}
+
+func (m *mockCountingRepo) ProducerFunc(ctx context.Context, sql string, params ...interface{}) (entities.DboSlice, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (m *mockCountingRepo) ByBundleId(ctx context.Context, bundleId int) (entities.DboSlice, error) {
+	return m.ByBundleIds(ctx, []int{bundleId}, time.Time{}, time.Time{})
+}
+
+func (m *mockCountingRepo) TimeRange(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
+	return m.ByBundleIds(ctx, []int{bundleId}, start, end)
+}
+
+func (m *mockCountingRepo) LastUpdates(ctx context.Context, bundleId, count int) (entities.DboSlice, error) {
+	return m.ByBundleIds(ctx, []int{bundleId}, time.Time{}, time.Time{})
+}
+
+func (m *mockCountingRepo) Page(ctx context.Context, bundleId int, page entities.Page) (entities.DboSlice, error) {
+	return m.ByBundleIds(ctx, []int{bundleId}, page.Start, page.End)
+}
+
+func (m *mockCountingRepo) Count(ctx context.Context, bundleId int, start, end time.Time) (int, error) {
+	atomic.AddInt32(&m.calls, 1)
+	return 2, nil
+}
+
+func (m *mockCountingRepo) Stream(ctx context.Context, bundleId, last int, start, end time.Time, f func(entities.DBO) error) error {
+	dbo, err := m.ByBundleIds(ctx, []int{bundleId}, start, end)
+	for _, v := range dbo {
+		if err := f(v); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (m *mockCountingRepo) ById(ctx context.Context, id int) (entities.App, error) {
+	atomic.AddInt32(&m.calls, 1)
+	return entities.App{Id: id, Bundle: "com.bundle"}, nil
+}
+
+func (m *mockCountingRepo) ByBundle(ctx context.Context, store entities.AppStore, bundle, geo string) (entities.App, error) {
+	atomic.AddInt32(&m.calls, 1)
+	return entities.App{Id: 1, Bundle: bundle, Geo: geo}, nil
+}
+
+func (m *mockCountingRepo) Search(ctx context.Context, query entities.AppQuery) (entities.DboSlice, error) {
+	return m.ByBundleIds(ctx, []int{1}, time.Time{}, time.Time{})
+}
+
+func (m *mockCountingRepo) CountApps(ctx context.Context, filter entities.AppFilter) (int, error) {
+	atomic.AddInt32(&m.calls, 1)
+	return 1, nil
+}
+
+func (m *mockCountingRepo) Create(ctx context.Context, app entities.App) (entities.App, error) {
+	atomic.AddInt32(&m.calls, 1)
+	app.Id = 1
+	return app, nil
+}
+
+func (m *mockCountingRepo) Update(ctx context.Context, app entities.App) (entities.App, error) {
+	atomic.AddInt32(&m.calls, 1)
+	return app, nil
+}
+
+func (m *mockCountingRepo) SetStatus(ctx context.Context, id int, status entities.AppStatus) (entities.App, error) {
+	atomic.AddInt32(&m.calls, 1)
+	return entities.App{Id: id, Status: status}, nil
+}
+
+func (m *mockCountingRepo) RankStats(ctx context.Context, bundleId int, typ string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	atomic.AddInt32(&m.calls, 1)
+	return entities.DboSlice{entities.RankStats{Type: typ, Count: 1}}, nil
+}
+
+func (m *mockCountingRepo) RankStatsByBundleIds(ctx context.Context, bundleIds []int, typ string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	atomic.AddInt32(&m.calls, 1)
+	return entities.DboSlice{entities.RankStats{BundleId: bundleIds[0], Type: typ, Count: 1}}, nil
+}
+
+func (m *mockCountingRepo) ByResolution(ctx context.Context, bundleIds []int, resolution entities.Resolution, start, end time.Time) (entities.DboSlice, error) {
+	return m.ByBundleIds(ctx, bundleIds, start, end)
+}
+
+func (m *mockCountingRepo) ByBundleIds(ctx context.Context, bundleIds []int, start, end time.Time) (entities.DboSlice, error) {
+	atomic.AddInt32(&m.calls, 1)
+
+	var dbo entities.DboSlice
+	for _, id := range bundleIds {
+		app := entities.App{Id: id, Bundle: "com.bundle"}
+		if m.apps {
+			dbo = append(dbo, app)
+			continue
+		}
+		dbo = append(dbo, entities.Meta{Id: 1, BundleId: id, App: app}, entities.Meta{Id: 2, BundleId: id, App: app})
+	}
+
+	return dbo, nil
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/config"
+	"Muromachi/httpresp"
+	"Muromachi/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"sync/atomic"
+)
+
+// Fiber handler which can be replaced while server is running
+type hotHandler struct {
+	handler atomic.Value
+}
+
+// Replace current handler with given one
+func (h *hotHandler) Set(handler fiber.Handler) {
+	h.handler.Store(handler)
+}
+
+// Call current handler
+func (h *hotHandler) Handle(c *fiber.Ctx) error {
+	return h.handler.Load().(fiber.Handler)(c)
+}
+
+func newHotHandler(handler fiber.Handler) *hotHandler {
+	h := &hotHandler{}
+	h.Set(handler)
+
+	return h
+}
+
+// Feature toggles which can be replaced while server is running
+type featureToggles struct {
+	value atomic.Value
+}
+
+// Current feature toggles
+func (f *featureToggles) Get() config.Features {
+	return f.value.Load().(config.Features)
+}
+
+// Replace feature toggles
+func (f *featureToggles) Set(features config.Features) {
+	f.value.Store(features)
+}
+
+func newFeatureToggles(features config.Features) *featureToggles {
+	f := &featureToggles{}
+	f.Set(features)
+
+	return f
+}
+
+// Create request limiter from config. Every new limiter starts
+// counting requests from zero
+func limiterHandler(cfg config.Limits) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        cfg.Max,
+		Expiration: cfg.Expiration,
+		LimitReached: func(c *fiber.Ctx) error {
+			return httpresp.Error(c, apperrors.New(apperrors.RateLimited, "too many requests, try again later"))
+		},
+	})
+}
+
+// Create cors middleware from config. If cors is disabled
+// the handler only passes request to the next one
+func corsHandler(cfg config.Cors) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return cors.New(cors.Config{
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	})
+}
+
+// Reload applies hot values of given config to running server. Values
+// which require restart are ignored here
+func (s *Server) Reload(cfg config.Config) {
+	s.security.Reload(cfg.Auth)
+	// New limiter resets counters of all clients, so it is replaced only on changes
+	if cfg.Limits != s.limits {
+		s.limiter.Set(limiterHandler(cfg.Limits))
+		s.limits = cfg.Limits
+	}
+	s.cors.Set(corsHandler(cfg.Cors))
+	s.features.Set(cfg.Features)
+	applyLogLevel(cfg.Log)
+}
+
+// Set level of logger from config
+func applyLogLevel(cfg config.Log) {
+	level, err := logging.ParseLevel(cfg.Level)
+	if err != nil {
+		logging.Warnf("%v, level is not changed", err)
+		return
+	}
+	logging.SetLevel(level)
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/auth"
+	"Muromachi/httpresp"
+	"Muromachi/store/tracking"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+// Download endpoint of digest report of request client, format is html or json
+func Report(tables *tracking.Tables) func(*fiber.Ctx) error {
+	return func(ctx *fiber.Ctx) error {
+		format := ctx.Params("format")
+		if format != "html" && format != "json" {
+			return fiber.ErrNotFound
+		}
+		id, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+		if err != nil || id <= 0 {
+			return httpresp.Error(ctx, apperrors.New(apperrors.BadRequest, "id of report should be positive integer"))
+		}
+		claims, err := auth.ClaimsFromContext(ctx.Context())
+		if err != nil {
+			return httpresp.Error(ctx, err)
+		}
+
+		report, err := tables.Reports.ReportById(ctx.Context(), id)
+		if err != nil {
+			return httpresp.Error(ctx, err)
+		}
+		if report.OwnerId != int(claims.ID) {
+			return httpresp.Error(ctx, apperrors.New(apperrors.Forbidden, "report belongs to another client"))
+		}
+
+		name := fmt.Sprintf("digest-%s.%s", report.PeriodEnd.Format("2006-01-02"), format)
+		ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s"`, name))
+		if format == "json" {
+			ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			return ctx.Send(report.Json)
+		}
+		ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+
+		return ctx.SendString(report.Html)
+	}
+}
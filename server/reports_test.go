@@ -0,0 +1,80 @@
+package server_test
+
+import (
+	"Muromachi/auth"
+	"Muromachi/httpresp"
+	"Muromachi/server"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Repository with stored reports, schedules are not used by download
+type mockReportRepo struct {
+	tracking.ReportRepository
+	reports []entities.Report
+}
+
+func (m mockReportRepo) ReportById(ctx context.Context, id int64) (entities.Report, error) {
+	for _, report := range m.reports {
+		if report.Id == id {
+			return report, nil
+		}
+	}
+	return entities.Report{}, pgx.ErrNoRows
+}
+
+func TestReport_ShouldReturnReportOfClient_Mock(t *testing.T) {
+	end := time.Date(2021, 1, 18, 9, 0, 0, 0, time.UTC)
+	app := fiber.New(fiber.Config{ErrorHandler: httpresp.ErrorHandler})
+	app.Get("/reports/:id/:format", func(ctx *fiber.Ctx) error {
+		ctx.Locals("request_user", &auth.UserClaims{ID: 7, Role: "user"})
+		return ctx.Next()
+	}, server.Report(&tracking.Tables{Reports: mockReportRepo{reports: []entities.Report{
+		{Id: 1, OwnerId: 7, PeriodEnd: end, Json: []byte(`{"ownerId":7}`), Html: "<h1>Weekly digest</h1>"},
+		{Id: 2, OwnerId: 8, PeriodEnd: end},
+	}}}))
+	request := func(url string) (*http.Response, string) {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp, err := app.Test(req, 1000*60)
+		assert.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return resp, string(body)
+	}
+
+	resp, body := request("/reports/1/html")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, fiber.MIMETextHTMLCharsetUTF8, resp.Header.Get("Content-Type"))
+	assert.Equal(t, `inline; filename="digest-2021-01-18.html"`, resp.Header.Get("Content-Disposition"))
+	assert.Equal(t, "<h1>Weekly digest</h1>", body)
+
+	resp, body = request("/reports/1/json")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get("Content-Type"))
+	assert.Equal(t, `{"ownerId":7}`, body)
+
+	var tt = []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{name: "unknown format", url: "/reports/1/pdf", status: http.StatusNotFound},
+		{name: "wrong id", url: "/reports/first/html", status: http.StatusBadRequest},
+		{name: "unknown report", url: "/reports/100/html", status: http.StatusNotFound},
+		{name: "report of another client", url: "/reports/2/html", status: http.StatusForbidden},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			resp, _ := request(test.url)
+			assert.Equal(t, test.status, resp.StatusCode)
+		})
+	}
+}
@@ -2,21 +2,32 @@ package server
 
 import (
 	"Muromachi/auth"
+	"Muromachi/collector"
 	"Muromachi/config"
 	"Muromachi/graph"
+	"Muromachi/httpresp"
+	"Muromachi/logging"
 	"Muromachi/store/connector"
 	tracking2 "Muromachi/store/tracking"
+	"Muromachi/store/tracking/detection"
+	"Muromachi/store/tracking/events"
+	"Muromachi/store/tracking/ingest"
+	"Muromachi/store/tracking/alerts"
+	"Muromachi/store/tracking/partitions"
+	"Muromachi/store/tracking/reports"
+	"Muromachi/store/tracking/rollups"
+	"Muromachi/store/tracking/scheduler"
 	"Muromachi/store/users"
 	"Muromachi/store/users/sessions"
 	"Muromachi/store/users/sessions/blacklist"
 	"Muromachi/store/users/sessions/tokens"
 	"Muromachi/store/users/userstore"
 	"Muromachi/utils"
+	"context"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"log"
-	"time"
+	"net/http"
 )
 
 type Server struct {
@@ -34,24 +45,69 @@ type Server struct {
 	sessions *users.Tables
 	// Pointer to tracking tables collection
 	tracking *tracking2.Tables
+	// Bulk writer of tracking data
+	ingester *ingest.Ingester
+	// Request limiter, replaced on config reload
+	limiter  *hotHandler
+	// Settings of current request limiter
+	limits   config.Limits
+	// Cors middleware, replaced on config reload
+	cors     *hotHandler
+	// Feature toggles, replaced on config reload
+	features *featureToggles
+	// Listener of tracking tables notifications
+	listener *events.Listener
+	// Scheduler of collection jobs, runs if enabled in config
+	scheduler *scheduler.Scheduler
+	// Evaluator of alert rules, runs if alerts are enabled in config
+	evaluator *alerts.Evaluator
+	// Sender of alert webhooks, runs if alerts are enabled in config
+	deliverer *alerts.Deliverer
+	// Generator of digest reports, runs if reports are enabled in config
+	reporter *reports.Generator
+	// Sender of report webhooks, runs if reports are enabled in config
+	reportDeliverer *alerts.Deliverer
+	// Maintainer of partitions of tracking tables, runs if partitions are enabled in config
+	partitions *partitions.Maintainer
+	// Rollup of places by days and weeks, runs if rollups are enabled in config
+	rollups *rollups.Job
+	// Detection of anomalies, runs if anomalies are enabled in config
+	anomalies *detection.Job
+	// Stops listener, scheduler and alerts on shutdown
+	stopBackground context.CancelFunc
 }
 
 // Init routes and apply middleware
 func (s *Server) initRoutes() {
+	s.app.Use(s.cors.Handle)
+	s.app.Use(LimitBody(fiber.DefaultBodyLimit, "/ingest"))
 
 	//Graphql playground
-	s.app.All("/playground", Testground())
+	s.app.All("/playground", func(c *fiber.Ctx) error {
+		if !s.features.Get().Playground {
+			return fiber.ErrNotFound
+		}
+		return c.Next()
+	}, Testground())
+	// Subscriptions are authenticated by connection init payload, so route is
+	// registered before auth middleware of group
+	s.app.Get("/ql/subscriptions", s.limiter.Handle, Subscriptions(s.resolver, s.security))
 	// GraphQL Group
 	ql := s.app.Group("/ql", auth.ApplyAuthMiddleware(s.security))
 	// Request limiter
-	ql.Use(limiter.New(limiter.Config{
-		Max: 20,
-		Expiration: time.Minute * 1,
-	}))
+	ql.Use(s.limiter.Handle)
 
-	ql.All("/query", Graphql(s.resolver))
+	ql.All("/query", Graphql(s.resolver, func() bool {
+		return s.features.Get().Introspection
+	}))
 
 	// Rest
+	// Ingestion of tracking data
+	s.app.Post("/ingest", auth.ApplyAuthMiddleware(s.security), s.limiter.Handle, Ingest(s.ingester, s.config.Ingest.BodyLimit))
+	// Export of tracking history in csv, ndjson or xlsx
+	s.app.Get("/export/:kind", auth.ApplyAuthMiddleware(s.security), s.limiter.Handle, Export(s.tracking))
+	// Download of digest reports
+	s.app.Get("/reports/:id/:format", auth.ApplyAuthMiddleware(s.security), s.limiter.Handle, Report(s.tracking))
 	// Auth
 	s.app.Post("/authorize", Authorize(s.security, s.sessions))
 	// Generate new company in system
@@ -63,11 +119,73 @@ func (s *Server) initRoutes() {
 // Start listening tcp port
 func (s *Server) Listen() error {
 	s.initRoutes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopBackground = cancel
+	go func() {
+		_ = s.listener.Run(ctx)
+	}()
+	if s.config.Scheduler.Enabled {
+		go func() {
+			if err := s.scheduler.Run(ctx); err != nil && ctx.Err() == nil {
+				logging.Errorf("%v", err)
+			}
+		}()
+	}
+	if s.config.Alerts.Enabled {
+		go func() {
+			if err := s.evaluator.Run(ctx); err != nil && ctx.Err() == nil {
+				logging.Errorf("%v", err)
+			}
+		}()
+		go func() {
+			if err := s.deliverer.Run(ctx); err != nil && ctx.Err() == nil {
+				logging.Errorf("%v", err)
+			}
+		}()
+	}
+	if s.config.Reports.Enabled {
+		go func() {
+			if err := s.reporter.Run(ctx); err != nil && ctx.Err() == nil {
+				logging.Errorf("%v", err)
+			}
+		}()
+		go func() {
+			if err := s.reportDeliverer.Run(ctx); err != nil && ctx.Err() == nil {
+				logging.Errorf("%v", err)
+			}
+		}()
+	}
+	if s.config.Partitions.Enabled {
+		go func() {
+			if err := s.partitions.Run(ctx); err != nil && ctx.Err() == nil {
+				logging.Errorf("%v", err)
+			}
+		}()
+	}
+	if s.config.Rollups.Enabled {
+		go func() {
+			if err := s.rollups.Run(ctx); err != nil && ctx.Err() == nil {
+				logging.Errorf("%v", err)
+			}
+		}()
+	}
+	if s.config.Anomalies.Enabled {
+		go func() {
+			if err := s.anomalies.Run(ctx); err != nil && ctx.Err() == nil {
+				logging.Errorf("%v", err)
+			}
+		}()
+	}
+
 	return s.app.Listen(s.port)
 }
 
 // Shutdown server
 func (s *Server) Shutdown() error {
+	if s.stopBackground != nil {
+		s.stopBackground()
+	}
 	return s.app.Shutdown()
 }
 
@@ -82,11 +200,20 @@ func New(port string, config config.Config) *Server {
 	redisConn := connector.EstablishRedisConnection(config.Database.Redis)
 	// Pointer to table collection
 	tables := tracking2.NewTrackingTables(conn)
+	// Tracking events for subscriptions
+	hub := events.NewHub()
 	// Interface of sessions
 	session := sessions.New(tokens.New(conn), blacklist.New(redisConn))
+	// Schedules and webhooks of digest reports
+	reportStore := &reports.PgStore{DB: conn}
 
 	server := &Server{
-		app:    fiber.New(),
+		app: fiber.New(fiber.Config{
+			ErrorHandler: httpresp.ErrorHandler,
+			// Bulk ingestion bodies are bigger than body limit, so bodies are streamed
+			// and bodies of other routes are limited by LimitBody
+			StreamRequestBody: true,
+		}),
 		port:   fmt.Sprintf(":%s", port),
 		config: config,
 		security: auth.NewSecurity(
@@ -97,8 +224,80 @@ func New(port string, config config.Config) *Server {
 		tracking: tables,
 		resolver: &graph.Resolver{
 			Tables: tables,
+			Events: hub,
+		},
+		ingester: &ingest.Ingester{Writer: &ingest.PgWriter{DB: conn}},
+		limiter:  newHotHandler(limiterHandler(config.Limits)),
+		limits:   config.Limits,
+		cors:     newHotHandler(corsHandler(config.Cors)),
+		features: newFeatureToggles(config.Features),
+		listener: &events.Listener{Pool: conn, Hub: hub},
+		scheduler: &scheduler.Scheduler{
+			Store: &scheduler.PgStore{DB: conn},
+			Collector: &collector.Collector{
+				Client: &collector.Client{
+					HTTP:      &http.Client{Timeout: config.Collector.Timeout},
+					BaseUrl:   config.Collector.BaseUrl,
+					UserAgent: config.Collector.UserAgent,
+				},
+				Writer:   &ingest.PgWriter{DB: conn},
+				Keywords: &collector.TrackedKeywords{Conn: conn},
+			},
+			Interval:  config.Scheduler.Interval,
+			BatchSize: config.Scheduler.BatchSize,
+			Timeout:   config.Scheduler.Timeout,
+		},
+		evaluator: &alerts.Evaluator{Store: &alerts.PgStore{DB: conn}, Hub: hub, ScanInterval: config.Alerts.ScanInterval},
+		deliverer: &alerts.Deliverer{
+			Store:       &alerts.PgStore{DB: conn},
+			HTTP:        alerts.NewClient(config.Alerts.Timeout),
+			Interval:    config.Alerts.Interval,
+			BatchSize:   config.Alerts.BatchSize,
+			Timeout:     config.Alerts.Timeout,
+			MaxAttempts: config.Alerts.MaxAttempts,
+			BaseDelay:   config.Alerts.BaseDelay,
+			MaxDelay:    config.Alerts.MaxDelay,
+		},
+		reporter: &reports.Generator{
+			Store:     reportStore,
+			Tables:    tables,
+			Interval:  config.Reports.Interval,
+			BatchSize: config.Reports.BatchSize,
+			Timeout:   config.Reports.Timeout,
+		},
+		reportDeliverer: &alerts.Deliverer{
+			Store:       reportStore.Deliveries(),
+			HTTP:        alerts.NewClient(config.Reports.WebhookTimeout),
+			Interval:    config.Reports.Interval,
+			BatchSize:   config.Reports.BatchSize,
+			Timeout:     config.Reports.WebhookTimeout,
+			MaxAttempts: config.Reports.MaxAttempts,
+			BaseDelay:   config.Reports.BaseDelay,
+			MaxDelay:    config.Reports.MaxDelay,
+			Name:        "reports",
+		},
+		partitions: &partitions.Maintainer{
+			Store: &partitions.PgStore{Pool: conn},
+			Tables: []partitions.Table{
+				{Name: "meta_tracking", Retention: config.Partitions.Meta.Retention, Detach: config.Partitions.Meta.Detach},
+				{Name: "category_tracking", Retention: config.Partitions.Categories.Retention, Detach: config.Partitions.Categories.Detach},
+				{Name: "keyword_tracking", Retention: config.Partitions.Keywords.Retention, Detach: config.Partitions.Keywords.Detach},
+			},
+			Interval: config.Partitions.Interval,
+			Ahead:    config.Partitions.Ahead,
+		},
+		rollups: &rollups.Job{
+			Store:     &rollups.PgStore{DB: conn},
+			Interval:  config.Rollups.Interval,
+			BatchSize: config.Rollups.BatchSize,
+		},
+		anomalies: &detection.Job{
+			Store:     &detection.PgStore{DB: conn},
+			Interval:  config.Anomalies.Interval,
+			BatchSize: config.Anomalies.BatchSize,
 		},
 	}
+	applyLogLevel(config.Log)
 
 	return server
 }
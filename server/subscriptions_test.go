@@ -0,0 +1,163 @@
+package server_test
+
+import (
+	"Muromachi/auth"
+	"Muromachi/config"
+	"Muromachi/graph"
+	"Muromachi/server"
+	"Muromachi/store/tracking"
+	"Muromachi/store/tracking/events"
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type wsMessage struct {
+	Id      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// startSubscriptions runs subscriptions handler on random port and
+// returns websocket url of handler
+func startSubscriptions(t *testing.T, cfg config.Authorization, hub *events.Hub) string {
+	apps := &mockCountingRepo{apps: true}
+	app := fiber.New()
+	app.Get("/ql/subscriptions", server.Subscriptions(&graph.Resolver{
+		Tables: &tracking.Tables{App: apps, Meta: apps, Cat: apps, Keys: apps},
+		Events: hub,
+	}, auth.NewSecurity(cfg, mockSession{})))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() {
+		_ = app.Listener(ln)
+	}()
+	// Shutdown of fasthttp server breaks contexts of hijacked connections,
+	// so only listener is closed
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	return "ws://" + ln.Addr().String() + "/ql/subscriptions"
+}
+
+// readMessage skips keep alive messages
+func readMessage(t *testing.T, conn *websocket.Conn) wsMessage {
+	for {
+		var msg wsMessage
+		assert.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second*5)))
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type != "ka" {
+			return msg
+		}
+	}
+}
+
+func TestSubscriptions_ShouldStreamTrackingUpdates_Mock(t *testing.T) {
+	cfg := config.Authorization{
+		JwtSalt:    "nunetprivet",
+		JwtExpires: time.Hour * 24,
+		JwtIss:     "apptwice.com",
+	}
+	hub := events.NewHub()
+	url := startSubscriptions(t, cfg, hub)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.Claims{
+		StandardClaims: &jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+			Issuer:    cfg.JwtIss,
+		},
+		UserClaims: &auth.UserClaims{ID: 123, Role: "user"},
+	}).SignedString([]byte(cfg.JwtSalt))
+	assert.NoError(t, err)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": {"graphql-ws"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	assert.NoError(t, conn.WriteJSON(map[string]interface{}{
+		"type":    "connection_init",
+		"payload": map[string]string{"Authorization": "Bearer " + token},
+	}))
+	assert.Equal(t, "connection_ack", readMessage(t, conn).Type)
+
+	assert.NoError(t, conn.WriteJSON(map[string]interface{}{
+		"id":   "1",
+		"type": "start",
+		"payload": map[string]string{
+			"query": "subscription { trackingUpdated(bundleIds: [1], kinds: [KEYWORD]) { kind bundleId id app { bundle } } }",
+		},
+	}))
+	for i := 0; hub.Count() == 0 && i < 100; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(t, 1, hub.Count())
+
+	hub.Publish(events.Event{Kind: events.Meta, BundleId: 1, Id: 9})
+	hub.Publish(events.Event{Kind: events.Keyword, BundleId: 2, Id: 10})
+	hub.Publish(events.Event{Kind: events.Keyword, BundleId: 1, Id: 11})
+
+	msg := readMessage(t, conn)
+	assert.Equal(t, "data", msg.Type)
+	assert.Equal(t, "1", msg.Id)
+	var payload struct {
+		Data struct {
+			TrackingUpdated struct {
+				Kind     string `json:"kind"`
+				BundleId int    `json:"bundleId"`
+				Id       int    `json:"id"`
+				App      struct {
+					Bundle string `json:"bundle"`
+				} `json:"app"`
+			} `json:"trackingUpdated"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(msg.Payload, &payload))
+	assert.Equal(t, "KEYWORD", payload.Data.TrackingUpdated.Kind)
+	assert.Equal(t, 11, payload.Data.TrackingUpdated.Id)
+	assert.NotEmpty(t, payload.Data.TrackingUpdated.App.Bundle)
+
+	// Stop of operation removes subscription from hub
+	assert.NoError(t, conn.WriteJSON(map[string]string{"id": "1", "type": "stop"}))
+	assert.Equal(t, "complete", readMessage(t, conn).Type)
+	for i := 0; hub.Count() != 0 && i < 100; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(t, 0, hub.Count())
+}
+
+func TestSubscriptions_ShouldRejectConnectionWithoutToken_Mock(t *testing.T) {
+	url := startSubscriptions(t, config.Authorization{JwtSalt: "nunetprivet"}, events.NewHub())
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Sec-WebSocket-Protocol": {"graphql-ws"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	assert.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "connection_init", "payload": map[string]string{}}))
+	msg := readMessage(t, conn)
+	assert.Equal(t, "connection_error", msg.Type)
+	assert.Contains(t, string(msg.Payload), "invalid auth token")
+}
+
+func TestSubscriptions_ShouldRequireWebsocketUpgrade_Mock(t *testing.T) {
+	app := fiber.New()
+	app.Get("/ql/subscriptions", server.Subscriptions(&graph.Resolver{}, auth.NewSecurity(config.Authorization{}, mockSession{})))
+
+	req, _ := http.NewRequest("GET", "/ql/subscriptions", nil)
+	resp, err := app.Test(req, 1000*60)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
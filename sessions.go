@@ -0,0 +1,121 @@
+package main
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/users/sessions"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const sessionsUsage = "usage: sessions list --user id | revoke | ban [--ttl 24h] | unban (--user id | --token token)"
+
+// sessionsCommand manages refresh sessions of clients
+func sessionsCommand(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", sessionsUsage)
+	}
+	flags := flag.NewFlagSet("sessions "+args[0], flag.ExitOnError)
+	userId := flags.Int("user", 0, "id of user")
+	token := flags.String("token", "", "refresh token of session")
+	ttl := flags.Duration("ttl", 0, "how long session will be banned, by default until session expires")
+	_ = flags.Parse(args[1:])
+
+	if *userId == 0 && *token == "" {
+		return fmt.Errorf("%s", sessionsUsage)
+	}
+
+	tables, closer, err := authTables(cfg)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	ctx := context.Background()
+
+	var selected []entities.Session
+	if *userId > 0 {
+		userSessions, err := tables.Sessions.UserSessions(ctx, *userId)
+		if err != nil {
+			return err
+		}
+		selected = append(selected, userSessions...)
+	}
+	if *token != "" {
+		session, err := tables.Sessions.Get(ctx, *token)
+		if err != nil {
+			return err
+		}
+		selected = append(selected, session)
+	}
+
+	switch args[0] {
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSER\tIP\tUSER AGENT\tCREATED AT\tEXPIRES IN\tBANNED")
+		for _, s := range selected {
+			banned := tables.Sessions.CheckIfExist(ctx, s.RefreshToken) == nil
+			fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%t\n",
+				s.ID, s.UserId, s.Ip, s.UserAgent,
+				s.CreatedAt.Format(time.RFC3339), s.ExpiresIn.Format(time.RFC3339), banned,
+			)
+		}
+		return w.Flush()
+	case "revoke":
+		if err = revokeSessions(ctx, tables.Sessions, selected); err != nil {
+			return err
+		}
+		fmt.Printf("%d sessions revoked\n", len(selected))
+	case "ban":
+		if err = banSessions(ctx, tables.Sessions, selected, *ttl); err != nil {
+			return err
+		}
+		fmt.Printf("%d sessions banned\n", len(selected))
+	case "unban":
+		keys := make([]string, len(selected))
+		for i, s := range selected {
+			keys[i] = s.RefreshToken
+		}
+		n, err := tables.Sessions.Del(ctx, keys...)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d sessions unbanned\n", n)
+	default:
+		return fmt.Errorf("%s", sessionsUsage)
+	}
+
+	return nil
+}
+
+// revokeSessions removes given refresh sessions
+func revokeSessions(ctx context.Context, session sessions.Session, list []entities.Session) error {
+	ids := make([]int, len(list))
+	for i, s := range list {
+		ids[i] = s.ID
+	}
+
+	return session.RemoveBatch(ctx, ids...)
+}
+
+// banSessions adds given refresh sessions to blacklist. If ttl is zero
+// session is banned until it expires
+func banSessions(ctx context.Context, session sessions.Session, list []entities.Session, ttl time.Duration) error {
+	for _, s := range list {
+		d := ttl
+		if d == 0 {
+			d = time.Until(s.ExpiresIn)
+		}
+		if d <= 0 {
+			continue
+		}
+		if err := session.Add(ctx, s.RefreshToken, s.ID, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
+	"time"
 )
 
 // Interface which help mock db query
@@ -12,3 +13,20 @@ type Conn interface {
 	QueryFunc(ctx context.Context, sql string, args []interface{}, scans []interface{}, f func(pgx.QueryFuncRow) error) (pgconn.CommandTag, error)
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
+
+// Database which starts transactions, *pgxpool.Pool satisfies it
+type DB interface {
+	Conn
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// NullTime returns nil for zero time, so optional bounds of time range
+// can be passed to queries like ($1::timestamp is null or date >= $1). Partitioned
+// tracking tables are queried like date >= coalesce($1::timestamp, '-infinity')
+// instead, so partitions out of range are pruned
+func NullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -2,13 +2,12 @@ package connector
 
 import (
 	"Muromachi/config"
+	"Muromachi/store/migrations"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"io/ioutil"
-	"strings"
 )
 
 // ConnectionUrl creates database connection url by given config.DBConfig
@@ -48,28 +47,15 @@ func Connect(url string) (*pgxpool.Pool, error) {
 	return connect, nil
 }
 
-// InitSchema creates database schema from shemafile string
-func InitSchema(connection *pgxpool.Pool, schemafile string) error {
-	b, err := ioutil.ReadFile(schemafile)
+// Migrate applies all pending migrations to database
+func Migrate(connection *pgxpool.Pool) error {
+	migrator, err := migrations.New(connection)
 	if err != nil {
 		return err
 	}
-	schema := string(b)
-	tables := strings.Split(schema, ";\r\n")
-
-	ctx := context.Background()
-	for _, v := range tables {
-		_, err = connection.Exec(ctx, v)
-		if err != nil {
-			if strings.Contains(err.Error(), "developercontacts") && strings.Contains(err.Error(), "42710")  {
-				continue
-			}
-			return err
-		}
-	}
-
+	_, err = migrator.Up(context.Background())
 
-	return nil
+	return err
 }
 
 // Conn to posgres db
@@ -84,7 +70,7 @@ func EstablishPostgresConnection(config config.DBConfig) (*pgxpool.Pool, error)
 
 		return nil, err
 	}
-	err = InitSchema(conn, config.Schema)
+	err = Migrate(conn)
 	if err != nil {
 		conn.Close()
 		return nil, err
@@ -59,7 +59,7 @@ func TestConnect_ShouldReturnErrorIfCanNotEstablishInitialConnection(t *testing.
 	assert.Nil(t, conn)
 }
 
-func TestInitSchema_ShouldCreateDatabaseSchemaOrDoNothing(t *testing.T) {
+func TestMigrate_ShouldCreateDatabaseSchemaOrDoNothing(t *testing.T) {
 	url, _ := connector.ConnectionUrl(c.Database)
 
 	conn, err := connector.Connect(url)
@@ -67,21 +67,7 @@ func TestInitSchema_ShouldCreateDatabaseSchemaOrDoNothing(t *testing.T) {
 	assert.NotNil(t, conn)
 
 	if err == nil {
-		c.Database.Schema = "../../config/schema.sql"
-		err = connector.InitSchema(conn, c.Database.Schema)
-		assert.NoError(t, err)
+		assert.NoError(t, connector.Migrate(conn))
+		assert.NoError(t, connector.Migrate(conn))
 	}
 }
-
-func TestInitSchema_ShouldReturnErrorIfFilepathWrong(t *testing.T) {
-	cfg := config.New("../../config/dev.yml")
-	url, _ := connector.ConnectionUrl(cfg.Database)
-
-	conn, err := connector.Connect(url)
-	assert.NoError(t, err)
-	assert.NotNil(t, conn)
-
-	cfg.Database.Schema = "../../config/schema"
-	err = connector.InitSchema(conn, cfg.Database.Schema)
-	assert.Error(t, err)
-}
@@ -0,0 +1,161 @@
+package entities
+
+import (
+	"Muromachi/graph/model"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Kind of tracking rows which are checked by alert rule
+type AlertKind string
+
+const (
+	AlertKeyword  AlertKind = "keyword"
+	AlertCategory AlertKind = "category"
+	AlertMeta     AlertKind = "meta"
+)
+
+// Condition of alert rule
+type AlertCondition string
+
+const (
+	// Place becomes worse than threshold
+	AlertBelow AlertCondition = "below"
+	// Place becomes threshold or better
+	AlertAbove AlertCondition = "above"
+	// Place changes by more than threshold within a day
+	AlertChangedBy AlertCondition = "changed_by"
+	// Meta field changes
+	AlertChanged AlertCondition = "changed"
+)
+
+// Rule of client which sends webhook when tracking rows of app match condition
+type AlertRule struct {
+	Id      int
+	OwnerId int
+	// Id of app in app_tracking
+	AppId int
+	Kind  AlertKind
+	// Keyword, category or meta field
+	Type      string
+	Condition AlertCondition
+	Threshold int
+	// Url which receives webhooks
+	WebhookUrl string
+	// Key of HMAC signature of webhooks
+	Secret    string
+	Enabled   bool
+	CreatedAt time.Time
+}
+
+// Converts DBO to *AlertRule or *model.AlertRule
+func (r AlertRule) To(to interface{}) error {
+	switch v := to.(type) {
+	case *AlertRule:
+		*v = r
+	case *model.AlertRule:
+		v.ID = r.Id
+		v.BundleID = r.AppId
+		v.Kind = model.AlertKind(strings.ToUpper(string(r.Kind)))
+		v.Type = r.Type
+		v.Condition = model.AlertCondition(strings.ToUpper(string(r.Condition)))
+		v.Threshold = r.Threshold
+		v.WebhookURL = r.WebhookUrl
+		v.Secret = r.Secret
+		v.Enabled = r.Enabled
+		v.CreatedAt = r.CreatedAt
+	default:
+		return fmt.Errorf("%s", "param 'to' not the same type with *AlertRule")
+	}
+
+	return nil
+}
+
+// Validate checks that condition of rule fits its kind
+func (r AlertRule) Validate() error {
+	switch r.Kind {
+	case AlertKeyword, AlertCategory:
+		if r.Type == "" || utf8.RuneCountInString(r.Type) > 128 {
+			return fmt.Errorf("%s should not be empty and longer than 128 symbols", r.Kind)
+		}
+		switch r.Condition {
+		case AlertBelow, AlertAbove:
+			if r.Threshold <= 0 {
+				return fmt.Errorf("%s", "threshold should be positive place")
+			}
+		case AlertChangedBy:
+			if r.Threshold < 0 {
+				return fmt.Errorf("%s", "threshold should not be negative")
+			}
+		default:
+			return fmt.Errorf("condition %s can not be used with %s", r.Condition, r.Kind)
+		}
+	case AlertMeta:
+		if _, err := (Meta{}).Value(MetaField(r.Type)); err != nil {
+			return err
+		}
+		if r.Condition != AlertChanged {
+			return fmt.Errorf("condition %s can not be used with meta", r.Condition)
+		}
+	default:
+		return fmt.Errorf("unknown alert kind %q", r.Kind)
+	}
+
+	return nil
+}
+
+// Status of webhook delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Webhook of triggered alert rule, deliveries are log of webhooks
+type AlertDelivery struct {
+	Id     int64
+	RuleId int
+	// Kind and id of tracking row which triggered rule
+	Kind       AlertKind
+	TrackingId int64
+	// JSON body of webhook
+	Payload  []byte
+	Status   DeliveryStatus
+	Attempts int
+	// Http status of last attempt, zero if request failed
+	ResponseStatus int
+	LastError      string
+	CreatedAt      time.Time
+	NextAttemptAt  time.Time
+	DeliveredAt    *time.Time
+}
+
+// Converts DBO to *AlertDelivery or *model.AlertDelivery
+func (d AlertDelivery) To(to interface{}) error {
+	switch v := to.(type) {
+	case *AlertDelivery:
+		*v = d
+	case *model.AlertDelivery:
+		v.ID = int(d.Id)
+		v.Status = model.DeliveryStatus(strings.ToUpper(string(d.Status)))
+		v.Payload = string(d.Payload)
+		v.Attempts = d.Attempts
+		v.ResponseStatus = nil
+		if d.ResponseStatus != 0 {
+			status := d.ResponseStatus
+			v.ResponseStatus = &status
+		}
+		v.LastError = d.LastError
+		v.CreatedAt = d.CreatedAt
+		v.NextAttemptAt = d.NextAttemptAt
+		v.DeliveredAt = d.DeliveredAt
+	default:
+		return fmt.Errorf("%s", "param 'to' not the same type with *AlertDelivery")
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package entities_test
+
+import (
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAlertRule_Validate(t *testing.T) {
+	assert.NoError(t, entities.AlertRule{Kind: entities.AlertKeyword, Type: "bank", Condition: entities.AlertBelow, Threshold: 10}.Validate())
+	assert.NoError(t, entities.AlertRule{Kind: entities.AlertCategory, Type: "FINANCE", Condition: entities.AlertChangedBy, Threshold: 5}.Validate())
+	assert.NoError(t, entities.AlertRule{Kind: entities.AlertMeta, Type: "title", Condition: entities.AlertChanged}.Validate())
+
+	assert.Error(t, entities.AlertRule{Kind: entities.AlertKeyword, Condition: entities.AlertBelow, Threshold: 10}.Validate())
+	assert.Error(t, entities.AlertRule{Kind: entities.AlertKeyword, Type: "bank", Condition: entities.AlertBelow}.Validate())
+	assert.Error(t, entities.AlertRule{Kind: entities.AlertKeyword, Type: "bank", Condition: entities.AlertChanged}.Validate())
+	assert.Error(t, entities.AlertRule{Kind: entities.AlertMeta, Type: "name", Condition: entities.AlertChanged}.Validate())
+	assert.Error(t, entities.AlertRule{Kind: entities.AlertMeta, Type: "title", Condition: entities.AlertBelow, Threshold: 1}.Validate())
+	assert.Error(t, entities.AlertRule{Kind: "reviews", Type: "bank", Condition: entities.AlertBelow, Threshold: 1}.Validate())
+}
+
+func TestDBO_AlertShouldGiveAReferenceOfValuesToGraphqlModel(t *testing.T) {
+	rule := &model.AlertRule{}
+	err := entities.AlertRule{Id: 1, AppId: 7, Kind: entities.AlertKeyword, Condition: entities.AlertChangedBy}.To(rule)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, rule.BundleID)
+	assert.Equal(t, model.AlertKindKeyword, rule.Kind)
+	assert.Equal(t, model.AlertConditionChangedBy, rule.Condition)
+
+	delivered := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	delivery := &model.AlertDelivery{}
+	err = entities.AlertDelivery{Id: 2, Payload: []byte(`{}`), Status: entities.DeliveryDelivered, DeliveredAt: &delivered}.To(delivery)
+	assert.NoError(t, err)
+	assert.Equal(t, model.DeliveryStatusDelivered, delivery.Status)
+	assert.Equal(t, "{}", delivery.Payload)
+	assert.Nil(t, delivery.ResponseStatus)
+	assert.Equal(t, delivered, *delivery.DeliveredAt)
+
+	assert.Error(t, entities.AlertRule{}.To(delivery))
+}
@@ -0,0 +1,162 @@
+package entities
+
+import (
+	"Muromachi/anomaly"
+	"Muromachi/graph/model"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Metric of app which is checked for anomalies
+type AnomalyMetric string
+
+const (
+	AnomalyKeyword     AnomalyMetric = "keyword"
+	AnomalyCategory    AnomalyMetric = "category"
+	AnomalyRating      AnomalyMetric = "rating"
+	AnomalyReviewCount AnomalyMetric = "review_count"
+)
+
+// Review status of anomaly
+type AnomalyStatus string
+
+const (
+	AnomalyNew       AnomalyStatus = "new"
+	AnomalyConfirmed AnomalyStatus = "confirmed"
+	AnomalyDismissed AnomalyStatus = "dismissed"
+)
+
+// Options of detection of metrics. Changes of places, rating and reviews are
+// checked, min scales are the least changes which are still usual for flat series
+var anomalyOptions = map[AnomalyMetric]anomaly.Options{
+	AnomalyKeyword:     {MinScale: 1},
+	AnomalyCategory:    {MinScale: 1},
+	AnomalyRating:      {MinScale: 0.05},
+	AnomalyReviewCount: {MinScale: 1},
+}
+
+// Abnormal jump of metric of app
+type Anomaly struct {
+	Id       int64
+	BundleId int
+	Metric   AnomalyMetric
+	// Keyword or category of place metrics, empty for meta metrics
+	Type string
+	Date time.Time
+	// Observed value
+	Value float64
+	// Change from previous observation
+	Change float64
+	// Median of previous changes
+	Baseline float64
+	// Robust z-score of change
+	Score      float64
+	Status     AnomalyStatus
+	DetectedAt time.Time
+}
+
+// Converts DBO to *Anomaly or *model.Anomaly
+func (a Anomaly) To(to interface{}) error {
+	switch v := to.(type) {
+	case *Anomaly:
+		*v = a
+	case *model.Anomaly:
+		v.ID = int(a.Id)
+		v.BundleID = a.BundleId
+		v.Metric = model.AnomalyMetric(strings.ToUpper(string(a.Metric)))
+		v.Type = a.Type
+		v.Date = a.Date
+		v.Value = a.Value
+		v.Change = a.Change
+		v.Baseline = a.Baseline
+		v.Score = a.Score
+		v.Status = model.AnomalyStatus(strings.ToUpper(string(a.Status)))
+		v.DetectedAt = a.DetectedAt
+	default:
+		return fmt.Errorf("%s", "param 'to' not the same type with *Anomaly")
+	}
+
+	return nil
+}
+
+// TrackAnomalies detects abnormal changes of places by every keyword or category
+// of tracks. Metric should be AnomalyKeyword or AnomalyCategory
+func TrackAnomalies(metric AnomalyMetric, tracks []Track) []Anomaly {
+	byType := make(map[string][]Track)
+	var types []string
+	for _, tr := range tracks {
+		if _, ok := byType[tr.Type]; !ok {
+			types = append(types, tr.Type)
+		}
+		byType[tr.Type] = append(byType[tr.Type], tr)
+	}
+	sort.Strings(types)
+
+	var anomalies []Anomaly
+	for _, typ := range types {
+		rows := byType[typ]
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i].Date.Before(rows[j].Date)
+		})
+		points := make([]anomaly.Point, len(rows))
+		for i, tr := range rows {
+			points[i] = anomaly.Point{Date: tr.Date, Value: float64(tr.Place)}
+		}
+		anomalies = append(anomalies, detect(rows[0].BundleId, metric, typ, points)...)
+	}
+
+	return anomalies
+}
+
+// MetaAnomalies detects abnormal changes of rating and review count of meta
+// snapshots. Snapshots without parsed values are skipped
+func MetaAnomalies(metas []Meta) []Anomaly {
+	if len(metas) == 0 {
+		return nil
+	}
+	sorted := make([]Meta, len(metas))
+	copy(sorted, metas)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	var rating, reviews []anomaly.Point
+	for _, m := range sorted {
+		if m.RatingValue != nil {
+			rating = append(rating, anomaly.Point{Date: m.Date, Value: *m.RatingValue})
+		}
+		if m.ReviewCountValue != nil {
+			reviews = append(reviews, anomaly.Point{Date: m.Date, Value: float64(*m.ReviewCountValue)})
+		}
+	}
+
+	bundleId := sorted[0].BundleId
+	return append(detect(bundleId, AnomalyRating, "", rating), detect(bundleId, AnomalyReviewCount, "", reviews)...)
+}
+
+// detect returns anomalies of changes of series
+func detect(bundleId int, metric AnomalyMetric, typ string, points []anomaly.Point) []Anomaly {
+	values := make(map[time.Time]float64, len(points))
+	for _, p := range points {
+		values[p.Date] = p.Value
+	}
+
+	var anomalies []Anomaly
+	for _, a := range anomaly.Detect(anomaly.Changes(points), anomalyOptions[metric]) {
+		anomalies = append(anomalies, Anomaly{
+			BundleId: bundleId,
+			Metric:   metric,
+			Type:     typ,
+			Date:     a.Date,
+			Value:    values[a.Date],
+			Change:   a.Value,
+			Baseline: a.Baseline,
+			Score:    a.Score,
+			Status:   AnomalyNew,
+		})
+	}
+
+	return anomalies
+}
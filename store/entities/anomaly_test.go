@@ -0,0 +1,68 @@
+package entities_test
+
+import (
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTrackAnomalies_ShouldDetectJumpsByEveryType(t *testing.T) {
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	var tracks []entities.Track
+	bank := []int32{10, 11, 10, 12, 11, 10, 11, 40, 41}
+	for i, place := range bank {
+		tracks = append(tracks, entities.Track{BundleId: 7, Type: "bank", Place: place, Date: day.AddDate(0, 0, i)})
+		// Places of other keyword are stable and have no anomalies
+		tracks = append(tracks, entities.Track{BundleId: 7, Type: "loan", Place: 5, Date: day.AddDate(0, 0, i)})
+	}
+
+	anomalies := entities.TrackAnomalies(entities.AnomalyKeyword, tracks)
+	assert.Len(t, anomalies, 1)
+	assert.Equal(t, entities.Anomaly{
+		BundleId: 7,
+		Metric:   entities.AnomalyKeyword,
+		Type:     "bank",
+		Date:     day.AddDate(0, 0, 7),
+		Value:    40,
+		Change:   29,
+		Baseline: 0,
+		Score:    anomalies[0].Score,
+		Status:   entities.AnomalyNew,
+	}, anomalies[0])
+	assert.True(t, anomalies[0].Score > 3.5)
+}
+
+func TestMetaAnomalies_ShouldDetectJumpsOfRatingAndReviews(t *testing.T) {
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	ratings := []float64{4.5, 4.5, 4.5, 4.5, 4.5, 4.5, 4.5, 3.9}
+	var metas []entities.Meta
+	for i := len(ratings) - 1; i >= 0; i-- {
+		rating, reviews := ratings[i], int64(100+i)
+		metas = append(metas, entities.Meta{BundleId: 7, Date: day.AddDate(0, 0, i), RatingValue: &rating, ReviewCountValue: &reviews})
+	}
+	// Snapshot without parsed values is skipped
+	metas = append(metas, entities.Meta{BundleId: 7, Date: day.AddDate(0, 0, 8)})
+
+	anomalies := entities.MetaAnomalies(metas)
+	assert.Len(t, anomalies, 1)
+	assert.Equal(t, entities.AnomalyRating, anomalies[0].Metric)
+	assert.Equal(t, 3.9, anomalies[0].Value)
+	assert.InDelta(t, -0.6, anomalies[0].Change, 1e-9)
+	assert.Empty(t, entities.MetaAnomalies(nil))
+}
+
+func TestAnomaly_To(t *testing.T) {
+	date := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	a := entities.Anomaly{Id: 3, BundleId: 7, Metric: entities.AnomalyReviewCount, Date: date, Value: 900, Change: 800, Score: 12, Status: entities.AnomalyConfirmed}
+
+	m := &model.Anomaly{}
+	assert.NoError(t, a.To(m))
+	assert.Equal(t, 3, m.ID)
+	assert.Equal(t, model.AnomalyMetricReviewCount, m.Metric)
+	assert.Equal(t, model.AnomalyStatusConfirmed, m.Status)
+	assert.Equal(t, 800.0, m.Change)
+
+	assert.Error(t, a.To(&model.App{}))
+}
@@ -3,9 +3,27 @@ package entities
 import (
 	"Muromachi/graph/model"
 	"fmt"
+	"strings"
 	"time"
 )
 
+// Status of app tracking
+type AppStatus string
+
+const (
+	AppActive  AppStatus = "active"
+	AppPaused  AppStatus = "paused"
+	AppStopped AppStatus = "stopped"
+)
+
+// Store where app is published
+type AppStore string
+
+const (
+	StorePlay     AppStore = "play"
+	StoreAppStore AppStore = "app_store"
+)
+
 // Representation of app in db
 type App struct {
 	Id          int       `json:"-"`
@@ -16,6 +34,19 @@ type App struct {
 	Geo         string    `json:"geo,omitempty"`
 	StartAt     time.Time `json:"start_at,omitempty"`
 	Period      uint32    `json:"period,omitempty"`
+	Status      AppStatus `json:"status,omitempty"`
+	// Id of client which registered app, zero for apps added by sql
+	OwnerId int `json:"owner_id,omitempty"`
+	// Store of app, empty store means Google Play
+	Store AppStore `json:"store,omitempty"`
+}
+
+// StoreOrDefault returns store of app, apps without store are from Google Play
+func (a App) StoreOrDefault() AppStore {
+	if a.Store == "" {
+		return StorePlay
+	}
+	return a.Store
 }
 
 // Converts DBO to *App or *model.App
@@ -32,9 +63,60 @@ func (a App) To(to interface{}) error {
 		v.Geo = a.Geo
 		v.StartAt = a.StartAt
 		v.Period = int(a.Period)
+		v.Status = model.TrackingStatus(strings.ToUpper(string(a.Status)))
+		v.Store = model.Store(strings.ToUpper(string(a.StoreOrDefault())))
 	default:
 		return fmt.Errorf("%s", "param 'to' not the same type with *App")
 	}
 
 	return nil
 }
+
+// Filter of tracked apps. Empty fields are not used
+type AppFilter struct {
+	// Part of bundle, case insensitive
+	Bundle string
+	// Part of developer name, case insensitive
+	Developer   string
+	DeveloperId string
+	Category    string
+	Geo         string
+	// Apps which tracking was started at this time or later
+	StartedAfter time.Time
+	Status       AppStatus
+	Store        AppStore
+	// Client which added app
+	OwnerId int
+}
+
+// Field of apps ordering
+type AppOrderField string
+
+const (
+	AppOrderId      AppOrderField = "id"
+	AppOrderBundle  AppOrderField = "bundle"
+	AppOrderStartAt AppOrderField = "startat"
+)
+
+// Order of apps, ties are always resolved by id
+type AppOrder struct {
+	Field AppOrderField
+	Desc  bool
+}
+
+// Position of app in ordered list. Only value of order field is used
+type AppCursor struct {
+	Id      int
+	Bundle  string
+	StartAt time.Time
+}
+
+// Request of single page of apps
+type AppQuery struct {
+	Filter AppFilter
+	Order  AppOrder
+	// Select apps which are after cursor in given order
+	After *AppCursor
+	// Max count of apps in page
+	Limit int
+}
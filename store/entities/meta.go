@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/jackc/pgtype"
 	"time"
+	"unicode/utf8"
 )
 
 // Meta information representation in db
@@ -32,14 +33,27 @@ type Meta struct {
 	PrivacyPolicy     string            `json:"privacyPolicy,omitempty"`
 	Date              time.Time         `json:"date,omitempty"`
 	App               App               `json:"app,omitempty"`
+	// Fields of App Store pages
+	IosVersion       string   `json:"iosVersion,omitempty" db:"ios_version"`
+	SupportedDevices []string `json:"supportedDevices,omitempty" db:"supported_devices"`
+	InAppPurchases   []string `json:"inAppPurchases,omitempty" db:"in_app_purchases"`
+	AgeRating        string   `json:"ageRating,omitempty" db:"age_rating"`
+	// Numeric values of store strings, they are filled by Normalize
+	RatingValue           *float64 `json:"-" db:"rating_value"`
+	ReviewCountValue      *int64   `json:"-" db:"review_count_value"`
+	InstallsMin           *int64   `json:"-" db:"installs_min"`
+	InstallsMax           *int64   `json:"-" db:"installs_max"`
+	SizeBytes             *int64   `json:"-" db:"size_bytes"`
+	RatingHistogramValues []int64  `json:"-" db:"rating_histogram_values"`
 }
 
-// Convert DBo to *Meta or *model.Meta
+// Convert DBo to *Meta, *model.PlayMeta, *model.AppStoreMeta or *model.Meta.
+// Implementation of *model.Meta is chosen by store of app
 func (m Meta) To(to interface{}) error {
 	switch v := to.(type) {
 	case *Meta:
 		*v = m
-	case *model.Meta:
+	case *model.PlayMeta:
 		v.ID = m.Id
 		v.BundleID = m.BundleId
 		v.Title = m.Title
@@ -49,6 +63,10 @@ func (m Meta) To(to interface{}) error {
 		v.Rating = m.Rating
 		v.ReviewCount = m.ReviewCount
 		v.RatingHistogram = m.RatingHistogram
+		v.RatingValue = m.RatingValue
+		v.ReviewCountValue = toInt(m.ReviewCountValue)
+		v.RatingHistogramValues = toInts(m.RatingHistogramValues)
+		v.SizeBytes = toInt(m.SizeBytes)
 		v.Description = m.Description
 		v.ShortDescription = m.ShortDescription
 		v.RecentChanges = m.RecentChanges
@@ -56,18 +74,57 @@ func (m Meta) To(to interface{}) error {
 		v.LastUpdateDate = m.LastUpdateDate
 		v.Appsize = m.AppSize
 		v.Installs = m.Installs
+		v.InstallsMin = toInt(m.InstallsMin)
+		v.InstallsMax = toInt(m.InstallsMax)
 		v.Version = m.Version
 		v.OsVersion = m.AndroidVersion
 		v.ContentRating = m.ContentRating
-		v.DevContacts = &model.DeveloperContacts{
-			Email:    m.DeveloperContacts.Email,
-			Contacts: m.DeveloperContacts.Email,
-		}
+		v.DevContacts = m.DeveloperContacts.model()
 		v.PrivacyPolicy = m.PrivacyPolicy
 		v.Date = m.Date
 		v.App = &model.App{}
 
 		return m.App.To(v.App)
+	case *model.AppStoreMeta:
+		v.ID = m.Id
+		v.BundleID = m.BundleId
+		v.Title = m.Title
+		v.Price = m.Price
+		v.Picture = m.Picture
+		v.Screenshots = m.Screenshots
+		v.Rating = m.Rating
+		v.ReviewCount = m.ReviewCount
+		v.RatingHistogram = m.RatingHistogram
+		v.RatingValue = m.RatingValue
+		v.ReviewCountValue = toInt(m.ReviewCountValue)
+		v.RatingHistogramValues = toInts(m.RatingHistogramValues)
+		v.SizeBytes = toInt(m.SizeBytes)
+		v.Description = m.Description
+		v.ShortDescription = m.ShortDescription
+		v.RecentChanges = m.RecentChanges
+		v.ReleaseDate = m.ReleaseDate
+		v.LastUpdateDate = m.LastUpdateDate
+		v.Appsize = m.AppSize
+		v.Version = m.Version
+		v.IosVersion = m.IosVersion
+		v.SupportedDevices = nonNil(m.SupportedDevices)
+		v.InAppPurchases = nonNil(m.InAppPurchases)
+		v.AgeRating = m.AgeRating
+		v.DevContacts = m.DeveloperContacts.model()
+		v.PrivacyPolicy = m.PrivacyPolicy
+		v.Date = m.Date
+		v.App = &model.App{}
+
+		return m.App.To(v.App)
+	case *model.Meta:
+		if m.App.StoreOrDefault() == StoreAppStore {
+			meta := &model.AppStoreMeta{}
+			*v = meta
+			return m.To(meta)
+		}
+		meta := &model.PlayMeta{}
+		*v = meta
+		return m.To(meta)
 	default:
 		return fmt.Errorf("%s", "param 'to' not the same type with *Meta")
 	}
@@ -75,12 +132,46 @@ func (m Meta) To(to interface{}) error {
 	return nil
 }
 
+// nonNil returns empty slice instead of nil for non null list fields
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// toInt converts nullable number to graphql int
+func toInt(n *int64) *int {
+	if n == nil {
+		return nil
+	}
+	v := int(*n)
+	return &v
+}
+
+// toInts converts numbers to graphql ints
+func toInts(numbers []int64) []int {
+	ints := make([]int, len(numbers))
+	for i, n := range numbers {
+		ints[i] = int(n)
+	}
+	return ints
+}
+
 // Developer contacts struct
 type DeveloperContacts struct {
 	Email    string `json:"email,omitempty"`
 	Contacts string `json:"contacts,omitempty"`
 }
 
+// model converts contacts to graphql model
+func (c DeveloperContacts) model() *model.DeveloperContacts {
+	return &model.DeveloperContacts{
+		Email:    c.Email,
+		Contacts: c.Contacts,
+	}
+}
+
 // Decode to binary representation
 func (dest *DeveloperContacts) DecodeBinary(ci *pgtype.ConnInfo, src []byte) error {
 	if src == nil {
@@ -101,3 +192,45 @@ func (src DeveloperContacts) EncodeBinary(ci *pgtype.ConnInfo, buf []byte) (newB
 
 	return (pgtype.CompositeFields{&email, &contacts}).EncodeBinary(ci, buf)
 }
+
+// Validate checks that meta can be saved to meta table
+func (m Meta) Validate() error {
+	if m.BundleId <= 0 {
+		return fmt.Errorf("%s", "bundle id should be positive")
+	}
+	if m.Date.IsZero() {
+		return fmt.Errorf("%s", "date is required")
+	}
+
+	// Max lengths of varchar columns
+	type limit struct {
+		name  string
+		value string
+		max   int
+	}
+	limits := []limit{
+		{"title", m.Title, 300},
+		{"price", m.Price, 50},
+		{"rating", m.Rating, 50},
+		{"reviewCount", m.ReviewCount, 50},
+		{"releaseDate", m.ReleaseDate, 50},
+		{"lastUpdateDate", m.LastUpdateDate, 50},
+		{"appSize", m.AppSize, 50},
+		{"installs", m.Installs, 50},
+		{"version", m.Version, 100},
+		{"androidVersion", m.AndroidVersion, 100},
+		{"contentRating", m.ContentRating, 100},
+		{"iosVersion", m.IosVersion, 100},
+		{"ageRating", m.AgeRating, 50},
+	}
+	for _, v := range m.RatingHistogram {
+		limits = append(limits, limit{"ratingHistogram", v, 50})
+	}
+	for _, l := range limits {
+		if utf8.RuneCountInString(l.value) > l.max {
+			return fmt.Errorf("%s should not be longer than %d symbols", l.name, l.max)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package entities
+
+import (
+	"Muromachi/graph/model"
+	"Muromachi/textdiff"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Field of meta snapshot, values are names of meta_tracking columns
+type MetaField string
+
+const (
+	MetaTitle             MetaField = "title"
+	MetaPrice             MetaField = "price"
+	MetaPicture           MetaField = "picture"
+	MetaScreenshots       MetaField = "screenshots"
+	MetaRating            MetaField = "rating"
+	MetaReviewCount       MetaField = "review_count"
+	MetaRatingHistogram   MetaField = "rating_histogram"
+	MetaDescription       MetaField = "description"
+	MetaShortDescription  MetaField = "short_description"
+	MetaRecentChanges     MetaField = "recent_changes"
+	MetaReleaseDate       MetaField = "release_date"
+	MetaLastUpdateDate    MetaField = "last_update_date"
+	MetaAppSize           MetaField = "app_size"
+	MetaInstalls          MetaField = "installs"
+	MetaVersion           MetaField = "version"
+	MetaAndroidVersion    MetaField = "android_version"
+	MetaContentRating     MetaField = "content_rating"
+	MetaDeveloperContacts MetaField = "developer_contacts"
+	MetaPrivacyPolicy     MetaField = "privacy_policy"
+	MetaIosVersion        MetaField = "ios_version"
+	MetaSupportedDevices  MetaField = "supported_devices"
+	MetaInAppPurchases    MetaField = "in_app_purchases"
+	MetaAgeRating         MetaField = "age_rating"
+)
+
+// All fields of meta in order of columns
+var MetaFields = []MetaField{
+	MetaTitle, MetaPrice, MetaPicture, MetaScreenshots, MetaRating, MetaReviewCount,
+	MetaRatingHistogram, MetaDescription, MetaShortDescription, MetaRecentChanges,
+	MetaReleaseDate, MetaLastUpdateDate, MetaAppSize, MetaInstalls, MetaVersion,
+	MetaAndroidVersion, MetaContentRating, MetaDeveloperContacts, MetaPrivacyPolicy,
+	MetaIosVersion, MetaSupportedDevices, MetaInAppPurchases, MetaAgeRating,
+}
+
+// Long text fields which changes are described with line diff
+var diffFields = map[MetaField]bool{
+	MetaDescription:   true,
+	MetaRecentChanges: true,
+}
+
+// Value returns field of meta as string, list values are joined by new line
+func (m Meta) Value(field MetaField) (string, error) {
+	switch field {
+	case MetaTitle:
+		return m.Title, nil
+	case MetaPrice:
+		return m.Price, nil
+	case MetaPicture:
+		return m.Picture, nil
+	case MetaScreenshots:
+		return strings.Join(m.Screenshots, "\n"), nil
+	case MetaRating:
+		return m.Rating, nil
+	case MetaReviewCount:
+		return m.ReviewCount, nil
+	case MetaRatingHistogram:
+		return strings.Join(m.RatingHistogram, "\n"), nil
+	case MetaDescription:
+		return m.Description, nil
+	case MetaShortDescription:
+		return m.ShortDescription, nil
+	case MetaRecentChanges:
+		return m.RecentChanges, nil
+	case MetaReleaseDate:
+		return m.ReleaseDate, nil
+	case MetaLastUpdateDate:
+		return m.LastUpdateDate, nil
+	case MetaAppSize:
+		return m.AppSize, nil
+	case MetaInstalls:
+		return m.Installs, nil
+	case MetaVersion:
+		return m.Version, nil
+	case MetaAndroidVersion:
+		return m.AndroidVersion, nil
+	case MetaContentRating:
+		return m.ContentRating, nil
+	case MetaDeveloperContacts:
+		return m.DeveloperContacts.Email + "\n" + m.DeveloperContacts.Contacts, nil
+	case MetaPrivacyPolicy:
+		return m.PrivacyPolicy, nil
+	case MetaIosVersion:
+		return m.IosVersion, nil
+	case MetaSupportedDevices:
+		return strings.Join(m.SupportedDevices, "\n"), nil
+	case MetaInAppPurchases:
+		return strings.Join(m.InAppPurchases, "\n"), nil
+	case MetaAgeRating:
+		return m.AgeRating, nil
+	}
+	return "", fmt.Errorf("unknown meta field %q", field)
+}
+
+// Change of one field between two consecutive meta snapshots
+type MetaChange struct {
+	BundleId int       `json:"bundleId"`
+	Field    MetaField `json:"field"`
+	// Date of snapshot with new value
+	Date time.Time `json:"date"`
+	// Date of previous snapshot
+	PreviousDate time.Time `json:"previousDate"`
+	Old          string    `json:"old"`
+	New          string    `json:"new"`
+	// Line diff of long text fields
+	Diff []textdiff.Line `json:"diff,omitempty"`
+}
+
+// Converts DBO to *MetaChange or *model.MetaChange
+func (c MetaChange) To(to interface{}) error {
+	switch v := to.(type) {
+	case *MetaChange:
+		*v = c
+	case *model.MetaChange:
+		v.BundleID = c.BundleId
+		v.Field = model.MetaField(strings.ToUpper(string(c.Field)))
+		v.Date = c.Date
+		v.PreviousDate = c.PreviousDate
+		v.OldValue = c.Old
+		v.NewValue = c.New
+		v.Diff = nil
+		if c.Diff != nil {
+			v.Diff = make([]*model.DiffLine, len(c.Diff))
+			for i, line := range c.Diff {
+				v.Diff[i] = &model.DiffLine{Op: model.DiffOp(strings.ToUpper(string(line.Op))), Text: line.Text}
+			}
+		}
+	default:
+		return fmt.Errorf("%s", "param 'to' not the same type with *MetaChange")
+	}
+
+	return nil
+}
+
+// MetaChanges compares consecutive snapshots of one app ordered by date and
+// returns changes of given fields. Empty fields means all fields of meta
+func MetaChanges(snapshots []Meta, fields []MetaField) (DboSlice, error) {
+	if len(fields) == 0 {
+		fields = MetaFields
+	}
+
+	sorted := make([]Meta, len(snapshots))
+	copy(sorted, snapshots)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Date.Equal(sorted[j].Date) {
+			return sorted[i].Id < sorted[j].Id
+		}
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	changes := DboSlice{}
+	for i := 1; i < len(sorted); i++ {
+		prev, next := sorted[i-1], sorted[i]
+		for _, field := range fields {
+			old, err := prev.Value(field)
+			if err != nil {
+				return nil, err
+			}
+			value, _ := next.Value(field)
+			if old == value {
+				continue
+			}
+
+			change := MetaChange{
+				BundleId:     next.BundleId,
+				Field:        field,
+				Date:         next.Date,
+				PreviousDate: prev.Date,
+				Old:          old,
+				New:          value,
+			}
+			if diffFields[field] {
+				change.Diff = textdiff.Lines(old, value)
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
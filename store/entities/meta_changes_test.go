@@ -0,0 +1,37 @@
+package entities_test
+
+import (
+	"Muromachi/store/entities"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMetaChanges_ShouldCompareConsecutiveSnapshots(t *testing.T) {
+	date, _ := time.Parse("2006-01-02", "2021-01-18")
+	first := entities.Meta{Id: 1, BundleId: 1, Title: "Bank", Screenshots: []string{"a", "b"}, Date: date}
+	second := first
+	second.Id, second.Date, second.Screenshots = 2, date.AddDate(0, 0, 1), []string{"a", "c"}
+	third := second
+	third.Id, third.Date, third.Title = 3, date.AddDate(0, 0, 2), "Bank Pro"
+
+	dbo, err := entities.MetaChanges([]entities.Meta{third, first, second}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, dbo, 2)
+
+	var change entities.MetaChange
+	assert.NoError(t, dbo[0].To(&change))
+	assert.Equal(t, entities.MetaScreenshots, change.Field)
+	assert.Equal(t, "a\nb", change.Old)
+	assert.Equal(t, "a\nc", change.New)
+	assert.True(t, second.Date.Equal(change.Date))
+
+	assert.NoError(t, dbo[1].To(&change))
+	assert.Equal(t, entities.MetaTitle, change.Field)
+	assert.Equal(t, "Bank Pro", change.New)
+}
+
+func TestMetaChanges_ShouldReturnErrorForUnknownField(t *testing.T) {
+	_, err := entities.MetaChanges([]entities.Meta{{}, {}}, []entities.MetaField{"color"})
+	assert.Error(t, err)
+}
@@ -4,7 +4,9 @@ import (
 	"Muromachi/graph/model"
 	"Muromachi/store/entities"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDBO_MetaShouldGiveAReferenceOfValues(t *testing.T) {
@@ -18,7 +20,7 @@ func TestDBO_MetaShouldGiveAReferenceOfValues(t *testing.T) {
 }
 
 func TestDBO_MetaShouldGiveAReferenceOfValuesToGraphqlModel(t *testing.T) {
-	meta := &model.Meta{}
+	meta := &model.PlayMeta{}
 
 	dboMeta := entities.Meta{Id: 10, App: entities.App{Bundle: "123"}}
 	err := dboMeta.To(meta)
@@ -26,6 +28,39 @@ func TestDBO_MetaShouldGiveAReferenceOfValuesToGraphqlModel(t *testing.T) {
 
 	assert.Equal(t, 10, meta.ID)
 	assert.Equal(t, "123", meta.App.Bundle)
+	assert.Equal(t, model.StorePlay, meta.App.Store)
+}
+
+func TestDBO_MetaShouldChooseGraphqlModelByStoreOfApp(t *testing.T) {
+	var meta model.Meta
+
+	err := entities.Meta{Id: 10, AndroidVersion: "9.0"}.To(&meta)
+	assert.NoError(t, err)
+	play, ok := meta.(*model.PlayMeta)
+	assert.True(t, ok)
+	assert.Equal(t, "9.0", play.OsVersion)
+
+	err = entities.Meta{
+		Id:               11,
+		IosVersion:       "14.0",
+		SupportedDevices: []string{"iPhone", "iPad"},
+		AgeRating:        "4+",
+		DeveloperContacts: entities.DeveloperContacts{
+			Email:    "email@email.com",
+			Contacts: "virginia",
+		},
+		App: entities.App{Bundle: "123", Store: entities.StoreAppStore},
+	}.To(&meta)
+	assert.NoError(t, err)
+	ios, ok := meta.(*model.AppStoreMeta)
+	assert.True(t, ok)
+	assert.Equal(t, 11, ios.ID)
+	assert.Equal(t, "14.0", ios.IosVersion)
+	assert.Equal(t, []string{"iPhone", "iPad"}, ios.SupportedDevices)
+	assert.Equal(t, []string{}, ios.InAppPurchases)
+	assert.Equal(t, "4+", ios.AgeRating)
+	assert.Equal(t, "virginia", ios.DevContacts.Contacts)
+	assert.Equal(t, model.StoreAppStore, ios.App.Store)
 }
 
 func TestDBO_Meta_ShouldReturnErrorIfWrongReference(t *testing.T) {
@@ -36,3 +71,28 @@ func TestDBO_Meta_ShouldReturnErrorIfWrongReference(t *testing.T) {
 	assert.Error(t, err)
 }
 
+
+func TestMeta_Validate(t *testing.T) {
+	date := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, entities.Meta{BundleId: 1, Date: date, Title: strings.Repeat("я", 300)}.Validate())
+	assert.Error(t, entities.Meta{Date: date}.Validate())
+	assert.Error(t, entities.Meta{BundleId: 1}.Validate())
+	assert.EqualError(t, entities.Meta{BundleId: 1, Date: date, Title: strings.Repeat("я", 301)}.Validate(), "title should not be longer than 300 symbols")
+	assert.Error(t, entities.Meta{BundleId: 1, Date: date, RatingHistogram: []string{"1", strings.Repeat("1", 51)}}.Validate())
+}
+
+func TestDBO_MetaShouldGiveNumbersToGraphqlModel(t *testing.T) {
+	meta := &model.PlayMeta{}
+	dboMeta := entities.Meta{Id: 10, Rating: "4.6", Installs: "100+", AppSize: "Varies with device"}
+	dboMeta.Normalize()
+
+	err := dboMeta.To(meta)
+	assert.NoError(t, err)
+	assert.Equal(t, 4.6, *meta.RatingValue)
+	assert.Equal(t, 100, *meta.InstallsMin)
+	assert.Equal(t, 500, *meta.InstallsMax)
+	assert.Nil(t, meta.SizeBytes)
+	assert.Nil(t, meta.ReviewCountValue)
+	assert.Equal(t, []int{}, meta.RatingHistogramValues)
+}
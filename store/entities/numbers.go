@@ -0,0 +1,166 @@
+package entities
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// Decimal number with point or comma like 4.5 or 4,5
+	decimalPattern = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+	// Count with short suffix like 1.2K, 3M or 1 234
+	shortCountPattern = regexp.MustCompile(`(?i)^(\d+(?:[.,]\d+)?)\s*([kmb])\b`)
+	// Size with unit like 23M, 1.2 GB or 512k
+	sizePattern = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*([kmg])i?b?\b`)
+)
+
+// Multipliers of count suffixes
+var countSuffixes = map[string]float64{"k": 1e3, "m": 1e6, "b": 1e9}
+
+// Multipliers of size units, stores report sizes in binary units
+var sizeUnits = map[string]float64{"k": 1 << 10, "m": 1 << 20, "g": 1 << 30}
+
+// Normalize parses numeric values of store strings. Values which can not be
+// parsed are left nil, so "Varies with device" size is unknown rather than zero
+func (m *Meta) Normalize() {
+	m.RatingValue = parseRating(m.Rating)
+	m.ReviewCountValue = parseCount(m.ReviewCount)
+	m.InstallsMin, m.InstallsMax = parseInstalls(m.Installs)
+	m.SizeBytes = parseSize(m.AppSize)
+	m.RatingHistogramValues = parseHistogram(m.RatingHistogram)
+}
+
+// parseRating parses rating like 4.5 or 4,5 out of 5
+func parseRating(s string) *float64 {
+	number := decimalPattern.FindString(s)
+	if number == "" {
+		return nil
+	}
+	rating, err := strconv.ParseFloat(strings.Replace(number, ",", ".", 1), 64)
+	if err != nil {
+		return nil
+	}
+
+	return &rating
+}
+
+// parseCount parses count like 1,234,567, 1 234 567 or 1.2K
+func parseCount(s string) *int64 {
+	s = strings.TrimSpace(s)
+	if match := shortCountPattern.FindStringSubmatch(s); match != nil {
+		value, err := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+		if err != nil {
+			return nil
+		}
+		count := int64(math.Round(value * countSuffixes[strings.ToLower(match[2])]))
+		return &count
+	}
+
+	// Digits of count are split by commas, points or spaces of any locale
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ',' || r == '.' || unicode.IsSpace(r) || r == ' ':
+			if digits.Len() == 0 {
+				return nil
+			}
+		default:
+			if digits.Len() > 0 {
+				return toCount(digits.String())
+			}
+			if !unicode.IsLetter(r) && r != '+' {
+				return nil
+			}
+		}
+	}
+
+	return toCount(digits.String())
+}
+
+// toCount converts digits to count
+func toCount(digits string) *int64 {
+	count, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &count
+}
+
+// parseInstalls parses bounds of installs. Google Play shows lower bound like
+// 1,000,000+ and upper bound is the next threshold of 1, 5, 10, 50... series.
+// Range like 1,000 - 5,000 gives both bounds and exact count gives equal ones
+func parseInstalls(s string) (min, max *int64) {
+	s = strings.TrimSpace(s)
+	if parts := strings.SplitN(s, "-", 2); len(parts) == 2 {
+		min, max = parseCount(parts[0]), parseCount(parts[1])
+		if min == nil || max == nil || *min > *max {
+			return nil, nil
+		}
+		return min, max
+	}
+
+	min = parseCount(s)
+	if min == nil {
+		return nil, nil
+	}
+	if !strings.HasSuffix(s, "+") {
+		return min, min
+	}
+	next := nextThreshold(*min)
+
+	return min, &next
+}
+
+// nextThreshold returns next value of 1, 5, 10, 50, 100... series
+func nextThreshold(n int64) int64 {
+	if n <= 0 {
+		return 1
+	}
+	step := int64(1)
+	for step*10 <= n {
+		step *= 10
+	}
+	if n < 5*step {
+		return 5 * step
+	}
+
+	return 10 * step
+}
+
+// parseSize parses size like 23M, 1.2 GB or 512k to bytes
+func parseSize(s string) *int64 {
+	match := sizePattern.FindStringSubmatch(s)
+	if match == nil {
+		return nil
+	}
+	value, err := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+	if err != nil {
+		return nil
+	}
+	size := int64(math.Round(value * sizeUnits[strings.ToLower(match[2])]))
+
+	return &size
+}
+
+// parseHistogram parses counts of ratings. Histogram is unknown if any
+// of counts can not be parsed, so positions of stars are kept
+func parseHistogram(values []string) []int64 {
+	if len(values) == 0 {
+		return nil
+	}
+	counts := make([]int64, len(values))
+	for i, v := range values {
+		count := parseCount(v)
+		if count == nil {
+			return nil
+		}
+		counts[i] = *count
+	}
+
+	return counts
+}
@@ -0,0 +1,91 @@
+package entities_test
+
+import (
+	"Muromachi/store/entities"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMeta_NormalizeShouldParseNumbersOfPlay(t *testing.T) {
+	meta := entities.Meta{
+		Rating:          "4.6",
+		ReviewCount:     "1,002,323",
+		Installs:        "1,000,000+",
+		AppSize:         "90M",
+		RatingHistogram: []string{"800,100", "120,000", "40,223", "12,000", "30,000"},
+	}
+	meta.Normalize()
+
+	assert.Equal(t, 4.6, *meta.RatingValue)
+	assert.Equal(t, int64(1002323), *meta.ReviewCountValue)
+	assert.Equal(t, int64(1000000), *meta.InstallsMin)
+	assert.Equal(t, int64(5000000), *meta.InstallsMax)
+	assert.Equal(t, int64(90*1024*1024), *meta.SizeBytes)
+	assert.Equal(t, []int64{800100, 120000, 40223, 12000, 30000}, meta.RatingHistogramValues)
+}
+
+func TestMeta_NormalizeShouldParseFormatsOfLocalesAndStores(t *testing.T) {
+	var tt = []struct {
+		name     string
+		meta     entities.Meta
+		rating   float64
+		reviews  int64
+		min, max int64
+		size     int64
+	}{
+		{
+			name:    "russian",
+			meta:    entities.Meta{Rating: "4,5", ReviewCount: "1 234 567", Installs: "50 000+", AppSize: "1,5G"},
+			rating:  4.5,
+			reviews: 1234567,
+			min:     50000,
+			max:     100000,
+			size:    1610612736,
+		},
+		{
+			name:    "app store",
+			meta:    entities.Meta{Rating: "4.7 out of 5", ReviewCount: "1.2K Ratings", Installs: "1,000 - 5,000", AppSize: "23.4 MB"},
+			rating:  4.7,
+			reviews: 1200,
+			min:     1000,
+			max:     5000,
+			size:    24536678,
+		},
+		{
+			name:    "exact installs",
+			meta:    entities.Meta{Rating: "5", ReviewCount: "12 ratings", Installs: "500", AppSize: "512k"},
+			rating:  5,
+			reviews: 12,
+			min:     500,
+			max:     500,
+			size:    512 * 1024,
+		},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			test.meta.Normalize()
+			assert.Equal(t, test.rating, *test.meta.RatingValue)
+			assert.Equal(t, test.reviews, *test.meta.ReviewCountValue)
+			assert.Equal(t, test.min, *test.meta.InstallsMin)
+			assert.Equal(t, test.max, *test.meta.InstallsMax)
+			assert.Equal(t, test.size, *test.meta.SizeBytes)
+		})
+	}
+}
+
+func TestMeta_NormalizeShouldLeaveUnknownValuesNil(t *testing.T) {
+	meta := entities.Meta{
+		Rating:          "no rating",
+		Installs:        "Varies",
+		AppSize:         "Varies with device",
+		RatingHistogram: []string{"10", "n/a", "1"},
+	}
+	meta.Normalize()
+
+	assert.Nil(t, meta.RatingValue)
+	assert.Nil(t, meta.ReviewCountValue)
+	assert.Nil(t, meta.InstallsMin)
+	assert.Nil(t, meta.InstallsMax)
+	assert.Nil(t, meta.SizeBytes)
+	assert.Nil(t, meta.RatingHistogramValues)
+}
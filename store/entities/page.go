@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"time"
+)
+
+// Position of tracking row in keyset pagination. Rows are
+// ordered by date and then by id
+type Cursor struct {
+	Date time.Time
+	Id   int
+}
+
+// Values returns date and id of cursor for query params.
+// Date is nil if cursor is nil
+func (c *Cursor) Values() (*time.Time, int) {
+	if c == nil {
+		return nil, 0
+	}
+	date := c.Date
+	return &date, c.Id
+}
+
+// Request of single page of tracking rows
+type Page struct {
+	// Max count of rows in page
+	Limit int
+	// Select rows which are after cursor
+	After *Cursor
+	// Select rows which are before cursor
+	Before *Cursor
+	// If true then page is taken from the end of rows which are
+	// matched by cursors, otherwise from the beginning
+	Backward bool
+	// Time range of rows, zero Start or End means unbounded range
+	Start time.Time
+	End   time.Time
+}
+
+// CursorOf returns position of tracking row
+func CursorOf(dbo DBO) (Cursor, bool) {
+	switch v := dbo.(type) {
+	case Meta:
+		return Cursor{Date: v.Date, Id: v.Id}, true
+	case Track:
+		return Cursor{Date: v.Date, Id: v.Id}, true
+	}
+	return Cursor{}, false
+}
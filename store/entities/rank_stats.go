@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"Muromachi/graph/model"
+	"fmt"
+	"time"
+)
+
+// Size of time bucket for aggregation, values are
+// accepted by postgres date_trunc
+type Bucket string
+
+const (
+	BucketHour  Bucket = "hour"
+	BucketDay   Bucket = "day"
+	BucketWeek  Bucket = "week"
+	BucketMonth Bucket = "month"
+)
+
+// Valid reports whether bucket is one of known sizes
+func (b Bucket) Valid() bool {
+	switch b {
+	case BucketHour, BucketDay, BucketWeek, BucketMonth:
+		return true
+	}
+	return false
+}
+
+// Statistics of keyword or category places within one time bucket
+type RankStats struct {
+	BundleId int `json:"bundleId"`
+	// Start of bucket
+	Bucket time.Time `json:"bucket"`
+	// Keyword or category
+	Type   string  `json:"type"`
+	Min    int32   `json:"min"`
+	Max    int32   `json:"max"`
+	Avg    float64 `json:"avg"`
+	Median float64 `json:"median"`
+	// Place of the earliest row in bucket
+	First int32 `json:"first"`
+	// Place of the latest row in bucket
+	Last int32 `json:"last"`
+	// Count of rows in bucket
+	Count int `json:"count"`
+}
+
+// Converts DBO to *RankStats or *model.RankStats
+func (r RankStats) To(to interface{}) error {
+	switch v := to.(type) {
+	case *RankStats:
+		*v = r
+	case *model.RankStats:
+		v.BundleID = r.BundleId
+		v.Bucket = r.Bucket
+		v.Type = r.Type
+		v.Min = int(r.Min)
+		v.Max = int(r.Max)
+		v.Avg = r.Avg
+		v.Median = r.Median
+		v.First = int(r.First)
+		v.Last = int(r.Last)
+		v.Count = r.Count
+	default:
+		return fmt.Errorf("%s", "param 'to' not the same type with *RankStats")
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package entities_test
+
+import (
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDBO_RankStatsShouldGiveAReferenceOfValuesToGraphqlModel(t *testing.T) {
+	dbo := entities.DboSlice{
+		entities.RankStats{Type: "bank", Min: 1, Max: 10, Avg: 5.5, Median: 5, First: 10, Last: 1, Count: 4},
+	}
+	stats := make([]*model.RankStats, 1)
+	assert.NoError(t, dbo.To(stats))
+
+	assert.Equal(t, "bank", stats[0].Type)
+	assert.Equal(t, 1, stats[0].Min)
+	assert.Equal(t, 10, stats[0].First)
+	assert.Equal(t, 4, stats[0].Count)
+}
+
+func TestDBO_RankStats_ShouldReturnErrorIfWrongReference(t *testing.T) {
+	stats := &model.Keywords{}
+	assert.Error(t, entities.RankStats{}.To(stats))
+}
+
+func TestBucket_Valid(t *testing.T) {
+	assert.True(t, entities.BucketWeek.Valid())
+	assert.False(t, entities.Bucket("WEEK").Valid())
+	assert.False(t, entities.Bucket("year").Valid())
+}
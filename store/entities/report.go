@@ -0,0 +1,118 @@
+package entities
+
+import (
+	"Muromachi/graph/model"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Period of digest report
+const ReportPeriod = time.Hour * 24 * 7
+
+// Weekly schedule of digest reports of client
+type ReportSchedule struct {
+	OwnerId int
+	// Day of week of generation
+	Weekday time.Weekday
+	// Hour of generation in UTC
+	Hour int
+	// Url which receives reports, empty url means reports are only stored
+	WebhookUrl string
+	// Key of HMAC signature of webhooks
+	Secret    string
+	Enabled   bool
+	NextRunAt time.Time
+	CreatedAt time.Time
+}
+
+// Converts DBO to *ReportSchedule or *model.ReportSchedule
+func (s ReportSchedule) To(to interface{}) error {
+	switch v := to.(type) {
+	case *ReportSchedule:
+		*v = s
+	case *model.ReportSchedule:
+		v.Weekday = int(s.Weekday)
+		v.Hour = s.Hour
+		v.WebhookURL = nil
+		if s.WebhookUrl != "" {
+			url := s.WebhookUrl
+			v.WebhookURL = &url
+		}
+		v.Secret = s.Secret
+		v.Enabled = s.Enabled
+		v.NextRunAt = s.NextRunAt
+	default:
+		return fmt.Errorf("%s", "param 'to' not the same type with *ReportSchedule")
+	}
+
+	return nil
+}
+
+// Validate checks day and hour of schedule
+func (s ReportSchedule) Validate() error {
+	if s.Weekday < time.Sunday || s.Weekday > time.Saturday {
+		return fmt.Errorf("%s", "weekday should be from 0 (Sunday) to 6 (Saturday)")
+	}
+	if s.Hour < 0 || s.Hour > 23 {
+		return fmt.Errorf("%s", "hour should be from 0 to 23")
+	}
+
+	return nil
+}
+
+// NextRun returns the first time of schedule which is after now
+func (s ReportSchedule) NextRun(now time.Time) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), s.Hour, 0, 0, 0, time.UTC)
+	next = next.AddDate(0, 0, (int(s.Weekday)-int(now.Weekday())+7)%7)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 7)
+	}
+
+	return next
+}
+
+// Generated digest report with its html and json bodies
+type Report struct {
+	Id      int64
+	OwnerId int
+	// Digest covers tracking rows from start until end
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Json        []byte
+	Html        string
+	CreatedAt   time.Time
+	// Status of webhook, empty status means report without webhook
+	Delivery       DeliveryStatus
+	Attempts       int
+	ResponseStatus int
+	LastError      string
+	NextAttemptAt  *time.Time
+	DeliveredAt    *time.Time
+}
+
+// Converts DBO to *Report or *model.Report
+func (r Report) To(to interface{}) error {
+	switch v := to.(type) {
+	case *Report:
+		*v = r
+	case *model.Report:
+		v.ID = int(r.Id)
+		v.PeriodStart = r.PeriodStart
+		v.PeriodEnd = r.PeriodEnd
+		v.CreatedAt = r.CreatedAt
+		v.Delivery = nil
+		if r.Delivery != "" {
+			status := model.DeliveryStatus(strings.ToUpper(string(r.Delivery)))
+			v.Delivery = &status
+		}
+		v.DeliveredAt = r.DeliveredAt
+		v.HTMLURL = fmt.Sprintf("/reports/%d/html", r.Id)
+		v.JSONURL = fmt.Sprintf("/reports/%d/json", r.Id)
+	default:
+		return fmt.Errorf("%s", "param 'to' not the same type with *Report")
+	}
+
+	return nil
+}
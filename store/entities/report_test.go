@@ -0,0 +1,64 @@
+package entities_test
+
+import (
+	"Muromachi/graph/model"
+	"Muromachi/store/entities"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReportSchedule_NextRun(t *testing.T) {
+	// Monday
+	now := time.Date(2021, 1, 18, 10, 30, 0, 0, time.UTC)
+
+	var tt = []struct {
+		name     string
+		weekday  time.Weekday
+		hour     int
+		expected time.Time
+	}{
+		{name: "later today", weekday: time.Monday, hour: 12, expected: time.Date(2021, 1, 18, 12, 0, 0, 0, time.UTC)},
+		{name: "earlier today", weekday: time.Monday, hour: 9, expected: time.Date(2021, 1, 25, 9, 0, 0, 0, time.UTC)},
+		{name: "this week", weekday: time.Friday, hour: 0, expected: time.Date(2021, 1, 22, 0, 0, 0, 0, time.UTC)},
+		{name: "next week", weekday: time.Sunday, hour: 8, expected: time.Date(2021, 1, 24, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			schedule := entities.ReportSchedule{Weekday: test.weekday, Hour: test.hour}
+			assert.Equal(t, test.expected, schedule.NextRun(now))
+		})
+	}
+
+	// Exact time of run is moved to the next week
+	schedule := entities.ReportSchedule{Weekday: time.Monday, Hour: 12}
+	assert.Equal(t, time.Date(2021, 1, 25, 12, 0, 0, 0, time.UTC), schedule.NextRun(time.Date(2021, 1, 18, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestReportSchedule_Validate(t *testing.T) {
+	assert.NoError(t, entities.ReportSchedule{Weekday: time.Saturday, Hour: 23}.Validate())
+	assert.Error(t, entities.ReportSchedule{Weekday: 7}.Validate())
+	assert.Error(t, entities.ReportSchedule{Weekday: time.Monday, Hour: 24}.Validate())
+}
+
+func TestReport_To(t *testing.T) {
+	end := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	report := entities.Report{Id: 5, PeriodStart: end.Add(-entities.ReportPeriod), PeriodEnd: end, Delivery: entities.DeliveryFailed}
+
+	m := &model.Report{}
+	assert.NoError(t, report.To(m))
+	assert.Equal(t, 5, m.ID)
+	assert.Equal(t, model.DeliveryStatusFailed, *m.Delivery)
+	assert.Equal(t, "/reports/5/html", m.HTMLURL)
+	assert.Equal(t, "/reports/5/json", m.JSONURL)
+
+	report.Delivery = ""
+	assert.NoError(t, report.To(m))
+	assert.Nil(t, m.Delivery)
+
+	schedule := &model.ReportSchedule{}
+	assert.NoError(t, entities.ReportSchedule{Weekday: time.Friday, Hour: 9, Secret: "s"}.To(schedule))
+	assert.Equal(t, 5, schedule.Weekday)
+	assert.Nil(t, schedule.WebhookURL)
+	assert.Error(t, report.To(schedule))
+}
@@ -0,0 +1,53 @@
+package entities
+
+import "time"
+
+// Granularity of rank history. Day and week resolutions return one row
+// per bucket with the latest place of bucket, values are accepted by postgres date_trunc
+type Resolution string
+
+const (
+	// Resolution is picked by width of time range
+	ResolutionAuto Resolution = ""
+	ResolutionRaw  Resolution = "raw"
+	ResolutionDay  Resolution = "day"
+	ResolutionWeek Resolution = "week"
+)
+
+const (
+	// Ranges wider than this are read by days
+	DayResolutionSpan = time.Hour * 24 * 90
+	// Ranges wider than this are read by weeks
+	WeekResolutionSpan = time.Hour * 24 * 365
+)
+
+// Valid reports whether resolution is auto or one of known resolutions
+func (r Resolution) Valid() bool {
+	switch r {
+	case ResolutionAuto, ResolutionRaw, ResolutionDay, ResolutionWeek:
+		return true
+	}
+	return false
+}
+
+// Pick returns r if it is set explicitly, otherwise the coarsest resolution suitable
+// for time range. Range without start is read raw, zero end means now
+func (r Resolution) Pick(start, end time.Time) Resolution {
+	if r != ResolutionAuto {
+		return r
+	}
+	if start.IsZero() {
+		return ResolutionRaw
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	switch span := end.Sub(start); {
+	case span > WeekResolutionSpan:
+		return ResolutionWeek
+	case span > DayResolutionSpan:
+		return ResolutionDay
+	}
+	return ResolutionRaw
+}
@@ -0,0 +1,35 @@
+package entities_test
+
+import (
+	"Muromachi/store/entities"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestResolution_Pick(t *testing.T) {
+	end := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, entities.ResolutionRaw, entities.ResolutionAuto.Pick(end.AddDate(0, 0, -30), end))
+	assert.Equal(t, entities.ResolutionRaw, entities.ResolutionAuto.Pick(end.AddDate(0, 0, -90), end))
+	assert.Equal(t, entities.ResolutionDay, entities.ResolutionAuto.Pick(end.AddDate(0, 0, -91), end))
+	assert.Equal(t, entities.ResolutionDay, entities.ResolutionAuto.Pick(end.AddDate(-1, 0, 0), end))
+	assert.Equal(t, entities.ResolutionWeek, entities.ResolutionAuto.Pick(end.AddDate(-2, 0, 0), end))
+	// Range without start is not aggregated
+	assert.Equal(t, entities.ResolutionRaw, entities.ResolutionAuto.Pick(time.Time{}, end))
+	// Range without end lasts until now
+	assert.Equal(t, entities.ResolutionWeek, entities.ResolutionAuto.Pick(time.Now().AddDate(-2, 0, 0), time.Time{}))
+}
+
+func TestResolution_PickShouldKeepExplicitResolution(t *testing.T) {
+	end := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, entities.ResolutionRaw, entities.ResolutionRaw.Pick(end.AddDate(-2, 0, 0), end))
+	assert.Equal(t, entities.ResolutionWeek, entities.ResolutionWeek.Pick(end.AddDate(0, 0, -1), end))
+}
+
+func TestResolution_Valid(t *testing.T) {
+	assert.True(t, entities.ResolutionAuto.Valid())
+	assert.True(t, entities.ResolutionDay.Valid())
+	assert.False(t, entities.Resolution("month").Valid())
+}
@@ -22,12 +22,32 @@ func (d DboSlice) To(to interface{}) error {
 			}
 			v[i] = app
 		}
-	case []*model.Meta:
+	case []model.Meta:
 		if len(v) != len(d) {
 			return fmt.Errorf("len of pointer 'to' not the same with len of DboSlice")
 		}
 		for i, value := range d {
-			meta := &model.Meta{}
+			if err := value.To(&v[i]); err != nil {
+				return err
+			}
+		}
+	case []*model.PlayMeta:
+		if len(v) != len(d) {
+			return fmt.Errorf("len of pointer 'to' not the same with len of DboSlice")
+		}
+		for i, value := range d {
+			meta := &model.PlayMeta{}
+			if err := value.To(meta); err != nil {
+				return err
+			}
+			v[i] = meta
+		}
+	case []*model.AppStoreMeta:
+		if len(v) != len(d) {
+			return fmt.Errorf("len of pointer 'to' not the same with len of DboSlice")
+		}
+		for i, value := range d {
+			meta := &model.AppStoreMeta{}
 			if err := value.To(meta); err != nil {
 				return err
 			}
@@ -55,9 +75,82 @@ func (d DboSlice) To(to interface{}) error {
 			}
 			v[i] = key
 		}
+	case []*model.RankStats:
+		if len(v) != len(d) {
+			return fmt.Errorf("len of pointer 'to' not the same with len of DboSlice")
+		}
+		for i, value := range d {
+			stats := &model.RankStats{}
+			if err := value.To(stats); err != nil {
+				return err
+			}
+			v[i] = stats
+		}
+	case []*model.MetaChange:
+		if len(v) != len(d) {
+			return fmt.Errorf("len of pointer 'to' not the same with len of DboSlice")
+		}
+		for i, value := range d {
+			change := &model.MetaChange{}
+			if err := value.To(change); err != nil {
+				return err
+			}
+			v[i] = change
+		}
+	case []*model.AlertRule:
+		if len(v) != len(d) {
+			return fmt.Errorf("len of pointer 'to' not the same with len of DboSlice")
+		}
+		for i, value := range d {
+			rule := &model.AlertRule{}
+			if err := value.To(rule); err != nil {
+				return err
+			}
+			v[i] = rule
+		}
+	case []*model.AlertDelivery:
+		if len(v) != len(d) {
+			return fmt.Errorf("len of pointer 'to' not the same with len of DboSlice")
+		}
+		for i, value := range d {
+			delivery := &model.AlertDelivery{}
+			if err := value.To(delivery); err != nil {
+				return err
+			}
+			v[i] = delivery
+		}
+	case []*model.Anomaly:
+		if len(v) != len(d) {
+			return fmt.Errorf("len of pointer 'to' not the same with len of DboSlice")
+		}
+		for i, value := range d {
+			a := &model.Anomaly{}
+			if err := value.To(a); err != nil {
+				return err
+			}
+			v[i] = a
+		}
+	case []*model.Report:
+		if len(v) != len(d) {
+			return fmt.Errorf("len of pointer 'to' not the same with len of DboSlice")
+		}
+		for i, value := range d {
+			r := &model.Report{}
+			if err := value.To(r); err != nil {
+				return err
+			}
+			v[i] = r
+		}
 	default:
-		return fmt.Errorf("param 'to' not the same type with next types ([]*model.App, []*model.Meta, []*model.Categories, []*model.Keywords)")
+		return fmt.Errorf("param 'to' not the same type with next types ([]*model.App, []model.Meta, []*model.PlayMeta, []*model.AppStoreMeta, []*model.Categories, []*model.Keywords, []*model.RankStats, []*model.MetaChange, []*model.AlertRule, []*model.AlertDelivery, []*model.Anomaly, []*model.Report)")
 	}
 
 	return nil
 }
+
+// Reverse reverses order of slice in place
+func (d DboSlice) Reverse() {
+	for i, j := 0, len(d)-1; i < j; i, j = i+1, j-1 {
+		d[i], d[j] = d[j], d[i]
+	}
+}
@@ -28,7 +28,7 @@ func TestDboSlice_To_ShouldReturnSliceOfGraphqlFromDBOWithMetaStruct(t *testing.
 			Id: i+1,
 		})
 	}
-	modelApps :=  make([]*model.Meta, len(dboSlice))
+	modelApps :=  make([]model.Meta, len(dboSlice))
 
 	assert.NoError(t, dboSlice.To(modelApps))
 
@@ -81,7 +81,7 @@ func TestDboSlice_To_ShouldReturnErrorIfLengthOfMetaIsNotTheSame(t *testing.T) {
 			Id: i+1,
 		})
 	}
-	modelApps :=  make([]*model.Meta, len(dboSlice) + 2)
+	modelApps :=  make([]model.Meta, len(dboSlice) + 2)
 	assert.Error(t, dboSlice.To(modelApps))
 }
 
@@ -103,7 +103,7 @@ func TestDboSlice_To_ShouldReturnErrorIfLengthOfCategoriesIsNotTheSame(t *testin
 			Id: i+1,
 		})
 	}
-	modelApps :=  make([]*model.Meta, len(dboSlice) + 2)
+	modelApps :=  make([]model.Meta, len(dboSlice) + 2)
 	assert.Error(t, dboSlice.To(modelApps))
 }
 
@@ -132,7 +132,7 @@ func TestDboSlice_To_ShouldReturnErrorIfDifferentDataTypesInSliceForExampleMetaA
 	for i := 0; i < 200_000; i++ {
 		dboSlice = append(dboSlice, entities.Meta{Id: i+1}, entities.Track{Id: i+1})
 	}
-	modelApps :=  make([]*model.Meta, len(dboSlice))
+	modelApps :=  make([]model.Meta, len(dboSlice))
 	assert.Error(t, dboSlice.To(modelApps))
 }
 
@@ -147,3 +147,19 @@ func TestDboSlice_To_ShouldReturnErrorIfDifferentDataTypesInSliceForExampleTrack
 	modelCats := make([]*model.Categories, len(dboSlice))
 	assert.Error(t, dboSlice.To(modelCats))
 }
+
+func TestDboSlice_To_ShouldConvertMetaOfBothStores(t *testing.T) {
+	dboSlice := entities.DboSlice{
+		entities.Meta{Id: 1, App: entities.App{Store: entities.StorePlay}},
+		entities.Meta{Id: 2, App: entities.App{Store: entities.StoreAppStore}},
+	}
+
+	metas := make([]model.Meta, len(dboSlice))
+	assert.NoError(t, dboSlice.To(metas))
+	assert.IsType(t, &model.PlayMeta{}, metas[0])
+	assert.IsType(t, &model.AppStoreMeta{}, metas[1])
+
+	iosMetas := make([]*model.AppStoreMeta, len(dboSlice))
+	assert.NoError(t, dboSlice.To(iosMetas))
+	assert.Equal(t, 2, iosMetas[1].ID)
+}
@@ -4,6 +4,7 @@ import (
 	"Muromachi/graph/model"
 	"fmt"
 	"time"
+	"unicode/utf8"
 )
 
 // Categories and Keywords representation in db
@@ -46,3 +47,19 @@ func (tr Track) To(to interface{}) error {
 
 	return nil
 }
+
+// Validate checks that track can be saved to category or keyword table
+func (tr Track) Validate() error {
+	switch {
+	case tr.BundleId <= 0:
+		return fmt.Errorf("%s", "bundle id should be positive")
+	case tr.Type == "" || utf8.RuneCountInString(tr.Type) > 128:
+		return fmt.Errorf("%s", "type should not be empty and longer than 128 symbols")
+	case tr.Place <= 0:
+		return fmt.Errorf("%s", "place should be positive")
+	case tr.Date.IsZero():
+		return fmt.Errorf("%s", "date is required")
+	}
+
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"Muromachi/graph/model"
 	"Muromachi/store/entities"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDBO_TrackShouldGiveAReferenceOfValues(t *testing.T) {
@@ -43,3 +45,14 @@ func TestDBO_Track_ShouldReturnErrorIfWrongReference(t *testing.T) {
 	err := dboApp.To(&track)
 	assert.Error(t, err)
 }
+
+func TestTrack_Validate(t *testing.T) {
+	date := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, entities.Track{BundleId: 1, Type: "bank", Place: 1, Date: date}.Validate())
+	assert.Error(t, entities.Track{Type: "bank", Place: 1, Date: date}.Validate())
+	assert.Error(t, entities.Track{BundleId: 1, Place: 1, Date: date}.Validate())
+	assert.Error(t, entities.Track{BundleId: 1, Type: strings.Repeat("a", 129), Place: 1, Date: date}.Validate())
+	assert.Error(t, entities.Track{BundleId: 1, Type: "bank", Date: date}.Validate())
+	assert.Error(t, entities.Track{BundleId: 1, Type: "bank", Place: 1}.Validate())
+}
@@ -16,6 +16,7 @@ type User struct {
 	ClientSecret string    `json:"client_secret,omitempty"`
 	Company      string    `json:"company,omitempty"`
 	AddedAt      time.Time `json:"added_at,omitempty"`
+	Disabled     bool      `json:"disabled,omitempty"`
 }
 
 // Generate random ClientId and ClientSecret for *User struct
@@ -0,0 +1,280 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Key of postgres advisory lock which guards concurrent migration runs
+const lockKey = 7_241_900_117
+
+// Embedded migration files. Every migration is a pair of files
+// named like 000001_init.up.sql and 000001_init.down.sql
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+// Single versioned migration
+type Migration struct {
+	// Version of migration, taken from file name prefix
+	Version int64
+	// Human readable name of migration
+	Name string
+	// Sql which applies migration
+	Up string
+	// Sql which reverts migration
+	Down string
+}
+
+// Migration with information about applying
+type Status struct {
+	Migration
+	// Is migration already applied
+	Applied bool
+	// When migration was applied
+	AppliedAt time.Time
+}
+
+// Load reads and orders migrations from given file system
+//
+// Files should be placed in the sql folder and named like {version}_{name}.{up|down}.sql
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, name := range names {
+		base := path.Base(name)
+		parts := strings.SplitN(strings.TrimSuffix(base, ".sql"), "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("migration %s: name should look like {version}_{name}.{up|down}.sql", base)
+		}
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: wrong version, %v", base, err)
+		}
+		ext := path.Ext(parts[1])
+		title := strings.TrimSuffix(parts[1], ext)
+		if ext != ".up" && ext != ".down" {
+			return nil, fmt.Errorf("migration %s: direction should be up or down", base)
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if m.Name != title {
+			return nil, fmt.Errorf("migration %d: different names %s and %s", version, m.Name, title)
+		}
+		if ext == ".up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s: up file not found", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies and reverts embedded migrations
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// Up applies all not applied migrations in order of versions
+//
+// Returns the list of applied migrations
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err = apply(ctx, conn, migration.Up,
+				"insert into schema_migrations (version, name) values ($1, $2)",
+				migration.Version, migration.Name,
+			); err != nil {
+				return fmt.Errorf("migration %d_%s: %v", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts last n applied migrations
+//
+// Returns the list of reverted migrations
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < n; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s: down file not found", migration.Version, migration.Name)
+			}
+			if err = apply(ctx, conn, migration.Down,
+				"delete from schema_migrations where version = $1",
+				migration.Version,
+			); err != nil {
+				return fmt.Errorf("migration %d_%s: %v", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Status returns all known migrations with information about applying
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			at, ok := applied[migration.Version]
+			statuses = append(statuses, Status{
+				Migration: migration,
+				Applied:   ok,
+				AppliedAt: at,
+			})
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// locked runs f on single connection which holds advisory lock
+func (m *Migrator) locked(ctx context.Context, f func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "select pg_advisory_lock($1)", lockKey); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.Exec(context.Background(), "select pg_advisory_unlock($1)", lockKey)
+	}()
+
+	if _, err = conn.Exec(
+		ctx,
+		"create table if not exists schema_migrations (version bigint primary key not null, name text not null, appliedAt timestamp with time zone not null default now())",
+	); err != nil {
+		return err
+	}
+
+	return f(conn)
+}
+
+// appliedVersions returns already applied versions with time of applying
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int64]time.Time, error) {
+	var (
+		version int64
+		at      time.Time
+		applied = make(map[int64]time.Time)
+	)
+
+	_, err := conn.QueryFunc(
+		ctx,
+		"select version, appliedAt from schema_migrations",
+		nil,
+		[]interface{}{&version, &at},
+		func(row pgx.QueryFuncRow) error {
+			applied[version] = at
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// apply executes migration sql and bookkeeping query inside one transaction
+func apply(ctx context.Context, conn *pgxpool.Conn, sql, bookkeeping string, args ...interface{}) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// New creates migrator with embedded migrations
+func New(pool *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := Load(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		pool:       pool,
+		migrations: migrations,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package migrations_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/connector"
+	"Muromachi/store/migrations"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad_ShouldReturnOrderedMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/000002_second.up.sql":   {Data: []byte("create table b (id int);")},
+		"sql/000002_second.down.sql": {Data: []byte("drop table b;")},
+		"sql/000001_first.up.sql":    {Data: []byte("create table a (id int);")},
+		"sql/000001_first.down.sql":  {Data: []byte("drop table a;")},
+	}
+
+	list, err := migrations.Load(fsys)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, int64(1), list[0].Version)
+	assert.Equal(t, "first", list[0].Name)
+	assert.Equal(t, "drop table a;", list[0].Down)
+	assert.Equal(t, int64(2), list[1].Version)
+	assert.Equal(t, "create table b (id int);", list[1].Up)
+}
+
+func TestLoad_ShouldReturnErrorIfUpFileNotFound(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/000001_first.down.sql": {Data: []byte("drop table a;")},
+	}
+
+	_, err := migrations.Load(fsys)
+	assert.Error(t, err)
+}
+
+func TestLoad_ShouldReturnErrorIfNameIsWrong(t *testing.T) {
+	var tt = []string{
+		"sql/first.up.sql",
+		"sql/v1_first.up.sql",
+		"sql/000001_first.sql",
+	}
+
+	for _, name := range tt {
+		_, err := migrations.Load(fstest.MapFS{name: {Data: []byte("select 1;")}})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoad_EmbeddedMigrationsShouldBeValid(t *testing.T) {
+	list, err := migrations.Load(os.DirFS("."))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, list)
+
+	for _, m := range list {
+		assert.NotEmpty(t, m.Down, m.Name)
+	}
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	cfg := config.New("../../config/dev.yml")
+	url, _ := connector.ConnectionUrl(cfg.Database)
+	conn, err := connector.Connect(url)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	migrator, err := migrations.New(conn)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+
+	statuses, err := migrator.Status(ctx)
+	assert.NoError(t, err)
+	for _, s := range statuses {
+		assert.True(t, s.Applied)
+	}
+
+	last := statuses[len(statuses)-1]
+	reverted, err := migrator.Down(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(reverted))
+	assert.Equal(t, last.Version, reverted[0].Version)
+
+	applied, err := migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(applied))
+}
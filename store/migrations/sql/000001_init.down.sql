@@ -0,0 +1,7 @@
+drop table if exists refresh_sessions;
+drop table if exists users;
+drop table if exists meta_tracking;
+drop type if exists developerContacts;
+drop table if exists keyword_tracking;
+drop table if exists category_tracking;
+drop table if exists app_tracking;
@@ -25,12 +25,18 @@ create table if not exists keyword_tracking
     place    int not null,
     date     timestamp not null
 );
-create
-type developerContacts as
-(
-    email    text,
-    contacts text
-);
+do
+$$
+    begin
+        create type developerContacts as
+        (
+            email    text,
+            contacts text
+        );
+    exception
+        when duplicate_object then null;
+    end
+$$;
 create table if not exists meta_tracking
 (
     id               bigserial primary key not null,
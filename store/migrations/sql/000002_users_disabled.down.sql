@@ -0,0 +1,3 @@
+drop index if exists users_client_id_idx;
+alter table users
+    drop column if exists disabled;
@@ -0,0 +1,3 @@
+alter table users
+    add column if not exists disabled boolean not null default false;
+create unique index if not exists users_client_id_idx on users (clientId);
@@ -0,0 +1,4 @@
+drop trigger if exists meta_tracking_notify on meta_tracking;
+drop trigger if exists category_tracking_notify on category_tracking;
+drop trigger if exists keyword_tracking_notify on keyword_tracking;
+drop function if exists notify_tracking_updated();
@@ -0,0 +1,33 @@
+create or replace function notify_tracking_updated() returns trigger as
+$$
+begin
+    perform pg_notify(
+            'tracking_updated',
+            json_build_object(
+                    'kind', TG_ARGV[0],
+                    'bundleId', new.bundleId,
+                    'id', new.id,
+                    'date', to_char(new.date, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+                )::text
+        );
+    return new;
+end;
+$$ language plpgsql;
+drop trigger if exists meta_tracking_notify on meta_tracking;
+create trigger meta_tracking_notify
+    after insert or update
+    on meta_tracking
+    for each row
+execute procedure notify_tracking_updated('meta');
+drop trigger if exists category_tracking_notify on category_tracking;
+create trigger category_tracking_notify
+    after insert or update
+    on category_tracking
+    for each row
+execute procedure notify_tracking_updated('category');
+drop trigger if exists keyword_tracking_notify on keyword_tracking;
+create trigger keyword_tracking_notify
+    after insert or update
+    on keyword_tracking
+    for each row
+execute procedure notify_tracking_updated('keyword');
@@ -0,0 +1,4 @@
+drop index if exists app_tracking_bundle_geo_idx;
+alter table app_tracking
+    drop column if exists status,
+    drop column if exists ownerId;
@@ -0,0 +1,4 @@
+alter table app_tracking
+    add column if not exists ownerId int references users (id) on delete set null,
+    add column if not exists status  varchar(16) not null default 'active';
+create unique index if not exists app_tracking_bundle_geo_idx on app_tracking (bundle, geo) where status <> 'stopped';
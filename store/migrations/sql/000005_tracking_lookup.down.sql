@@ -0,0 +1,3 @@
+drop index if exists meta_tracking_bundle_date_idx;
+drop index if exists keyword_tracking_bundle_type_date_idx;
+drop index if exists category_tracking_bundle_type_date_idx;
@@ -0,0 +1,3 @@
+create index if not exists category_tracking_bundle_type_date_idx on category_tracking (bundleId, type, date);
+create index if not exists keyword_tracking_bundle_type_date_idx on keyword_tracking (bundleId, type, date);
+create index if not exists meta_tracking_bundle_date_idx on meta_tracking (bundleId, date);
@@ -0,0 +1,3 @@
+drop table if exists collection_runs;
+alter table app_tracking
+    drop column if exists nextRunAt;
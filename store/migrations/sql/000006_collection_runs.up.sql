@@ -0,0 +1,12 @@
+alter table app_tracking
+    add column if not exists nextRunAt timestamp;
+create table if not exists collection_runs
+(
+    id          bigserial primary key not null,
+    appId       int references app_tracking (id) on delete cascade not null,
+    scheduledAt timestamp not null,
+    startedAt   timestamp not null,
+    finishedAt  timestamp,
+    error       text
+);
+create index if not exists collection_runs_app_idx on collection_runs (appId, startedAt);
@@ -0,0 +1,9 @@
+alter table meta_tracking
+    drop column if exists ageRating,
+    drop column if exists inAppPurchases,
+    drop column if exists supportedDevices,
+    drop column if exists iosVersion;
+drop index if exists app_tracking_store_bundle_geo_idx;
+create unique index if not exists app_tracking_bundle_geo_idx on app_tracking (bundle, geo) where status <> 'stopped';
+alter table app_tracking
+    drop column if exists store;
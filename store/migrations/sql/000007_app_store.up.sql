@@ -0,0 +1,9 @@
+alter table app_tracking
+    add column if not exists store varchar(16) not null default 'play';
+drop index if exists app_tracking_bundle_geo_idx;
+create unique index if not exists app_tracking_store_bundle_geo_idx on app_tracking (store, bundle, geo) where status <> 'stopped';
+alter table meta_tracking
+    add column if not exists iosVersion       varchar(100) not null default '',
+    add column if not exists supportedDevices text[]       not null default '{}',
+    add column if not exists inAppPurchases   text[]       not null default '{}',
+    add column if not exists ageRating        varchar(50)  not null default '';
@@ -0,0 +1,7 @@
+alter table meta_tracking
+    drop column if exists ratingValue,
+    drop column if exists reviewCountValue,
+    drop column if exists installsMin,
+    drop column if exists installsMax,
+    drop column if exists sizeBytes,
+    drop column if exists ratingHistogramValues;
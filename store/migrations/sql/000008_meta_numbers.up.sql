@@ -0,0 +1,68 @@
+alter table meta_tracking
+    add column if not exists ratingValue           double precision,
+    add column if not exists reviewCountValue      bigint,
+    add column if not exists installsMin           bigint,
+    add column if not exists installsMax           bigint,
+    add column if not exists sizeBytes             bigint,
+    add column if not exists ratingHistogramValues bigint[];
+
+-- Next value of 1, 5, 10, 50, 100... series of Google Play installs
+create function pg_temp.next_threshold(n bigint) returns bigint as $$
+select case
+           when n <= 0 then 1
+           when left(n::text, 1)::int < 5 then 5 * (10::bigint ^ (length(n::text) - 1))::bigint
+           else (10::bigint ^ length(n::text))::bigint
+           end
+$$ language sql immutable;
+
+-- Count like 1,234,567, 1 234 567 or 1.2K, the same as entities.parseCount
+create function pg_temp.parse_count(s text) returns bigint as $$
+select case
+           when btrim(s) ~* '^\d+([.,]\d+)?\s*[kmb]\y'
+               then round(replace(substring(btrim(s) from '^(\d+(?:[.,]\d+)?)'), ',', '.')::numeric *
+                          case lower(substring(btrim(s) from '^\d+(?:[.,]\d+)?\s*([kmbKMB])'))
+                              when 'k' then 1000
+                              when 'm' then 1000000
+                              else 1000000000
+                              end)::bigint
+           -- Digits may be preceded only by letters or plus and are split by commas, points or spaces
+           else nullif(regexp_replace(substring(btrim(s) from '^[[:alpha:]+]*(\d[\d.,[:space:]]*)'), '\D', '', 'g'), '')::bigint
+           end
+$$ language sql immutable;
+
+with parsed as (
+    select id,
+           substring(replace(rating, ',', '.') from '\d+(?:\.\d+)?')::float8           as ratingValue,
+           pg_temp.parse_count(reviewCount)                                            as reviewCountValue,
+           pg_temp.parse_count(split_part(installs, '-', 1))                           as installsMin,
+           case
+               when installs like '%-%'
+                   then pg_temp.parse_count(split_part(installs, '-', 2))
+               end                                                                      as installsRangeMax,
+           installs like '%+'                                                          as installsOpen,
+           replace(substring(appSize from '(?i)(\d+(?:[.,]\d+)?)\s*[kmg]'), ',', '.')::float8 as size,
+           lower(substring(appSize from '(?i)\d\s*([kmg])'))                           as sizeUnit,
+           case
+               when cardinality(ratingHistogram) > 0 and
+                    not exists(select from unnest(ratingHistogram) v where pg_temp.parse_count(v) is null)
+                   then array(select pg_temp.parse_count(v) from unnest(ratingHistogram) with ordinality H(v, n) order by n)
+               end                                                                      as histogram
+    from meta_tracking
+)
+update meta_tracking META
+set ratingValue           = parsed.ratingValue,
+    reviewCountValue      = parsed.reviewCountValue,
+    installsMin           = parsed.installsMin,
+    installsMax           = case
+                                when parsed.installsRangeMax is not null then parsed.installsRangeMax
+                                when parsed.installsOpen then pg_temp.next_threshold(parsed.installsMin)
+                                else parsed.installsMin
+                                end,
+    sizeBytes             = round(parsed.size * case parsed.sizeUnit
+                                                    when 'k' then 1024
+                                                    when 'm' then 1024 * 1024
+                                                    when 'g' then 1024 * 1024 * 1024
+                                                    end)::bigint,
+    ratingHistogramValues = parsed.histogram
+from parsed
+where META.id = parsed.id;
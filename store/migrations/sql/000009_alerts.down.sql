@@ -0,0 +1,2 @@
+drop table if exists alert_deliveries;
+drop table if exists alert_rules;
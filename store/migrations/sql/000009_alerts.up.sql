@@ -0,0 +1,31 @@
+create table if not exists alert_rules
+(
+    id         serial primary key not null,
+    ownerId    int references users (id) on delete cascade not null,
+    appId      int references app_tracking (id) on delete cascade not null,
+    kind       varchar(16)  not null,
+    type       varchar(128) not null,
+    condition  varchar(16)  not null,
+    threshold  int          not null default 0,
+    webhookUrl text         not null,
+    secret     varchar(64)  not null,
+    enabled    boolean      not null default true,
+    createdAt  timestamp    not null default (now() at time zone 'utc')
+);
+create index if not exists alert_rules_app_kind_idx on alert_rules (appId, kind) where enabled;
+create index if not exists alert_rules_owner_idx on alert_rules (ownerId);
+create table if not exists alert_deliveries
+(
+    id             bigserial primary key not null,
+    ruleId         int references alert_rules (id) on delete cascade not null,
+    payload        jsonb       not null,
+    status         varchar(16) not null default 'pending',
+    attempts       int         not null default 0,
+    responseStatus int,
+    lastError      text        not null default '',
+    createdAt      timestamp   not null,
+    nextAttemptAt  timestamp   not null,
+    deliveredAt    timestamp
+);
+create index if not exists alert_deliveries_pending_idx on alert_deliveries (nextAttemptAt) where status = 'pending';
+create index if not exists alert_deliveries_rule_idx on alert_deliveries (ruleId, id);
@@ -0,0 +1,15 @@
+create table if not exists anomalies
+(
+    id         bigserial primary key not null,
+    bundleId   int references app_tracking (id) on delete cascade not null,
+    metric     varchar(16)      not null,
+    type       varchar(128)     not null default '',
+    date       timestamp        not null,
+    value      double precision not null,
+    change     double precision not null,
+    baseline   double precision not null,
+    score      double precision not null,
+    status     varchar(16)      not null default 'new',
+    detectedAt timestamp        not null default (now() at time zone 'utc')
+);
+create unique index if not exists anomalies_bundle_metric_type_date_idx on anomalies (bundleId, metric, type, date);
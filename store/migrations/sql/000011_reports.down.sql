@@ -0,0 +1,2 @@
+drop table if exists reports;
+drop table if exists report_schedules;
@@ -0,0 +1,30 @@
+create table if not exists report_schedules
+(
+    ownerId    int primary key references users (id) on delete cascade not null,
+    weekday    int         not null,
+    hour       int         not null,
+    webhookUrl text        not null default '',
+    secret     varchar(64) not null,
+    enabled    boolean     not null default true,
+    nextRunAt  timestamp   not null,
+    createdAt  timestamp   not null default (now() at time zone 'utc')
+);
+create index if not exists report_schedules_due_idx on report_schedules (nextRunAt) where enabled;
+create table if not exists reports
+(
+    id             bigserial primary key not null,
+    ownerId        int references users (id) on delete cascade not null,
+    periodStart    timestamp   not null,
+    periodEnd      timestamp   not null,
+    json           jsonb       not null,
+    html           text        not null,
+    createdAt      timestamp   not null default (now() at time zone 'utc'),
+    delivery       varchar(16),
+    attempts       int         not null default 0,
+    responseStatus int,
+    lastError      text        not null default '',
+    nextAttemptAt  timestamp,
+    deliveredAt    timestamp
+);
+create unique index if not exists reports_owner_period_idx on reports (ownerId, periodEnd);
+create index if not exists reports_pending_idx on reports (nextAttemptAt) where delivery = 'pending';
@@ -0,0 +1,27 @@
+-- Rows of attached partitions are moved back to plain tables, detached partitions are kept
+create function pg_temp.unpartition_tracking(parent text, kind text, indexName text, indexColumns text) returns void as
+$$
+declare
+    old text := parent || '_partitioned';
+begin
+    execute format('alter table %I rename to %I', parent, old);
+    execute format('create table %I (like %I including defaults)', parent, old);
+    execute format('insert into %I select * from %I', parent, old);
+    execute format('alter sequence %s owned by %I.id', pg_get_serial_sequence(old, 'id'), parent);
+    execute format('drop table %I', old);
+
+    execute format('alter table %I add primary key (id)', parent);
+    execute format('alter table %I add foreign key (bundleId) references app_tracking', parent);
+    execute format('create index %I on %I (%s)', indexName, parent, indexColumns);
+    execute format(
+            'create trigger %I after insert or update on %I for each row execute procedure notify_tracking_updated(%L)',
+            parent || '_notify', parent, kind
+        );
+end;
+$$ language plpgsql;
+
+select pg_temp.unpartition_tracking('category_tracking', 'category', 'category_tracking_bundle_type_date_idx', 'bundleId, type, date');
+select pg_temp.unpartition_tracking('keyword_tracking', 'keyword', 'keyword_tracking_bundle_type_date_idx', 'bundleId, type, date');
+select pg_temp.unpartition_tracking('meta_tracking', 'meta', 'meta_tracking_bundle_date_idx', 'bundleId, date');
+alter table meta_tracking
+    alter column date drop not null;
@@ -0,0 +1,45 @@
+-- Tracking tables are range partitioned by date with monthly partitions. Partitions
+-- from the first tracked month to three months ahead are created here, next ones are
+-- created by partitions maintenance. Rows out of every partition are kept in default partition
+create function pg_temp.partition_tracking(parent text, kind text, indexName text, indexColumns text) returns void as
+$$
+declare
+    old   text      := parent || '_old';
+    cur   timestamp;
+    until timestamp := date_trunc('month', now() at time zone 'utc') + interval '3 month';
+begin
+    execute format('alter table %I rename to %I', parent, old);
+    -- Snapshots without date are placed at the start of epoch
+    execute format('update %I set date = %L where date is null', old, 'epoch');
+    execute format('create table %I (like %I including defaults) partition by range (date)', parent, old);
+    execute format('alter table %I alter column date set not null', parent);
+
+    execute format('select date_trunc(%L, min(date)) from %I where date > %L', 'month', old, 'epoch') into cur;
+    cur := least(coalesce(cur, until), date_trunc('month', now() at time zone 'utc'));
+    while cur <= until
+        loop
+            execute format(
+                    'create table %I partition of %I for values from (%L) to (%L)',
+                    parent || '_p' || to_char(cur, 'YYYYMM'), parent, cur, cur + interval '1 month'
+                );
+            cur := cur + interval '1 month';
+        end loop;
+    execute format('create table %I partition of %I default', parent || '_default', parent);
+
+    execute format('insert into %I select * from %I', parent, old);
+    execute format('alter sequence %s owned by %I.id', pg_get_serial_sequence(old, 'id'), parent);
+    execute format('drop table %I', old);
+
+    execute format('alter table %I add primary key (id, date)', parent);
+    execute format('alter table %I add foreign key (bundleId) references app_tracking', parent);
+    execute format('create index %I on %I (%s)', indexName, parent, indexColumns);
+    execute format(
+            'create trigger %I after insert or update on %I for each row execute procedure notify_tracking_updated(%L)',
+            parent || '_notify', parent, kind
+        );
+end;
+$$ language plpgsql;
+
+select pg_temp.partition_tracking('category_tracking', 'category', 'category_tracking_bundle_type_date_idx', 'bundleId, type, date');
+select pg_temp.partition_tracking('keyword_tracking', 'keyword', 'keyword_tracking_bundle_type_date_idx', 'bundleId, type, date');
+select pg_temp.partition_tracking('meta_tracking', 'meta', 'meta_tracking_bundle_date_idx', 'bundleId, date');
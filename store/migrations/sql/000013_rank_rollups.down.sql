@@ -0,0 +1,3 @@
+drop table if exists watermarks;
+drop table if exists keyword_rollups;
+drop table if exists category_rollups;
@@ -0,0 +1,38 @@
+create table if not exists category_rollups
+(
+    resolution varchar(8)       not null,
+    bundleId   int references app_tracking (id) on delete cascade not null,
+    type       varchar(128)     not null,
+    bucket     timestamp        not null,
+    min        int              not null,
+    max        int              not null,
+    avg        double precision not null,
+    median     double precision not null,
+    first      int              not null,
+    last       int              not null,
+    count      int              not null,
+    primary key (resolution, bundleId, type, bucket)
+);
+create table if not exists keyword_rollups
+(
+    resolution varchar(8)       not null,
+    bundleId   int references app_tracking (id) on delete cascade not null,
+    type       varchar(128)     not null,
+    bucket     timestamp        not null,
+    min        int              not null,
+    max        int              not null,
+    avg        double precision not null,
+    median     double precision not null,
+    first      int              not null,
+    last       int              not null,
+    count      int              not null,
+    primary key (resolution, bundleId, type, bucket)
+);
+-- Ids of the last processed rows of tracking tables by workers
+create table if not exists watermarks
+(
+    source    varchar(64) primary key not null,
+    lastId    bigint      not null default 0,
+    ready     boolean     not null default false,
+    updatedAt timestamp   not null default (now() at time zone 'utc')
+);
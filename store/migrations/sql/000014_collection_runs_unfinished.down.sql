@@ -0,0 +1 @@
+drop index if exists collection_runs_unfinished_idx;
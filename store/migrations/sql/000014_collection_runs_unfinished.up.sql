@@ -0,0 +1 @@
+create index if not exists collection_runs_unfinished_idx on collection_runs (startedAt) where finishedAt is null;
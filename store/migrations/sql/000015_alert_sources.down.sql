@@ -0,0 +1,4 @@
+delete from watermarks where source like 'alerts:%';
+drop index if exists alert_deliveries_source_idx;
+alter table alert_deliveries drop column if exists trackingId;
+alter table alert_deliveries drop column if exists kind;
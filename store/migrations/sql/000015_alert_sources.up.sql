@@ -0,0 +1,12 @@
+alter table alert_deliveries add column if not exists kind varchar(16);
+alter table alert_deliveries add column if not exists trackingId bigint;
+-- Every instance evaluates every tracking row, so rule gets one delivery per row
+create unique index if not exists alert_deliveries_source_idx on alert_deliveries (ruleId, kind, trackingId);
+-- Rows which were tracked before alerts were evaluated by scan do not trigger rules again
+insert into watermarks (source, lastId)
+select 'alerts:category_tracking', coalesce(max(id), 0) from category_tracking
+union all
+select 'alerts:keyword_tracking', coalesce(max(id), 0) from keyword_tracking
+union all
+select 'alerts:meta_tracking', coalesce(max(id), 0) from meta_tracking
+on conflict do nothing;
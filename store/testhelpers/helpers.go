@@ -33,7 +33,7 @@ func RealDb(c ...config.DBConfig) (*pgxpool.Pool, func(names ...string)) {
 		panic(err)
 	}
 
-	if err = connector.InitSchema(conn, cfg.Schema); err != nil {
+	if err = connector.Migrate(conn); err != nil {
 		panic(err)
 	}
 	return conn, func(names ...string) {
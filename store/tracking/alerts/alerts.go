@@ -0,0 +1,362 @@
+package alerts
+
+import (
+	"Muromachi/logging"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/events"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Max time between two observations which are compared by CHANGED_BY rule
+const changeWindow = time.Hour * 24
+
+const (
+	// Default delay between scans of tracking rows after watermarks
+	DefaultScanInterval = time.Minute
+	// Default count of tracking rows which are scanned at once
+	DefaultScanSize = 1000
+	// Default count of rows behind watermark which are scanned again
+	DefaultScanLag = 1000
+)
+
+// Kinds of tracking rows which are scanned
+var scanKinds = []entities.AlertKind{entities.AlertCategory, entities.AlertKeyword, entities.AlertMeta}
+
+// Rows of tracking table which were read by one scan
+type Batch struct {
+	// Events of rows which apps have enabled rules of kind
+	Events []events.Event
+	worker.Batch
+}
+
+// Store of rules and tracking rows which are checked by them
+type RuleStore interface {
+	// Rules returns enabled rules of app for rows of kind
+	Rules(ctx context.Context, appId int, kind entities.AlertKind) ([]entities.AlertRule, error)
+	// Places returns category or keyword row with given id and the previous row of
+	// the same app and type. Previous row is nil for the first observation
+	Places(ctx context.Context, kind entities.AlertKind, id int) (entities.Track, *entities.Track, error)
+	// Metas returns meta snapshot with given id and the previous snapshot of the
+	// same app. Previous snapshot is nil for the first observation
+	Metas(ctx context.Context, id int) (entities.Meta, *entities.Meta, error)
+	// Enqueue saves pending deliveries of triggered rules, deliveries of rule and
+	// tracking row which were saved already are skipped
+	Enqueue(ctx context.Context, deliveries []entities.AlertDelivery) error
+	// Watermark returns id of the last scanned row of kind
+	Watermark(ctx context.Context, kind entities.AlertKind) (int64, error)
+	// Scan reads at most limit rows of kind with ids greater than after
+	Scan(ctx context.Context, kind entities.AlertKind, after int64, limit int) (Batch, error)
+	// Advance moves watermark of kind forward to id, caughtUp means that there are no rows after id
+	Advance(ctx context.Context, kind entities.AlertKind, id int64, caughtUp bool) error
+}
+
+// Alert is body of webhook
+type Alert struct {
+	RuleId    int                     `json:"ruleId"`
+	Kind      entities.AlertKind      `json:"kind"`
+	Type      string                  `json:"type"`
+	Condition entities.AlertCondition `json:"condition"`
+	Threshold int                     `json:"threshold"`
+	BundleId  int                     `json:"bundleId"`
+	Bundle    string                  `json:"bundle"`
+	Geo       string                  `json:"geo"`
+	// Date of observation which triggered rule
+	Date          time.Time  `json:"date"`
+	Place         int32      `json:"place,omitempty"`
+	PreviousPlace int32      `json:"previousPlace,omitempty"`
+	PreviousDate  *time.Time `json:"previousDate,omitempty"`
+	// Change of meta field
+	Change *entities.MetaChange `json:"change,omitempty"`
+}
+
+// Triggered reports whether category or keyword row triggers rule. BELOW and
+// ABOVE rules fire when place crosses threshold, not on every observation
+func Triggered(rule entities.AlertRule, cur entities.Track, prev *entities.Track) bool {
+	if rule.Type != cur.Type {
+		return false
+	}
+	threshold := int32(rule.Threshold)
+
+	switch rule.Condition {
+	case entities.AlertBelow:
+		return cur.Place > threshold && (prev == nil || prev.Place <= threshold)
+	case entities.AlertAbove:
+		return cur.Place <= threshold && (prev == nil || prev.Place > threshold)
+	case entities.AlertChangedBy:
+		if prev == nil || cur.Date.Sub(prev.Date) > changeWindow {
+			return false
+		}
+		diff := cur.Place - prev.Place
+		if diff < 0 {
+			diff = -diff
+		}
+		return diff > threshold
+	}
+
+	return false
+}
+
+// Evaluator checks rules of apps when new tracking rows land and enqueues
+// webhooks of triggered rules. Rows which were written while no instance
+// listened to events are found by scans after watermarks kept in store
+type Evaluator struct {
+	Store RuleStore
+	Now   worker.Clock
+	// Hub of tracking events
+	Hub *events.Hub
+	// Delay between scans of tracking rows
+	ScanInterval time.Duration
+	// Count of tracking rows which are scanned at once
+	ScanSize int
+	// Count of rows behind watermark which are scanned again
+	ScanLag int
+}
+
+// Run evaluates rules on tracking events and scans tracking rows at start and then
+// every scan interval until ctx is done. Events are read from subscription without
+// delay and queued, so hub does not drop them while rules are evaluated
+func (e *Evaluator) Run(ctx context.Context) error {
+	sub := e.Hub.Subscribe(events.Filter{})
+	defer sub.Close()
+
+	var (
+		mu      sync.Mutex
+		pending []events.Event
+	)
+	wake := make(chan struct{}, 1)
+	go func() {
+		for event := range sub.Events() {
+			mu.Lock()
+			pending = append(pending, event)
+			mu.Unlock()
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(e.scanInterval())
+	defer ticker.Stop()
+	scan := true
+	for {
+		if scan {
+			if _, err := e.Scan(ctx); err != nil && ctx.Err() == nil {
+				logging.Errorf("alerts: %v", err)
+			}
+			scan = false
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			scan = true
+			continue
+		case <-wake:
+		}
+
+		mu.Lock()
+		batch := pending
+		pending = nil
+		mu.Unlock()
+		for _, event := range batch {
+			if _, err := e.Evaluate(ctx, event); err != nil && ctx.Err() == nil {
+				logging.Errorf("alerts: evaluation of %s row %d failed: %v", event.Kind, event.Id, err)
+			}
+		}
+	}
+}
+
+// Evaluate checks rules of app against row of event and enqueues webhooks of
+// triggered rules. Returns count of triggered rules
+func (e *Evaluator) Evaluate(ctx context.Context, event events.Event) (int, error) {
+	kind := entities.AlertKind(event.Kind)
+	rules, err := e.Store.Rules(ctx, event.BundleId, kind)
+	if err != nil || len(rules) == 0 {
+		return 0, err
+	}
+
+	var alerts []Alert
+	switch kind {
+	case entities.AlertKeyword, entities.AlertCategory:
+		alerts, err = e.placeAlerts(ctx, kind, event.Id, rules)
+	case entities.AlertMeta:
+		alerts, err = e.metaAlerts(ctx, event.Id, rules)
+	default:
+		return 0, fmt.Errorf("unknown kind of tracking event %q", event.Kind)
+	}
+	if err != nil || len(alerts) == 0 {
+		return 0, err
+	}
+
+	now := e.Now.UTC()
+	deliveries := make([]entities.AlertDelivery, len(alerts))
+	for i, alert := range alerts {
+		payload, err := json.Marshal(alert)
+		if err != nil {
+			return 0, err
+		}
+		deliveries[i] = entities.AlertDelivery{
+			RuleId:        alert.RuleId,
+			Kind:          kind,
+			TrackingId:    int64(event.Id),
+			Payload:       payload,
+			Status:        entities.DeliveryPending,
+			CreatedAt:     now,
+			NextAttemptAt: now,
+		}
+	}
+
+	return len(deliveries), e.Store.Enqueue(ctx, deliveries)
+}
+
+// Scan evaluates rules on tracking rows of every kind after its watermark and moves
+// watermark forward. Deliveries of rows which were evaluated already are not enqueued
+// again, so scans and several instances do not send webhook twice. Returns count of
+// triggered rules. Errors of one kind do not stop scan of others
+func (e *Evaluator) Scan(ctx context.Context) (int, error) {
+	var (
+		total    int
+		problems []string
+	)
+	for _, kind := range scanKinds {
+		count, err := e.scan(ctx, kind)
+		total += count
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("scan of %s rows failed: %v", kind, err))
+		}
+	}
+	if len(problems) > 0 {
+		return total, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	return total, nil
+}
+
+// scan evaluates rows of kind from scan lag behind watermark while batches are full
+func (e *Evaluator) scan(ctx context.Context, kind entities.AlertKind) (int, error) {
+	lastId, err := e.Store.Watermark(ctx, kind)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	err = worker.Scan(ctx, lastId, e.scanSize(), e.scanLag(),
+		func(ctx context.Context, after int64) (worker.Batch, error) {
+			batch, err := e.Store.Scan(ctx, kind, after, e.scanSize())
+			if err != nil {
+				return batch.Batch, err
+			}
+			for _, event := range batch.Events {
+				count, err := e.Evaluate(ctx, event)
+				total += count
+				if err != nil && ctx.Err() == nil {
+					logging.Errorf("alerts: evaluation of %s row %d failed: %v", event.Kind, event.Id, err)
+				}
+			}
+			return batch.Batch, nil
+		},
+		func(ctx context.Context, id int64, caughtUp bool) error {
+			return e.Store.Advance(ctx, kind, id, caughtUp)
+		},
+	)
+
+	return total, err
+}
+
+// placeAlerts returns alerts of rules triggered by category or keyword row
+func (e *Evaluator) placeAlerts(ctx context.Context, kind entities.AlertKind, id int, rules []entities.AlertRule) ([]Alert, error) {
+	cur, prev, err := e.Store.Places(ctx, kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []Alert
+	for _, rule := range rules {
+		if !Triggered(rule, cur, prev) {
+			continue
+		}
+		alert := newAlert(rule, cur.App, cur.Date)
+		alert.Place = cur.Place
+		if prev != nil {
+			alert.PreviousPlace = prev.Place
+			alert.PreviousDate = &prev.Date
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+// metaAlerts returns alerts of rules which fields were changed by meta snapshot
+func (e *Evaluator) metaAlerts(ctx context.Context, id int, rules []entities.AlertRule) ([]Alert, error) {
+	cur, prev, err := e.Store.Metas(ctx, id)
+	if err != nil || prev == nil {
+		return nil, err
+	}
+
+	var alerts []Alert
+	for _, rule := range rules {
+		changes, err := entities.MetaChanges([]entities.Meta{*prev, cur}, []entities.MetaField{entities.MetaField(rule.Type)})
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		var change entities.MetaChange
+		if err := changes[0].To(&change); err != nil {
+			return nil, err
+		}
+		alert := newAlert(rule, cur.App, cur.Date)
+		alert.PreviousDate = &prev.Date
+		alert.Change = &change
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+func (e *Evaluator) scanInterval() time.Duration {
+	if e.ScanInterval <= 0 {
+		return DefaultScanInterval
+	}
+	return e.ScanInterval
+}
+
+func (e *Evaluator) scanSize() int {
+	if e.ScanSize <= 0 {
+		return DefaultScanSize
+	}
+	return e.ScanSize
+}
+
+func (e *Evaluator) scanLag() int {
+	if e.ScanLag <= 0 {
+		return DefaultScanLag
+	}
+	return e.ScanLag
+}
+
+// newAlert returns alert of rule about app
+func newAlert(rule entities.AlertRule, app entities.App, date time.Time) Alert {
+	return Alert{
+		RuleId:    rule.Id,
+		Kind:      rule.Kind,
+		Type:      rule.Type,
+		Condition: rule.Condition,
+		Threshold: rule.Threshold,
+		BundleId:  rule.AppId,
+		Bundle:    app.Bundle,
+		Geo:       app.Geo,
+		Date:      date,
+	}
+}
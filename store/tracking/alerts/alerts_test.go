@@ -0,0 +1,246 @@
+package alerts_test
+
+import (
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/alerts"
+	"Muromachi/store/tracking/events"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Store which keeps rules and rows in memory
+type mockRuleStore struct {
+	mu         sync.Mutex
+	rules      []entities.AlertRule
+	tracks     map[int]entities.Track
+	metas      map[int]entities.Meta
+	deliveries []entities.AlertDelivery
+	// Events of scanned rows by kind in order of ids
+	rows       map[entities.AlertKind][]events.Event
+	watermarks map[entities.AlertKind]int64
+}
+
+func (m *mockRuleStore) Rules(ctx context.Context, appId int, kind entities.AlertKind) ([]entities.AlertRule, error) {
+	var rules []entities.AlertRule
+	for _, rule := range m.rules {
+		if rule.AppId == appId && rule.Kind == kind && rule.Enabled {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (m *mockRuleStore) Places(ctx context.Context, kind entities.AlertKind, id int) (entities.Track, *entities.Track, error) {
+	cur := m.tracks[id]
+	if prev, ok := m.tracks[id-1]; ok {
+		return cur, &prev, nil
+	}
+	return cur, nil, nil
+}
+
+func (m *mockRuleStore) Metas(ctx context.Context, id int) (entities.Meta, *entities.Meta, error) {
+	cur := m.metas[id]
+	if prev, ok := m.metas[id-1]; ok {
+		return cur, &prev, nil
+	}
+	return cur, nil, nil
+}
+
+func (m *mockRuleStore) Enqueue(ctx context.Context, deliveries []entities.AlertDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range deliveries {
+		duplicate := false
+		for _, v := range m.deliveries {
+			if v.RuleId == d.RuleId && v.Kind == d.Kind && v.TrackingId == d.TrackingId {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			m.deliveries = append(m.deliveries, d)
+		}
+	}
+	return nil
+}
+
+func (m *mockRuleStore) Watermark(ctx context.Context, kind entities.AlertKind) (int64, error) {
+	return m.watermarks[kind], nil
+}
+
+func (m *mockRuleStore) Scan(ctx context.Context, kind entities.AlertKind, after int64, limit int) (alerts.Batch, error) {
+	batch := alerts.Batch{Batch: worker.Batch{LastId: after}}
+	for _, event := range m.rows[kind] {
+		if int64(event.Id) <= after || batch.Count == limit {
+			continue
+		}
+		batch.Events = append(batch.Events, event)
+		batch.Count++
+		batch.LastId = int64(event.Id)
+	}
+	return batch, nil
+}
+
+func (m *mockRuleStore) Advance(ctx context.Context, kind entities.AlertKind, id int64, caughtUp bool) error {
+	if id > m.watermarks[kind] {
+		m.watermarks[kind] = id
+	}
+	return nil
+}
+
+func (m *mockRuleStore) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.deliveries)
+}
+
+func TestTriggered(t *testing.T) {
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	track := func(place int32, date time.Time) *entities.Track {
+		return &entities.Track{Type: "bank", Place: place, Date: date}
+	}
+	below := entities.AlertRule{Type: "bank", Condition: entities.AlertBelow, Threshold: 10}
+	above := entities.AlertRule{Type: "bank", Condition: entities.AlertAbove, Threshold: 3}
+	changed := entities.AlertRule{Type: "bank", Condition: entities.AlertChangedBy, Threshold: 5}
+
+	var tt = []struct {
+		name      string
+		rule      entities.AlertRule
+		cur       *entities.Track
+		prev      *entities.Track
+		triggered bool
+	}{
+		{name: "drops below", rule: below, cur: track(11, day), prev: track(10, day), triggered: true},
+		{name: "stays below", rule: below, cur: track(12, day), prev: track(11, day)},
+		{name: "first observation below", rule: below, cur: track(30, day), triggered: true},
+		{name: "other type", rule: below, cur: &entities.Track{Type: "loan", Place: 30, Date: day}},
+		{name: "rises above", rule: above, cur: track(3, day), prev: track(4, day), triggered: true},
+		{name: "stays above", rule: above, cur: track(1, day), prev: track(2, day)},
+		{name: "changes by more", rule: changed, cur: track(4, day.Add(time.Hour*24)), prev: track(10, day), triggered: true},
+		{name: "changes by threshold", rule: changed, cur: track(15, day), prev: track(10, day)},
+		{name: "changes in two days", rule: changed, cur: track(20, day.Add(time.Hour*48)), prev: track(10, day)},
+		{name: "changes without previous", rule: changed, cur: track(20, day)},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.triggered, alerts.Triggered(test.rule, *test.cur, test.prev))
+		})
+	}
+}
+
+func TestEvaluator_Evaluate_ShouldEnqueueWebhooksOfTriggeredRules_Mock(t *testing.T) {
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	app := entities.App{Id: 7, Bundle: "com.muromachi.bank", Geo: "en_us"}
+	store := &mockRuleStore{
+		rules: []entities.AlertRule{
+			{Id: 1, AppId: 7, Kind: entities.AlertKeyword, Type: "bank", Condition: entities.AlertBelow, Threshold: 10, Enabled: true},
+			{Id: 2, AppId: 7, Kind: entities.AlertKeyword, Type: "bank", Condition: entities.AlertAbove, Threshold: 1, Enabled: false},
+			{Id: 3, AppId: 7, Kind: entities.AlertMeta, Type: string(entities.MetaTitle), Condition: entities.AlertChanged, Enabled: true},
+			{Id: 4, AppId: 7, Kind: entities.AlertMeta, Type: string(entities.MetaPrice), Condition: entities.AlertChanged, Enabled: true},
+		},
+		tracks: map[int]entities.Track{
+			1: {Id: 1, BundleId: 7, Type: "bank", Place: 8, Date: day, App: app},
+			2: {Id: 2, BundleId: 7, Type: "bank", Place: 12, Date: day.Add(time.Hour * 24), App: app},
+		},
+		metas: map[int]entities.Meta{
+			1: {Id: 1, BundleId: 7, Title: "Bank", Price: "0", Date: day, App: app},
+			2: {Id: 2, BundleId: 7, Title: "My Bank", Price: "0", Date: day.Add(time.Hour * 24), App: app},
+		},
+	}
+	now := day.Add(time.Hour * 25)
+	evaluator := alerts.Evaluator{Store: store, Now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	count, err := evaluator.Evaluate(ctx, events.Event{Kind: events.Keyword, BundleId: 7, Id: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = evaluator.Evaluate(ctx, events.Event{Kind: events.Meta, BundleId: 7, Id: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	// Rules of other apps are not checked
+	count, err = evaluator.Evaluate(ctx, events.Event{Kind: events.Keyword, BundleId: 8, Id: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	assert.Len(t, store.deliveries, 2)
+	delivery := store.deliveries[0]
+	assert.Equal(t, 1, delivery.RuleId)
+	assert.Equal(t, entities.DeliveryPending, delivery.Status)
+	assert.Equal(t, now, delivery.NextAttemptAt)
+
+	var alert alerts.Alert
+	assert.NoError(t, json.Unmarshal(delivery.Payload, &alert))
+	assert.Equal(t, "com.muromachi.bank", alert.Bundle)
+	assert.Equal(t, int32(12), alert.Place)
+	assert.Equal(t, int32(8), alert.PreviousPlace)
+
+	assert.NoError(t, json.Unmarshal(store.deliveries[1].Payload, &alert))
+	assert.Equal(t, 3, alert.RuleId)
+	assert.Equal(t, entities.MetaTitle, alert.Change.Field)
+	assert.Equal(t, "Bank", alert.Change.Old)
+	assert.Equal(t, "My Bank", alert.Change.New)
+}
+
+func TestEvaluator_Run_ShouldEvaluateEventsOfHub_Mock(t *testing.T) {
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	store := &mockRuleStore{
+		rules: []entities.AlertRule{
+			{Id: 1, AppId: 7, Kind: entities.AlertCategory, Type: "FINANCE", Condition: entities.AlertBelow, Threshold: 10, Enabled: true},
+		},
+		tracks: map[int]entities.Track{
+			1: {Id: 1, BundleId: 7, Type: "FINANCE", Place: 20, Date: day},
+		},
+	}
+	hub := events.NewHub()
+	evaluator := alerts.Evaluator{Store: store, Hub: hub}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- evaluator.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool { return hub.Count() == 1 }, time.Second, time.Millisecond*10)
+	hub.Publish(events.Event{Kind: events.Category, BundleId: 7, Id: 1})
+	assert.Eventually(t, func() bool { return store.count() == 1 }, time.Second, time.Millisecond*10)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Equal(t, 0, hub.Count())
+}
+
+func TestEvaluator_Scan_ShouldEvaluateRowsAfterWatermarkOnce_Mock(t *testing.T) {
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	store := &mockRuleStore{
+		rules: []entities.AlertRule{
+			{Id: 1, AppId: 7, Kind: entities.AlertCategory, Type: "FINANCE", Condition: entities.AlertChangedBy, Threshold: 1, Enabled: true},
+		},
+		tracks:     map[int]entities.Track{},
+		rows:       map[entities.AlertKind][]events.Event{},
+		watermarks: map[entities.AlertKind]int64{entities.AlertCategory: 3},
+	}
+	// Every row moves app by five places
+	for id := 1; id <= 5; id++ {
+		store.tracks[id] = entities.Track{Id: id, BundleId: 7, Type: "FINANCE", Place: int32(id * 5), Date: day.Add(time.Hour * time.Duration(id))}
+		store.rows[entities.AlertCategory] = append(store.rows[entities.AlertCategory], events.Event{Kind: events.Category, BundleId: 7, Id: id})
+	}
+	evaluator := alerts.Evaluator{Store: store, ScanSize: 1, ScanLag: 1}
+
+	// Row below watermark is scanned again, rows before lag are not
+	count, err := evaluator.Scan(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, int64(5), store.watermarks[entities.AlertCategory])
+	assert.Len(t, store.deliveries, 3)
+	assert.Equal(t, int64(3), store.deliveries[0].TrackingId)
+	assert.Equal(t, entities.AlertCategory, store.deliveries[0].Kind)
+
+	// Rows which were evaluated already do not enqueue deliveries again
+	_, err = evaluator.Scan(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, store.deliveries, 3)
+	assert.Equal(t, int64(5), store.watermarks[entities.AlertCategory])
+}
@@ -0,0 +1,245 @@
+package alerts
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/events"
+	"Muromachi/store/tracking/metastore"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// Tables of tracking rows which are checked by rules
+var placeTables = map[entities.AlertKind]string{
+	entities.AlertCategory: "category_tracking",
+	entities.AlertKeyword:  "keyword_tracking",
+}
+
+// Tables of tracking rows which are scanned after watermarks
+var scanTables = map[entities.AlertKind]string{
+	entities.AlertCategory: "category_tracking",
+	entities.AlertKeyword:  "keyword_tracking",
+	entities.AlertMeta:     "meta_tracking",
+}
+
+// Store of rules in alert_rules, webhooks log in alert_deliveries and watermarks of scans
+// in watermarks tables
+type PgStore struct {
+	DB connector.DB
+}
+
+// Rules returns enabled rules of app for rows of kind
+func (p *PgStore) Rules(ctx context.Context, appId int, kind entities.AlertKind) ([]entities.AlertRule, error) {
+	var (
+		rule  entities.AlertRule
+		rules []entities.AlertRule
+	)
+	_, err := p.DB.QueryFunc(
+		ctx,
+		"select id, ownerId, appId, kind, type, condition, threshold, webhookUrl, secret, enabled, createdAt"+
+			" from alert_rules where appId = $1 and kind = $2 and enabled order by id",
+		[]interface{}{appId, kind},
+		[]interface{}{
+			&rule.Id, &rule.OwnerId, &rule.AppId, &rule.Kind, &rule.Type, &rule.Condition,
+			&rule.Threshold, &rule.WebhookUrl, &rule.Secret, &rule.Enabled, &rule.CreatedAt,
+		},
+		func(pgx.QueryFuncRow) error {
+			rules = append(rules, rule)
+			return nil
+		},
+	)
+
+	return rules, err
+}
+
+// Places returns row with given id and the previous row of the same app and type
+func (p *PgStore) Places(ctx context.Context, kind entities.AlertKind, id int) (entities.Track, *entities.Track, error) {
+	table, ok := placeTables[kind]
+	if !ok {
+		return entities.Track{}, nil, fmt.Errorf("rows of %s have not places", kind)
+	}
+
+	var (
+		track  entities.Track
+		tracks []entities.Track
+	)
+	_, err := p.DB.QueryFunc(
+		ctx,
+		"with CUR as (select bundleId, type, date, id from "+table+" where id = $1)"+
+			" select T.id, T.bundleId, T.type, T.place, T.date, APP.id, APP.bundle, APP.geo from "+table+" T"+
+			" inner join CUR on T.bundleId = CUR.bundleId and T.type = CUR.type inner join app_tracking APP on T.bundleId = APP.id"+
+			" where (T.date, T.id) <= (CUR.date, CUR.id) order by T.date desc, T.id desc limit 2",
+		[]interface{}{id},
+		[]interface{}{&track.Id, &track.BundleId, &track.Type, &track.Place, &track.Date, &track.App.Id, &track.App.Bundle, &track.App.Geo},
+		func(pgx.QueryFuncRow) error {
+			tracks = append(tracks, track)
+			return nil
+		},
+	)
+	if err != nil {
+		return entities.Track{}, nil, err
+	}
+	switch len(tracks) {
+	case 0:
+		return entities.Track{}, nil, pgx.ErrNoRows
+	case 1:
+		return tracks[0], nil, nil
+	}
+
+	return tracks[0], &tracks[1], nil
+}
+
+// Metas returns snapshot with given id and the previous snapshot of the same app
+func (p *PgStore) Metas(ctx context.Context, id int) (entities.Meta, *entities.Meta, error) {
+	dbo, err := (&metastore.Repo{Conn: p.DB}).WithPrevious(ctx, id)
+	if err != nil {
+		return entities.Meta{}, nil, err
+	}
+
+	metas := make([]entities.Meta, len(dbo))
+	for i, v := range dbo {
+		if err := v.To(&metas[i]); err != nil {
+			return entities.Meta{}, nil, err
+		}
+	}
+	if len(metas) == 1 {
+		return metas[0], nil, nil
+	}
+
+	return metas[0], &metas[1], nil
+}
+
+// Enqueue inserts pending deliveries, deliveries of the same rule and tracking row are skipped
+// by unique index, so every instance can evaluate every row
+func (p *PgStore) Enqueue(ctx context.Context, deliveries []entities.AlertDelivery) error {
+	batch := &pgx.Batch{}
+	for _, d := range deliveries {
+		batch.Queue(
+			"insert into alert_deliveries (ruleId, kind, trackingId, payload, status, createdAt, nextAttemptAt)"+
+				" values ($1, nullif($2, ''), nullif($3, 0), $4, $5, $6, $7) on conflict (ruleId, kind, trackingId) do nothing",
+			d.RuleId, d.Kind, d.TrackingId, d.Payload, d.Status, d.CreatedAt, d.NextAttemptAt,
+		)
+	}
+
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	results := tx.SendBatch(ctx, batch)
+	for range deliveries {
+		if _, err := results.Exec(); err != nil {
+			_ = results.Close()
+			return err
+		}
+	}
+	if err := results.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Watermark returns id of the last scanned row of kind, zero if kind was never scanned
+func (p *PgStore) Watermark(ctx context.Context, kind entities.AlertKind) (int64, error) {
+	return worker.Watermarks{DB: p.DB}.Get(ctx, worker.Source("alerts", scanTables[kind]))
+}
+
+// Scan reads ids of at most limit rows of kind after id and returns events of rows
+// which apps have enabled rules of kind
+func (p *PgStore) Scan(ctx context.Context, kind entities.AlertKind, after int64, limit int) (Batch, error) {
+	table, ok := scanTables[kind]
+	if !ok {
+		return Batch{}, fmt.Errorf("rows of %s are not tracked", kind)
+	}
+
+	next, err := worker.Next(ctx, p.DB, table, after, limit)
+	batch := Batch{Batch: next}
+	if err != nil || batch.Count == 0 {
+		return batch, err
+	}
+
+	event := events.Event{Kind: events.Kind(kind)}
+	_, err = p.DB.QueryFunc(
+		ctx,
+		"select id, bundleId, date from "+table+" where id > $1 and id <= $2"+
+			" and bundleId in (select appId from alert_rules where kind = $3 and enabled) order by id",
+		[]interface{}{after, batch.LastId, kind},
+		[]interface{}{&event.Id, &event.BundleId, &event.Date},
+		func(pgx.QueryFuncRow) error {
+			batch.Events = append(batch.Events, event)
+			return nil
+		},
+	)
+
+	return batch, err
+}
+
+// Advance moves watermark of kind forward to id, watermark never moves back
+func (p *PgStore) Advance(ctx context.Context, kind entities.AlertKind, id int64, caughtUp bool) error {
+	return worker.Watermarks{DB: p.DB}.Advance(ctx, worker.Source("alerts", scanTables[kind]), id, caughtUp)
+}
+
+// Lease selects due deliveries of enabled rules with FOR UPDATE SKIP LOCKED, so
+// deliveries locked by other instance are skipped, and postpones them in the same transaction
+func (p *PgStore) Lease(ctx context.Context, now, until time.Time, limit int) ([]Delivery, error) {
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		deliveries []Delivery
+		d          Delivery
+	)
+	_, err = tx.QueryFunc(
+		ctx,
+		"select D.id, D.ruleId, D.payload, D.status, D.attempts, coalesce(D.responseStatus, 0), D.lastError,"+
+			" D.createdAt, D.nextAttemptAt, R.webhookUrl, R.secret"+
+			" from alert_deliveries D inner join alert_rules R on D.ruleId = R.id"+
+			" where D.status = $1 and D.nextAttemptAt <= $2 and R.enabled"+
+			" order by D.nextAttemptAt, D.id limit $3 for update of D skip locked",
+		[]interface{}{entities.DeliveryPending, now, limit},
+		[]interface{}{
+			&d.Id, &d.RuleId, &d.Payload, &d.Status, &d.Attempts, &d.ResponseStatus, &d.LastError,
+			&d.CreatedAt, &d.NextAttemptAt, &d.WebhookUrl, &d.Secret,
+		},
+		func(pgx.QueryFuncRow) error {
+			deliveries = append(deliveries, d)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(deliveries))
+	for i, delivery := range deliveries {
+		ids[i] = delivery.Id
+	}
+	if _, err := tx.Exec(ctx, "update alert_deliveries set nextAttemptAt = $2 where id = any($1)", ids, until); err != nil {
+		return nil, err
+	}
+
+	return deliveries, tx.Commit(ctx)
+}
+
+// Finish saves result of attempt
+func (p *PgStore) Finish(ctx context.Context, d entities.AlertDelivery) error {
+	var status *int
+	if d.ResponseStatus != 0 {
+		status = &d.ResponseStatus
+	}
+	_, err := p.DB.Exec(
+		ctx,
+		"update alert_deliveries set status = $2, attempts = $3, responseStatus = $4, lastError = $5, nextAttemptAt = $6, deliveredAt = $7 where id = $1",
+		d.Id, d.Status, d.Attempts, status, d.LastError, d.NextAttemptAt, d.DeliveredAt,
+	)
+
+	return err
+}
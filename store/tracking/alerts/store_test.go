@@ -0,0 +1,108 @@
+package alerts_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking/alerts"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPgStore_ShouldFindPreviousRowsAndLeaseDeliveriesOnce(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "keyword_tracking", "meta_tracking", "users", "alert_rules", "alert_deliveries", "watermarks")
+	cleaner("watermarks")
+	store := alerts.PgStore{DB: conn}
+	ctx := context.Background()
+
+	appId, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "com.muromachi.bank", Geo: "en_us"})
+	assert.NoError(t, err)
+	var ownerId int
+	assert.NoError(t, conn.QueryRow(ctx, "insert into users (clientId, clientSecret, company) values ('id', 'secret', 'company') returning id").Scan(&ownerId))
+
+	track := testhelpers.TrackStruct(appId, "bank")
+	first, err := testhelpers.AddNewTrack(conn, ctx, track, "keyword_tracking")
+	assert.NoError(t, err)
+	track.Date, track.Place = track.Date.Add(time.Hour*24), 30
+	second, err := testhelpers.AddNewTrack(conn, ctx, track, "keyword_tracking")
+	assert.NoError(t, err)
+
+	cur, prev, err := store.Places(ctx, entities.AlertKeyword, first)
+	assert.NoError(t, err)
+	assert.Nil(t, prev)
+	assert.Equal(t, int32(19), cur.Place)
+	cur, prev, err = store.Places(ctx, entities.AlertKeyword, second)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(30), cur.Place)
+	assert.Equal(t, "com.muromachi.bank", cur.App.Bundle)
+	assert.Equal(t, first, prev.Id)
+
+	meta := testhelpers.MetaStruct(appId)
+	_, err = testhelpers.AddNewMeta(conn, ctx, meta)
+	assert.NoError(t, err)
+	meta.Title, meta.Date = "New title", meta.Date.Add(time.Hour*24)
+	last, err := testhelpers.AddNewMeta(conn, ctx, meta)
+	assert.NoError(t, err)
+	curMeta, prevMeta, err := store.Metas(ctx, last)
+	assert.NoError(t, err)
+	assert.Equal(t, "New title", curMeta.Title)
+	assert.Equal(t, "Im title", prevMeta.Title)
+
+	var ruleId int
+	assert.NoError(t, conn.QueryRow(
+		ctx,
+		"insert into alert_rules (ownerId, appId, kind, type, condition, threshold, webhookUrl, secret) values ($1, $2, 'keyword', 'bank', 'below', 10, 'http://hook', 'secret') returning id",
+		ownerId, appId,
+	).Scan(&ruleId))
+	rules, err := store.Rules(ctx, appId, entities.AlertKeyword)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+
+	// Both rows of app with rule are scanned
+	lastId, err := store.Watermark(ctx, entities.AlertKeyword)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), lastId)
+	batch, err := store.Scan(ctx, entities.AlertKeyword, lastId, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, batch.Count)
+	assert.Equal(t, int64(second), batch.LastId)
+	assert.Len(t, batch.Events, 2)
+	assert.Equal(t, appId, batch.Events[1].BundleId)
+	assert.NoError(t, store.Advance(ctx, entities.AlertKeyword, batch.LastId, true))
+	assert.NoError(t, store.Advance(ctx, entities.AlertKeyword, int64(first), false))
+	lastId, err = store.Watermark(ctx, entities.AlertKeyword)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(second), lastId)
+
+	// Delivery of the same rule and row is enqueued once
+	now := time.Date(2021, 1, 20, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, store.Enqueue(ctx, []entities.AlertDelivery{{
+			RuleId: ruleId, Kind: entities.AlertKeyword, TrackingId: int64(second), Payload: []byte(`{"ruleId":1}`),
+			Status: entities.DeliveryPending, CreatedAt: now, NextAttemptAt: now,
+		}}))
+	}
+
+	// Second instance does not get deliveries leased by first one
+	leased, err := store.Lease(ctx, now, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	again, err := store.Lease(ctx, now, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, leased, 1)
+	assert.Empty(t, again)
+	assert.Equal(t, "http://hook", leased[0].WebhookUrl)
+	assert.Equal(t, "secret", leased[0].Secret)
+
+	delivery := leased[0].AlertDelivery
+	delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.DeliveredAt = entities.DeliveryDelivered, 1, 200, &now
+	assert.NoError(t, store.Finish(ctx, delivery))
+
+	var status string
+	assert.NoError(t, conn.QueryRow(ctx, "select status from alert_deliveries where id = $1", delivery.Id).Scan(&status))
+	assert.Equal(t, "delivered", status)
+}
@@ -0,0 +1,109 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// Webhook url is not absolute http url
+	ErrWebhookUrl = errors.New("webhook url should be absolute http or https url")
+	// Webhook url points to address of internal network
+	ErrWebhookAddress = errors.New("webhook url should not point to loopback, private or link-local address")
+)
+
+// Networks of private and shared addresses, loopback and link-local ones are checked
+// by methods of net.IP
+var privateNets = parseNets(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fc00::/7",
+)
+
+// AllowedIP reports whether webhooks can be sent to ip. Loopback, private, link-local
+// and multicast addresses are not allowed, so clients can't reach internal services
+func AllowedIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return false
+	}
+	for _, n := range privateNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateWebhook checks that webhook is absolute http url and its host is not
+// internal address. Host names are resolved when webhook is sent, so addresses of
+// names are checked by dialer of NewClient
+func ValidateWebhook(webhook string) error {
+	u, err := url.Parse(webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrWebhookUrl
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && !AllowedIP(ip) {
+		return ErrWebhookAddress
+	}
+
+	return nil
+}
+
+// NewClient returns client of webhooks with given timeout. Client checks resolved
+// address of every connection by AllowedIP and does not follow redirects, so
+// webhooks are not sent to internal network via names or redirects
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !AllowedIP(net.ParseIP(host)) {
+				return fmt.Errorf("%w: %s", ErrWebhookAddress, host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// parseNets parses cidr networks
+func parseNets(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
@@ -0,0 +1,67 @@
+package alerts_test
+
+import (
+	"Muromachi/store/tracking/alerts"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAllowedIP(t *testing.T) {
+	var tt = []struct {
+		ip      string
+		allowed bool
+	}{
+		{ip: "93.184.216.34", allowed: true},
+		{ip: "2606:2800:220:1::248", allowed: true},
+		{ip: "127.0.0.1"},
+		{ip: "::1"},
+		{ip: "0.0.0.0"},
+		{ip: "10.1.2.3"},
+		{ip: "172.20.0.1"},
+		{ip: "192.168.1.1"},
+		{ip: "100.64.0.1"},
+		{ip: "169.254.169.254"},
+		{ip: "fe80::1"},
+		{ip: "fd00::1"},
+		{ip: "::ffff:127.0.0.1"},
+	}
+	for _, test := range tt {
+		t.Run(test.ip, func(t *testing.T) {
+			assert.Equal(t, test.allowed, alerts.AllowedIP(net.ParseIP(test.ip)))
+		})
+	}
+}
+
+func TestValidateWebhook(t *testing.T) {
+	assert.NoError(t, alerts.ValidateWebhook("https://hooks.example.com/alerts"))
+	assert.NoError(t, alerts.ValidateWebhook("http://93.184.216.34:8080/hook"))
+
+	assert.Equal(t, alerts.ErrWebhookUrl, alerts.ValidateWebhook("/hook"))
+	assert.Equal(t, alerts.ErrWebhookUrl, alerts.ValidateWebhook("ftp://hook"))
+	assert.Equal(t, alerts.ErrWebhookUrl, alerts.ValidateWebhook("http://:80/hook"))
+
+	assert.Equal(t, alerts.ErrWebhookAddress, alerts.ValidateWebhook("http://localhost:6379"))
+	assert.Equal(t, alerts.ErrWebhookAddress, alerts.ValidateWebhook("http://127.0.0.1:5432"))
+	assert.Equal(t, alerts.ErrWebhookAddress, alerts.ValidateWebhook("http://[::1]/hook"))
+	assert.Equal(t, alerts.ErrWebhookAddress, alerts.ValidateWebhook("http://169.254.169.254/latest/meta-data"))
+	assert.Equal(t, alerts.ErrWebhookAddress, alerts.ValidateWebhook("https://10.0.0.5/hook"))
+}
+
+func TestNewClient_ShouldNotConnectToInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// Server listens on loopback, so its address is checked when connection is dialed
+	_, err := alerts.NewClient(time.Second).Get(server.URL)
+	assert.True(t, errors.Is(err, alerts.ErrWebhookAddress), err)
+}
+
+func TestNewClient_ShouldNotFollowRedirects(t *testing.T) {
+	client := alerts.NewClient(time.Second)
+	assert.Equal(t, http.ErrUseLastResponse, client.CheckRedirect(nil, nil))
+}
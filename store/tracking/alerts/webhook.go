@@ -0,0 +1,242 @@
+package alerts
+
+import (
+	"Muromachi/logging"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/worker"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// Default delay between checks of pending webhooks
+	DefaultInterval = time.Second * 10
+	// Default count of webhooks leased at once
+	DefaultBatchSize = 20
+	// Default timeout of single webhook request
+	DefaultTimeout = time.Second * 10
+	// Default count of attempts before webhook is failed
+	DefaultMaxAttempts = 8
+	// Default delay before the first retry
+	DefaultBaseDelay = time.Second * 30
+	// Default max delay between retries
+	DefaultMaxDelay = time.Hour
+)
+
+// Headers of webhook request
+const (
+	// Hex HMAC-SHA256 of body with secret of rule prefixed by sha256=
+	SignatureHeader = "X-Muromachi-Signature"
+	// Id of delivery, it is the same for every attempt
+	DeliveryHeader = "X-Muromachi-Delivery"
+	// Number of attempt starting from 1
+	AttemptHeader = "X-Muromachi-Attempt"
+)
+
+// Max size of response body which is read before connection is reused
+const maxResponseSize = 64 << 10
+
+// Webhook with target of its rule
+type Delivery struct {
+	entities.AlertDelivery
+	WebhookUrl string
+	Secret     string
+}
+
+// Store of webhooks log
+type DeliveryStore interface {
+	// Lease locks at most limit pending deliveries which are due at now and
+	// postpones them until given time, so other instances skip them while they are sent
+	Lease(ctx context.Context, now, until time.Time, limit int) ([]Delivery, error)
+	// Finish saves result of attempt
+	Finish(ctx context.Context, delivery entities.AlertDelivery) error
+}
+
+// Client which sends webhooks, *http.Client satisfies it
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Sign returns signature of body which is sent in SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns delay before retry after given count of failed attempts.
+// Delay is doubled after every attempt and is limited by max
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+
+	return delay
+}
+
+// Deliverer sends pending webhooks and retries failed ones with exponential
+// backoff. Deliveries are leased in store, so several instances can share work
+type Deliverer struct {
+	Store DeliveryStore
+	HTTP  HTTPClient
+	Now   worker.Clock
+	// Delay between checks of pending webhooks
+	Interval time.Duration
+	// Count of webhooks leased at once
+	BatchSize int
+	// Timeout of single request
+	Timeout time.Duration
+	// Count of attempts before webhook is failed
+	MaxAttempts int
+	// Delay before the first retry, next delays are doubled
+	BaseDelay time.Duration
+	// Max delay between retries
+	MaxDelay time.Duration
+	// Prefix of log messages, alerts by default
+	Name string
+}
+
+// Run sends pending webhooks every interval until ctx is done
+func (d *Deliverer) Run(ctx context.Context) error {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return worker.Run(ctx, d.name(), interval, d.batchSize(), d.DeliverDue)
+}
+
+// DeliverDue leases one batch of due webhooks and sends them one by one.
+// Returns count of leased webhooks
+func (d *Deliverer) DeliverDue(ctx context.Context) (int, error) {
+	now := d.Now.UTC()
+	// Webhooks of batch are sent one by one, so lease outlives requests of the whole
+	// batch and webhook is not sent twice by other instance
+	lease := d.timeout() * time.Duration(d.batchSize()+1)
+	deliveries, err := d.Store.Lease(ctx, now, now.Add(lease), d.batchSize())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		result := d.attempt(ctx, delivery)
+		if result.Status == entities.DeliveryFailed {
+			logging.Warnf("%s: webhook %d failed after %d attempts: %s", d.name(), result.Id, result.Attempts, result.LastError)
+		}
+		// Result is saved even if ctx is done, otherwise webhook waits for end of lease
+		if err := d.Store.Finish(context.Background(), result); err != nil {
+			return len(deliveries), err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// attempt sends webhook once and returns delivery with result of attempt
+func (d *Deliverer) attempt(ctx context.Context, delivery Delivery) entities.AlertDelivery {
+	result := delivery.AlertDelivery
+	result.Attempts++
+
+	status, err := d.send(ctx, delivery, result.Attempts)
+	now := d.Now.UTC()
+	result.ResponseStatus = status
+	if err == nil {
+		result.Status = entities.DeliveryDelivered
+		result.LastError = ""
+		result.DeliveredAt = &now
+		return result
+	}
+
+	result.LastError = err.Error()
+	if result.Attempts >= d.maxAttempts() {
+		result.Status = entities.DeliveryFailed
+		return result
+	}
+	result.NextAttemptAt = now.Add(Backoff(result.Attempts, d.baseDelay(), d.maxDelay()))
+
+	return result
+}
+
+// send posts signed payload to webhook url. Any status except 2xx is error
+func (d *Deliverer) send(ctx context.Context, delivery Delivery, attempt int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.WebhookUrl, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, delivery.Payload))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(AttemptHeader, strconv.Itoa(attempt))
+
+	resp, err := d.HTTP.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (d *Deliverer) batchSize() int {
+	if d.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return d.BatchSize
+}
+
+func (d *Deliverer) timeout() time.Duration {
+	if d.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return d.Timeout
+}
+
+func (d *Deliverer) maxAttempts() int {
+	if d.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return d.MaxAttempts
+}
+
+func (d *Deliverer) baseDelay() time.Duration {
+	if d.BaseDelay <= 0 {
+		return DefaultBaseDelay
+	}
+	return d.BaseDelay
+}
+
+func (d *Deliverer) maxDelay() time.Duration {
+	if d.MaxDelay <= 0 {
+		return DefaultMaxDelay
+	}
+	return d.MaxDelay
+}
+
+func (d *Deliverer) name() string {
+	if d.Name == "" {
+		return "alerts"
+	}
+	return d.Name
+}
@@ -0,0 +1,163 @@
+package alerts_test
+
+import (
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/alerts"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Store which keeps deliveries in memory
+type mockDeliveryStore struct {
+	mu         sync.Mutex
+	deliveries []alerts.Delivery
+	until      time.Time
+}
+
+func (m *mockDeliveryStore) Lease(ctx context.Context, now, until time.Time, limit int) ([]alerts.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.until = until
+	var leased []alerts.Delivery
+	for i, d := range m.deliveries {
+		if d.Status != entities.DeliveryPending || d.NextAttemptAt.After(now) || len(leased) == limit {
+			continue
+		}
+		m.deliveries[i].NextAttemptAt = until
+		leased = append(leased, d)
+	}
+	return leased, nil
+}
+
+func (m *mockDeliveryStore) Finish(ctx context.Context, delivery entities.AlertDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, d := range m.deliveries {
+		if d.Id == delivery.Id {
+			m.deliveries[i].AlertDelivery = delivery
+		}
+	}
+	return nil
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{"ruleId":1}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=0684a30b18df4ab9656029c2e54fac4422ed9bee3a867e08fda42928cd14be86", alerts.Sign("secret", []byte(`{"ruleId":1}`)))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second*30, alerts.Backoff(1, time.Second*30, time.Hour))
+	assert.Equal(t, time.Minute, alerts.Backoff(2, time.Second*30, time.Hour))
+	assert.Equal(t, time.Minute*4, alerts.Backoff(4, time.Second*30, time.Hour))
+	assert.Equal(t, time.Hour, alerts.Backoff(20, time.Second*30, time.Hour))
+}
+
+func TestDeliverer_DeliverDue_ShouldSendSignedWebhooksAndRetryFailures_Mock(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []*http.Request
+		bodies   []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r)
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Date(2021, 1, 18, 10, 0, 0, 0, time.UTC)
+	pending := func(id int64, path string) alerts.Delivery {
+		return alerts.Delivery{
+			AlertDelivery: entities.AlertDelivery{
+				Id:            id,
+				RuleId:        int(id),
+				Payload:       []byte(`{"ruleId":1}`),
+				Status:        entities.DeliveryPending,
+				NextAttemptAt: now,
+			},
+			WebhookUrl: server.URL + path,
+			Secret:     "secret",
+		}
+	}
+	store := &mockDeliveryStore{deliveries: []alerts.Delivery{pending(1, "/ok"), pending(2, "/broken")}}
+	deliverer := alerts.Deliverer{
+		Store:       store,
+		HTTP:        server.Client(),
+		MaxAttempts: 2,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		Now:         func() time.Time { return now },
+	}
+
+	count, err := deliverer.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.Len(t, received, 2)
+	assert.Equal(t, `{"ruleId":1}`, bodies[0])
+	assert.Equal(t, alerts.Sign("secret", []byte(bodies[0])), received[0].Header.Get(alerts.SignatureHeader))
+	assert.Equal(t, "1", received[0].Header.Get(alerts.DeliveryHeader))
+	assert.Equal(t, "1", received[0].Header.Get(alerts.AttemptHeader))
+
+	ok, broken := store.deliveries[0], store.deliveries[1]
+	assert.Equal(t, entities.DeliveryDelivered, ok.Status)
+	assert.Equal(t, http.StatusOK, ok.ResponseStatus)
+	assert.Equal(t, now, *ok.DeliveredAt)
+	assert.Equal(t, entities.DeliveryPending, broken.Status)
+	assert.Equal(t, 1, broken.Attempts)
+	assert.Equal(t, http.StatusBadGateway, broken.ResponseStatus)
+	assert.Equal(t, now.Add(time.Minute), broken.NextAttemptAt)
+	assert.Equal(t, "webhook responded with status 502", broken.LastError)
+
+	// Retry is not due yet
+	count, err = deliverer.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	now = now.Add(time.Minute)
+	count, err = deliverer.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "2", received[2].Header.Get(alerts.AttemptHeader))
+	assert.Equal(t, entities.DeliveryFailed, store.deliveries[1].Status)
+	assert.Equal(t, 2, store.deliveries[1].Attempts)
+}
+
+func TestDeliverer_DeliverDue_ShouldLeaseWebhooksForWholeBatch_Mock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	now := time.Date(2021, 1, 18, 10, 0, 0, 0, time.UTC)
+	store := &mockDeliveryStore{}
+	for i := int64(1); i <= 3; i++ {
+		store.deliveries = append(store.deliveries, alerts.Delivery{
+			AlertDelivery: entities.AlertDelivery{Id: i, Status: entities.DeliveryPending, NextAttemptAt: now},
+			WebhookUrl:    server.URL,
+		})
+	}
+	deliverer := alerts.Deliverer{
+		Store:     store,
+		HTTP:      server.Client(),
+		BatchSize: 3,
+		Timeout:   time.Second * 10,
+		Now:       func() time.Time { return now },
+	}
+
+	count, err := deliverer.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	// Every webhook of batch can take the whole timeout, so lease is longer than all of them
+	assert.Equal(t, now.Add(time.Second*40), store.until)
+}
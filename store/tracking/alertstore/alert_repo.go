@@ -0,0 +1,131 @@
+package alertstore
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/entities"
+	"context"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	// Columns of alert_rules in order of scanning
+	ruleColumns = "id, ownerId, appId, kind, type, condition, threshold, webhookUrl, secret, enabled, createdAt"
+	// Columns of alert_deliveries in order of scanning
+	deliveryColumns = "id, ruleId, payload, status, attempts, coalesce(responseStatus, 0), lastError, createdAt, nextAttemptAt, deliveredAt"
+)
+
+// Repository of alert rules and their delivery log
+type Repo struct {
+	Conn connector.Conn
+}
+
+// Making database queries of rules
+func (a *Repo) ProducerFunc(ctx context.Context, sql string, params ...interface{}) (entities.DboSlice, error) {
+	var rule entities.AlertRule
+	var rules []entities.DBO
+
+	_, err := a.Conn.QueryFunc(
+		ctx,
+		sql,
+		params,
+		[]interface{}{
+			&rule.Id, &rule.OwnerId, &rule.AppId, &rule.Kind, &rule.Type, &rule.Condition,
+			&rule.Threshold, &rule.WebhookUrl, &rule.Secret, &rule.Enabled, &rule.CreatedAt,
+		},
+		func(row pgx.QueryFuncRow) error {
+			rules = append(rules, rule)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return rules, nil
+}
+
+// Return rules of owner, zero appId means rules of all apps
+func (a *Repo) Rules(ctx context.Context, ownerId, appId int) (entities.DboSlice, error) {
+	return a.ProducerFunc(
+		ctx,
+		"select "+ruleColumns+" from alert_rules where ownerId = $1 and ($2 = 0 or appId = $2) order by id",
+		ownerId, appId,
+	)
+}
+
+// Get rule by id
+func (a *Repo) RuleById(ctx context.Context, id int) (entities.AlertRule, error) {
+	return a.one(ctx, "select "+ruleColumns+" from alert_rules where id = $1", id)
+}
+
+// Insert new enabled rule
+func (a *Repo) CreateRule(ctx context.Context, rule entities.AlertRule) (entities.AlertRule, error) {
+	return a.one(
+		ctx,
+		"insert into alert_rules (ownerId, appId, kind, type, condition, threshold, webhookUrl, secret)"+
+			" values ($1, $2, $3, $4, $5, $6, $7, $8) returning "+ruleColumns,
+		rule.OwnerId, rule.AppId, rule.Kind, rule.Type, rule.Condition, rule.Threshold, rule.WebhookUrl, rule.Secret,
+	)
+}
+
+// Enable or disable rule
+func (a *Repo) SetRuleEnabled(ctx context.Context, id int, enabled bool) (entities.AlertRule, error) {
+	return a.one(ctx, "update alert_rules set enabled = $2 where id = $1 returning "+ruleColumns, id, enabled)
+}
+
+// Delete rule with its deliveries
+func (a *Repo) DeleteRule(ctx context.Context, id int) error {
+	tag, err := a.Conn.Exec(ctx, "delete from alert_rules where id = $1", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// Return last deliveries of rule, newest first
+func (a *Repo) Deliveries(ctx context.Context, ruleId, limit int) (entities.DboSlice, error) {
+	var delivery entities.AlertDelivery
+	var deliveries []entities.DBO
+
+	_, err := a.Conn.QueryFunc(
+		ctx,
+		"select "+deliveryColumns+" from alert_deliveries where ruleId = $1 order by id desc limit $2",
+		[]interface{}{ruleId, limit},
+		[]interface{}{
+			&delivery.Id, &delivery.RuleId, &delivery.Payload, &delivery.Status, &delivery.Attempts,
+			&delivery.ResponseStatus, &delivery.LastError, &delivery.CreatedAt, &delivery.NextAttemptAt,
+			&delivery.DeliveredAt,
+		},
+		func(row pgx.QueryFuncRow) error {
+			deliveries = append(deliveries, delivery)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return deliveries, nil
+}
+
+// one returns the first rule selected by query
+func (a *Repo) one(ctx context.Context, sql string, params ...interface{}) (entities.AlertRule, error) {
+	var rule entities.AlertRule
+	dbo, err := a.ProducerFunc(ctx, sql, params...)
+	if err != nil {
+		return rule, err
+	}
+	err = dbo[0].To(&rule)
+
+	return rule, err
+}
@@ -0,0 +1,60 @@
+package alertstore_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking/alertstore"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAlertRepo_ShouldCreateChangeAndDeleteRules(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "users", "alert_rules", "alert_deliveries")
+	repo := alertstore.Repo{Conn: conn}
+	ctx := context.Background()
+
+	appId, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "com.muromachi.bank", Geo: "en_us"})
+	assert.NoError(t, err)
+	var ownerId int
+	assert.NoError(t, conn.QueryRow(ctx, "insert into users (clientId, clientSecret, company) values ('id', 'secret', 'company') returning id").Scan(&ownerId))
+
+	rule, err := repo.CreateRule(ctx, entities.AlertRule{
+		OwnerId:    ownerId,
+		AppId:      appId,
+		Kind:       entities.AlertKeyword,
+		Type:       "bank",
+		Condition:  entities.AlertBelow,
+		Threshold:  10,
+		WebhookUrl: "https://hook",
+		Secret:     "secret",
+	})
+	assert.NoError(t, err)
+	assert.True(t, rule.Enabled)
+	assert.False(t, rule.CreatedAt.IsZero())
+
+	dbo, err := repo.Rules(ctx, ownerId, 0)
+	assert.NoError(t, err)
+	assert.Len(t, dbo, 1)
+	_, err = repo.Rules(ctx, ownerId, appId+1)
+	assert.Equal(t, pgx.ErrNoRows, err)
+
+	rule, err = repo.SetRuleEnabled(ctx, rule.Id, false)
+	assert.NoError(t, err)
+	assert.False(t, rule.Enabled)
+
+	_, err = conn.Exec(ctx, "insert into alert_deliveries (ruleId, payload, createdAt, nextAttemptAt) values ($1, '{}', now(), now())", rule.Id)
+	assert.NoError(t, err)
+	deliveries, err := repo.Deliveries(ctx, rule.Id, 10)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	assert.NoError(t, repo.DeleteRule(ctx, rule.Id))
+	assert.Equal(t, pgx.ErrNoRows, repo.DeleteRule(ctx, rule.Id))
+	_, err = repo.Deliveries(ctx, rule.Id, 10)
+	assert.Equal(t, pgx.ErrNoRows, err)
+}
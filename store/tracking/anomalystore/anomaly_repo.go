@@ -0,0 +1,103 @@
+package anomalystore
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/entities"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// Columns of anomalies in order of scanning
+const columns = "id, bundleId, metric, type, date, value, change, baseline, score, status, detectedAt"
+
+// Repository of detected anomalies
+type Repo struct {
+	Conn connector.Conn
+}
+
+// Making database queries
+func (a *Repo) ProducerFunc(ctx context.Context, sql string, params ...interface{}) (entities.DboSlice, error) {
+	var anomaly entities.Anomaly
+	var anomalies []entities.DBO
+
+	_, err := a.Conn.QueryFunc(
+		ctx,
+		sql,
+		params,
+		[]interface{}{
+			&anomaly.Id, &anomaly.BundleId, &anomaly.Metric, &anomaly.Type, &anomaly.Date, &anomaly.Value,
+			&anomaly.Change, &anomaly.Baseline, &anomaly.Score, &anomaly.Status, &anomaly.DetectedAt,
+		},
+		func(row pgx.QueryFuncRow) error {
+			anomalies = append(anomalies, anomaly)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(anomalies) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return anomalies, nil
+}
+
+// Return anomalies of app within time range from start to end ordered by date.
+// Zero start or end means that range is not bounded from this side
+func (a *Repo) Anomalies(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
+	return a.ProducerFunc(
+		ctx,
+		"select "+columns+" from anomalies where bundleId = $1 and ($2::timestamp is null or date >= $2) and ($3::timestamp is null or date <= $3) order by date, id",
+		bundleId, connector.NullTime(start), connector.NullTime(end),
+	)
+}
+
+// Save new anomalies with one statement. Anomaly which was already detected keeps its review status
+func (a *Repo) Save(ctx context.Context, anomalies []entities.Anomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	// Columns are passed as arrays and unnested to rows
+	n := len(anomalies)
+	bundleIds, dates := make([]int, n), make([]time.Time, n)
+	metrics, types := make([]string, n), make([]string, n)
+	values, changes, baselines, scores := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	for i, v := range anomalies {
+		bundleIds[i], metrics[i], types[i], dates[i] = v.BundleId, string(v.Metric), v.Type, v.Date
+		values[i], changes[i], baselines[i], scores[i] = v.Value, v.Change, v.Baseline, v.Score
+	}
+	_, err := a.Conn.Exec(
+		ctx,
+		"insert into anomalies (bundleId, metric, type, date, value, change, baseline, score, status)"+
+			" select *, $9::text from unnest($1::int[], $2::text[], $3::text[], $4::timestamp[], $5::float8[], $6::float8[], $7::float8[], $8::float8[])"+
+			" on conflict (bundleId, metric, type, date) do nothing",
+		bundleIds, metrics, types, dates, values, changes, baselines, scores, entities.AnomalyNew,
+	)
+
+	return err
+}
+
+// Get anomaly by id
+func (a *Repo) ById(ctx context.Context, id int) (entities.Anomaly, error) {
+	return a.one(ctx, "select "+columns+" from anomalies where id = $1", id)
+}
+
+// Change review status of anomaly
+func (a *Repo) SetStatus(ctx context.Context, id int, status entities.AnomalyStatus) (entities.Anomaly, error) {
+	return a.one(ctx, "update anomalies set status = $2 where id = $1 returning "+columns, id, status)
+}
+
+// one returns the first anomaly selected by query
+func (a *Repo) one(ctx context.Context, sql string, params ...interface{}) (entities.Anomaly, error) {
+	var anomaly entities.Anomaly
+	dbo, err := a.ProducerFunc(ctx, sql, params...)
+	if err != nil {
+		return anomaly, err
+	}
+	err = dbo[0].To(&anomaly)
+
+	return anomaly, err
+}
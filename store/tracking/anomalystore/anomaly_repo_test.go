@@ -0,0 +1,55 @@
+package anomalystore_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking/anomalystore"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAnomalyRepo_ShouldSaveAnomaliesOnceAndChangeStatus(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "anomalies")
+	repo := anomalystore.Repo{Conn: conn}
+	ctx := context.Background()
+
+	appId, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "com.muromachi.bank", Geo: "en_us"})
+	assert.NoError(t, err)
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	anomalies := []entities.Anomaly{
+		{BundleId: appId, Metric: entities.AnomalyKeyword, Type: "bank", Date: day, Value: 40, Change: 29, Score: 19.5},
+		{BundleId: appId, Metric: entities.AnomalyRating, Date: day.AddDate(0, 0, 1), Value: 3.9, Change: -0.6, Score: -12},
+	}
+	assert.NoError(t, repo.Save(ctx, anomalies))
+
+	dbo, err := repo.Anomalies(ctx, appId, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, dbo, 2)
+	var saved entities.Anomaly
+	assert.NoError(t, dbo[0].To(&saved))
+	assert.Equal(t, "bank", saved.Type)
+	assert.Equal(t, entities.AnomalyNew, saved.Status)
+	assert.False(t, saved.DetectedAt.IsZero())
+
+	saved, err = repo.SetStatus(ctx, int(saved.Id), entities.AnomalyConfirmed)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.AnomalyConfirmed, saved.Status)
+
+	// Saved again anomaly keeps review status
+	assert.NoError(t, repo.Save(ctx, anomalies[:1]))
+	dbo, err = repo.Anomalies(ctx, appId, day, day)
+	assert.NoError(t, err)
+	assert.Len(t, dbo, 1)
+	saved, err = repo.ById(ctx, int(saved.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, entities.AnomalyConfirmed, saved.Status)
+
+	_, err = repo.Anomalies(ctx, appId, day.AddDate(0, 0, 2), time.Time{})
+	assert.Equal(t, pgx.ErrNoRows, err)
+}
@@ -1,14 +1,25 @@
 package appstore
 
 import (
+	"Muromachi/apperrors"
 	"Muromachi/store/connector"
 	"Muromachi/store/entities"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// Columns of app_tracking in order of scanning
+const columns = "id, bundle, category, developerId, developer, geo, startAt, period, status, coalesce(ownerId, 0), store"
+
+// Postgres code of unique constraint violation
+const uniqueViolation = "23505"
+
 // Struct for holds connection with db
 type Repo struct {
 	Conn connector.Conn
@@ -26,6 +37,7 @@ func (a *Repo) ProducerFunc(ctx context.Context, sql string, params ...interface
 		[]interface{}{
 			&app.Id, &app.Bundle, &app.Category, &app.DeveloperId,
 			&app.Developer, &app.Geo, &app.StartAt, &app.Period,
+			&app.Status, &app.OwnerId, &app.Store,
 		},
 		func(row pgx.QueryFuncRow) error {
 			apps = append(apps, app)
@@ -46,7 +58,7 @@ func (a *Repo) ProducerFunc(ctx context.Context, sql string, params ...interface
 func (a *Repo) ByBundleId(ctx context.Context, bundleId int) (entities.DboSlice, error) {
 	return a.ProducerFunc(
 		ctx,
-		"select * from app_tracking where id = $1",
+		"select "+columns+" from app_tracking where id = $1",
 		bundleId,
 	)
 }
@@ -55,12 +67,202 @@ func (a *Repo) ByBundleId(ctx context.Context, bundleId int) (entities.DboSlice,
 func (a *Repo) TimeRange(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
 	return a.ProducerFunc(
 		ctx,
-		"select * from app_tracking where bundleid = $1 and startat >= $2 and startat <= $3",
+		"select "+columns+" from app_tracking where id = $1 and startat >= $2 and startat <= $3",
 		bundleId, start, end,
 	)
 }
 
+// Return DboSlice with entities.App with given ids which were started within time range
+// from start to end. Zero start or end means that range is not bounded from this side
+func (a *Repo) ByBundleIds(ctx context.Context, bundleIds []int, start, end time.Time) (entities.DboSlice, error) {
+	return a.ProducerFunc(
+		ctx,
+		"select "+columns+" from app_tracking where id = any($1) and ($2::timestamp is null or startat >= $2) and ($3::timestamp is null or startat <= $3) order by id",
+		bundleIds, connector.NullTime(start), connector.NullTime(end),
+	)
+}
+
 // Do nothing here
 func (a *Repo) LastUpdates(_ context.Context, _, _ int) (entities.DboSlice, error) {
 	return nil, fmt.Errorf("%s", "no last updates in this table")
 }
+
+// Do nothing here
+func (a *Repo) Page(_ context.Context, _ int, _ entities.Page) (entities.DboSlice, error) {
+	return nil, fmt.Errorf("%s", "no pages in this table")
+}
+
+// Do nothing here
+func (a *Repo) Count(_ context.Context, _ int, _, _ time.Time) (int, error) {
+	return 0, fmt.Errorf("%s", "no tracking rows in this table")
+}
+
+// Get app by id
+func (a *Repo) ById(ctx context.Context, id int) (entities.App, error) {
+	return a.one(ctx, "select "+columns+" from app_tracking where id = $1", id)
+}
+
+// Get app by store, bundle and geo, bundle is tracked once for every store and geo
+func (a *Repo) ByBundle(ctx context.Context, store entities.AppStore, bundle, geo string) (entities.App, error) {
+	return a.one(ctx, "select "+columns+" from app_tracking where store = $1 and bundle = $2 and geo = $3 order by id limit 1", store, bundle, geo)
+}
+
+// Return page of apps which are matched by filter in given order
+func (a *Repo) Search(ctx context.Context, query entities.AppQuery) (entities.DboSlice, error) {
+	where, args := appFilter(query.Filter)
+
+	column := orderColumn(query.Order.Field)
+	direction, compare := "asc", ">"
+	if query.Order.Desc {
+		direction, compare = "desc", "<"
+	}
+	if query.After != nil {
+		switch query.Order.Field {
+		case entities.AppOrderBundle:
+			args = append(args, query.After.Bundle, query.After.Id)
+			where = append(where, fmt.Sprintf("(bundle, id) %s ($%d, $%d)", compare, len(args)-1, len(args)))
+		case entities.AppOrderStartAt:
+			args = append(args, connector.NullTime(query.After.StartAt), query.After.Id)
+			where = append(where, fmt.Sprintf(
+				"(%s, id) %s (coalesce($%d::timestamp, '-infinity'), $%d)",
+				column, compare, len(args)-1, len(args),
+			))
+		default:
+			args = append(args, query.After.Id)
+			where = append(where, fmt.Sprintf("id %s $%d", compare, len(args)))
+		}
+	}
+
+	sql := "select " + columns + " from app_tracking"
+	if len(where) > 0 {
+		sql += " where " + strings.Join(where, " and ")
+	}
+	if column == "id" {
+		sql += " order by id " + direction
+	} else {
+		sql += fmt.Sprintf(" order by %s %s, id %s", column, direction, direction)
+	}
+	args = append(args, query.Limit)
+	sql += " limit $" + strconv.Itoa(len(args))
+
+	return a.ProducerFunc(ctx, sql, args...)
+}
+
+// Count apps which are matched by filter
+func (a *Repo) CountApps(ctx context.Context, filter entities.AppFilter) (int, error) {
+	where, args := appFilter(filter)
+	sql := "select count(*) from app_tracking"
+	if len(where) > 0 {
+		sql += " where " + strings.Join(where, " and ")
+	}
+
+	var count int
+	err := a.Conn.QueryRow(ctx, sql, args...).Scan(&count)
+
+	return count, err
+}
+
+// Insert new active app owned by app.OwnerId. Returns apperrors.Conflict
+// if bundle is already tracked in store and geo
+func (a *Repo) Create(ctx context.Context, app entities.App) (entities.App, error) {
+	created, err := a.one(
+		ctx,
+		"insert into app_tracking (bundle, category, developerId, developer, geo, startAt, period, status, ownerId, store)"+
+			" values ($1, $2, $3, $4, $5, $6, $7, $8, nullif($9, 0), $10) returning "+columns,
+		app.Bundle, app.Category, app.DeveloperId, app.Developer, app.Geo, app.StartAt, app.Period,
+		entities.AppActive, app.OwnerId, app.StoreOrDefault(),
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return created, apperrors.New(apperrors.Conflict, fmt.Sprintf("bundle %s is already tracked in %s store in geo %s", app.Bundle, app.StoreOrDefault(), app.Geo))
+	}
+
+	return created, err
+}
+
+// Update category, start and period of tracking. Next run of changed
+// schedule is reset, so scheduler recomputes it from new start
+func (a *Repo) Update(ctx context.Context, app entities.App) (entities.App, error) {
+	return a.one(
+		ctx,
+		"update app_tracking set category = $2, startAt = $3, period = $4,"+
+			" nextRunAt = case when startAt = $3 and period = $4 then nextRunAt end where id = $1 returning "+columns,
+		app.Id, app.Category, app.StartAt, app.Period,
+	)
+}
+
+// Change status of tracking
+func (a *Repo) SetStatus(ctx context.Context, id int, status entities.AppStatus) (entities.App, error) {
+	return a.one(ctx, "update app_tracking set status = $2 where id = $1 returning "+columns, id, status)
+}
+
+// one returns the first app selected by query
+func (a *Repo) one(ctx context.Context, sql string, params ...interface{}) (entities.App, error) {
+	var app entities.App
+	dbo, err := a.ProducerFunc(ctx, sql, params...)
+	if err != nil {
+		return app, err
+	}
+	err = dbo[0].To(&app)
+
+	return app, err
+}
+
+// appFilter returns conditions and params of filter
+func appFilter(filter entities.AppFilter) ([]string, []interface{}) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Bundle != "" {
+		add("bundle ilike $%d", "%"+escapeLike(filter.Bundle)+"%")
+	}
+	if filter.Developer != "" {
+		add("developer ilike $%d", "%"+escapeLike(filter.Developer)+"%")
+	}
+	if filter.DeveloperId != "" {
+		add("developerid = $%d", filter.DeveloperId)
+	}
+	if filter.Category != "" {
+		add("category = $%d", filter.Category)
+	}
+	if filter.Geo != "" {
+		add("geo = $%d", filter.Geo)
+	}
+	if !filter.StartedAfter.IsZero() {
+		add("startat >= $%d", filter.StartedAfter)
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.Store != "" {
+		add("store = $%d", filter.Store)
+	}
+	if filter.OwnerId != 0 {
+		add("ownerid = $%d", filter.OwnerId)
+	}
+
+	return where, args
+}
+
+// orderColumn returns sql expression of order field. Apps without start
+// date are placed before others
+func orderColumn(field entities.AppOrderField) string {
+	switch field {
+	case entities.AppOrderBundle:
+		return "bundle"
+	case entities.AppOrderStartAt:
+		return "coalesce(startat, '-infinity')"
+	}
+	return "id"
+}
+
+// escapeLike escapes special symbols of like pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -1,11 +1,13 @@
 package appstore_test
 
 import (
+	"Muromachi/apperrors"
 	"Muromachi/config"
 	"Muromachi/store/entities"
 	"Muromachi/store/testhelpers"
 	"Muromachi/store/tracking/appstore"
 	"context"
+	"errors"
 	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -28,7 +30,6 @@ func TestAppRepo_ByBundleId_ShouldReturnSliceOfApps_Mock(t *testing.T) {
 
 func TestAppRepo_ByBundleId_ShouldReturnSliceOfApps(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("app_tracking")
 	repo := appstore.Repo{Conn: conn}
@@ -79,7 +80,6 @@ func TestAppRepo_LastUpdates_ShouldReturnAllApplicationWithinGivenInterval_Mock(
 
 func TestAppRepo_LastUpdates_ShouldReturnErrorBecauseTheFuncNotAllowedInThisTable(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 	conn, _ := testhelpers.RealDb(cfg.Database)
 	repo := appstore.Repo{Conn: conn}
 	ctx := context.Background()
@@ -116,3 +116,217 @@ func TestAppRepo_ByBundleIdShouldReturnErrNoRows_Mock(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, pgx.ErrNoRows, err)
 }
+
+func TestAppRepo_ByBundleIds_ShouldReturnSliceOfApps_Mock(t *testing.T) {
+	conn := mockAppConnection{}
+	repo := appstore.Repo{Conn: conn}
+	ctx := context.Background()
+
+	dboSlice, err := repo.ByBundleIds(ctx, []int{10, 11}, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, dboSlice)
+}
+
+func TestAppRepo_ByBundleIds_ShouldReturnSliceOfApps(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking")
+	repo := appstore.Repo{Conn: conn}
+	ctx := context.Background()
+
+	var ids []int
+	for _, bundle := range []string{"com.test.hello", "com.test.bye", "com.test.other"} {
+		id, err := testhelpers.AddNewApp(conn, ctx, entities.App{
+			Bundle:   bundle,
+			Category: "FINANCE",
+			Geo:      "ru_ru",
+			StartAt:  time.Now(),
+			Period:   31,
+		})
+		assert.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	dbo, err := repo.ByBundleIds(ctx, ids[:2], time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(dbo))
+
+	var app entities.App
+	for _, v := range dbo {
+		assert.NoError(t, v.To(&app))
+		assert.Contains(t, ids[:2], app.Id)
+	}
+}
+
+func TestAppRepo_ById_ShouldReturnApp_Mock(t *testing.T) {
+	conn := mockAppConnection{}
+	repo := appstore.Repo{Conn: conn}
+
+	app, err := repo.ById(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "FINANCE", app.Category)
+}
+
+func TestAppRepo_ByBundle_ShouldReturnErrNoRows_Mock(t *testing.T) {
+	conn := mockAppConnectionErrors{}
+	repo := appstore.Repo{Conn: conn}
+
+	_, err := repo.ByBundle(context.Background(), entities.StorePlay, "com.test", "ru_ru")
+	assert.Equal(t, pgx.ErrNoRows, err)
+}
+
+func TestAppRepo_ByBundle_ShouldReturnAppOfGeo(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking")
+	repo := appstore.Repo{Conn: conn}
+	ctx := context.Background()
+
+	for _, geo := range []string{"ru_ru", "en_us"} {
+		_, err := testhelpers.AddNewApp(conn, ctx, entities.App{
+			Bundle:   "com.test.hello",
+			Category: "FINANCE",
+			Geo:      geo,
+			StartAt:  time.Now(),
+		})
+		assert.NoError(t, err)
+	}
+
+	app, err := repo.ByBundle(ctx, entities.StorePlay, "com.test.hello", "en_us")
+	assert.NoError(t, err)
+	assert.Equal(t, "en_us", app.Geo)
+
+	byId, err := repo.ById(ctx, app.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, app, byId)
+
+	_, err = repo.ByBundle(ctx, entities.StorePlay, "com.test.hello", "de_de")
+	assert.Equal(t, pgx.ErrNoRows, err)
+}
+
+func TestAppRepo_Search_ShouldFilterAndOrderApps(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking")
+	repo := appstore.Repo{Conn: conn}
+	ctx := context.Background()
+
+	start, _ := time.Parse("2006-01-02", "2021-01-18")
+	apps := []entities.App{
+		{Bundle: "com.bank.one", Category: "FINANCE", Developer: "Bank Inc", Geo: "ru_ru", StartAt: start},
+		{Bundle: "com.bank.two", Category: "FINANCE", Developer: "Bank Inc", Geo: "ru_ru", StartAt: start.AddDate(0, 0, 2)},
+		{Bundle: "com.game.one", Category: "GAME", Developer: "Games 100%", Geo: "ru_ru", StartAt: start.AddDate(0, 0, 1)},
+		{Bundle: "com.bank.three", Category: "FINANCE", Developer: "Bank Inc", Geo: "en_us", StartAt: start.AddDate(0, 0, 3)},
+	}
+	for _, app := range apps {
+		_, err := testhelpers.AddNewApp(conn, ctx, app)
+		assert.NoError(t, err)
+	}
+
+	var tt = []struct {
+		name            string
+		query           entities.AppQuery
+		expectedBundles []string
+	}{
+		{
+			name: "filter by part of bundle and geo",
+			query: entities.AppQuery{
+				Filter: entities.AppFilter{Bundle: "BANK", Geo: "ru_ru"},
+				Order:  entities.AppOrder{Field: entities.AppOrderBundle},
+				Limit:  10,
+			},
+			expectedBundles: []string{"com.bank.one", "com.bank.two"},
+		},
+		{
+			name: "like symbols should be escaped",
+			query: entities.AppQuery{
+				Filter: entities.AppFilter{Developer: "100%"},
+				Limit:  10,
+			},
+			expectedBundles: []string{"com.game.one"},
+		},
+		{
+			name: "order by start date desc after cursor",
+			query: entities.AppQuery{
+				Filter: entities.AppFilter{Category: "FINANCE", StartedAfter: start.AddDate(0, 0, 1)},
+				Order:  entities.AppOrder{Field: entities.AppOrderStartAt, Desc: true},
+				After:  &entities.AppCursor{Id: 1 << 30, StartAt: start.AddDate(0, 0, 3)},
+				Limit:  10,
+			},
+			expectedBundles: []string{"com.bank.three", "com.bank.two"},
+		},
+		{
+			name: "limit",
+			query: entities.AppQuery{
+				Order: entities.AppOrder{Field: entities.AppOrderBundle, Desc: true},
+				Limit: 1,
+			},
+			expectedBundles: []string{"com.game.one"},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			dbo, err := repo.Search(ctx, test.query)
+			assert.NoError(t, err)
+
+			var bundles []string
+			for _, v := range dbo {
+				var app entities.App
+				assert.NoError(t, v.To(&app))
+				bundles = append(bundles, app.Bundle)
+			}
+			assert.Equal(t, test.expectedBundles, bundles)
+		})
+	}
+
+	count, err := repo.CountApps(ctx, entities.AppFilter{Category: "FINANCE"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestAppRepo_Create_ShouldReturnConflictForTrackedBundle_Mock(t *testing.T) {
+	repo := appstore.Repo{Conn: mockAppConnectionUnique{}}
+
+	_, err := repo.Create(context.Background(), entities.App{Bundle: "com.test", Geo: "ru_ru"})
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.Conflict, "")))
+}
+
+func TestAppRepo_Create_ShouldPreventDuplicatesOfBundleInGeo(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "users")
+	repo := appstore.Repo{Conn: conn}
+	ctx := context.Background()
+
+	var ownerId int
+	err := conn.QueryRow(ctx, "insert into users (clientId, clientSecret, company) values ('id', 'secret', 'company') returning id").Scan(&ownerId)
+	assert.NoError(t, err)
+
+	start, _ := time.Parse("2006-01-02", "2021-01-18")
+	app := entities.App{Bundle: "com.test.hello", Category: "FINANCE", Geo: "ru_ru", StartAt: start, Period: 7, OwnerId: ownerId}
+	created, err := repo.Create(ctx, app)
+	assert.NoError(t, err)
+	assert.NotZero(t, created.Id)
+	assert.Equal(t, entities.AppActive, created.Status)
+	assert.Equal(t, ownerId, created.OwnerId)
+
+	_, err = repo.Create(ctx, app)
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.Conflict, "")))
+
+	created.Period, created.Category = 14, "BUSINESS"
+	updated, err := repo.Update(ctx, created)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(14), updated.Period)
+	assert.Equal(t, "BUSINESS", updated.Category)
+
+	// Stopped app can be tracked again
+	stopped, err := repo.SetStatus(ctx, created.Id, entities.AppStopped)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.AppStopped, stopped.Status)
+	_, err = repo.Create(ctx, app)
+	assert.NoError(t, err)
+
+	_, err = repo.SetStatus(ctx, 1<<30, entities.AppPaused)
+	assert.Equal(t, pgx.ErrNoRows, err)
+}
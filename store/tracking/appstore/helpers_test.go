@@ -127,3 +127,12 @@ func (mr mockRow) Scan(dest ...interface{}) error {
 
 	return nil
 }
+
+// Mock connection which violates unique constraint (App table)
+type mockAppConnectionUnique struct {
+	mockAppConnectionErrors
+}
+
+func (m mockAppConnectionUnique) QueryFunc(ctx context.Context, sql string, args []interface{}, scans []interface{}, f func(pgx.QueryFuncRow) error) (pgconn.CommandTag, error) {
+	return nil, &pgconn.PgError{Code: "23505", Message: "duplicate key value violates unique constraint"}
+}
@@ -0,0 +1,230 @@
+package detection
+
+import (
+	"Muromachi/logging"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"strings"
+	"time"
+)
+
+const (
+	// Default delay between detection runs
+	DefaultInterval = time.Minute * 10
+	// Default count of tracking rows which are checked at once
+	DefaultBatchSize = 10000
+	// Default count of rows behind watermark which are checked again
+	DefaultLag = 1000
+	// History before the first new row of app which is loaded for baseline of its changes
+	DefaultLookback = time.Hour * 24 * 30
+)
+
+// Tracking table which is checked for anomalies
+type Source struct {
+	Table string
+	// Metric of places of table, empty for meta snapshots
+	Metric entities.AnomalyMetric
+}
+
+// Tracking tables which are checked for anomalies
+var Sources = []Source{
+	{Table: "category_tracking", Metric: entities.AnomalyCategory},
+	{Table: "keyword_tracking", Metric: entities.AnomalyKeyword},
+	{Table: "meta_tracking"},
+}
+
+// New rows of app
+type Change struct {
+	BundleId int
+	// Date of the earliest new row
+	Since time.Time
+}
+
+// Rows of tracking table which were read by one batch
+type Changes struct {
+	// Apps of rows ordered by id of app
+	Apps []Change
+	worker.Batch
+}
+
+// Store of tracking rows, anomalies and watermarks of sources
+type Store interface {
+	// Watermark returns id of the last checked row of table
+	Watermark(ctx context.Context, table string) (int64, error)
+	// Changes reads at most limit rows of table with ids greater than after
+	Changes(ctx context.Context, table string, after int64, limit int) (Changes, error)
+	// Advance moves watermark of table forward to id, caughtUp means that there are no rows after id
+	Advance(ctx context.Context, table string, id int64, caughtUp bool) error
+	// History returns rows of app in table from start, pgx.ErrNoRows if there are no rows
+	History(ctx context.Context, table string, bundleId int, start time.Time) (entities.DboSlice, error)
+	// Save saves new anomalies, saved anomalies keep review status
+	Save(ctx context.Context, anomalies []entities.Anomaly) error
+}
+
+// Job detects anomalies of apps which have new tracking rows and saves them, so
+// queries only read saved anomalies. Rows are checked incrementally by id, watermark
+// of every source is kept in store, so the job resumes where it stopped
+type Job struct {
+	Store Store
+	// Sources which are checked, Sources by default
+	Sources []Source
+	// Delay between detection runs
+	Interval time.Duration
+	// Count of tracking rows which are checked at once
+	BatchSize int
+	// Count of rows behind watermark which are checked again
+	Lag int
+	// History before the first new row of app which is loaded for baseline
+	Lookback time.Duration
+}
+
+// Run detects anomalies of new tracking rows at start and then every interval until ctx is done
+func (j *Job) Run(ctx context.Context) error {
+	interval := j.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := j.Detect(ctx); err != nil && ctx.Err() == nil {
+			logging.Errorf("anomalies: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Detect checks every source by batches until it catches up with tracking table and
+// returns count of detected anomalies. Errors of one source do not stop others
+func (j *Job) Detect(ctx context.Context) (int, error) {
+	var (
+		total    int
+		problems []string
+	)
+	for _, source := range j.sources() {
+		count, err := j.detect(ctx, source)
+		total += count
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", source.Table, err))
+		}
+	}
+	if len(problems) > 0 {
+		return total, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	return total, nil
+}
+
+// detect checks apps of rows from lag behind watermark while batches are full
+func (j *Job) detect(ctx context.Context, source Source) (int, error) {
+	lastId, err := j.Store.Watermark(ctx, source.Table)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	err = worker.Scan(ctx, lastId, j.batchSize(), j.lag(),
+		func(ctx context.Context, after int64) (worker.Batch, error) {
+			changes, err := j.Store.Changes(ctx, source.Table, after, j.batchSize())
+			if err != nil {
+				return changes.Batch, err
+			}
+			for _, change := range changes.Apps {
+				anomalies, err := j.anomalies(ctx, source, change)
+				if err != nil {
+					return changes.Batch, err
+				}
+				if len(anomalies) == 0 {
+					continue
+				}
+				if err := j.Store.Save(ctx, anomalies); err != nil {
+					return changes.Batch, err
+				}
+				total += len(anomalies)
+			}
+			return changes.Batch, nil
+		},
+		func(ctx context.Context, id int64, caughtUp bool) error {
+			return j.Store.Advance(ctx, source.Table, id, caughtUp)
+		},
+	)
+
+	return total, err
+}
+
+// anomalies returns anomalies of app since its first new row. Changes are detected
+// between single rows, so history is never aggregated
+func (j *Job) anomalies(ctx context.Context, source Source, change Change) ([]entities.Anomaly, error) {
+	dbo, err := j.Store.History(ctx, source.Table, change.BundleId, change.Since.Add(-j.lookback()))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var all []entities.Anomaly
+	if source.Metric == "" {
+		metas := make([]entities.Meta, len(dbo))
+		for i, v := range dbo {
+			if err := v.To(&metas[i]); err != nil {
+				return nil, err
+			}
+		}
+		all = entities.MetaAnomalies(metas)
+	} else {
+		tracks := make([]entities.Track, len(dbo))
+		for i, v := range dbo {
+			if err := v.To(&tracks[i]); err != nil {
+				return nil, err
+			}
+		}
+		all = entities.TrackAnomalies(source.Metric, tracks)
+	}
+
+	var anomalies []entities.Anomaly
+	for _, a := range all {
+		if !a.Date.Before(change.Since) {
+			anomalies = append(anomalies, a)
+		}
+	}
+
+	return anomalies, nil
+}
+
+func (j *Job) sources() []Source {
+	if len(j.Sources) == 0 {
+		return Sources
+	}
+	return j.Sources
+}
+
+func (j *Job) batchSize() int {
+	if j.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return j.BatchSize
+}
+
+func (j *Job) lag() int {
+	if j.Lag <= 0 {
+		return DefaultLag
+	}
+	return j.Lag
+}
+
+func (j *Job) lookback() time.Duration {
+	if j.Lookback <= 0 {
+		return DefaultLookback
+	}
+	return j.Lookback
+}
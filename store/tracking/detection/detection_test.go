@@ -0,0 +1,123 @@
+package detection_test
+
+import (
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/detection"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Store which keeps keyword rows and anomalies in memory
+type mockStore struct {
+	tracks     []entities.Track
+	watermarks map[string]int64
+	anomalies  []entities.Anomaly
+	failed     string
+}
+
+func (m *mockStore) Watermark(ctx context.Context, table string) (int64, error) {
+	if table == m.failed {
+		return 0, errors.New("connection reset")
+	}
+	return m.watermarks[table], nil
+}
+
+func (m *mockStore) Changes(ctx context.Context, table string, after int64, limit int) (detection.Changes, error) {
+	changes := detection.Changes{Batch: worker.Batch{LastId: after}}
+	if table != "keyword_tracking" {
+		return changes, nil
+	}
+	since := map[int]time.Time{}
+	for _, tr := range m.tracks {
+		if int64(tr.Id) <= after || changes.Count == limit {
+			continue
+		}
+		if s, ok := since[tr.BundleId]; !ok || tr.Date.Before(s) {
+			since[tr.BundleId] = tr.Date
+		}
+		changes.Count++
+		changes.LastId = int64(tr.Id)
+	}
+	for id, date := range since {
+		changes.Apps = append(changes.Apps, detection.Change{BundleId: id, Since: date})
+	}
+	sort.Slice(changes.Apps, func(i, j int) bool { return changes.Apps[i].BundleId < changes.Apps[j].BundleId })
+	return changes, nil
+}
+
+func (m *mockStore) Advance(ctx context.Context, table string, id int64, caughtUp bool) error {
+	if id > m.watermarks[table] {
+		m.watermarks[table] = id
+	}
+	return nil
+}
+
+func (m *mockStore) History(ctx context.Context, table string, bundleId int, start time.Time) (entities.DboSlice, error) {
+	var dbo entities.DboSlice
+	for _, tr := range m.tracks {
+		if tr.BundleId == bundleId && !tr.Date.Before(start) {
+			dbo = append(dbo, tr)
+		}
+	}
+	if len(dbo) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return dbo, nil
+}
+
+func (m *mockStore) Save(ctx context.Context, anomalies []entities.Anomaly) error {
+	for _, a := range anomalies {
+		saved := false
+		for _, v := range m.anomalies {
+			saved = saved || (v.BundleId == a.BundleId && v.Metric == a.Metric && v.Type == a.Type && v.Date.Equal(a.Date))
+		}
+		if !saved {
+			m.anomalies = append(m.anomalies, a)
+		}
+	}
+	return nil
+}
+
+func TestJob_DetectShouldSaveAnomaliesOfNewRows_Mock(t *testing.T) {
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	store := &mockStore{watermarks: map[string]int64{}}
+	for i, place := range []int32{10, 11, 10, 12, 11, 10, 11, 40} {
+		store.tracks = append(store.tracks, entities.Track{Id: i + 1, BundleId: 1, Type: "bank", Place: place, Date: day.AddDate(0, 0, i)})
+	}
+	job := detection.Job{Store: store, BatchSize: 10, Lag: 1}
+
+	count, err := job.Detect(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, store.anomalies, 1)
+	assert.Equal(t, entities.AnomalyKeyword, store.anomalies[0].Metric)
+	assert.Equal(t, 29.0, store.anomalies[0].Change)
+	assert.Equal(t, int64(8), store.watermarks["keyword_tracking"])
+
+	// Rows behind watermark are checked again, saved anomaly is not duplicated
+	count, err = job.Detect(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, store.anomalies, 1)
+}
+
+func TestJob_DetectShouldContinueAfterErrorOfSource_Mock(t *testing.T) {
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	store := &mockStore{
+		tracks:     []entities.Track{{Id: 1, BundleId: 1, Type: "bank", Place: 10, Date: day}},
+		watermarks: map[string]int64{},
+		failed:     "category_tracking",
+	}
+	job := detection.Job{Store: store}
+
+	count, err := job.Detect(context.Background())
+	assert.EqualError(t, err, "category_tracking: connection reset")
+	assert.Equal(t, 0, count)
+	assert.Equal(t, int64(1), store.watermarks["keyword_tracking"])
+}
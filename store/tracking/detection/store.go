@@ -0,0 +1,74 @@
+package detection
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/anomalystore"
+	"Muromachi/store/tracking/metastore"
+	"Muromachi/store/tracking/trackstore"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// Store of tracking rows, anomalies in anomalies table and watermarks in watermarks table
+type PgStore struct {
+	DB connector.Conn
+}
+
+// Watermark returns id of the last checked row of table, zero if table was never checked
+func (p *PgStore) Watermark(ctx context.Context, table string) (int64, error) {
+	return worker.Watermarks{DB: p.DB}.Get(ctx, worker.Source("anomalies", table))
+}
+
+// Changes reads at most limit rows of table after id and returns the earliest date of rows of every app
+func (p *PgStore) Changes(ctx context.Context, table string, after int64, limit int) (Changes, error) {
+	batch, err := worker.Next(ctx, p.DB, table, after, limit)
+	changes := Changes{Batch: batch}
+	if err != nil || changes.Count == 0 {
+		return changes, err
+	}
+
+	var change Change
+	_, err = p.DB.QueryFunc(
+		ctx,
+		fmt.Sprintf(
+			"select bundleId, min(date) from %s where id > $1 and id <= $2 and bundleId is not null group by bundleId order by bundleId",
+			pgx.Identifier{table}.Sanitize(),
+		),
+		[]interface{}{after, changes.LastId},
+		[]interface{}{&change.BundleId, &change.Since},
+		func(pgx.QueryFuncRow) error {
+			changes.Apps = append(changes.Apps, change)
+			return nil
+		},
+	)
+
+	return changes, err
+}
+
+// Advance moves watermark of table forward to id, watermark never moves back
+func (p *PgStore) Advance(ctx context.Context, table string, id int64, caughtUp bool) error {
+	return worker.Watermarks{DB: p.DB}.Advance(ctx, worker.Source("anomalies", table), id, caughtUp)
+}
+
+// History returns rows of app in table from start
+func (p *PgStore) History(ctx context.Context, table string, bundleId int, start time.Time) (entities.DboSlice, error) {
+	switch table {
+	case "category_tracking":
+		return (&trackstore.CatRepo{Conn: p.DB}).ByBundleIds(ctx, []int{bundleId}, start, time.Time{})
+	case "keyword_tracking":
+		return (&trackstore.KeysRepo{Conn: p.DB}).ByBundleIds(ctx, []int{bundleId}, start, time.Time{})
+	case "meta_tracking":
+		return (&metastore.Repo{Conn: p.DB}).ByBundleIds(ctx, []int{bundleId}, start, time.Time{})
+	}
+
+	return nil, fmt.Errorf("anomalies of %s are not detected", table)
+}
+
+// Save saves new anomalies, saved anomalies keep review status
+func (p *PgStore) Save(ctx context.Context, anomalies []entities.Anomaly) error {
+	return (&anomalystore.Repo{Conn: p.DB}).Save(ctx, anomalies)
+}
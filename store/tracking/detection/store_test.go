@@ -0,0 +1,53 @@
+package detection_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking/detection"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPgStore_ShouldDetectAnomaliesOfNewRows(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "keyword_tracking", "anomalies", "watermarks")
+	cleaner("watermarks")
+	store := &detection.PgStore{DB: conn}
+	job := detection.Job{Store: store, Sources: []detection.Source{detection.Sources[1]}}
+	ctx := context.Background()
+
+	appId, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "com.muromachi.bank", Geo: "en_us"})
+	assert.NoError(t, err)
+	day := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	var lastId int
+	for i, place := range []int32{10, 11, 10, 12, 11, 10, 11, 40} {
+		track := testhelpers.TrackStruct(appId, "bank")
+		track.Date, track.Place = day.AddDate(0, 0, i), place
+		lastId, err = testhelpers.AddNewTrack(conn, ctx, track, "keyword_tracking")
+		assert.NoError(t, err)
+	}
+
+	changes, err := store.Changes(ctx, "keyword_tracking", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, changes.Count)
+	assert.Equal(t, int64(lastId), changes.LastId)
+	assert.Equal(t, []detection.Change{{BundleId: appId, Since: day}}, changes.Apps)
+
+	count, err := job.Detect(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	watermark, err := store.Watermark(ctx, "keyword_tracking")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(lastId), watermark)
+
+	// Rows behind watermark are checked again without duplicates
+	_, err = job.Detect(ctx)
+	assert.NoError(t, err)
+	var saved int
+	assert.NoError(t, conn.QueryRow(ctx, "select count(*) from anomalies where bundleId = $1 and type = 'bank'", appId).Scan(&saved))
+	assert.Equal(t, 1, saved)
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"Muromachi/logging"
+	"sync"
+	"time"
+)
+
+// Kind of tracking table which row was updated
+type Kind string
+
+const (
+	Meta     Kind = "meta"
+	Category Kind = "category"
+	Keyword  Kind = "keyword"
+)
+
+// Event about inserted or updated tracking row
+type Event struct {
+	Kind     Kind      `json:"kind"`
+	BundleId int       `json:"bundleId"`
+	Id       int       `json:"id"`
+	Date     time.Time `json:"date"`
+}
+
+// Size of subscription buffer, events are dropped for subscribers
+// which are not able to read them in time
+const bufferSize = 64
+
+// Filter of subscription, empty fields match everything
+type Filter struct {
+	BundleIds []int
+	Kinds     []Kind
+}
+
+// Match reports whether event is passed by filter
+func (f Filter) Match(e Event) bool {
+	return containsInt(f.BundleIds, e.BundleId) && containsKind(f.Kinds, e.Kind)
+}
+
+// Subscription on events matched by filter
+type Subscription struct {
+	filter Filter
+	events chan Event
+	hub    *Hub
+	once   sync.Once
+}
+
+// Events returns channel of events, channel is closed after Close
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close removes subscription from hub
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		delete(s.hub.subs, s)
+		s.hub.mu.Unlock()
+		close(s.events)
+	})
+}
+
+// Hub fans out events to subscribers. Safe for concurrent use
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// NewHub creates hub without subscribers
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe creates subscription on events matched by filter
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	sub := &Subscription{
+		filter: filter,
+		events: make(chan Event, bufferSize),
+		hub:    h,
+	}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Publish sends event to every matched subscriber without blocking
+func (h *Hub) Publish(e Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			logging.Warnf("tracking event of bundle %d is dropped for slow subscriber", e.BundleId)
+		}
+	}
+}
+
+// Count returns count of subscribers
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.subs)
+}
+
+func containsInt(list []int, v int) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsKind(list []Kind, v Kind) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package events_test
+
+import (
+	"Muromachi/store/tracking/events"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHub_ShouldFanOutEventsToMatchedSubscribers(t *testing.T) {
+	hub := events.NewHub()
+	all := hub.Subscribe(events.Filter{})
+	keys := hub.Subscribe(events.Filter{BundleIds: []int{1, 2}, Kinds: []events.Kind{events.Keyword}})
+	assert.Equal(t, 2, hub.Count())
+
+	hub.Publish(events.Event{Kind: events.Meta, BundleId: 1, Id: 1})
+	hub.Publish(events.Event{Kind: events.Keyword, BundleId: 3, Id: 2})
+	hub.Publish(events.Event{Kind: events.Keyword, BundleId: 2, Id: 3})
+
+	assert.Equal(t, 1, (<-all.Events()).Id)
+	assert.Equal(t, 2, (<-all.Events()).Id)
+	assert.Equal(t, 3, (<-all.Events()).Id)
+	assert.Equal(t, 3, (<-keys.Events()).Id)
+	assert.Len(t, keys.Events(), 0)
+
+	keys.Close()
+	keys.Close()
+	_, ok := <-keys.Events()
+	assert.False(t, ok)
+	assert.Equal(t, 1, hub.Count())
+}
+
+func TestHub_PublishShouldNotBlockOnSlowSubscriber(t *testing.T) {
+	hub := events.NewHub()
+	sub := hub.Subscribe(events.Filter{})
+	defer sub.Close()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			hub.Publish(events.Event{Kind: events.Meta, BundleId: 1, Id: i})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("publish is blocked by subscriber")
+	}
+	assert.Equal(t, 0, (<-sub.Events()).Id)
+}
+
+func TestParse(t *testing.T) {
+	event, err := events.Parse(`{"kind":"category","bundleId":12,"id":7,"date":"2021-01-18T10:00:00.000000Z"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, events.Category, event.Kind)
+	assert.Equal(t, 12, event.BundleId)
+	assert.Equal(t, 7, event.Id)
+	assert.Equal(t, 10, event.Date.Hour())
+
+	_, err = events.Parse("{")
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"Muromachi/logging"
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+// Channel which is notified by triggers of tracking tables
+const Channel = "tracking_updated"
+
+// Delay before listener reconnects after error
+var reconnectDelay = time.Second * 5
+
+// Listener holds single connection which listens notifications of tracking
+// tables and publishes them to hub
+type Listener struct {
+	Pool *pgxpool.Pool
+	Hub  *Hub
+}
+
+// Run listens notifications until ctx is done. Listener reconnects on errors
+// of connection, so method returns only ctx error
+func (l *Listener) Run(ctx context.Context) error {
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logging.Errorf("tracking listener: %v, reconnect in %s", err, reconnectDelay)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// listen acquires connection from pool and waits notifications on it
+func (l *Listener) listen(ctx context.Context) error {
+	conn, err := l.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "listen "+Channel); err != nil {
+		return err
+	}
+	// Connection returns to pool without subscription
+	defer func() {
+		_, _ = conn.Exec(context.Background(), "unlisten "+Channel)
+	}()
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		event, err := Parse(notification.Payload)
+		if err != nil {
+			logging.Warnf("tracking listener: invalid payload %q: %v", notification.Payload, err)
+			continue
+		}
+		l.Hub.Publish(event)
+	}
+}
+
+// Parse decodes notification payload to Event
+func Parse(payload string) (Event, error) {
+	var event Event
+	err := json.Unmarshal([]byte(payload), &event)
+
+	return event, err
+}
@@ -0,0 +1,142 @@
+package ingest
+
+import (
+	"Muromachi/apperrors"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	// Default count of lines in batch
+	DefaultBatchSize = 1000
+	// Max size of line, long descriptions of meta should fit
+	maxLineSize = 1 << 20
+)
+
+// Rejected line of body
+type Rejection struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Result of writing of batch records
+type Result struct {
+	// Count of new rows
+	Inserted int `json:"inserted"`
+	// Count of records which were already saved
+	Duplicates int `json:"duplicates"`
+	// Records which were not saved
+	Rejected []Rejection `json:"rejected"`
+}
+
+// Writes valid records of batch. Records of apps tracked by other client than owner
+// are rejected, zero owner writes records of any app
+type Writer interface {
+	Write(ctx context.Context, owner int, records []Record) (Result, error)
+}
+
+// Report of single batch
+type BatchReport struct {
+	// Number of batch, starts from 1
+	Batch int `json:"batch"`
+	// Count of lines in batch
+	Received int `json:"received"`
+	Result
+}
+
+// Report of whole body
+type Report struct {
+	Received   int           `json:"received"`
+	Inserted   int           `json:"inserted"`
+	Duplicates int           `json:"duplicates"`
+	Rejected   int           `json:"rejected"`
+	Batches    []BatchReport `json:"batches"`
+}
+
+// Ingester reads NDJSON observations and writes them by batches. Writing
+// is idempotent, so body can be sent again after error
+type Ingester struct {
+	Writer    Writer
+	BatchSize int
+}
+
+// Ingest reads body of owner line by line. Invalid lines are rejected, valid ones
+// are passed to writer when batch is full. Error of writer stops reading, report
+// contains batches which were written before error
+func (i *Ingester) Ingest(ctx context.Context, owner int, body io.Reader) (Report, error) {
+	size := i.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	report := Report{Batches: []BatchReport{}}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	var (
+		number   int
+		batch    = BatchReport{Batch: 1}
+		records  []Record
+		rejected []Rejection
+	)
+	flush := func() error {
+		if batch.Received == 0 {
+			return nil
+		}
+		result := Result{}
+		if len(records) > 0 {
+			var err error
+			if result, err = i.Writer.Write(ctx, owner, records); err != nil {
+				return err
+			}
+		}
+		batch.Result = result
+		batch.Rejected = append(append([]Rejection{}, rejected...), result.Rejected...)
+		sort.Slice(batch.Rejected, func(a, b int) bool {
+			return batch.Rejected[a].Line < batch.Rejected[b].Line
+		})
+
+		report.Received += batch.Received
+		report.Inserted += batch.Inserted
+		report.Duplicates += batch.Duplicates
+		report.Rejected += len(batch.Rejected)
+		report.Batches = append(report.Batches, batch)
+
+		batch = BatchReport{Batch: batch.Batch + 1}
+		records, rejected = nil, nil
+		return nil
+	}
+
+	for scanner.Scan() {
+		number++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		batch.Received++
+		record, err := Decode(number, data)
+		if err != nil {
+			rejected = append(rejected, Rejection{Line: number, Error: err.Error()})
+		} else {
+			records = append(records, record)
+		}
+
+		if batch.Received == size {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := scanner.Err(); err == bufio.ErrTooLong {
+		return report, apperrors.New(apperrors.BadRequest, fmt.Sprintf("line %d is longer than %d bytes", number+1, maxLineSize))
+	} else if err != nil {
+		return report, err
+	}
+
+	return report, flush()
+}
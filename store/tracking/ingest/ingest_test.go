@@ -0,0 +1,120 @@
+package ingest_test
+
+import (
+	"Muromachi/apperrors"
+	"Muromachi/store/tracking/ingest"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// Writer which saves every record once and remembers batches
+type mockWriter struct {
+	batches [][]ingest.Record
+	saved   map[string]bool
+	err     error
+}
+
+func (m *mockWriter) Write(ctx context.Context, owner int, records []ingest.Record) (ingest.Result, error) {
+	if m.err != nil {
+		return ingest.Result{}, m.err
+	}
+	if m.saved == nil {
+		m.saved = make(map[string]bool)
+	}
+	m.batches = append(m.batches, records)
+
+	var result ingest.Result
+	for _, r := range records {
+		key := fmt.Sprintf("%s/%d/%s/%v", r.Kind, r.BundleId(), r.Track.Type, r.Track.Date)
+		if m.saved[key] {
+			result.Duplicates++
+			continue
+		}
+		m.saved[key] = true
+		result.Inserted++
+	}
+
+	return result, nil
+}
+
+func TestDecode_ShouldParseTrackAndMeta(t *testing.T) {
+	record, err := ingest.Decode(1, []byte(`{"kind": "keyword", "bundleId": 1, "type": "bank", "place": 3, "date": "2021-01-18T03:00:00+03:00"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, ingest.Keyword, record.Kind)
+	assert.Equal(t, 1, record.BundleId())
+	assert.Equal(t, "bank", record.Track.Type)
+	assert.Equal(t, int32(3), record.Track.Place)
+	assert.Equal(t, "2021-01-18T00:00:00Z", record.Track.Date.Format("2006-01-02T15:04:05Z07:00"))
+
+	record, err = ingest.Decode(2, []byte(`{"kind": "meta", "bundleId": 2, "date": "2021-01-18T00:00:00Z", "meta": {"title": "Bank", "screenshots": ["a", "b"]}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, record.Line)
+	assert.Equal(t, 2, record.BundleId())
+	assert.Equal(t, "Bank", record.Meta.Title)
+	assert.Equal(t, []string{"a", "b"}, record.Meta.Screenshots)
+}
+
+func TestDecode_ShouldRejectInvalidLines(t *testing.T) {
+	lines := []string{
+		`not json`,
+		`{"kind": "rating", "bundleId": 1, "date": "2021-01-18T00:00:00Z"}`,
+		`{"kind": "meta", "bundleId": 1, "date": "2021-01-18T00:00:00Z"}`,
+		`{"kind": "keyword", "bundleId": 0, "type": "bank", "place": 3, "date": "2021-01-18T00:00:00Z"}`,
+		`{"kind": "category", "bundleId": 1, "type": "", "place": 3, "date": "2021-01-18T00:00:00Z"}`,
+		`{"kind": "category", "bundleId": 1, "type": "finance", "place": 0, "date": "2021-01-18T00:00:00Z"}`,
+		`{"kind": "category", "bundleId": 1, "type": "finance", "place": 1}`,
+		`{"kind": "meta", "bundleId": 1, "date": "2021-01-18T00:00:00Z", "meta": {"title": "` + strings.Repeat("a", 301) + `"}}`,
+	}
+	for _, line := range lines {
+		_, err := ingest.Decode(1, []byte(line))
+		assert.Error(t, err, line)
+	}
+}
+
+func TestIngester_ShouldWriteByBatchesAndReportRejections(t *testing.T) {
+	writer := &mockWriter{}
+	ingester := ingest.Ingester{Writer: writer, BatchSize: 2}
+
+	body := strings.Join([]string{
+		`{"kind": "keyword", "bundleId": 1, "type": "bank", "place": 3, "date": "2021-01-18T00:00:00Z"}`,
+		`{"kind": "keyword", "bundleId": 1, "type": "bank", "place": 3, "date": "2021-01-18T00:00:00Z"}`,
+		``,
+		`{"kind": "unknown"}`,
+		`{"kind": "category", "bundleId": 1, "type": "finance", "place": 1, "date": "2021-01-18T00:00:00Z"}`,
+		`{"kind": "category", "bundleId": 1, "type": "finance", "place": 0, "date": "2021-01-18T00:00:00Z"}`,
+	}, "\n")
+
+	report, err := ingester.Ingest(context.Background(), 1, strings.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, report.Received)
+	assert.Equal(t, 2, report.Inserted)
+	assert.Equal(t, 1, report.Duplicates)
+	assert.Equal(t, 2, report.Rejected)
+
+	assert.Len(t, report.Batches, 3)
+	assert.Len(t, writer.batches, 2)
+	assert.Equal(t, 2, report.Batches[0].Received)
+	assert.Equal(t, 1, report.Batches[0].Duplicates)
+	assert.Equal(t, []ingest.Rejection{{Line: 4, Error: `unknown kind "unknown"`}}, report.Batches[1].Rejected)
+	assert.Equal(t, 6, report.Batches[2].Rejected[0].Line)
+}
+
+func TestIngester_ShouldStopOnWriterError(t *testing.T) {
+	ingester := ingest.Ingester{Writer: &mockWriter{err: errors.New("db is down")}, BatchSize: 1}
+
+	_, err := ingester.Ingest(context.Background(), 1, strings.NewReader(
+		`{"kind": "keyword", "bundleId": 1, "type": "bank", "place": 3, "date": "2021-01-18T00:00:00Z"}`,
+	))
+	assert.EqualError(t, err, "db is down")
+}
+
+func TestIngester_ShouldReturnBadRequestForTooLongLine(t *testing.T) {
+	ingester := ingest.Ingester{Writer: &mockWriter{}}
+
+	_, err := ingester.Ingest(context.Background(), 1, strings.NewReader(strings.Repeat("a", 2<<20)))
+	assert.True(t, errors.Is(err, apperrors.New(apperrors.BadRequest, "")))
+}
@@ -0,0 +1,81 @@
+package ingest
+
+import (
+	"Muromachi/store/entities"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Kind of tracking observation
+type Kind string
+
+const (
+	Meta     Kind = "meta"
+	Category Kind = "category"
+	Keyword  Kind = "keyword"
+)
+
+// Single line of NDJSON body, for example
+//
+//	{"kind": "keyword", "bundleId": 1, "type": "bank", "place": 3, "date": "2021-01-18T00:00:00Z"}
+//	{"kind": "meta", "bundleId": 1, "date": "2021-01-18T00:00:00Z", "meta": {"title": "Bank", ...}}
+type line struct {
+	Kind     Kind            `json:"kind"`
+	BundleId int             `json:"bundleId"`
+	Type     string          `json:"type"`
+	Place    int32           `json:"place"`
+	Date     time.Time       `json:"date"`
+	Meta     json.RawMessage `json:"meta"`
+}
+
+// Validated observation
+type Record struct {
+	// Number of line in body, starts from 1
+	Line  int
+	Kind  Kind
+	Track entities.Track
+	Meta  entities.Meta
+}
+
+// BundleId returns id of app of record
+func (r Record) BundleId() int {
+	if r.Kind == Meta {
+		return r.Meta.BundleId
+	}
+	return r.Track.BundleId
+}
+
+// Decode parses and validates line of body
+func Decode(number int, data []byte) (Record, error) {
+	var l line
+	if err := json.Unmarshal(data, &l); err != nil {
+		return Record{}, fmt.Errorf("invalid json: %v", err)
+	}
+
+	record := Record{Line: number, Kind: l.Kind}
+	switch l.Kind {
+	case Meta:
+		if len(l.Meta) == 0 {
+			return record, fmt.Errorf("%s", "meta is required")
+		}
+		if err := json.Unmarshal(l.Meta, &record.Meta); err != nil {
+			return record, fmt.Errorf("invalid meta: %v", err)
+		}
+		record.Meta.BundleId = l.BundleId
+		record.Meta.Date = l.Date.UTC()
+
+		return record, record.Meta.Validate()
+	case Category, Keyword:
+		record.Track = entities.Track{
+			BundleId: l.BundleId,
+			Type:     l.Type,
+			Place:    l.Place,
+			Date:     l.Date.UTC(),
+		}
+
+		return record, record.Track.Validate()
+	}
+
+	return record, fmt.Errorf("unknown kind %q", l.Kind)
+}
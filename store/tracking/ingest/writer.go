@@ -0,0 +1,190 @@
+package ingest
+
+import (
+	"Muromachi/store/connector"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"strings"
+)
+
+// Target table of records of one kind
+type table struct {
+	name string
+	// Columns which are copied
+	columns []string
+	// Columns which identify observation
+	key []string
+	// Values of record in order of columns
+	values func(r Record) []interface{}
+}
+
+var tables = map[Kind]table{
+	Meta: {
+		name: "meta_tracking",
+		columns: []string{
+			"bundleid", "title", "price", "picture", "screenshots", "rating", "reviewcount",
+			"ratinghistogram", "description", "shortdescription", "recentchanges", "releasedate",
+			"lastupdatedate", "appsize", "installs", "version", "androidversion", "contentrating",
+			"devcontacts", "privacypolicy", "date", "iosversion", "supporteddevices", "inapppurchases", "agerating",
+			"ratingvalue", "reviewcountvalue", "installsmin", "installsmax", "sizebytes", "ratinghistogramvalues",
+		},
+		key: []string{"bundleid", "date"},
+		values: func(r Record) []interface{} {
+			m := r.Meta
+			m.Normalize()
+			return []interface{}{
+				m.BundleId, m.Title, m.Price, m.Picture, m.Screenshots, m.Rating, m.ReviewCount,
+				m.RatingHistogram, m.Description, m.ShortDescription, m.RecentChanges, m.ReleaseDate,
+				m.LastUpdateDate, m.AppSize, m.Installs, m.Version, m.AndroidVersion, m.ContentRating,
+				m.DeveloperContacts, m.PrivacyPolicy, m.Date, m.IosVersion, nonNil(m.SupportedDevices),
+				nonNil(m.InAppPurchases), m.AgeRating, m.RatingValue, m.ReviewCountValue, m.InstallsMin,
+				m.InstallsMax, m.SizeBytes, m.RatingHistogramValues,
+			}
+		},
+	},
+	Category: {
+		name:    "category_tracking",
+		columns: []string{"bundleid", "type", "place", "date"},
+		key:     []string{"bundleid", "type", "date"},
+		values: func(r Record) []interface{} {
+			return []interface{}{r.Track.BundleId, r.Track.Type, r.Track.Place, r.Track.Date}
+		},
+	},
+	Keyword: {
+		name:    "keyword_tracking",
+		columns: []string{"bundleid", "type", "place", "date"},
+		key:     []string{"bundleid", "type", "date"},
+		values: func(r Record) []interface{} {
+			return []interface{}{r.Track.BundleId, r.Track.Type, r.Track.Place, r.Track.Date}
+		},
+	},
+}
+
+// Writer which copies records to tracking tables. Every batch is written in one
+// transaction, observations which are already saved are skipped
+type PgWriter struct {
+	DB connector.DB
+}
+
+// Write copies records to staging tables by pgx CopyFrom and moves new rows to
+// tracking tables. Records of unknown apps and apps of other clients are rejected
+func (w *PgWriter) Write(ctx context.Context, owner int, records []Record) (Result, error) {
+	var result Result
+
+	tx, err := w.DB.Begin(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback(ctx)
+
+	owners, err := appOwners(ctx, tx, records)
+	if err != nil {
+		return result, err
+	}
+
+	byKind := make(map[Kind][]Record)
+	for _, r := range records {
+		appOwner, ok := owners[r.BundleId()]
+		if !ok {
+			result.Rejected = append(result.Rejected, Rejection{Line: r.Line, Error: fmt.Sprintf("unknown bundle id %d", r.BundleId())})
+			continue
+		}
+		if owner != 0 && appOwner != 0 && appOwner != owner {
+			result.Rejected = append(result.Rejected, Rejection{Line: r.Line, Error: fmt.Sprintf("bundle id %d is tracked by another client", r.BundleId())})
+			continue
+		}
+		byKind[r.Kind] = append(byKind[r.Kind], r)
+	}
+
+	for _, kind := range []Kind{Meta, Category, Keyword} {
+		if len(byKind[kind]) == 0 {
+			continue
+		}
+		inserted, err := copyRecords(ctx, tx, tables[kind], byKind[kind])
+		if err != nil {
+			return Result{}, err
+		}
+		result.Inserted += inserted
+		result.Duplicates += len(byKind[kind]) - inserted
+	}
+
+	return result, tx.Commit(ctx)
+}
+
+// appOwners returns owners of existing apps of records by ids, owner of app
+// without owner is zero
+func appOwners(ctx context.Context, tx pgx.Tx, records []Record) (map[int]int, error) {
+	ids := make([]int, 0, len(records))
+	for _, r := range records {
+		ids = append(ids, r.BundleId())
+	}
+
+	owners := make(map[int]int)
+	var id, owner int
+	_, err := tx.QueryFunc(ctx, "select id, coalesce(ownerId, 0) from app_tracking where id = any($1)", []interface{}{ids}, []interface{}{&id, &owner}, func(pgx.QueryFuncRow) error {
+		owners[id] = owner
+		return nil
+	})
+
+	return owners, err
+}
+
+// copyRecords copies records to staging table and inserts rows which are not
+// saved yet. Concurrent writers of the same table are serialized by advisory lock,
+// so the same observation is never inserted twice
+func copyRecords(ctx context.Context, tx pgx.Tx, t table, records []Record) (int, error) {
+	if _, err := tx.Exec(ctx, "select pg_advisory_xact_lock(hashtext($1))", "ingest:"+t.name); err != nil {
+		return 0, err
+	}
+
+	staging := "ingest_" + t.name
+	columns := join(t.columns, "")
+	if _, err := tx.Exec(ctx, fmt.Sprintf(
+		"create temp table %s on commit drop as select %s from %s with no data",
+		staging, columns, t.name,
+	)); err != nil {
+		return 0, err
+	}
+
+	rows := make([][]interface{}, len(records))
+	for i, r := range records {
+		rows[i] = t.values(r)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{staging}, t.columns, pgx.CopyFromRows(rows)); err != nil {
+		return 0, err
+	}
+
+	var same []string
+	for _, column := range t.key {
+		same = append(same, fmt.Sprintf("T.%s = S.%s", column, column))
+	}
+	tag, err := tx.Exec(ctx, fmt.Sprintf(
+		"insert into %s (%s) select distinct on (%s) %s from %s S"+
+			" where not exists (select 1 from %s T where %s)",
+		t.name, columns, join(t.key, "S."), join(t.columns, "S."), staging,
+		t.name, strings.Join(same, " and "),
+	))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// nonNil returns empty slice instead of nil for not null array columns
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// join joins columns with prefix by comma
+func join(columns []string, prefix string) string {
+	prefixed := make([]string, len(columns))
+	for i, c := range columns {
+		prefixed[i] = prefix + c
+	}
+	return strings.Join(prefixed, ", ")
+}
@@ -0,0 +1,84 @@
+package ingest_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking/ingest"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPgWriter_ShouldSkipSavedObservations(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "keyword_tracking", "meta_tracking")
+	writer := ingest.PgWriter{DB: conn}
+	ctx := context.Background()
+
+	bundleId, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "123"})
+	assert.NoError(t, err)
+
+	records := []ingest.Record{
+		{Line: 1, Kind: ingest.Keyword, Track: testhelpers.TrackStruct(bundleId, "bank")},
+		{Line: 2, Kind: ingest.Keyword, Track: testhelpers.TrackStruct(bundleId, "bank")},
+		{Line: 3, Kind: ingest.Meta, Meta: testhelpers.MetaStruct(bundleId)},
+		{Line: 4, Kind: ingest.Keyword, Track: testhelpers.TrackStruct(bundleId+1000, "bank")},
+	}
+
+	result, err := writer.Write(ctx, 0, records)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Inserted)
+	assert.Equal(t, 1, result.Duplicates)
+	assert.Equal(t, []ingest.Rejection{{Line: 4, Error: fmt.Sprintf("unknown bundle id %d", bundleId+1000)}}, result.Rejected)
+
+	// Second write of the same body inserts nothing
+	result, err = writer.Write(ctx, 0, records[:3])
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Inserted)
+	assert.Equal(t, 3, result.Duplicates)
+
+	var count int
+	assert.NoError(t, conn.QueryRow(ctx, "select count(*) from keyword_tracking where bundleid = $1", bundleId).Scan(&count))
+	assert.Equal(t, 1, count)
+	assert.NoError(t, conn.QueryRow(ctx, "select count(*) from meta_tracking where bundleid = $1", bundleId).Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestPgWriter_ShouldRejectAppsOfOtherClients(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("users", "app_tracking", "keyword_tracking")
+	writer := ingest.PgWriter{DB: conn}
+	ctx := context.Background()
+
+	var ownerId int
+	assert.NoError(t, conn.QueryRow(ctx, "insert into users (clientId, clientSecret, company) values ('id', 'secret', 'company') returning id").Scan(&ownerId))
+	owned, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "owned"})
+	assert.NoError(t, err)
+	_, err = conn.Exec(ctx, "update app_tracking set ownerId = $1 where id = $2", ownerId, owned)
+	assert.NoError(t, err)
+	shared, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "shared"})
+	assert.NoError(t, err)
+
+	records := []ingest.Record{
+		{Line: 1, Kind: ingest.Keyword, Track: testhelpers.TrackStruct(owned, "bank")},
+		{Line: 2, Kind: ingest.Keyword, Track: testhelpers.TrackStruct(shared, "bank")},
+	}
+
+	// Apps without owner are written by every client
+	result, err := writer.Write(ctx, ownerId+1, records)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Inserted)
+	assert.Equal(t, []ingest.Rejection{{Line: 1, Error: fmt.Sprintf("bundle id %d is tracked by another client", owned)}}, result.Rejected)
+
+	result, err = writer.Write(ctx, ownerId, records)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Inserted)
+	assert.Equal(t, 1, result.Duplicates)
+	assert.Empty(t, result.Rejected)
+}
@@ -8,6 +8,22 @@ import (
 	"time"
 )
 
+const (
+	// Columns of meta_tracking in order of scanning
+	metaColumns = "META.id, META.bundleId, META.title, META.price, META.picture, META.screenshots, META.rating, META.reviewCount," +
+		" META.ratingHistogram, META.description, META.shortDescription, META.recentChanges, META.releaseDate," +
+		" META.lastUpdateDate, META.appSize, META.installs, META.version, META.androidVersion, META.contentRating," +
+		" META.devContacts, META.privacyPolicy, META.date"
+	// Columns of app_tracking joined to tracking rows
+	appColumns = "APP.id, APP.bundle, APP.category, APP.developerId, APP.developer, APP.geo, APP.startAt, APP.period, APP.store"
+	// Columns of App Store pages, they are scanned after app
+	iosColumns = "META.iosVersion, META.supportedDevices, META.inAppPurchases, META.ageRating"
+	// Numeric values of store strings
+	numberColumns = "META.ratingValue, META.reviewCountValue, META.installsMin, META.installsMax, META.sizeBytes, META.ratingHistogramValues"
+	// Columns of select of meta with app
+	selectColumns = metaColumns + ", " + appColumns + ", " + iosColumns + ", " + numberColumns
+)
+
 // Struct for holds connection with db
 type Repo struct {
 	Conn connector.Conn
@@ -15,10 +31,27 @@ type Repo struct {
 
 // Making database queries
 func (m *Repo) ProducerFunc(ctx context.Context, sql string, params ...interface{}) (entities.DboSlice, error) {
+	var apps []entities.DBO
+
+	err := m.scan(ctx, sql, params, func(meta entities.DBO) error {
+		apps = append(apps, meta)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(apps) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return apps, nil
+}
+
+// scan calls f for every meta with app selected by query
+func (m *Repo) scan(ctx context.Context, sql string, params []interface{}, f func(entities.DBO) error) error {
 	var (
 		meta entities.Meta
 		app  entities.App
-		apps []entities.DBO
 	)
 
 	_, err := m.Conn.QueryFunc(
@@ -32,29 +65,25 @@ func (m *Repo) ProducerFunc(ctx context.Context, sql string, params ...interface
 			&meta.LastUpdateDate, &meta.AppSize, &meta.Installs, &meta.Version, &meta.AndroidVersion,
 			&meta.ContentRating, &meta.DeveloperContacts, &meta.PrivacyPolicy, &meta.Date,
 			&app.Id, &app.Bundle, &app.Category, &app.DeveloperId, &app.Developer, &app.Geo,
-			&app.StartAt, &app.Period,
+			&app.StartAt, &app.Period, &app.Store,
+			&meta.IosVersion, &meta.SupportedDevices, &meta.InAppPurchases, &meta.AgeRating,
+			&meta.RatingValue, &meta.ReviewCountValue, &meta.InstallsMin, &meta.InstallsMax,
+			&meta.SizeBytes, &meta.RatingHistogramValues,
 		},
 		func(row pgx.QueryFuncRow) error {
 			meta.App = app
-			apps = append(apps, meta)
-			return nil
+			return f(meta)
 		},
 	)
-	if err != nil {
-		return nil, err
-	}
-	if len(apps) == 0 {
-		return nil, pgx.ErrNoRows
-	}
 
-	return apps, nil
+	return err
 }
 
 // Return DboSlice with entities.Meta with bundleId which is equal to given id
 func (m *Repo) ByBundleId(ctx context.Context, bundleId int) (entities.DboSlice, error) {
 	return m.ProducerFunc(
 		ctx,
-		"select * from meta_tracking inner join app_tracking APP on bundleid = APP.id  where bundleid = $1",
+		"select "+selectColumns+" from meta_tracking META inner join app_tracking APP on bundleid = APP.id  where bundleid = $1",
 		bundleId,
 	)
 }
@@ -63,16 +92,94 @@ func (m *Repo) ByBundleId(ctx context.Context, bundleId int) (entities.DboSlice,
 func (m *Repo) TimeRange(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
 	return m.ProducerFunc(
 		ctx,
-		"select * from meta_tracking inner join app_tracking APP on bundleid = APP.id  where bundleid = $1 and date >= $2 and date <= $3",
+		"select "+selectColumns+" from meta_tracking META inner join app_tracking APP on bundleid = APP.id  where bundleid = $1 and date >= $2 and date <= $3",
 		bundleId, start, end,
 	)
 }
 
+// Return DboSlice with entities.Meta of several bundle ids within time range from start to end.
+// Zero start or end means that range is not bounded from this side
+func (m *Repo) ByBundleIds(ctx context.Context, bundleIds []int, start, end time.Time) (entities.DboSlice, error) {
+	return m.ProducerFunc(
+		ctx,
+		"select "+selectColumns+" from meta_tracking META inner join app_tracking APP on bundleid = APP.id where bundleid = any($1) and date >= coalesce($2::timestamp, '-infinity') and date <= coalesce($3::timestamp, 'infinity') order by META.id",
+		bundleIds, connector.NullTime(start), connector.NullTime(end),
+	)
+}
+
+// Return page of entities.Meta with given bundle id ordered by date and id. Rows are selected
+// by keyset (date, id) of cursors, so pages are stable while new rows are added
+func (m *Repo) Page(ctx context.Context, bundleId int, page entities.Page) (entities.DboSlice, error) {
+	order := " order by date, META.id limit $8"
+	if page.Backward {
+		order = " order by date desc, META.id desc limit $8"
+	}
+	afterDate, afterId := page.After.Values()
+	beforeDate, beforeId := page.Before.Values()
+
+	dbo, err := m.ProducerFunc(
+		ctx,
+		"select "+selectColumns+" from meta_tracking META inner join app_tracking APP on bundleid = APP.id where bundleid = $1 and date >= coalesce($2::timestamp, '-infinity') and date <= coalesce($3::timestamp, 'infinity') and ($4::timestamp is null or (date, META.id) > ($4, $5)) and ($6::timestamp is null or (date, META.id) < ($6, $7))"+order,
+		bundleId, connector.NullTime(page.Start), connector.NullTime(page.End),
+		afterDate, afterId, beforeDate, beforeId, page.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if page.Backward {
+		dbo.Reverse()
+	}
+
+	return dbo, nil
+}
+
+// Count rows with given bundle id within time range from start to end.
+// Zero start or end means that range is not bounded from this side
+func (m *Repo) Count(ctx context.Context, bundleId int, start, end time.Time) (int, error) {
+	var count int
+	err := m.Conn.QueryRow(
+		ctx,
+		"select count(*) from meta_tracking META where bundleid = $1 and date >= coalesce($2::timestamp, '-infinity') and date <= coalesce($3::timestamp, 'infinity')",
+		bundleId, connector.NullTime(start), connector.NullTime(end),
+	).Scan(&count)
+
+	return count, err
+}
+
 // Get last n updates of app with bundle id equals given bundle id
 func (m *Repo) LastUpdates(ctx context.Context, bundleId, count int) (entities.DboSlice, error) {
 	return m.ProducerFunc(
 		ctx,
-		"select * from meta_tracking META inner join app_tracking APP on bundleid = APP.id  where bundleid = $1 order by META.id desc limit $2",
+		"select "+selectColumns+" from meta_tracking META inner join app_tracking APP on bundleid = APP.id  where bundleid = $1 order by META.id desc limit $2",
 		bundleId, count,
 	)
 }
+
+// Get snapshot with given id and the previous snapshot of the same app, newest first
+func (m *Repo) WithPrevious(ctx context.Context, id int) (entities.DboSlice, error) {
+	return m.ProducerFunc(
+		ctx,
+		"with CUR as (select bundleId, date, id from meta_tracking where id = $1)"+
+			" select "+selectColumns+" from meta_tracking META inner join app_tracking APP on META.bundleid = APP.id inner join CUR on META.bundleid = CUR.bundleId"+
+			" where (META.date, META.id) <= (CUR.date, CUR.id) order by META.date desc, META.id desc limit 2",
+		id,
+	)
+}
+
+// Call f for every snapshot with given bundle id within time range from start to end without
+// collecting snapshots. Zero start or end means unbounded range, positive last selects last
+// snapshots newest first like LastUpdates
+func (m *Repo) Stream(ctx context.Context, bundleId, last int, start, end time.Time, f func(entities.DBO) error) error {
+	var limit *int
+	order := " order by date, META.id"
+	if last > 0 {
+		order, limit = " order by META.id desc", &last
+	}
+
+	return m.scan(
+		ctx,
+		"select "+selectColumns+" from meta_tracking META inner join app_tracking APP on bundleid = APP.id where bundleid = $1 and date >= coalesce($2::timestamp, '-infinity') and date <= coalesce($3::timestamp, 'infinity')"+order+" limit $4",
+		[]interface{}{bundleId, connector.NullTime(start), connector.NullTime(end), limit},
+		f,
+	)
+}
@@ -33,7 +33,6 @@ func TestMetaRepo_ByBundleId_ShouldReturnSomeApps_Mock(t *testing.T) {
 
 func TestMetaRepo_ByBundleId_ShouldReturnSomeApps(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("app_tracking, meta_tracking")
@@ -85,7 +84,6 @@ func TestMetaRepo_TimeRange_ShouldReturnAppsWithGivenTimeRange_Mock(t *testing.T
 
 func TestMetaRepo_TimeRange_ShouldReturnAppsWithGivenTimeRange(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("app_tracking, meta_tracking")
@@ -130,7 +128,6 @@ func TestMetaRepo_LastUpdates_ShouldReturnLastNApps_Mock(t *testing.T) {
 
 func TestMetaRepo_LastUpdates_ShouldReturnLastNApps(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("app_tracking, meta_tracking")
@@ -177,7 +174,6 @@ func TestMetaRepo_ByBundleId_ShouldReturnErrorIfNoRows_Mock(t *testing.T) {
 
 func TestMetaRepo_ByBundleId_ShouldReturnErrorIfNoRows(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, _ := testhelpers.RealDb(cfg.Database)
 	repo := metastore.Repo{Conn: conn}
@@ -202,7 +198,6 @@ func TestMetaRepo_TimeRange_ShouldReturnErrorIfNoRows_Mock(t *testing.T) {
 
 func TestMetaRepo_TimeRange_ShouldReturnErrorIfNoRows(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, _ := testhelpers.RealDb(cfg.Database)
 	repo := metastore.Repo{Conn: conn}
@@ -227,7 +222,6 @@ func TestMetaRepo_LastUpdate_ShouldReturnErrorIfNoRows_Mock(t *testing.T) {
 
 func TestMetaRepo_LastUpdate_ShouldReturnErrorIfNoRows(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, _ := testhelpers.RealDb(cfg.Database)
 	repo := metastore.Repo{Conn: conn}
@@ -239,3 +233,117 @@ func TestMetaRepo_LastUpdate_ShouldReturnErrorIfNoRows(t *testing.T) {
 	assert.Nil(t, dboSlice)
 }
 
+
+func TestMetaRepo_ByBundleIds_ShouldReturnAppsOfAllBundles_Mock(t *testing.T) {
+	conn := mockMetaConnection{}
+	repo := metastore.Repo{Conn: conn}
+	ctx := context.Background()
+
+	dboSlice, err := repo.ByBundleIds(ctx, []int{12, 13}, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(dboSlice))
+}
+
+func TestMetaRepo_ByBundleIds_ShouldReturnAppsOfAllBundles(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking, meta_tracking")
+	repo := metastore.Repo{Conn: conn}
+	ctx := context.Background()
+
+	var ids []int
+	for _, bundle := range []string{"123", "456"} {
+		bundleId, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: bundle})
+		assert.NoError(t, err)
+		ids = append(ids, bundleId)
+
+		meta := testhelpers.MetaStruct(bundleId)
+		for i := 0; i < 3; i++ {
+			_, err := testhelpers.AddNewMeta(conn, ctx, meta)
+			assert.NoError(t, err)
+			meta.Date = meta.Date.AddDate(0, 0, 1)
+		}
+	}
+	start := testhelpers.MetaStruct(0).Date.AddDate(0, 0, 1)
+
+	// Without range
+	dboSlice, err := repo.ByBundleIds(ctx, ids, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(dboSlice))
+
+	// Range bounded only from start
+	dboSlice, err = repo.ByBundleIds(ctx, ids, start, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(dboSlice))
+
+	var app entities.Meta
+	for _, v := range dboSlice {
+		assert.NoError(t, v.To(&app))
+		assert.Contains(t, ids, app.BundleId)
+		assert.False(t, app.Date.Before(start))
+	}
+}
+
+func TestMetaRepo_Page_ShouldReturnPageOfRows_Mock(t *testing.T) {
+	conn := mockMetaConnection{}
+	repo := metastore.Repo{Conn: conn}
+	ctx := context.Background()
+
+	dboSlice, err := repo.Page(ctx, 12, entities.Page{Limit: 3, Backward: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(dboSlice))
+
+	// Backward page should be returned in ascending order
+	var first, last entities.Meta
+	assert.NoError(t, dboSlice[0].To(&first))
+	assert.NoError(t, dboSlice[2].To(&last))
+	assert.True(t, first.Date.After(last.Date))
+}
+
+func TestMetaRepo_Page_ShouldReturnRowsAroundCursors(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking, meta_tracking")
+	repo := metastore.Repo{Conn: conn}
+	ctx := context.Background()
+
+	bundleId, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "123"})
+	assert.NoError(t, err)
+	meta := testhelpers.MetaStruct(bundleId)
+	for i := 0; i < 5; i++ {
+		_, err := testhelpers.AddNewMeta(conn, ctx, meta)
+		assert.NoError(t, err)
+		meta.Date = meta.Date.AddDate(0, 0, 1)
+	}
+
+	first, err := repo.Page(ctx, bundleId, entities.Page{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(first))
+
+	cursor, _ := entities.CursorOf(first[1])
+	next, err := repo.Page(ctx, bundleId, entities.Page{Limit: 10, After: &cursor})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(next))
+
+	last, err := repo.Page(ctx, bundleId, entities.Page{Limit: 10, Before: &cursor, Backward: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(last))
+
+	var m entities.Meta
+	assert.NoError(t, next[0].To(&m))
+	assert.True(t, m.Date.After(cursor.Date))
+
+	count, err := repo.Count(ctx, bundleId, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+func TestMetaRepo_Count_ShouldReturnError_Mock(t *testing.T) {
+	conn := mockMetaConnectionErrors{}
+	repo := metastore.Repo{Conn: conn}
+
+	_, err := repo.Count(context.Background(), 12, time.Time{}, time.Time{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,199 @@
+package partitions
+
+import (
+	"Muromachi/logging"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Default delay between maintenance runs
+	DefaultInterval = time.Hour * 6
+	// Default count of monthly partitions which are created ahead of current month
+	DefaultAhead = 3
+)
+
+// Partition of tracking table which keeps rows from From inclusive until To exclusive
+type Partition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// Tracking table which is partitioned by month of date
+type Table struct {
+	Name string
+	// Partitions which end before now minus retention are removed, zero keeps partitions forever.
+	// Rows of default partition before the same border are deleted unless partitions are detached
+	Retention time.Duration
+	// Detach expired partitions and keep them as plain tables instead of dropping
+	Detach bool
+}
+
+// Store of partitions of tracking tables
+type Store interface {
+	// Lock takes lock of maintenance which is released by returned function. Returns false
+	// if maintenance is already running in other instance
+	Lock(ctx context.Context) (bool, func(), error)
+	// Partitions returns range partitions of table, default partition is not returned
+	Partitions(ctx context.Context, table string) ([]Partition, error)
+	// Create creates partition of table and moves rows of its range from default partition
+	Create(ctx context.Context, table string, partition Partition) error
+	// Trim deletes rows of default partition of table dated before border, returns count of deleted rows
+	Trim(ctx context.Context, table string, before time.Time) (int64, error)
+	// Remove detaches partition from table or drops it
+	Remove(ctx context.Context, table string, partition Partition, detach bool) error
+}
+
+// Month returns partition of table which keeps rows of month of t
+func Month(table string, t time.Time) Partition {
+	t = t.UTC()
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return Partition{
+		Name: fmt.Sprintf("%s_p%s", table, from.Format("200601")),
+		From: from,
+		To:   from.AddDate(0, 1, 0),
+	}
+}
+
+// Plan returns partitions of table which should be created to cover months from
+// current until ahead months later, and existing partitions which are expired at now
+func Plan(table Table, existing []Partition, now time.Time, ahead int) (create, expired []Partition) {
+	covered := func(p Partition) bool {
+		for _, e := range existing {
+			if e.From.Before(p.To) && p.From.Before(e.To) {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i <= ahead; i++ {
+		// Months are added to the first day, so days like 31th do not skip months
+		p := Month(table.Name, time.Date(now.Year(), now.Month()+time.Month(i), 1, 0, 0, 0, 0, time.UTC))
+		if !covered(p) {
+			create = append(create, p)
+		}
+	}
+
+	if table.Retention <= 0 {
+		return create, nil
+	}
+	border := now.Add(-table.Retention)
+	for _, e := range existing {
+		if !e.To.After(border) {
+			expired = append(expired, e)
+		}
+	}
+
+	return create, expired
+}
+
+// Maintainer keeps partitions of tracking tables: creates partitions ahead of current
+// month and removes expired ones. Only one instance maintains partitions at once
+type Maintainer struct {
+	Store  Store
+	Tables []Table
+	Now    worker.Clock
+	// Delay between maintenance runs
+	Interval time.Duration
+	// Count of monthly partitions which are created ahead of current month
+	Ahead int
+}
+
+// Run maintains partitions at start and then every interval until ctx is done
+func (m *Maintainer) Run(ctx context.Context) error {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Maintain(ctx); err != nil && ctx.Err() == nil {
+			logging.Errorf("partitions: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Maintain creates missing partitions, removes expired ones and expired rows of default
+// partition of every table.
+// Errors of one table do not stop maintenance of others
+func (m *Maintainer) Maintain(ctx context.Context) error {
+	locked, unlock, err := m.Store.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	defer unlock()
+
+	var problems []string
+	for _, table := range m.Tables {
+		if err := m.maintain(ctx, table); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", table.Name, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// maintain creates missing partitions and removes expired ones of table
+func (m *Maintainer) maintain(ctx context.Context, table Table) error {
+	existing, err := m.Store.Partitions(ctx, table.Name)
+	if err != nil {
+		return err
+	}
+
+	now := m.Now.UTC()
+	create, expired := Plan(table, existing, now, m.ahead())
+	for _, p := range create {
+		if err := m.Store.Create(ctx, table.Name, p); err != nil {
+			return err
+		}
+		logging.Infof("partitions: created %s", p.Name)
+	}
+	for _, p := range expired {
+		if err := m.Store.Remove(ctx, table.Name, p, table.Detach); err != nil {
+			return err
+		}
+		if table.Detach {
+			logging.Infof("partitions: detached %s", p.Name)
+		} else {
+			logging.Infof("partitions: dropped %s", p.Name)
+		}
+	}
+	// Rows out of partitions are kept in default partition, they expire with dropped partitions
+	if table.Retention > 0 && !table.Detach {
+		count, err := m.Store.Trim(ctx, table.Name, now.Add(-table.Retention))
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			logging.Infof("partitions: deleted %d expired rows of default partition of %s", count, table.Name)
+		}
+	}
+
+	return nil
+}
+
+func (m *Maintainer) ahead() int {
+	if m.Ahead <= 0 {
+		return DefaultAhead
+	}
+	return m.Ahead
+}
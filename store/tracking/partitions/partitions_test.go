@@ -0,0 +1,141 @@
+package partitions_test
+
+import (
+	"Muromachi/store/tracking/partitions"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestMonth(t *testing.T) {
+	p := partitions.Month("meta_tracking", time.Date(2021, 12, 31, 23, 59, 0, 0, time.UTC))
+	assert.Equal(t, partitions.Partition{Name: "meta_tracking_p202112", From: month(2021, 12), To: month(2022, 1)}, p)
+}
+
+func TestPlan(t *testing.T) {
+	// The 31th of January, adding of months should not skip February
+	now := time.Date(2021, 1, 31, 12, 0, 0, 0, time.UTC)
+	existing := []partitions.Partition{
+		partitions.Month("keyword_tracking", month(2020, 1)),
+		partitions.Month("keyword_tracking", month(2020, 12)),
+		partitions.Month("keyword_tracking", month(2021, 1)),
+		partitions.Month("keyword_tracking", month(2021, 2)),
+	}
+
+	create, expired := partitions.Plan(partitions.Table{Name: "keyword_tracking"}, existing, now, 3)
+	assert.Equal(t, []partitions.Partition{
+		partitions.Month("keyword_tracking", month(2021, 3)),
+		partitions.Month("keyword_tracking", month(2021, 4)),
+	}, create)
+	assert.Empty(t, expired)
+
+	// Partition is expired when its whole range is older than retention
+	table := partitions.Table{Name: "keyword_tracking", Retention: time.Hour * 24 * 45}
+	_, expired = partitions.Plan(table, existing, now, 3)
+	assert.Equal(t, existing[:1], expired)
+	_, expired = partitions.Plan(table, existing, month(2021, 1).Add(time.Hour*24*45-time.Second), 3)
+	assert.Equal(t, existing[:1], expired)
+	_, expired = partitions.Plan(table, existing, month(2021, 1).Add(time.Hour*24*45), 3)
+	assert.Equal(t, existing[:2], expired)
+
+	// Ranges which overlap months are not created again
+	wide := []partitions.Partition{{Name: "keyword_tracking_2021", From: month(2021, 1), To: month(2022, 1)}}
+	create, _ = partitions.Plan(partitions.Table{Name: "keyword_tracking"}, wide, now, 3)
+	assert.Empty(t, create)
+}
+
+// Store which keeps partitions in memory
+type mockStore struct {
+	locked     bool
+	partitions map[string][]partitions.Partition
+	removed    map[string]bool
+	// Borders of trimmed default partitions by table
+	trimmed map[string]time.Time
+	failed  string
+}
+
+func (m *mockStore) Lock(ctx context.Context) (bool, func(), error) {
+	if m.locked {
+		return false, nil, nil
+	}
+	m.locked = true
+	return true, func() { m.locked = false }, nil
+}
+
+func (m *mockStore) Partitions(ctx context.Context, table string) ([]partitions.Partition, error) {
+	return m.partitions[table], nil
+}
+
+func (m *mockStore) Create(ctx context.Context, table string, partition partitions.Partition) error {
+	if table == m.failed {
+		return errors.New("lock timeout")
+	}
+	m.partitions[table] = append(m.partitions[table], partition)
+	return nil
+}
+
+func (m *mockStore) Remove(ctx context.Context, table string, partition partitions.Partition, detach bool) error {
+	var kept []partitions.Partition
+	for _, p := range m.partitions[table] {
+		if p.Name != partition.Name {
+			kept = append(kept, p)
+		}
+	}
+	m.partitions[table] = kept
+	m.removed[partition.Name] = detach
+	return nil
+}
+
+func (m *mockStore) Trim(ctx context.Context, table string, before time.Time) (int64, error) {
+	m.trimmed[table] = before
+	return 1, nil
+}
+
+func TestMaintainer_Maintain_ShouldCreateAndRemovePartitions_Mock(t *testing.T) {
+	now := time.Date(2021, 3, 10, 0, 0, 0, 0, time.UTC)
+	store := &mockStore{
+		partitions: map[string][]partitions.Partition{
+			"meta_tracking": {
+				partitions.Month("meta_tracking", month(2020, 12)),
+				partitions.Month("meta_tracking", month(2021, 3)),
+			},
+		},
+		removed: map[string]bool{},
+		trimmed: map[string]time.Time{},
+		failed:  "category_tracking",
+	}
+	m := &partitions.Maintainer{
+		Store: store,
+		Tables: []partitions.Table{
+			{Name: "category_tracking"},
+			{Name: "meta_tracking", Retention: time.Hour * 24 * 60, Detach: true},
+			{Name: "keyword_tracking", Retention: time.Hour * 24 * 60},
+		},
+		Ahead: 1,
+		Now:   func() time.Time { return now },
+	}
+
+	err := m.Maintain(context.Background())
+	assert.EqualError(t, err, "category_tracking: lock timeout")
+	assert.False(t, store.locked)
+	assert.Equal(t, []partitions.Partition{
+		partitions.Month("meta_tracking", month(2021, 3)),
+		partitions.Month("meta_tracking", month(2021, 4)),
+	}, store.partitions["meta_tracking"])
+	assert.Equal(t, map[string]bool{"meta_tracking_p202012": true}, store.removed)
+	assert.Len(t, store.partitions["keyword_tracking"], 2)
+	// Default partition is trimmed only if expired partitions are dropped
+	assert.Equal(t, map[string]time.Time{"keyword_tracking": now.Add(-time.Hour * 24 * 60)}, store.trimmed)
+
+	// Maintenance is skipped while other instance holds lock
+	store.locked = true
+	store.partitions["keyword_tracking"] = nil
+	assert.NoError(t, m.Maintain(context.Background()))
+	assert.Empty(t, store.partitions["keyword_tracking"])
+}
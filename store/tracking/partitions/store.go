@@ -0,0 +1,182 @@
+package partitions
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"regexp"
+	"time"
+)
+
+const (
+	// Key of postgres advisory lock which guards concurrent maintenance
+	lockKey = 7_241_900_118
+	// Max wait of locks of tracking table, so maintenance does not block queries for long
+	lockTimeout = "10s"
+)
+
+// Bounds of range partition like FOR VALUES FROM ('2021-01-01 00:00:00') TO ('2021-02-01 00:00:00')
+var boundsRe = regexp.MustCompile(`FROM \('([^']+)'\) TO \('([^']+)'\)`)
+
+// Store of partitions in postgres catalog
+type PgStore struct {
+	Pool *pgxpool.Pool
+}
+
+// Lock takes session advisory lock on dedicated connection
+func (p *PgStore) Lock(ctx context.Context) (bool, func(), error) {
+	conn, err := p.Pool.Acquire(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "select pg_try_advisory_lock($1)", lockKey).Scan(&locked); err != nil || !locked {
+		conn.Release()
+		return false, nil, err
+	}
+
+	return true, func() {
+		_, _ = conn.Exec(context.Background(), "select pg_advisory_unlock($1)", lockKey)
+		conn.Release()
+	}, nil
+}
+
+// Partitions returns range partitions of table ordered by name
+func (p *PgStore) Partitions(ctx context.Context, table string) ([]Partition, error) {
+	var (
+		name, bound string
+		partitions  []Partition
+	)
+	_, err := p.Pool.QueryFunc(
+		ctx,
+		"select C.relname, pg_get_expr(C.relpartbound, C.oid) from pg_inherits I inner join pg_class C on C.oid = I.inhrelid"+
+			" where I.inhparent = $1::regclass order by C.relname",
+		[]interface{}{table},
+		[]interface{}{&name, &bound},
+		func(pgx.QueryFuncRow) error {
+			match := boundsRe.FindStringSubmatch(bound)
+			if match == nil {
+				// Default partition
+				return nil
+			}
+			from, err := time.Parse("2006-01-02 15:04:05", match[1])
+			if err != nil {
+				return fmt.Errorf("partition %s: %v", name, err)
+			}
+			to, err := time.Parse("2006-01-02 15:04:05", match[2])
+			if err != nil {
+				return fmt.Errorf("partition %s: %v", name, err)
+			}
+			partitions = append(partitions, Partition{Name: name, From: from, To: to})
+			return nil
+		},
+	)
+
+	return partitions, err
+}
+
+// Create creates partition of table. Postgres refuses to create partition while default partition
+// keeps rows of its range, so default partition is detached, rows of range are moved to new partition
+// and default partition is attached again in the same transaction
+func (p *PgStore) Create(ctx context.Context, table string, partition Partition) error {
+	from, to := partition.From.Format("2006-01-02 15:04:05"), partition.To.Format("2006-01-02 15:04:05")
+	create := fmt.Sprintf(
+		"create table if not exists %s partition of %s for values from ('%s') to ('%s')",
+		pgx.Identifier{partition.Name}.Sanitize(), pgx.Identifier{table}.Sanitize(), from, to,
+	)
+
+	def, err := p.defaultPartition(ctx, table)
+	if err != nil {
+		return err
+	}
+	misplaced := false
+	if def != "" {
+		err = p.Pool.QueryRow(
+			ctx,
+			fmt.Sprintf("select exists (select 1 from %s where date >= $1 and date < $2)", pgx.Identifier{def}.Sanitize()),
+			partition.From, partition.To,
+		).Scan(&misplaced)
+		if err != nil {
+			return err
+		}
+	}
+	if !misplaced {
+		return p.ddl(ctx, create)
+	}
+
+	parent, name := pgx.Identifier{table}.Sanitize(), pgx.Identifier{partition.Name}.Sanitize()
+	def = pgx.Identifier{def}.Sanitize()
+	return p.ddl(
+		ctx,
+		fmt.Sprintf("alter table %s detach partition %s", parent, def),
+		create,
+		fmt.Sprintf("insert into %s select * from %s where date >= '%s' and date < '%s'", name, def, from, to),
+		fmt.Sprintf("delete from %s where date >= '%s' and date < '%s'", def, from, to),
+		fmt.Sprintf("alter table %s attach partition %s default", parent, def),
+	)
+}
+
+// Trim deletes rows of default partition of table which are dated before border
+func (p *PgStore) Trim(ctx context.Context, table string, before time.Time) (int64, error) {
+	def, err := p.defaultPartition(ctx, table)
+	if err != nil || def == "" {
+		return 0, err
+	}
+
+	tag, err := p.Pool.Exec(ctx, fmt.Sprintf("delete from %s where date < $1", pgx.Identifier{def}.Sanitize()), before)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// Remove detaches partition from table and keeps it as plain table or drops it
+func (p *PgStore) Remove(ctx context.Context, table string, partition Partition, detach bool) error {
+	if detach {
+		return p.ddl(ctx, fmt.Sprintf(
+			"alter table %s detach partition %s",
+			pgx.Identifier{table}.Sanitize(), pgx.Identifier{partition.Name}.Sanitize(),
+		))
+	}
+
+	return p.ddl(ctx, "drop table "+pgx.Identifier{partition.Name}.Sanitize())
+}
+
+// defaultPartition returns name of default partition of table, empty if table has no default partition
+func (p *PgStore) defaultPartition(ctx context.Context, table string) (string, error) {
+	var name string
+	err := p.Pool.QueryRow(
+		ctx,
+		"select C.relname from pg_inherits I inner join pg_class C on C.oid = I.inhrelid"+
+			" where I.inhparent = $1::regclass and pg_get_expr(C.relpartbound, C.oid) = 'DEFAULT'",
+		table,
+	).Scan(&name)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+
+	return name, err
+}
+
+// ddl executes statements in one transaction with limited wait of locks
+func (p *PgStore) ddl(ctx context.Context, statements ...string) error {
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "set local lock_timeout = '"+lockTimeout+"'"); err != nil {
+		return err
+	}
+	for _, sql := range statements {
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,117 @@
+package partitions_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking/partitions"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPgStore_ShouldCreateAndRemovePartitions(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "keyword_tracking")
+	store := partitions.PgStore{Pool: conn}
+	ctx := context.Background()
+
+	locked, unlock, err := store.Lock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	// Lock is held by session, so the second lock fails
+	again, _, err := store.Lock(ctx)
+	assert.NoError(t, err)
+	assert.False(t, again)
+	unlock()
+
+	first := partitions.Month("keyword_tracking", month(2099, 1))
+	second := partitions.Month("keyword_tracking", month(2099, 2))
+	assert.NoError(t, store.Create(ctx, "keyword_tracking", first))
+	assert.NoError(t, store.Create(ctx, "keyword_tracking", second))
+	defer conn.Exec(ctx, "drop table if exists keyword_tracking_p209901, keyword_tracking_p209902")
+
+	existing, err := store.Partitions(ctx, "keyword_tracking")
+	assert.NoError(t, err)
+	assert.Contains(t, existing, first)
+	assert.Contains(t, existing, second)
+	for _, p := range existing {
+		assert.False(t, strings.HasSuffix(p.Name, "_default"))
+	}
+
+	appId, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "com.muromachi.bank", Geo: "en_us"})
+	assert.NoError(t, err)
+	track := testhelpers.TrackStruct(appId, "bank")
+	track.Date = time.Date(2099, 1, 10, 0, 0, 0, 0, time.UTC)
+	_, err = testhelpers.AddNewTrack(conn, ctx, track, "keyword_tracking")
+	assert.NoError(t, err)
+
+	// Range of query prunes partitions out of range
+	var plan []string
+	var line string
+	_, err = conn.QueryFunc(
+		ctx,
+		"explain select count(*) from keyword_tracking where bundleid = $1 and date >= coalesce($2::timestamp, '-infinity') and date <= coalesce($3::timestamp, 'infinity')",
+		[]interface{}{appId, month(2099, 1), month(2099, 1).AddDate(0, 0, 20)},
+		[]interface{}{&line},
+		func(pgx.QueryFuncRow) error {
+			plan = append(plan, line)
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+	assert.Contains(t, strings.Join(plan, "\n"), "keyword_tracking_p209901")
+	assert.NotContains(t, strings.Join(plan, "\n"), "keyword_tracking_p209902")
+
+	// Detached partition keeps rows out of table
+	assert.NoError(t, store.Remove(ctx, "keyword_tracking", first, true))
+	var count int
+	assert.NoError(t, conn.QueryRow(ctx, "select count(*) from keyword_tracking where bundleid = $1", appId).Scan(&count))
+	assert.Equal(t, 0, count)
+	assert.NoError(t, conn.QueryRow(ctx, "select count(*) from keyword_tracking_p209901").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	assert.NoError(t, store.Remove(ctx, "keyword_tracking", second, false))
+	existing, err = store.Partitions(ctx, "keyword_tracking")
+	assert.NoError(t, err)
+	assert.NotContains(t, existing, first)
+	assert.NotContains(t, existing, second)
+	_, err = conn.Exec(ctx, "select 1 from keyword_tracking_p209902")
+	assert.Error(t, err)
+}
+
+func TestPgStore_ShouldMoveRowsOfDefaultPartitionToCreatedPartition(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "keyword_tracking")
+	store := partitions.PgStore{Pool: conn}
+	ctx := context.Background()
+
+	appId, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "com.muromachi.bank", Geo: "en_us"})
+	assert.NoError(t, err)
+	// Rows out of every partition land in default partition
+	for _, date := range []time.Time{time.Date(2098, 5, 10, 0, 0, 0, 0, time.UTC), time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)} {
+		track := testhelpers.TrackStruct(appId, "bank")
+		track.Date = date
+		_, err = testhelpers.AddNewTrack(conn, ctx, track, "keyword_tracking")
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, store.Create(ctx, "keyword_tracking", partitions.Month("keyword_tracking", month(2098, 5))))
+	defer conn.Exec(ctx, "drop table if exists keyword_tracking_p209805")
+	var count int
+	assert.NoError(t, conn.QueryRow(ctx, "select count(*) from keyword_tracking_p209805").Scan(&count))
+	assert.Equal(t, 1, count)
+	assert.NoError(t, conn.QueryRow(ctx, "select count(*) from keyword_tracking_default where bundleid = $1", appId).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	trimmed, err := store.Trim(ctx, "keyword_tracking", month(1980, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), trimmed)
+	assert.NoError(t, conn.QueryRow(ctx, "select count(*) from keyword_tracking where bundleid = $1", appId).Scan(&count))
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,246 @@
+package reports
+
+import (
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Count of apps of client which are loaded at once
+	appsPage = 100
+	// Max length of meta values in digest, long texts are cut
+	maxValueLength = 200
+)
+
+// Digest of tracked apps of client for period
+type Digest struct {
+	OwnerId     int         `json:"ownerId"`
+	PeriodStart time.Time   `json:"periodStart"`
+	PeriodEnd   time.Time   `json:"periodEnd"`
+	GeneratedAt time.Time   `json:"generatedAt"`
+	Apps        []AppDigest `json:"apps"`
+}
+
+// Digest of app
+type AppDigest struct {
+	Id     int               `json:"id"`
+	Bundle string            `json:"bundle"`
+	Geo    string            `json:"geo"`
+	Store  entities.AppStore `json:"store"`
+	// Movements of places by keywords and in categories, the biggest movements first
+	Keywords   []Movement `json:"keywords"`
+	Categories []Movement `json:"categories"`
+	// Changes of meta fields within period
+	MetaChanges []MetaChange `json:"metaChanges"`
+	// Trends of parsed rating and review count, nil if store values were not parsed
+	Rating  *Trend `json:"rating,omitempty"`
+	Reviews *Trend `json:"reviews,omitempty"`
+}
+
+// Movement of place by keyword or in category. Lower place is better,
+// so positive change means that app moved up
+type Movement struct {
+	Type   string `json:"type"`
+	Start  int32  `json:"start"`
+	End    int32  `json:"end"`
+	Change int32  `json:"change"`
+	Best   int32  `json:"best"`
+	Worst  int32  `json:"worst"`
+}
+
+// Change of meta field, long values are cut
+type MetaChange struct {
+	Field entities.MetaField `json:"field"`
+	Date  time.Time          `json:"date"`
+	Old   string             `json:"old"`
+	New   string             `json:"new"`
+}
+
+// Trend of numeric value within period
+type Trend struct {
+	Start  float64 `json:"start"`
+	End    float64 `json:"end"`
+	Change float64 `json:"change"`
+}
+
+// Compose builds digest of every tracked app of owner from tracking rows of period
+func Compose(ctx context.Context, tables *tracking.Tables, ownerId int, start, end time.Time) (Digest, error) {
+	digest := Digest{OwnerId: ownerId, PeriodStart: start, PeriodEnd: end, Apps: []AppDigest{}}
+
+	query := entities.AppQuery{Filter: entities.AppFilter{OwnerId: ownerId}, Limit: appsPage}
+	for {
+		dbo, err := tables.App.Search(ctx, query)
+		if err == pgx.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return digest, err
+		}
+
+		for _, v := range dbo {
+			var app entities.App
+			if err := v.To(&app); err != nil {
+				return digest, err
+			}
+			d, err := composeApp(ctx, tables, app, start, end)
+			if err != nil {
+				return digest, err
+			}
+			digest.Apps = append(digest.Apps, d)
+			query.After = &entities.AppCursor{Id: app.Id}
+		}
+		if len(dbo) < appsPage {
+			break
+		}
+	}
+
+	return digest, nil
+}
+
+// composeApp builds digest of app
+func composeApp(ctx context.Context, tables *tracking.Tables, app entities.App, start, end time.Time) (AppDigest, error) {
+	d := AppDigest{Id: app.Id, Bundle: app.Bundle, Geo: app.Geo, Store: app.Store}
+
+	var err error
+	if d.Keywords, err = movements(ctx, tables.Keys, app.Id, start, end); err != nil {
+		return d, err
+	}
+	if d.Categories, err = movements(ctx, tables.Cat, app.Id, start, end); err != nil {
+		return d, err
+	}
+
+	dbo, err := tables.Meta.TimeRange(ctx, app.Id, start, end)
+	if err != nil && err != pgx.ErrNoRows {
+		return d, err
+	}
+	snapshots := make([]entities.Meta, len(dbo))
+	for i, v := range dbo {
+		if err := v.To(&snapshots[i]); err != nil {
+			return d, err
+		}
+	}
+
+	d.MetaChanges = []MetaChange{}
+	changes, err := entities.MetaChanges(snapshots, nil)
+	if err != nil {
+		return d, err
+	}
+	for _, v := range changes {
+		var change entities.MetaChange
+		if err := v.To(&change); err != nil {
+			return d, err
+		}
+		d.MetaChanges = append(d.MetaChanges, MetaChange{
+			Field: change.Field,
+			Date:  change.Date,
+			Old:   shorten(change.Old),
+			New:   shorten(change.New),
+		})
+	}
+	d.Rating, d.Reviews = trends(snapshots)
+
+	return d, nil
+}
+
+// movements returns movements of places of app by every type, the biggest movements first
+func movements(ctx context.Context, repo tracking.TrackRepository, bundleId int, start, end time.Time) ([]Movement, error) {
+	dbo, err := repo.TimeRange(ctx, bundleId, start, end)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, err
+	}
+
+	tracks := make([]entities.Track, len(dbo))
+	for i, v := range dbo {
+		if err := v.To(&tracks[i]); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(tracks, func(i, j int) bool {
+		if tracks[i].Date.Equal(tracks[j].Date) {
+			return tracks[i].Id < tracks[j].Id
+		}
+		return tracks[i].Date.Before(tracks[j].Date)
+	})
+
+	byType := make(map[string]*Movement)
+	result := []Movement{}
+	for _, tr := range tracks {
+		m, ok := byType[tr.Type]
+		if !ok {
+			m = &Movement{Type: tr.Type, Start: tr.Place, Best: tr.Place, Worst: tr.Place}
+			byType[tr.Type] = m
+		}
+		m.End = tr.Place
+		if tr.Place < m.Best {
+			m.Best = tr.Place
+		}
+		if tr.Place > m.Worst {
+			m.Worst = tr.Place
+		}
+	}
+	for _, m := range byType {
+		m.Change = m.Start - m.End
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := abs(result[i].Change), abs(result[j].Change)
+		if a != b {
+			return a > b
+		}
+		return result[i].Type < result[j].Type
+	})
+
+	return result, nil
+}
+
+// trends returns trends of parsed rating and review count of snapshots
+func trends(snapshots []entities.Meta) (rating, reviews *Trend) {
+	sorted := make([]entities.Meta, len(snapshots))
+	copy(sorted, snapshots)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	for _, m := range sorted {
+		if m.RatingValue != nil {
+			rating = extend(rating, *m.RatingValue)
+		}
+		if m.ReviewCountValue != nil {
+			reviews = extend(reviews, float64(*m.ReviewCountValue))
+		}
+	}
+
+	return rating, reviews
+}
+
+// extend adds the next value to trend
+func extend(trend *Trend, value float64) *Trend {
+	if trend == nil {
+		return &Trend{Start: value, End: value}
+	}
+	trend.End = value
+	trend.Change = trend.End - trend.Start
+
+	return trend
+}
+
+// shorten cuts long value by whole characters
+func shorten(value string) string {
+	if utf8.RuneCountInString(value) <= maxValueLength {
+		return value
+	}
+
+	return string([]rune(value)[:maxValueLength]) + "…"
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
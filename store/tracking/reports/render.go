@@ -0,0 +1,84 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+)
+
+// Template of html body of digest
+var page = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"movements": func(title string, rows []Movement) interface{} {
+		return struct {
+			Title string
+			Rows  []Movement
+		}{title, rows}
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Weekly digest {{.PeriodStart.Format "2006-01-02"}} – {{.PeriodEnd.Format "2006-01-02"}}</title>
+<style>
+body { font-family: sans-serif; color: #222; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; }
+.up { color: #1a7f37; }
+.down { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>Weekly digest</h1>
+<p>{{.PeriodStart.Format "2006-01-02 15:04"}} – {{.PeriodEnd.Format "2006-01-02 15:04"}} UTC</p>
+{{- if not .Apps}}
+<p>No tracked apps.</p>
+{{- end}}
+{{- range .Apps}}
+<h2>{{.Bundle}} ({{.Geo}}, {{.Store}})</h2>
+{{- if .Rating}}
+<p>Rating: {{printf "%.2f" .Rating.Start}} → {{printf "%.2f" .Rating.End}} ({{printf "%+.2f" .Rating.Change}})</p>
+{{- end}}
+{{- if .Reviews}}
+<p>Reviews: {{printf "%.0f" .Reviews.Start}} → {{printf "%.0f" .Reviews.End}} ({{printf "%+.0f" .Reviews.Change}})</p>
+{{- end}}
+{{- template "movements" (movements "Keywords" .Keywords)}}
+{{- template "movements" (movements "Categories" .Categories)}}
+{{- if .MetaChanges}}
+<h3>Meta changes</h3>
+<table>
+<tr><th>Date</th><th>Field</th><th>Old</th><th>New</th></tr>
+{{- range .MetaChanges}}
+<tr><td>{{.Date.Format "2006-01-02"}}</td><td>{{.Field}}</td><td>{{.Old}}</td><td>{{.New}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+{{define "movements"}}
+{{- if .Rows}}
+<h3>{{.Title}}</h3>
+<table>
+<tr><th></th><th>Start</th><th>End</th><th>Change</th><th>Best</th><th>Worst</th></tr>
+{{- range .Rows}}
+<tr><td>{{.Type}}</td><td>{{.Start}}</td><td>{{.End}}</td><td class="{{if gt .Change 0}}up{{else if lt .Change 0}}down{{end}}">{{printf "%+d" .Change}}</td><td>{{.Best}}</td><td>{{.Worst}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+`))
+
+// RenderHTML renders digest as html page
+func RenderHTML(digest Digest) (string, error) {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, digest); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// RenderJSON renders digest as json document
+func RenderJSON(digest Digest) ([]byte, error) {
+	return json.Marshal(digest)
+}
@@ -0,0 +1,123 @@
+package reports
+
+import (
+	"Muromachi/logging"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// Default delay between checks of due schedules
+	DefaultInterval = time.Minute
+	// Default count of schedules leased at once
+	DefaultBatchSize = 10
+	// Default max duration of generation of single report
+	DefaultTimeout = time.Minute * 5
+)
+
+// Store of schedules and generated reports
+type Store interface {
+	// Lease locks at most limit enabled schedules which are due at now and moves their
+	// next run. Returned schedules keep time when they were due in NextRunAt
+	Lease(ctx context.Context, now time.Time, limit int) ([]entities.ReportSchedule, error)
+	// Save stores report, report of the same owner and period is stored once
+	Save(ctx context.Context, report entities.Report) error
+}
+
+// Generator composes weekly digests of clients by their schedules. Schedules are
+// leased in store, so several instances can share work
+type Generator struct {
+	Store  Store
+	Tables *tracking.Tables
+	Now    worker.Clock
+	// Delay between checks of due schedules
+	Interval time.Duration
+	// Count of schedules leased at once
+	BatchSize int
+	// Max duration of generation of single report
+	Timeout time.Duration
+}
+
+// Run generates due reports every interval until ctx is done
+func (g *Generator) Run(ctx context.Context) error {
+	if g.Tables == nil {
+		return fmt.Errorf("%s", "reports: tracking tables are not set")
+	}
+	interval := g.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return worker.Run(ctx, "reports", interval, g.batchSize(), g.GenerateDue)
+}
+
+// GenerateDue leases one batch of due schedules and generates their reports.
+// Returns count of leased schedules
+func (g *Generator) GenerateDue(ctx context.Context) (int, error) {
+	schedules, err := g.Store.Lease(ctx, g.Now.UTC(), g.batchSize())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, schedule := range schedules {
+		report, err := g.Generate(ctx, schedule, schedule.NextRunAt)
+		if err != nil {
+			// Schedule is already moved, so report of period is skipped instead of retried forever
+			logging.Warnf("reports: report of client %d failed: %v", schedule.OwnerId, err)
+			continue
+		}
+		if err := g.Store.Save(ctx, report); err != nil {
+			return len(schedules), err
+		}
+	}
+
+	return len(schedules), nil
+}
+
+// Generate composes and renders report of schedule for period which ends at end.
+// Report is pending for webhook if schedule has webhook url
+func (g *Generator) Generate(ctx context.Context, schedule entities.ReportSchedule, end time.Time) (entities.Report, error) {
+	timeout := g.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	now := g.Now.UTC()
+	digest, err := Compose(ctx, g.Tables, schedule.OwnerId, end.Add(-entities.ReportPeriod), end)
+	if err != nil {
+		return entities.Report{}, err
+	}
+	digest.GeneratedAt = now
+
+	report := entities.Report{
+		OwnerId:     schedule.OwnerId,
+		PeriodStart: digest.PeriodStart,
+		PeriodEnd:   digest.PeriodEnd,
+		CreatedAt:   now,
+	}
+	if report.Json, err = RenderJSON(digest); err != nil {
+		return report, err
+	}
+	if report.Html, err = RenderHTML(digest); err != nil {
+		return report, err
+	}
+	if schedule.WebhookUrl != "" {
+		report.Delivery = entities.DeliveryPending
+		report.NextAttemptAt = &now
+	}
+
+	return report, nil
+}
+
+func (g *Generator) batchSize() int {
+	if g.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return g.BatchSize
+}
@@ -0,0 +1,218 @@
+package reports_test
+
+import (
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking"
+	"Muromachi/store/tracking/reports"
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Apps of clients, only Search is used by digest
+type mockAppRepo struct {
+	tracking.AppRepository
+	apps []entities.App
+}
+
+func (m *mockAppRepo) Search(ctx context.Context, query entities.AppQuery) (entities.DboSlice, error) {
+	var result entities.DboSlice
+	for _, app := range m.apps {
+		if app.OwnerId != query.Filter.OwnerId || (query.After != nil && app.Id <= query.After.Id) {
+			continue
+		}
+		if len(result) == query.Limit {
+			break
+		}
+		result = append(result, app)
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return result, nil
+}
+
+// Places of apps, only TimeRange is used by digest
+type mockTrackRepo struct {
+	tracking.TrackRepository
+	tracks []entities.Track
+}
+
+func (m *mockTrackRepo) TimeRange(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
+	var result entities.DboSlice
+	for _, tr := range m.tracks {
+		if tr.BundleId == bundleId && !tr.Date.Before(start) && !tr.Date.After(end) {
+			result = append(result, tr)
+		}
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return result, nil
+}
+
+// Meta of apps, only TimeRange is used by digest
+type mockMetaRepo struct {
+	tracking.MetaRepository
+	metas []entities.Meta
+}
+
+func (m *mockMetaRepo) TimeRange(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
+	var result entities.DboSlice
+	for _, meta := range m.metas {
+		if meta.BundleId == bundleId && !meta.Date.Before(start) && !meta.Date.After(end) {
+			result = append(result, meta)
+		}
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return result, nil
+}
+
+// Store which returns given schedules once and keeps saved reports
+type mockStore struct {
+	schedules []entities.ReportSchedule
+	saved     []entities.Report
+}
+
+func (m *mockStore) Lease(ctx context.Context, now time.Time, limit int) ([]entities.ReportSchedule, error) {
+	leased := m.schedules
+	m.schedules = nil
+	return leased, nil
+}
+
+func (m *mockStore) Save(ctx context.Context, report entities.Report) error {
+	m.saved = append(m.saved, report)
+	return nil
+}
+
+var end = time.Date(2021, 1, 18, 9, 0, 0, 0, time.UTC)
+
+func day(n int) time.Time {
+	return end.AddDate(0, 0, -7+n)
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func int64p(v int64) *int64 {
+	return &v
+}
+
+func newTables() *tracking.Tables {
+	return &tracking.Tables{
+		App: &mockAppRepo{apps: []entities.App{
+			{Id: 1, Bundle: "com.first", Geo: "us", Store: entities.StorePlay, OwnerId: 7},
+			{Id: 2, Bundle: "com.other", Geo: "us", Store: entities.StorePlay, OwnerId: 8},
+			{Id: 3, Bundle: "com.second", Geo: "de", Store: entities.StorePlay, OwnerId: 7},
+		}},
+		Keys: &mockTrackRepo{tracks: []entities.Track{
+			{Id: 1, BundleId: 1, Type: "chat", Date: day(1), Place: 10},
+			{Id: 2, BundleId: 1, Type: "chat", Date: day(3), Place: 3},
+			{Id: 3, BundleId: 1, Type: "chat", Date: day(5), Place: 4},
+			{Id: 4, BundleId: 1, Type: "video", Date: day(1), Place: 20},
+			{Id: 5, BundleId: 1, Type: "video", Date: day(5), Place: 50},
+			// Out of period
+			{Id: 6, BundleId: 1, Type: "chat", Date: day(-3), Place: 100},
+		}},
+		Cat: &mockTrackRepo{tracks: []entities.Track{
+			{Id: 1, BundleId: 3, Type: "social", Date: day(2), Place: 5},
+		}},
+		Meta: &mockMetaRepo{metas: []entities.Meta{
+			{Id: 1, BundleId: 1, Title: "First", Version: "1.0", Date: day(1), RatingValue: float(4.1), ReviewCountValue: int64p(100)},
+			{Id: 2, BundleId: 1, Title: "First <b>", Version: "1.1", Date: day(4), RatingValue: float(4.3), ReviewCountValue: int64p(150)},
+		}},
+	}
+}
+
+func TestCompose_ShouldDescribeAppsOfOwner_Mock(t *testing.T) {
+	digest, err := reports.Compose(context.Background(), newTables(), 7, end.Add(-entities.ReportPeriod), end)
+	assert.NoError(t, err)
+	assert.Len(t, digest.Apps, 2)
+
+	first := digest.Apps[0]
+	assert.Equal(t, "com.first", first.Bundle)
+	assert.Equal(t, []reports.Movement{
+		{Type: "video", Start: 20, End: 50, Change: -30, Best: 20, Worst: 50},
+		{Type: "chat", Start: 10, End: 4, Change: 6, Best: 3, Worst: 10},
+	}, first.Keywords)
+	assert.Empty(t, first.Categories)
+	assert.Equal(t, 4.1, first.Rating.Start)
+	assert.Equal(t, 4.3, first.Rating.End)
+	assert.InDelta(t, 0.2, first.Rating.Change, 1e-9)
+	assert.Equal(t, &reports.Trend{Start: 100, End: 150, Change: 50}, first.Reviews)
+
+	fields := map[entities.MetaField]reports.MetaChange{}
+	for _, change := range first.MetaChanges {
+		fields[change.Field] = change
+	}
+	assert.Equal(t, "First <b>", fields[entities.MetaTitle].New)
+	assert.Equal(t, "1.0", fields[entities.MetaVersion].Old)
+
+	second := digest.Apps[1]
+	assert.Equal(t, "com.second", second.Bundle)
+	assert.Empty(t, second.Keywords)
+	assert.Equal(t, []reports.Movement{{Type: "social", Start: 5, End: 5, Best: 5, Worst: 5}}, second.Categories)
+	assert.Empty(t, second.MetaChanges)
+	assert.Nil(t, second.Rating)
+}
+
+func TestRender_ShouldEscapeHtmlAndKeepJson_Mock(t *testing.T) {
+	digest, err := reports.Compose(context.Background(), newTables(), 7, end.Add(-entities.ReportPeriod), end)
+	assert.NoError(t, err)
+
+	html, err := reports.RenderHTML(digest)
+	assert.NoError(t, err)
+	assert.Contains(t, html, "com.first")
+	assert.Contains(t, html, "First &lt;b&gt;")
+	assert.NotContains(t, html, "First <b>")
+	assert.Contains(t, html, `<td class="up">&#43;6</td>`)
+
+	body, err := reports.RenderJSON(digest)
+	assert.NoError(t, err)
+	var decoded reports.Digest
+	assert.NoError(t, json.Unmarshal(body, &decoded))
+	assert.Equal(t, digest.Apps, decoded.Apps)
+
+	empty, err := reports.RenderHTML(reports.Digest{PeriodStart: end.Add(-entities.ReportPeriod), PeriodEnd: end})
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(empty, "<h2>"))
+}
+
+func TestGenerator_GenerateDue_ShouldSaveReportsOfDueSchedules_Mock(t *testing.T) {
+	now := end.Add(time.Minute)
+	store := &mockStore{schedules: []entities.ReportSchedule{
+		{OwnerId: 7, NextRunAt: end, WebhookUrl: "https://example.com/hook"},
+		{OwnerId: 8, NextRunAt: end},
+	}}
+	g := &reports.Generator{Store: store, Tables: newTables(), Now: func() time.Time { return now }}
+
+	count, err := g.GenerateDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Len(t, store.saved, 2)
+
+	withHook := store.saved[0]
+	assert.Equal(t, 7, withHook.OwnerId)
+	assert.Equal(t, end.Add(-entities.ReportPeriod), withHook.PeriodStart)
+	assert.Equal(t, end, withHook.PeriodEnd)
+	assert.Equal(t, entities.DeliveryPending, withHook.Delivery)
+	assert.Equal(t, now, *withHook.NextAttemptAt)
+	assert.Contains(t, withHook.Html, "com.second")
+	assert.True(t, json.Valid(withHook.Json))
+
+	withoutHook := store.saved[1]
+	assert.Equal(t, 8, withoutHook.OwnerId)
+	assert.Equal(t, entities.DeliveryStatus(""), withoutHook.Delivery)
+	assert.Nil(t, withoutHook.NextAttemptAt)
+
+	count, err = g.GenerateDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,148 @@
+package reports
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/alerts"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// Store of schedules in report_schedules and reports in reports tables. It is also
+// alerts.DeliveryStore of report webhooks, so webhooks are sent by alerts.Deliverer
+type PgStore struct {
+	DB connector.DB
+}
+
+// Lease selects due schedules with FOR UPDATE SKIP LOCKED, so schedules locked by
+// other instance are skipped, and moves next run of selected schedules in the same transaction
+func (p *PgStore) Lease(ctx context.Context, now time.Time, limit int) ([]entities.ReportSchedule, error) {
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		schedules []entities.ReportSchedule
+		s         entities.ReportSchedule
+		weekday   int
+	)
+	_, err = tx.QueryFunc(
+		ctx,
+		"select ownerId, weekday, hour, webhookUrl, secret, enabled, nextRunAt, createdAt from report_schedules"+
+			" where enabled and nextRunAt <= $1 order by nextRunAt, ownerId limit $2 for update skip locked",
+		[]interface{}{now, limit},
+		[]interface{}{&s.OwnerId, &weekday, &s.Hour, &s.WebhookUrl, &s.Secret, &s.Enabled, &s.NextRunAt, &s.CreatedAt},
+		func(pgx.QueryFuncRow) error {
+			s.Weekday = time.Weekday(weekday)
+			schedules = append(schedules, s)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, schedule := range schedules {
+		if _, err := tx.Exec(ctx, "update report_schedules set nextRunAt = $2 where ownerId = $1", schedule.OwnerId, schedule.NextRun(now)); err != nil {
+			return nil, err
+		}
+	}
+
+	return schedules, tx.Commit(ctx)
+}
+
+// Save inserts report, report of the same owner and period is not inserted twice
+func (p *PgStore) Save(ctx context.Context, r entities.Report) error {
+	var delivery *entities.DeliveryStatus
+	if r.Delivery != "" {
+		delivery = &r.Delivery
+	}
+	_, err := p.DB.Exec(
+		ctx,
+		"insert into reports (ownerId, periodStart, periodEnd, json, html, createdAt, delivery, nextAttemptAt)"+
+			" values ($1, $2, $3, $4, $5, $6, $7, $8) on conflict (ownerId, periodEnd) do nothing",
+		r.OwnerId, r.PeriodStart, r.PeriodEnd, r.Json, r.Html, r.CreatedAt, delivery, r.NextAttemptAt,
+	)
+
+	return err
+}
+
+// LeaseDeliveries selects due report webhooks of enabled schedules with FOR UPDATE SKIP LOCKED and postpones
+// them in the same transaction. Json body of report is payload of webhook
+func (p *PgStore) LeaseDeliveries(ctx context.Context, now, until time.Time, limit int) ([]alerts.Delivery, error) {
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		deliveries []alerts.Delivery
+		d          alerts.Delivery
+	)
+	_, err = tx.QueryFunc(
+		ctx,
+		"select R.id, R.json, R.delivery, R.attempts, coalesce(R.responseStatus, 0), R.lastError, R.createdAt, R.nextAttemptAt, S.webhookUrl, S.secret"+
+			" from reports R inner join report_schedules S on R.ownerId = S.ownerId"+
+			" where R.delivery = $1 and R.nextAttemptAt <= $2 and S.enabled and S.webhookUrl <> ''"+
+			" order by R.nextAttemptAt, R.id limit $3 for update of R skip locked",
+		[]interface{}{entities.DeliveryPending, now, limit},
+		[]interface{}{
+			&d.Id, &d.Payload, &d.Status, &d.Attempts, &d.ResponseStatus, &d.LastError,
+			&d.CreatedAt, &d.NextAttemptAt, &d.WebhookUrl, &d.Secret,
+		},
+		func(pgx.QueryFuncRow) error {
+			deliveries = append(deliveries, d)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(deliveries))
+	for i, delivery := range deliveries {
+		ids[i] = delivery.Id
+	}
+	if _, err := tx.Exec(ctx, "update reports set nextAttemptAt = $2 where id = any($1)", ids, until); err != nil {
+		return nil, err
+	}
+
+	return deliveries, tx.Commit(ctx)
+}
+
+// FinishDelivery saves result of attempt of report webhook
+func (p *PgStore) FinishDelivery(ctx context.Context, d entities.AlertDelivery) error {
+	var status *int
+	if d.ResponseStatus != 0 {
+		status = &d.ResponseStatus
+	}
+	_, err := p.DB.Exec(
+		ctx,
+		"update reports set delivery = $2, attempts = $3, responseStatus = $4, lastError = $5, nextAttemptAt = $6, deliveredAt = $7 where id = $1",
+		d.Id, d.Status, d.Attempts, status, d.LastError, d.NextAttemptAt, d.DeliveredAt,
+	)
+
+	return err
+}
+
+// Deliveries returns store of report webhooks for alerts.Deliverer
+func (p *PgStore) Deliveries() alerts.DeliveryStore {
+	return deliveryStore{p}
+}
+
+// Adapter of report webhooks to alerts.DeliveryStore
+type deliveryStore struct {
+	store *PgStore
+}
+
+func (d deliveryStore) Lease(ctx context.Context, now, until time.Time, limit int) ([]alerts.Delivery, error) {
+	return d.store.LeaseDeliveries(ctx, now, until, limit)
+}
+
+func (d deliveryStore) Finish(ctx context.Context, delivery entities.AlertDelivery) error {
+	return d.store.FinishDelivery(ctx, delivery)
+}
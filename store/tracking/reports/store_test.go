@@ -0,0 +1,67 @@
+package reports_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking/reports"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPgStore_ShouldLeaseDueSchedulesAndDeliveriesOnce(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("users", "report_schedules", "reports")
+	store := reports.PgStore{DB: conn}
+	ctx := context.Background()
+
+	var ownerId int
+	assert.NoError(t, conn.QueryRow(ctx, "insert into users (clientId, clientSecret, company) values ('id', 'secret', 'company') returning id").Scan(&ownerId))
+	due := time.Date(2021, 1, 18, 9, 0, 0, 0, time.UTC)
+	_, err := conn.Exec(
+		ctx,
+		"insert into report_schedules (ownerId, weekday, hour, webhookUrl, secret, enabled, nextRunAt) values ($1, 1, 9, 'https://example.com/hook', 'secret', true, $2)",
+		ownerId, due,
+	)
+	assert.NoError(t, err)
+
+	now := due.Add(time.Minute)
+	schedules, err := store.Lease(ctx, now, 10)
+	assert.NoError(t, err)
+	assert.Len(t, schedules, 1)
+	assert.Equal(t, due, schedules[0].NextRunAt.UTC())
+	// Next run is moved to the next week
+	schedules, err = store.Lease(ctx, now, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, schedules)
+
+	report := entities.Report{
+		OwnerId: ownerId, PeriodStart: due.Add(-entities.ReportPeriod), PeriodEnd: due, Json: []byte(`{"ownerId":1}`),
+		CreatedAt: now, Delivery: entities.DeliveryPending, NextAttemptAt: &now,
+	}
+	assert.NoError(t, store.Save(ctx, report))
+	// Report of the same period is saved once
+	assert.NoError(t, store.Save(ctx, report))
+
+	deliveries := store.Deliveries()
+	leased, err := deliveries.Lease(ctx, now, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, leased, 1)
+	assert.Equal(t, "https://example.com/hook", leased[0].WebhookUrl)
+	assert.JSONEq(t, `{"ownerId":1}`, string(leased[0].Payload))
+
+	again, err := deliveries.Lease(ctx, now, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, again)
+
+	result := leased[0].AlertDelivery
+	result.Status, result.Attempts, result.ResponseStatus, result.DeliveredAt = entities.DeliveryDelivered, 1, 200, &now
+	assert.NoError(t, deliveries.Finish(ctx, result))
+
+	var status string
+	assert.NoError(t, conn.QueryRow(ctx, "select delivery from reports where id = $1", result.Id).Scan(&status))
+	assert.Equal(t, string(entities.DeliveryDelivered), status)
+}
@@ -0,0 +1,97 @@
+package reportstore
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/entities"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+const (
+	// Columns of report_schedules in order of scanning
+	scheduleColumns = "ownerId, weekday, hour, webhookUrl, secret, enabled, nextRunAt, createdAt"
+	// Columns of reports without bodies in order of scanning
+	reportColumns = "id, ownerId, periodStart, periodEnd, createdAt, coalesce(delivery, ''), attempts," +
+		" coalesce(responseStatus, 0), lastError, nextAttemptAt, deliveredAt"
+)
+
+// Repository of digest schedules and generated reports
+type Repo struct {
+	Conn connector.Conn
+}
+
+// Get schedule of owner
+func (r *Repo) Schedule(ctx context.Context, ownerId int) (entities.ReportSchedule, error) {
+	return r.schedule(ctx, "select "+scheduleColumns+" from report_schedules where ownerId = $1", ownerId)
+}
+
+// Create schedule of owner or change it. Secret of existing schedule is not changed
+func (r *Repo) SaveSchedule(ctx context.Context, s entities.ReportSchedule) (entities.ReportSchedule, error) {
+	return r.schedule(
+		ctx,
+		"insert into report_schedules (ownerId, weekday, hour, webhookUrl, secret, enabled, nextRunAt) values ($1, $2, $3, $4, $5, $6, $7)"+
+			" on conflict (ownerId) do update set weekday = excluded.weekday, hour = excluded.hour, webhookUrl = excluded.webhookUrl,"+
+			" enabled = excluded.enabled, nextRunAt = excluded.nextRunAt returning "+scheduleColumns,
+		s.OwnerId, int(s.Weekday), s.Hour, s.WebhookUrl, s.Secret, s.Enabled, s.NextRunAt,
+	)
+}
+
+// Get last reports of owner without bodies, newest first
+func (r *Repo) Reports(ctx context.Context, ownerId, limit int) (entities.DboSlice, error) {
+	var (
+		report  entities.Report
+		reports []entities.DBO
+	)
+	_, err := r.Conn.QueryFunc(
+		ctx,
+		"select "+reportColumns+" from reports where ownerId = $1 order by periodEnd desc, id desc limit $2",
+		[]interface{}{ownerId, limit},
+		[]interface{}{
+			&report.Id, &report.OwnerId, &report.PeriodStart, &report.PeriodEnd, &report.CreatedAt, &report.Delivery,
+			&report.Attempts, &report.ResponseStatus, &report.LastError, &report.NextAttemptAt, &report.DeliveredAt,
+		},
+		func(pgx.QueryFuncRow) error {
+			reports = append(reports, report)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return reports, nil
+}
+
+// Get report with bodies by id
+func (r *Repo) ReportById(ctx context.Context, id int64) (entities.Report, error) {
+	var report entities.Report
+	err := r.Conn.QueryRow(
+		ctx,
+		"select "+reportColumns+", json, html from reports where id = $1",
+		id,
+	).Scan(
+		&report.Id, &report.OwnerId, &report.PeriodStart, &report.PeriodEnd, &report.CreatedAt, &report.Delivery,
+		&report.Attempts, &report.ResponseStatus, &report.LastError, &report.NextAttemptAt, &report.DeliveredAt,
+		&report.Json, &report.Html,
+	)
+
+	return report, err
+}
+
+// schedule returns schedule selected by query
+func (r *Repo) schedule(ctx context.Context, sql string, params ...interface{}) (entities.ReportSchedule, error) {
+	var (
+		s       entities.ReportSchedule
+		weekday int
+	)
+	err := r.Conn.QueryRow(ctx, sql, params...).Scan(
+		&s.OwnerId, &weekday, &s.Hour, &s.WebhookUrl, &s.Secret, &s.Enabled, &s.NextRunAt, &s.CreatedAt,
+	)
+	s.Weekday = time.Weekday(weekday)
+
+	return s, err
+}
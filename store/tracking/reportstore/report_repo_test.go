@@ -0,0 +1,72 @@
+package reportstore_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking/reports"
+	"Muromachi/store/tracking/reportstore"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReportRepo_ShouldSaveScheduleAndListReports(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("users", "report_schedules", "reports")
+	repo := reportstore.Repo{Conn: conn}
+	store := reports.PgStore{DB: conn}
+	ctx := context.Background()
+
+	var ownerId int
+	assert.NoError(t, conn.QueryRow(ctx, "insert into users (clientId, clientSecret, company) values ('id', 'secret', 'company') returning id").Scan(&ownerId))
+
+	_, err := repo.Schedule(ctx, ownerId)
+	assert.Equal(t, pgx.ErrNoRows, err)
+
+	next := time.Date(2021, 1, 18, 9, 0, 0, 0, time.UTC)
+	schedule, err := repo.SaveSchedule(ctx, entities.ReportSchedule{
+		OwnerId: ownerId, Weekday: time.Monday, Hour: 9, WebhookUrl: "https://example.com/hook",
+		Secret: "first", Enabled: true, NextRunAt: next,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Monday, schedule.Weekday)
+	assert.Equal(t, "first", schedule.Secret)
+
+	// Secret of existing schedule is kept
+	schedule, err = repo.SaveSchedule(ctx, entities.ReportSchedule{
+		OwnerId: ownerId, Weekday: time.Friday, Hour: 18, Secret: "second", Enabled: true, NextRunAt: next,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Friday, schedule.Weekday)
+	assert.Equal(t, "", schedule.WebhookUrl)
+	assert.Equal(t, "first", schedule.Secret)
+
+	_, err = repo.Reports(ctx, ownerId, 10)
+	assert.Equal(t, pgx.ErrNoRows, err)
+
+	for i := 0; i < 2; i++ {
+		end := next.AddDate(0, 0, 7*i)
+		assert.NoError(t, store.Save(ctx, entities.Report{
+			OwnerId: ownerId, PeriodStart: end.Add(-entities.ReportPeriod), PeriodEnd: end,
+			Json: []byte(`{"ownerId":1}`), Html: "<h1>Weekly digest</h1>", CreatedAt: end,
+		}))
+	}
+
+	dbo, err := repo.Reports(ctx, ownerId, 10)
+	assert.NoError(t, err)
+	assert.Len(t, dbo, 2)
+	var last entities.Report
+	assert.NoError(t, dbo[0].To(&last))
+	assert.Equal(t, next.AddDate(0, 0, 7), last.PeriodEnd)
+	assert.Nil(t, last.Json)
+	assert.Equal(t, entities.DeliveryStatus(""), last.Delivery)
+
+	report, err := repo.ReportById(ctx, last.Id)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"ownerId":1}`, string(report.Json))
+	assert.Equal(t, "<h1>Weekly digest</h1>", report.Html)
+}
@@ -3,8 +3,11 @@ package tracking
 import (
 	"Muromachi/store/connector"
 	"Muromachi/store/entities"
+	"Muromachi/store/tracking/alertstore"
+	"Muromachi/store/tracking/anomalystore"
 	"Muromachi/store/tracking/appstore"
 	"Muromachi/store/tracking/metastore"
+	"Muromachi/store/tracking/reportstore"
 	"Muromachi/store/tracking/trackstore"
 	"context"
 	"time"
@@ -17,10 +20,102 @@ type Repository interface {
 	ByBundleId(ctx context.Context, bundleId int) (entities.DboSlice, error)
 	// Get entities.DboSlice by bundle id and time range from start to end
 	TimeRange(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error)
+	// Get entities.DboSlice of several bundle ids within time range from start to end,
+	// zero start or end means unbounded range
+	ByBundleIds(ctx context.Context, bundleIds []int, start, end time.Time) (entities.DboSlice, error)
+	// Get page of entities.DboSlice ordered by date and id
+	Page(ctx context.Context, bundleId int, page entities.Page) (entities.DboSlice, error)
+	// Count rows of bundle id within time range from start to end,
+	// zero start or end means unbounded range
+	Count(ctx context.Context, bundleId int, start, end time.Time) (int, error)
 	// Get last updates of DBO
 	LastUpdates(ctx context.Context, bundleId, count int) (entities.DboSlice, error)
 }
 
+// Repository of tracked apps
+type AppRepository interface {
+	Repository
+	// Get app by id
+	ById(ctx context.Context, id int) (entities.App, error)
+	// Get app by store, bundle and geo
+	ByBundle(ctx context.Context, store entities.AppStore, bundle, geo string) (entities.App, error)
+	// Get page of apps matched by filter in given order
+	Search(ctx context.Context, query entities.AppQuery) (entities.DboSlice, error)
+	// Count apps matched by filter
+	CountApps(ctx context.Context, filter entities.AppFilter) (int, error)
+	// Insert new active app
+	Create(ctx context.Context, app entities.App) (entities.App, error)
+	// Update category, start and period of app tracking
+	Update(ctx context.Context, app entities.App) (entities.App, error)
+	// Change status of app tracking
+	SetStatus(ctx context.Context, id int, status entities.AppStatus) (entities.App, error)
+}
+
+// Repository which streams rows one by one instead of collecting them
+type Streamer interface {
+	// Call f for every row of bundle id within time range from start to end ordered by date and id,
+	// zero start or end means unbounded range. Positive last selects last rows newest first
+	Stream(ctx context.Context, bundleId, last int, start, end time.Time, f func(entities.DBO) error) error
+}
+
+// Repository of meta snapshots
+type MetaRepository interface {
+	Repository
+	Streamer
+}
+
+// Repository of category or keyword places
+type TrackRepository interface {
+	Repository
+	Streamer
+	// Get entities.RankStats of places by time buckets, empty typ means all types
+	RankStats(ctx context.Context, bundleId int, typ string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error)
+	// Get entities.RankStats of places of given bundles by time buckets
+	RankStatsByBundleIds(ctx context.Context, bundleIds []int, typ string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error)
+	// Get places of given bundles by resolution, auto resolution is picked by width of range
+	ByResolution(ctx context.Context, bundleIds []int, resolution entities.Resolution, start, end time.Time) (entities.DboSlice, error)
+}
+
+// Repository of alert rules of clients and their delivery log
+type AlertRepository interface {
+	// Get rules of owner, zero appId means rules of all apps
+	Rules(ctx context.Context, ownerId, appId int) (entities.DboSlice, error)
+	// Get rule by id
+	RuleById(ctx context.Context, id int) (entities.AlertRule, error)
+	// Insert new enabled rule
+	CreateRule(ctx context.Context, rule entities.AlertRule) (entities.AlertRule, error)
+	// Enable or disable rule
+	SetRuleEnabled(ctx context.Context, id int, enabled bool) (entities.AlertRule, error)
+	// Delete rule with its deliveries
+	DeleteRule(ctx context.Context, id int) error
+	// Get last deliveries of rule, newest first
+	Deliveries(ctx context.Context, ruleId, limit int) (entities.DboSlice, error)
+}
+
+// Repository of detected anomalies
+type AnomalyRepository interface {
+	// Get anomalies of app within time range from start to end, zero start or end means unbounded range
+	Anomalies(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error)
+	// Save new anomalies, already saved anomalies keep review status
+	Save(ctx context.Context, anomalies []entities.Anomaly) error
+	// Get anomaly by id
+	ById(ctx context.Context, id int) (entities.Anomaly, error)
+	// Change review status of anomaly
+	SetStatus(ctx context.Context, id int, status entities.AnomalyStatus) (entities.Anomaly, error)
+}
+
+// Repository of digest schedules and generated reports
+type ReportRepository interface {
+	// Get schedule of owner
+	Schedule(ctx context.Context, ownerId int) (entities.ReportSchedule, error)
+	// Create or change schedule of owner, secret of existing schedule is kept
+	SaveSchedule(ctx context.Context, schedule entities.ReportSchedule) (entities.ReportSchedule, error)
+	// Get last reports of owner without bodies, newest first
+	Reports(ctx context.Context, ownerId, limit int) (entities.DboSlice, error)
+	// Get report with bodies by id
+	ReportById(ctx context.Context, id int64) (entities.Report, error)
+}
+
 func NewCatRepo(conn connector.Conn) *trackstore.CatRepo {
 	return &trackstore.CatRepo{
 		Conn: conn,
@@ -44,4 +139,22 @@ func NewAppRepo(conn connector.Conn) *appstore.Repo {
 	return &appstore.Repo{
 		Conn: conn,
 	}
-}
\ No newline at end of file
+}
+
+func NewAlertRepo(conn connector.Conn) *alertstore.Repo {
+	return &alertstore.Repo{
+		Conn: conn,
+	}
+}
+
+func NewAnomalyRepo(conn connector.Conn) *anomalystore.Repo {
+	return &anomalystore.Repo{
+		Conn: conn,
+	}
+}
+
+func NewReportRepo(conn connector.Conn) *reportstore.Repo {
+	return &reportstore.Repo{
+		Conn: conn,
+	}
+}
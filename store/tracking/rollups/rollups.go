@@ -0,0 +1,146 @@
+package rollups
+
+import (
+	"Muromachi/logging"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Default delay between rollup runs
+	DefaultInterval = time.Minute
+	// Default count of tracking rows which are rolled up at once
+	DefaultBatchSize = 10000
+	// Default count of rows behind watermark which buckets are aggregated again
+	DefaultLag = 1000
+)
+
+// Tracking table and table of its aggregates
+type Source struct {
+	Table   string
+	Rollups string
+}
+
+// Watermark returns name of watermark of source
+func (s Source) Watermark() string {
+	return worker.Source("rollups", s.Table)
+}
+
+// Tracking tables which are rolled up
+var Sources = []Source{
+	{Table: "category_tracking", Rollups: "category_rollups"},
+	{Table: "keyword_tracking", Rollups: "keyword_rollups"},
+}
+
+// Resolutions of aggregates which are kept in rollups tables
+var Resolutions = []entities.Resolution{entities.ResolutionDay, entities.ResolutionWeek}
+
+// Store of aggregates and watermarks of sources
+type Store interface {
+	// Advance aggregates buckets which have at most limit rows of source after its watermark
+	// or lag rows behind it and moves watermark past these rows in one transaction. Returns
+	// count of rolled up rows after watermark, zero when source is rolled up already or it is
+	// rolled up by other instance
+	Advance(ctx context.Context, source Source, limit, lag int) (int, error)
+}
+
+// Job maintains daily and weekly aggregates of places per bundle and type. Tracking rows
+// are rolled up incrementally by id, watermark of every source is kept in store, so the job
+// resumes where it stopped and several instances can share work
+type Job struct {
+	Store Store
+	// Sources which are rolled up, Sources by default
+	Sources []Source
+	// Delay between rollup runs
+	Interval time.Duration
+	// Count of tracking rows which are rolled up at once
+	BatchSize int
+	// Count of rows behind watermark which buckets are aggregated again
+	Lag int
+}
+
+// Run rolls up new tracking rows at start and then every interval until ctx is done
+func (j *Job) Run(ctx context.Context) error {
+	interval := j.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := j.Rollup(ctx); err != nil && ctx.Err() == nil {
+			logging.Errorf("rollups: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rollup rolls up every source by batches until it catches up with tracking table and
+// returns count of rolled up rows. Errors of one source do not stop rollup of others
+func (j *Job) Rollup(ctx context.Context) (int, error) {
+	var (
+		total    int
+		problems []string
+	)
+	for _, source := range j.sources() {
+		count, err := j.rollup(ctx, source)
+		total += count
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", source.Table, err))
+		}
+	}
+	if len(problems) > 0 {
+		return total, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	return total, nil
+}
+
+// rollup advances watermark of source while batches are full
+func (j *Job) rollup(ctx context.Context, source Source) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		count, err := j.Store.Advance(ctx, source, j.batchSize(), j.lag())
+		total += count
+		if err != nil {
+			return total, err
+		}
+		// Not full batch means that source is caught up
+		if count < j.batchSize() {
+			break
+		}
+	}
+
+	return total, ctx.Err()
+}
+
+func (j *Job) sources() []Source {
+	if len(j.Sources) == 0 {
+		return Sources
+	}
+	return j.Sources
+}
+
+func (j *Job) batchSize() int {
+	if j.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return j.BatchSize
+}
+
+func (j *Job) lag() int {
+	if j.Lag <= 0 {
+		return DefaultLag
+	}
+	return j.Lag
+}
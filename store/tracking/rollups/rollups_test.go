@@ -0,0 +1,80 @@
+package rollups_test
+
+import (
+	"Muromachi/store/tracking/rollups"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Store which keeps count of pending rows of every source in memory
+type mockStore struct {
+	pending map[string]int
+	// Batches of every source
+	batches map[string]int
+	failed  string
+}
+
+func (m *mockStore) Advance(ctx context.Context, source rollups.Source, limit, lag int) (int, error) {
+	if source.Table == m.failed {
+		return 0, errors.New("deadlock detected")
+	}
+	m.batches[source.Table]++
+	count := m.pending[source.Table]
+	if count > limit {
+		count = limit
+	}
+	m.pending[source.Table] -= count
+	return count, nil
+}
+
+func TestJob_RollupShouldAdvanceUntilSourceIsCaughtUp_Mock(t *testing.T) {
+	store := &mockStore{
+		pending: map[string]int{"category_tracking": 25, "keyword_tracking": 20},
+		batches: map[string]int{},
+	}
+	job := rollups.Job{Store: store, BatchSize: 10}
+
+	count, err := job.Rollup(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 45, count)
+	assert.Equal(t, 0, store.pending["category_tracking"])
+	assert.Equal(t, 0, store.pending["keyword_tracking"])
+	assert.Equal(t, 3, store.batches["category_tracking"])
+	// The last empty batch shows that source is caught up
+	assert.Equal(t, 3, store.batches["keyword_tracking"])
+
+	count, err = job.Rollup(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestJob_RollupShouldContinueAfterErrorOfSource_Mock(t *testing.T) {
+	store := &mockStore{
+		pending: map[string]int{"keyword_tracking": 5},
+		batches: map[string]int{},
+		failed:  "category_tracking",
+	}
+	job := rollups.Job{Store: store}
+
+	count, err := job.Rollup(context.Background())
+	assert.EqualError(t, err, "category_tracking: deadlock detected")
+	assert.Equal(t, 5, count)
+	assert.Equal(t, 0, store.pending["keyword_tracking"])
+}
+
+func TestJob_RollupShouldStopWhenContextIsDone_Mock(t *testing.T) {
+	store := &mockStore{
+		pending: map[string]int{"keyword_tracking": 100},
+		batches: map[string]int{},
+	}
+	job := rollups.Job{Store: store, Sources: []rollups.Source{rollups.Sources[1]}, BatchSize: 10}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	count, err := job.Rollup(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 100, store.pending["keyword_tracking"])
+}
@@ -0,0 +1,67 @@
+package rollups
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+)
+
+// Store of aggregates in rollups tables and watermarks of sources in watermarks table
+type PgStore struct {
+	DB connector.DB
+}
+
+// Advance locks watermark of source, so source which is rolled up by other instance is
+// skipped. Buckets touched by new rows and lag rows behind watermark are aggregated again
+// from all their rows, so aggregates stay exact
+func (p *PgStore) Advance(ctx context.Context, source Source, limit, lag int) (int, error) {
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	watermarks := worker.Watermarks{DB: tx}
+	lastId, ok, err := watermarks.Lock(ctx, source.Watermark())
+	if err != nil || !ok {
+		return 0, err
+	}
+	batch, err := worker.Next(ctx, tx, source.Table, lastId, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	lowerId := worker.Behind(lastId, lag)
+	if batch.LastId > lowerId {
+		for _, resolution := range Resolutions {
+			if _, err := tx.Exec(ctx, upsertSql(source), lowerId, batch.LastId, string(resolution)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	// Watermark is ready when source is caught up, so readers do not see partial history
+	if err := watermarks.Advance(ctx, source.Watermark(), batch.LastId, batch.Count < limit); err != nil {
+		return 0, err
+	}
+
+	return batch.Count, tx.Commit(ctx)
+}
+
+// upsertSql aggregates buckets touched by rows of source with ids from $1 exclusive to $2 inclusive
+func upsertSql(source Source) string {
+	return fmt.Sprintf(
+		"insert into %[2]s (resolution, bundleId, type, bucket, min, max, avg, median, first, last, count)"+
+			" select $3::text, T.bundleId, T.type, B.bucket, min(T.place), max(T.place), avg(T.place)::float8,"+
+			" percentile_cont(0.5) within group (order by T.place)::float8,"+
+			" (array_agg(T.place order by T.date, T.id))[1], (array_agg(T.place order by T.date desc, T.id desc))[1], count(*)"+
+			" from (select distinct bundleId, type, date_trunc($3::text, date) as bucket from %[1]s where id > $1 and id <= $2 and bundleId is not null) B"+
+			" inner join %[1]s T on T.bundleId = B.bundleId and T.type = B.type"+
+			" and T.date >= B.bucket and T.date < B.bucket + ('1 ' || $3::text)::interval"+
+			" group by T.bundleId, T.type, B.bucket"+
+			" on conflict (resolution, bundleId, type, bucket) do update set min = excluded.min, max = excluded.max,"+
+			" avg = excluded.avg, median = excluded.median, first = excluded.first, last = excluded.last, count = excluded.count",
+		pgx.Identifier{source.Table}.Sanitize(), pgx.Identifier{source.Rollups}.Sanitize(),
+	)
+}
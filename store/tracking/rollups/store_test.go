@@ -0,0 +1,105 @@
+package rollups_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking/rollups"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPgStore_ShouldRollupRowsIncrementally(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "keyword_tracking", "keyword_rollups", "watermarks")
+	cleaner("watermarks")
+	store := rollups.PgStore{DB: conn}
+	source := rollups.Sources[1]
+	ctx := context.Background()
+
+	appId, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "com.muromachi.bank", Geo: "en_us"})
+	assert.NoError(t, err)
+	// Monday and Tuesday of the same week
+	monday := time.Date(2021, 1, 18, 0, 0, 0, 0, time.UTC)
+	for i, place := range []int32{10, 4, 7} {
+		track := testhelpers.TrackStruct(appId, "bank")
+		track.Date = monday.Add(time.Hour * time.Duration(12*i))
+		track.Place = place
+		_, err = testhelpers.AddNewTrack(conn, ctx, track, source.Table)
+		assert.NoError(t, err)
+	}
+
+	// Full batch is not ready until source is caught up
+	count, err := store.Advance(ctx, source, 2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	var ready bool
+	assert.NoError(t, conn.QueryRow(ctx, "select ready from watermarks where source = $1", source.Watermark()).Scan(&ready))
+	assert.False(t, ready)
+
+	count, err = store.Advance(ctx, source, 2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, conn.QueryRow(ctx, "select ready from watermarks where source = $1", source.Watermark()).Scan(&ready))
+	assert.True(t, ready)
+
+	var (
+		min, max, first, last, rows int32
+		days                        int
+	)
+	assert.NoError(t, conn.QueryRow(
+		ctx,
+		"select min, max, first, last, count from keyword_rollups where resolution = 'week' and bundleId = $1 and bucket = $2",
+		appId, monday,
+	).Scan(&min, &max, &first, &last, &rows))
+	assert.Equal(t, []int32{4, 10, 10, 7, 3}, []int32{min, max, first, last, rows})
+	assert.NoError(t, conn.QueryRow(ctx, "select count(*) from keyword_rollups where resolution = 'day' and bundleId = $1", appId).Scan(&days))
+	assert.Equal(t, 2, days)
+
+	// New row updates aggregate of its bucket from all rows of bucket
+	track := testhelpers.TrackStruct(appId, "bank")
+	track.Date = monday.AddDate(0, 0, 3)
+	track.Place = 1
+	_, err = testhelpers.AddNewTrack(conn, ctx, track, source.Table)
+	assert.NoError(t, err)
+
+	count, err = store.Advance(ctx, source, 2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, conn.QueryRow(
+		ctx,
+		"select min, max, first, last, count from keyword_rollups where resolution = 'week' and bundleId = $1 and bucket = $2",
+		appId, monday,
+	).Scan(&min, &max, &first, &last, &rows))
+	assert.Equal(t, []int32{1, 10, 10, 1, 4}, []int32{min, max, first, last, rows})
+
+	count, err = store.Advance(ctx, source, 2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	// Row which is committed below watermark is rolled up within lag
+	var lateId int64
+	assert.NoError(t, conn.QueryRow(ctx, "select nextval(pg_get_serial_sequence('keyword_tracking', 'id'))").Scan(&lateId))
+	track.Date = monday.AddDate(0, 0, 4)
+	track.Place = 2
+	_, err = testhelpers.AddNewTrack(conn, ctx, track, source.Table)
+	assert.NoError(t, err)
+	count, err = store.Advance(ctx, source, 2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	_, err = conn.Exec(ctx, "insert into keyword_tracking (id, bundleId, type, place, date) values ($1, $2, 'bank', 20, $3)", lateId, appId, monday.AddDate(0, 0, 5))
+	assert.NoError(t, err)
+
+	count, err = store.Advance(ctx, source, 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, conn.QueryRow(
+		ctx,
+		"select min, max, first, last, count from keyword_rollups where resolution = 'week' and bundleId = $1 and bucket = $2",
+		appId, monday,
+	).Scan(&min, &max, &first, &last, &rows))
+	assert.Equal(t, []int32{1, 20, 10, 20, 6}, []int32{min, max, first, last, rows})
+}
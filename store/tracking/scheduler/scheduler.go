@@ -0,0 +1,166 @@
+package scheduler
+
+import (
+	"Muromachi/logging"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// Default delay between checks of due apps
+	DefaultInterval = time.Minute
+	// Default count of apps leased at once
+	DefaultBatchSize = 10
+	// Default max duration of single collection
+	DefaultTimeout = time.Minute * 10
+)
+
+// Collector collects meta and places of tracked app
+type Collector interface {
+	Collect(ctx context.Context, app entities.App) error
+}
+
+// Func which satisfies Collector
+type CollectorFunc func(ctx context.Context, app entities.App) error
+
+func (f CollectorFunc) Collect(ctx context.Context, app entities.App) error {
+	return f(ctx, app)
+}
+
+// Leased collection of app
+type Job struct {
+	// Id of run in history
+	RunId int64
+	App   entities.App
+	// Time when app was due
+	ScheduledAt time.Time
+	// Time when collection of app began
+	StartedAt time.Time
+}
+
+// Store of schedule and run history
+type Store interface {
+	// Lease locks at most limit apps which are due at now and moves their next run
+	// to the next period
+	Lease(ctx context.Context, now time.Time, limit int) ([]Job, error)
+	// Start records started run of job and returns id of run
+	Start(ctx context.Context, job Job) (int64, error)
+	// Finish records end of run, nil err means successful run
+	Finish(ctx context.Context, job Job, finishedAt time.Time, err error) error
+	// Expire finishes runs which were started before given time and are not finished yet,
+	// like runs of crashed instances. Returns count of expired runs
+	Expire(ctx context.Context, startedBefore, now time.Time) (int, error)
+}
+
+// Scheduler runs collector for every active app once per period starting
+// from App.StartAt. Apps are leased in store, so several instances can share work
+type Scheduler struct {
+	Store     Store
+	Collector Collector
+	Now       worker.Clock
+	// Delay between checks of due apps
+	Interval time.Duration
+	// Count of apps leased at once
+	BatchSize int
+	// Max duration of single collection
+	Timeout time.Duration
+}
+
+// NextRun returns the first run of schedule from start with given period which is
+// after now. Missed runs are skipped, so app is collected once after downtime
+func NextRun(start time.Time, period time.Duration, now time.Time) time.Time {
+	if period <= 0 || now.Before(start) {
+		return start
+	}
+
+	return start.Add((now.Sub(start)/period + 1) * period)
+}
+
+// Period returns schedule period of app, App.Period is measured in days
+func Period(app entities.App) time.Duration {
+	return time.Duration(app.Period) * time.Hour * 24
+}
+
+// Run checks due apps every interval until ctx is done
+func (s *Scheduler) Run(ctx context.Context) error {
+	if s.Collector == nil {
+		return fmt.Errorf("%s", "scheduler: collector is not set")
+	}
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return worker.Run(ctx, "scheduler", interval, s.batchSize(), func(ctx context.Context) (int, error) {
+		// Runs of crashed instances are expired before every batch, so they are not shown as running
+		if _, err := s.Expire(ctx); err != nil && ctx.Err() == nil {
+			logging.Errorf("scheduler: %v", err)
+		}
+		return s.RunDue(ctx)
+	})
+}
+
+// RunDue leases one batch of due apps and collects them one by one.
+// Returns count of leased apps
+func (s *Scheduler) RunDue(ctx context.Context) (int, error) {
+	jobs, err := s.Store.Lease(ctx, s.Now.UTC(), s.batchSize())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, job := range jobs {
+		// Jobs of batch run one by one, so start is recorded right before collection
+		job.StartedAt = s.Now.UTC()
+		if job.RunId, err = s.Store.Start(ctx, job); err != nil {
+			return len(jobs), err
+		}
+		collectErr := s.collect(ctx, job)
+		if collectErr != nil {
+			logging.Warnf("scheduler: collection of app %d failed: %v", job.App.Id, collectErr)
+		}
+		// Run is finished even if ctx is done, otherwise it stays started forever
+		if err := s.Store.Finish(context.Background(), job, s.Now.UTC(), collectErr); err != nil {
+			return len(jobs), err
+		}
+	}
+
+	return len(jobs), nil
+}
+
+// Expire finishes runs which are longer than timeout. Collection is cancelled by timeout,
+// so such runs were left by crashed instances. Returns count of expired runs
+func (s *Scheduler) Expire(ctx context.Context) (int, error) {
+	now := s.Now.UTC()
+	return s.Store.Expire(ctx, now.Add(-s.timeout()), now)
+}
+
+// collect runs collector with timeout, panic of collector is returned as error
+func (s *Scheduler) collect(ctx context.Context, job Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return s.Collector.Collect(ctx, job.App)
+}
+
+func (s *Scheduler) timeout() time.Duration {
+	if s.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return s.Timeout
+}
+
+func (s *Scheduler) batchSize() int {
+	if s.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return s.BatchSize
+}
@@ -0,0 +1,197 @@
+package scheduler_test
+
+import (
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/scheduler"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Store which keeps schedule of apps in memory
+type mockStore struct {
+	mu       sync.Mutex
+	apps     []entities.App
+	next     map[int]time.Time
+	runs     int64
+	started  []time.Time
+	finished map[int64]error
+	// Start time bound of the last Expire call
+	expiredBefore time.Time
+}
+
+func (m *mockStore) Lease(ctx context.Context, now time.Time, limit int) ([]scheduler.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.next == nil {
+		m.next = make(map[int]time.Time)
+		m.finished = make(map[int64]error)
+	}
+
+	var jobs []scheduler.Job
+	for _, app := range m.apps {
+		due, ok := m.next[app.Id]
+		if !ok {
+			due = app.StartAt
+		}
+		if app.Status != entities.AppActive || due.After(now) || len(jobs) == limit {
+			continue
+		}
+		m.next[app.Id] = scheduler.NextRun(app.StartAt, scheduler.Period(app), now)
+		jobs = append(jobs, scheduler.Job{App: app, ScheduledAt: due})
+	}
+
+	return jobs, nil
+}
+
+func (m *mockStore) Start(ctx context.Context, job scheduler.Job) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs++
+	m.started = append(m.started, job.StartedAt)
+	return m.runs, nil
+}
+
+func (m *mockStore) Finish(ctx context.Context, job scheduler.Job, finishedAt time.Time, err error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finished[job.RunId] = err
+	return nil
+}
+
+func (m *mockStore) Expire(ctx context.Context, startedBefore, now time.Time) (int, error) {
+	m.expiredBefore = startedBefore
+	return 0, nil
+}
+
+// Collector which remembers collected apps
+type mockCollector struct {
+	mu        sync.Mutex
+	collected []int
+	err       error
+}
+
+func (m *mockCollector) Collect(ctx context.Context, app entities.App) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collected = append(m.collected, app.Id)
+	return m.err
+}
+
+func TestNextRun(t *testing.T) {
+	start := time.Date(2021, 1, 18, 10, 0, 0, 0, time.UTC)
+	day := time.Hour * 24
+
+	assert.Equal(t, start, scheduler.NextRun(start, day, start.Add(-time.Hour)))
+	assert.Equal(t, start.Add(day), scheduler.NextRun(start, day, start))
+	assert.Equal(t, start.Add(day), scheduler.NextRun(start, day, start.Add(time.Hour)))
+	// Missed runs are skipped
+	assert.Equal(t, start.Add(day*4), scheduler.NextRun(start, day, start.Add(day*3+time.Minute)))
+	assert.Equal(t, start, scheduler.NextRun(start, 0, start.Add(day)))
+}
+
+func TestScheduler_RunDue_ShouldCollectDueAppsOncePerPeriod_Mock(t *testing.T) {
+	start := time.Date(2021, 1, 18, 10, 0, 0, 0, time.UTC)
+	now := start
+	store := &mockStore{apps: []entities.App{
+		{Id: 1, StartAt: start, Period: 1, Status: entities.AppActive},
+		{Id: 2, StartAt: start.Add(time.Hour), Period: 1, Status: entities.AppActive},
+		{Id: 3, StartAt: start, Period: 1, Status: entities.AppPaused},
+	}}
+	collector := &mockCollector{}
+	s := scheduler.Scheduler{Store: store, Collector: collector, Now: func() time.Time { return now }}
+
+	count, err := s.RunDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	now = start.Add(time.Hour * 2)
+	count, err = s.RunDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	now = start.Add(time.Hour * 24)
+	_, err = s.RunDue(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []int{1, 2, 1}, collector.collected)
+	assert.Len(t, store.finished, 3)
+}
+
+func TestScheduler_RunDue_ShouldRecordErrorsAndPanics_Mock(t *testing.T) {
+	start := time.Date(2021, 1, 18, 10, 0, 0, 0, time.UTC)
+	store := &mockStore{apps: []entities.App{
+		{Id: 1, StartAt: start, Period: 1, Status: entities.AppActive},
+		{Id: 2, StartAt: start, Period: 1, Status: entities.AppActive},
+	}}
+	s := scheduler.Scheduler{
+		Store: store,
+		Collector: scheduler.CollectorFunc(func(ctx context.Context, app entities.App) error {
+			if app.Id == 1 {
+				return errors.New("page not found")
+			}
+			panic("broken parser")
+		}),
+		Now: func() time.Time { return start },
+	}
+
+	count, err := s.RunDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.EqualError(t, store.finished[1], "page not found")
+	assert.EqualError(t, store.finished[2], "panic: broken parser")
+}
+
+func TestScheduler_RunDue_ShouldRecordStartOfEveryCollection_Mock(t *testing.T) {
+	start := time.Date(2021, 1, 18, 10, 0, 0, 0, time.UTC)
+	now := start
+	store := &mockStore{apps: []entities.App{
+		{Id: 1, StartAt: start, Period: 1, Status: entities.AppActive},
+		{Id: 2, StartAt: start, Period: 1, Status: entities.AppActive},
+	}}
+	s := scheduler.Scheduler{
+		Store: store,
+		Collector: scheduler.CollectorFunc(func(ctx context.Context, app entities.App) error {
+			now = now.Add(time.Minute * 3)
+			return nil
+		}),
+		Now: func() time.Time { return now },
+	}
+
+	_, err := s.RunDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{start, start.Add(time.Minute * 3)}, store.started)
+}
+
+func TestScheduler_Expire_ShouldExpireRunsLongerThanTimeout_Mock(t *testing.T) {
+	now := time.Date(2021, 1, 18, 10, 0, 0, 0, time.UTC)
+	store := &mockStore{}
+	s := scheduler.Scheduler{Store: store, Timeout: time.Minute * 5, Now: func() time.Time { return now }}
+
+	_, err := s.Expire(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(-time.Minute*5), store.expiredBefore)
+}
+
+func TestScheduler_Run_ShouldRequireCollector_Mock(t *testing.T) {
+	s := scheduler.Scheduler{Store: &mockStore{}}
+	assert.Error(t, s.Run(context.Background()))
+}
+
+func TestScheduler_Run_ShouldStopWhenContextIsDone_Mock(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	store := &mockStore{apps: []entities.App{{Id: 1, StartAt: start, Period: 1, Status: entities.AppActive}}}
+	collector := &mockCollector{}
+	s := scheduler.Scheduler{Store: store, Collector: collector, Interval: time.Millisecond * 10}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.Run(ctx))
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	assert.Equal(t, []int{1}, collector.collected)
+}
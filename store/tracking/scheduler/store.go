@@ -0,0 +1,96 @@
+package scheduler
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/entities"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// Store of schedule in app_tracking and history in collection_runs tables
+type PgStore struct {
+	DB connector.DB
+}
+
+// Lease selects due apps with FOR UPDATE SKIP LOCKED, so apps locked by other
+// instance are skipped, and moves next run of selected apps in the same transaction.
+// Runs are recorded later by Start, when collection of app begins
+func (p *PgStore) Lease(ctx context.Context, now time.Time, limit int) ([]Job, error) {
+	tx, err := p.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		jobs []Job
+		app  entities.App
+		due  time.Time
+	)
+	_, err = tx.QueryFunc(
+		ctx,
+		"select id, bundle, category, developerId, developer, geo, startAt, period, status, coalesce(ownerId, 0), store, coalesce(nextRunAt, startAt)"+
+			" from app_tracking where status = $1 and period > 0 and startAt is not null and coalesce(nextRunAt, startAt) <= $2"+
+			" order by coalesce(nextRunAt, startAt), id limit $3 for update skip locked",
+		[]interface{}{entities.AppActive, now, limit},
+		[]interface{}{
+			&app.Id, &app.Bundle, &app.Category, &app.DeveloperId, &app.Developer, &app.Geo,
+			&app.StartAt, &app.Period, &app.Status, &app.OwnerId, &app.Store, &due,
+		},
+		func(pgx.QueryFuncRow) error {
+			jobs = append(jobs, Job{App: app, ScheduledAt: due})
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		next := NextRun(job.App.StartAt, Period(job.App), now)
+		if _, err := tx.Exec(ctx, "update app_tracking set nextRunAt = $2 where id = $1", job.App.Id, next); err != nil {
+			return nil, err
+		}
+	}
+
+	return jobs, tx.Commit(ctx)
+}
+
+// Start inserts run with start time of job
+func (p *PgStore) Start(ctx context.Context, job Job) (int64, error) {
+	var id int64
+	err := p.DB.QueryRow(
+		ctx,
+		"insert into collection_runs (appId, scheduledAt, startedAt) values ($1, $2, $3) returning id",
+		job.App.Id, job.ScheduledAt, job.StartedAt,
+	).Scan(&id)
+
+	return id, err
+}
+
+// Finish sets end time and error of run
+func (p *PgStore) Finish(ctx context.Context, job Job, finishedAt time.Time, err error) error {
+	var message *string
+	if err != nil {
+		s := err.Error()
+		message = &s
+	}
+	_, execErr := p.DB.Exec(ctx, "update collection_runs set finishedAt = $2, error = $3 where id = $1", job.RunId, finishedAt, message)
+
+	return execErr
+}
+
+// Expire sets end time and error of unfinished runs which were started before given time
+func (p *PgStore) Expire(ctx context.Context, startedBefore, now time.Time) (int, error) {
+	tag, err := p.DB.Exec(
+		ctx,
+		"update collection_runs set finishedAt = $2, error = 'run expired' where finishedAt is null and startedAt < $1",
+		startedBefore, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,85 @@
+package scheduler_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking/scheduler"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPgStore_ShouldLeaseDueAppsOnceAndRecordRuns(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "collection_runs")
+	store := scheduler.PgStore{DB: conn}
+	ctx := context.Background()
+
+	start := time.Date(2021, 1, 18, 10, 0, 0, 0, time.UTC)
+	due, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "due", StartAt: start, Period: 1})
+	assert.NoError(t, err)
+	_, err = testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "later", StartAt: start.Add(time.Hour * 48), Period: 1})
+	assert.NoError(t, err)
+
+	now := start.Add(time.Hour)
+	// Second instance does not get apps leased by first one
+	first, err := store.Lease(ctx, now, 10)
+	assert.NoError(t, err)
+	second, err := store.Lease(ctx, now, 10)
+	assert.NoError(t, err)
+
+	assert.Len(t, first, 1)
+	assert.Empty(t, second)
+	assert.Equal(t, due, first[0].App.Id)
+	assert.Equal(t, start, first[0].ScheduledAt.UTC())
+
+	first[0].StartedAt = now
+	first[0].RunId, err = store.Start(ctx, first[0])
+	assert.NoError(t, err)
+	assert.NoError(t, store.Finish(ctx, first[0], now.Add(time.Minute), errors.New("page not found")))
+
+	var message string
+	assert.NoError(t, conn.QueryRow(ctx, "select error from collection_runs where id = $1", first[0].RunId).Scan(&message))
+	assert.Equal(t, "page not found", message)
+
+	// App is due again after period
+	next, err := store.Lease(ctx, start.Add(time.Hour*24), 10)
+	assert.NoError(t, err)
+	assert.Len(t, next, 1)
+	assert.Equal(t, start.Add(time.Hour*24), next[0].ScheduledAt.UTC())
+}
+
+func TestPgStore_ShouldExpireUnfinishedRuns(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "collection_runs")
+	store := scheduler.PgStore{DB: conn}
+	ctx := context.Background()
+
+	start := time.Date(2021, 1, 18, 10, 0, 0, 0, time.UTC)
+	appId, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "due", StartAt: start, Period: 1})
+	assert.NoError(t, err)
+	app := entities.App{Id: appId}
+	stale, err := store.Start(ctx, scheduler.Job{App: app, ScheduledAt: start, StartedAt: start})
+	assert.NoError(t, err)
+	running, err := store.Start(ctx, scheduler.Job{App: app, ScheduledAt: start, StartedAt: start.Add(time.Minute * 20)})
+	assert.NoError(t, err)
+
+	now := start.Add(time.Minute * 25)
+	count, err := store.Expire(ctx, now.Add(-time.Minute*10), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	var message string
+	assert.NoError(t, conn.QueryRow(ctx, "select error from collection_runs where id = $1", stale).Scan(&message))
+	assert.Equal(t, "run expired", message)
+	var finished bool
+	assert.NoError(t, conn.QueryRow(ctx, "select finishedAt is not null from collection_runs where id = $1", running).Scan(&finished))
+	assert.False(t, finished)
+}
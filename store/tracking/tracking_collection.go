@@ -6,17 +6,26 @@ import (
 
 // helper to working with different tables
 type Tables struct {
-	App  Repository
-	Meta Repository
-	Cat  Repository
-	Keys Repository
+	App  AppRepository
+	Meta MetaRepository
+	Cat  TrackRepository
+	Keys TrackRepository
+	// Alert rules of clients
+	Alerts AlertRepository
+	// Detected anomalies
+	Anomalies AnomalyRepository
+	// Digest schedules and reports of clients
+	Reports ReportRepository
 }
 
 func NewTrackingTables(conn connector.Conn) *Tables {
 	return &Tables{
-		App:  NewAppRepo(conn),
-		Meta: NewMetaRepo(conn),
-		Cat:  NewCatRepo(conn),
-		Keys: NewKeysRepo(conn),
+		App:       NewAppRepo(conn),
+		Meta:      NewMetaRepo(conn),
+		Cat:       NewCatRepo(conn),
+		Keys:      NewKeysRepo(conn),
+		Alerts:    NewAlertRepo(conn),
+		Anomalies: NewAnomalyRepo(conn),
+		Reports:   NewReportRepo(conn),
 	}
 }
@@ -4,10 +4,14 @@ import (
 	"Muromachi/store/connector"
 	"Muromachi/store/entities"
 	"context"
+	"fmt"
 	"github.com/jackc/pgx/v4"
 	"time"
 )
 
+// Columns of app_tracking joined to tracking rows
+const appColumns = "APP.id, APP.bundle, APP.category, APP.developerId, APP.developer, APP.geo, APP.startAt, APP.period, APP.store"
+
 // Struct for holds connection with db
 type CatRepo struct {
 	Conn connector.Conn
@@ -26,7 +30,7 @@ func (c *CatRepo) ProducerFunc(ctx context.Context, sql string, params ...interf
 		[]interface{}{
 			&key.Id, &key.BundleId, &key.Type, &key.Place, &key.Date,
 			&app.Id, &app.Bundle, &app.Category, &app.DeveloperId, &app.Developer, &app.Geo,
-			&app.StartAt, &app.Period,
+			&app.StartAt, &app.Period, &app.Store,
 		},
 		func(row pgx.QueryFuncRow) error {
 			key.App = app
@@ -48,28 +52,109 @@ func (c *CatRepo) ProducerFunc(ctx context.Context, sql string, params ...interf
 func (c *CatRepo) ByBundleId(ctx context.Context, bundleId int) (entities.DboSlice, error) {
 	return c.ProducerFunc(
 		ctx,
-		"select * from category_tracking CAT inner join app_tracking APP on CAT.bundleid = APP.id  where CAT.bundleid = $1",
+		"select CAT.*, "+appColumns+" from category_tracking CAT inner join app_tracking APP on CAT.bundleid = APP.id  where CAT.bundleid = $1",
 		bundleId,
 	)
 }
 
-// Return DboSlice with given bundle id and within time range from start to end
+// Return DboSlice with given bundle id and within time range from start to end. Wide ranges
+// are returned by days or weeks, see entities.Resolution.Pick
 func (c *CatRepo) TimeRange(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
+	if resolution := entities.ResolutionAuto.Pick(start, end); resolution != entities.ResolutionRaw {
+		return c.ByResolution(ctx, []int{bundleId}, resolution, start, end)
+	}
 	return c.ProducerFunc(
 		ctx,
-		"select * from category_tracking CAT inner join app_tracking APP on CAT.bundleid = APP.id where CAT.bundleid = $1 and CAT.date >= $2 and CAT.date <= $3",
+		"select CAT.*, "+appColumns+" from category_tracking CAT inner join app_tracking APP on CAT.bundleid = APP.id where CAT.bundleid = $1 and CAT.date >= $2 and CAT.date <= $3",
 		bundleId, start, end,
 	)
 }
 
+// Return DboSlice with entities.Track of several bundle ids within time range from start to end.
+// Zero start or end means that range is not bounded from this side
+func (c *CatRepo) ByBundleIds(ctx context.Context, bundleIds []int, start, end time.Time) (entities.DboSlice, error) {
+	return c.ProducerFunc(
+		ctx,
+		"select CAT.*, "+appColumns+" from category_tracking CAT inner join app_tracking APP on CAT.bundleid = APP.id where CAT.bundleid = any($1) and CAT.date >= coalesce($2::timestamp, '-infinity') and CAT.date <= coalesce($3::timestamp, 'infinity') order by CAT.id",
+		bundleIds, connector.NullTime(start), connector.NullTime(end),
+	)
+}
+
+// Return DboSlice with entities.Track of several bundle ids within time range by given resolution.
+// Auto resolution is picked by width of range, day and week resolutions return the latest category
+// place of every bucket with start of bucket as date. Zero start or end means unbounded range
+func (c *CatRepo) ByResolution(ctx context.Context, bundleIds []int, resolution entities.Resolution, start, end time.Time) (entities.DboSlice, error) {
+	if !resolution.Valid() {
+		return nil, fmt.Errorf("unknown resolution %q", resolution)
+	}
+	if resolution = resolution.Pick(start, end); resolution == entities.ResolutionRaw {
+		return c.ByBundleIds(ctx, bundleIds, start, end)
+	}
+	return downsample(ctx, c.Conn, c.ProducerFunc, "category_tracking", "category_rollups", bundleIds, resolution, start, end)
+}
+
+// Return page of entities.Track with given bundle id ordered by date and id. Rows are selected
+// by keyset (date, id) of cursors, so pages are stable while new rows are added
+func (c *CatRepo) Page(ctx context.Context, bundleId int, page entities.Page) (entities.DboSlice, error) {
+	order := " order by CAT.date, CAT.id limit $8"
+	if page.Backward {
+		order = " order by CAT.date desc, CAT.id desc limit $8"
+	}
+	afterDate, afterId := page.After.Values()
+	beforeDate, beforeId := page.Before.Values()
+
+	dbo, err := c.ProducerFunc(
+		ctx,
+		"select CAT.*, "+appColumns+" from category_tracking CAT inner join app_tracking APP on CAT.bundleid = APP.id where CAT.bundleid = $1 and CAT.date >= coalesce($2::timestamp, '-infinity') and CAT.date <= coalesce($3::timestamp, 'infinity') and ($4::timestamp is null or (CAT.date, CAT.id) > ($4, $5)) and ($6::timestamp is null or (CAT.date, CAT.id) < ($6, $7))"+order,
+		bundleId, connector.NullTime(page.Start), connector.NullTime(page.End),
+		afterDate, afterId, beforeDate, beforeId, page.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if page.Backward {
+		dbo.Reverse()
+	}
+
+	return dbo, nil
+}
+
+// Count rows with given bundle id within time range from start to end.
+// Zero start or end means that range is not bounded from this side
+func (c *CatRepo) Count(ctx context.Context, bundleId int, start, end time.Time) (int, error) {
+	var count int
+	err := c.Conn.QueryRow(
+		ctx,
+		"select count(*) from category_tracking CAT where CAT.bundleid = $1 and CAT.date >= coalesce($2::timestamp, '-infinity') and CAT.date <= coalesce($3::timestamp, 'infinity')",
+		bundleId, connector.NullTime(start), connector.NullTime(end),
+	).Scan(&count)
+
+	return count, err
+}
+
 // Get last n updates of categories with bundle id equals given bundle id
 func (c *CatRepo) LastUpdates(ctx context.Context, bundleId, count int) (entities.DboSlice, error) {
 	return c.ProducerFunc(
 		ctx,
-		"select * from category_tracking CAT inner join app_tracking APP on CAT.bundleid = APP.id where CAT.bundleid = $1 order by CAT.id desc limit $2",
+		"select CAT.*, "+appColumns+" from category_tracking CAT inner join app_tracking APP on CAT.bundleid = APP.id where CAT.bundleid = $1 order by CAT.id desc limit $2",
 		bundleId, count,
 	)
 }
 
+// Call f for every row with given bundle id within time range from start to end without
+// collecting rows. Zero start or end means unbounded range, positive last selects last rows
+func (c *CatRepo) Stream(ctx context.Context, bundleId, last int, start, end time.Time, f func(entities.DBO) error) error {
+	return streamTracks(ctx, c.Conn, "category_tracking", bundleId, last, start, end, f)
+}
 
+// Return DboSlice with entities.RankStats of category places by time buckets within time range.
+// Empty category means all category types of bundle, zero start or end means unbounded range
+func (c *CatRepo) RankStats(ctx context.Context, bundleId int, category string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	return rankStats(ctx, c.Conn, "category_tracking", []int{bundleId}, category, bucket, start, end)
+}
 
+// Return DboSlice with entities.RankStats of category places of every bundle by time buckets within time range.
+// All bundles are aggregated by one query, zero start or end means unbounded range
+func (c *CatRepo) RankStatsByBundleIds(ctx context.Context, bundleIds []int, category string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	return rankStats(ctx, c.Conn, "category_tracking", bundleIds, category, bucket, start, end)
+}
@@ -28,7 +28,6 @@ func TestCatRepo_ByBundleId_ShouldReturnApp_Mock(t *testing.T) {
 
 func TestCatRepo_ByBundleId_ShouldReturnApp(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("app_tracking", "category_tracking")
@@ -74,7 +73,6 @@ func TestCatRepo_TimeRange_ShouldReturnAppsInTimeRange_Mock(t *testing.T) {
 
 func TestCatRepo_TimeRange_ShouldReturnAppsInTimeRange(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("app_tracking", "category_tracking")
@@ -117,7 +115,6 @@ func TestCatRepo_LastUpdates_ShouldReturnLastNApps_Mock(t *testing.T) {
 
 func TestCatRepo_LastUpdates_ShouldReturnLastNApps(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("app_tracking", "category_tracking")
@@ -157,7 +154,6 @@ func TestCatRepo_ByBundleId_ShouldReturnErrorIfNoRows_Mock(t *testing.T) {
 
 func TestCatRepo_ByBundleId_ShouldReturnErrorIfNoRows(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, _ := testhelpers.RealDb(cfg.Database)
 	repo := trackstore.CatRepo{Conn: conn}
@@ -179,7 +175,6 @@ func TestCatRepo_TimeRange_ShouldReturnErrorIfNoRows_Mock(t *testing.T) {
 
 func TestCatRepo_TimeRange_ShouldReturnErrorIfNoRow(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, _ := testhelpers.RealDb(cfg.Database)
 	repo := trackstore.CatRepo{Conn: conn}
@@ -202,7 +197,6 @@ func TestCatRepo_LastUpdates_ShouldReturnErrorIfNoRows_Mock(t *testing.T) {
 
 func TestCatRepo_LastUpdates_ShouldReturnErrorIfNoRow(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, _ := testhelpers.RealDb(cfg.Database)
 	repo := trackstore.CatRepo{Conn: conn}
@@ -215,3 +209,115 @@ func TestCatRepo_LastUpdates_ShouldReturnErrorIfNoRow(t *testing.T) {
 
 
 
+
+func TestCatRepo_ByBundleIds_ShouldReturnAppsOfAllBundles_Mock(t *testing.T) {
+	conn := mockTrackConnection{}
+	repo := trackstore.CatRepo{Conn: conn}
+	ctx := context.Background()
+
+	dboSlice, err := repo.ByBundleIds(ctx, []int{123, 124}, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, dboSlice)
+
+	var key entities.Track
+	assert.NoError(t, dboSlice[0].To(&key))
+	assert.Equal(t, "123", key.App.Bundle)
+}
+
+func TestCatRepo_ByBundleIds_ShouldReturnAppsOfAllBundles(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "category_tracking")
+	repo := trackstore.CatRepo{Conn: conn}
+	ctx := context.Background()
+
+	var ids []int
+	for _, bundle := range []string{"123", "456"} {
+		bundleId, _ := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: bundle})
+		ids = append(ids, bundleId)
+
+		track := testhelpers.TrackStruct(bundleId, "key")
+		for i := 0; i < 4; i++ {
+			_, _ = testhelpers.AddNewTrack(conn, ctx, track, "category_tracking")
+			track.Date = track.Date.AddDate(0, 0, 1)
+		}
+	}
+	end := testhelpers.TrackStruct(0, "key").Date.AddDate(0, 0, 1)
+
+	// Without range
+	dboSlice, err := repo.ByBundleIds(ctx, ids, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(dboSlice))
+
+	// Range bounded only from end
+	dboSlice, err = repo.ByBundleIds(ctx, ids, time.Time{}, end)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(dboSlice))
+
+	var key entities.Track
+	for _, v := range dboSlice {
+		assert.NoError(t, v.To(&key))
+		assert.Contains(t, ids, key.BundleId)
+		assert.False(t, key.Date.After(end))
+	}
+}
+
+func TestCatRepo_Page_ShouldReturnPageOfRows_Mock(t *testing.T) {
+	conn := mockTrackConnection{}
+	repo := trackstore.CatRepo{Conn: conn}
+	ctx := context.Background()
+
+	dboSlice, err := repo.Page(ctx, 12, entities.Page{Limit: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(dboSlice))
+}
+
+func TestCatRepo_Page_ShouldReturnRowsAroundCursors(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "category_tracking")
+	repo := trackstore.CatRepo{Conn: conn}
+	ctx := context.Background()
+
+	bundleId, _ := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "123"})
+	track := testhelpers.TrackStruct(bundleId, "key")
+	// Rows with the same date are ordered by id
+	for i := 0; i < 6; i++ {
+		_, _ = testhelpers.AddNewTrack(conn, ctx, track, "category_tracking")
+		if i%2 == 1 {
+			track.Date = track.Date.AddDate(0, 0, 1)
+		}
+	}
+
+	first, err := repo.Page(ctx, bundleId, entities.Page{Limit: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(first))
+
+	cursor, _ := entities.CursorOf(first[2])
+	next, err := repo.Page(ctx, bundleId, entities.Page{Limit: 10, After: &cursor})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(next))
+
+	previous, err := repo.Page(ctx, bundleId, entities.Page{Limit: 2, Before: &cursor, Backward: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(previous))
+
+	var a, b entities.Track
+	assert.NoError(t, previous[0].To(&a))
+	assert.NoError(t, previous[1].To(&b))
+	assert.True(t, a.Id < b.Id)
+
+	count, err := repo.Count(ctx, bundleId, track.Date.AddDate(0, 0, -1), time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestCatRepo_Count_ShouldReturnError_Mock(t *testing.T) {
+	conn := mockTrackConnectionErrors{}
+	repo := trackstore.CatRepo{Conn: conn}
+
+	_, err := repo.Count(context.Background(), 12, time.Time{}, time.Time{})
+	assert.Error(t, err)
+}
@@ -4,6 +4,7 @@ import (
 	"Muromachi/store/connector"
 	"Muromachi/store/entities"
 	"context"
+	"fmt"
 	"github.com/jackc/pgx/v4"
 	"time"
 )
@@ -28,7 +29,7 @@ func (k *KeysRepo) ProducerFunc(ctx context.Context, sql string, params ...inter
 		[]interface{}{
 			&key.Id, &key.BundleId, &key.Type, &key.Place, &key.Date,
 			&app.Id, &app.Bundle, &app.Category, &app.DeveloperId, &app.Developer, &app.Geo,
-			&app.StartAt, &app.Period,
+			&app.StartAt, &app.Period, &app.Store,
 		},
 		func(row pgx.QueryFuncRow) error {
 			key.App = app
@@ -50,25 +51,109 @@ func (k *KeysRepo) ProducerFunc(ctx context.Context, sql string, params ...inter
 func (k *KeysRepo) ByBundleId(ctx context.Context, bundleId int) (entities.DboSlice, error) {
 	return k.ProducerFunc(
 		ctx,
-		"select * from keyword_tracking KEY inner join app_tracking APP on KEY.bundleid = APP.id where KEY.bundleid = $1",
+		"select KEY.*, "+appColumns+" from keyword_tracking KEY inner join app_tracking APP on KEY.bundleid = APP.id where KEY.bundleid = $1",
 		bundleId,
 	)
 }
 
-// Return DboSlice with given bundle id and within time range from start to end
+// Return DboSlice with given bundle id and within time range from start to end. Wide ranges
+// are returned by days or weeks, see entities.Resolution.Pick
 func (k *KeysRepo) TimeRange(ctx context.Context, bundleId int, start, end time.Time) (entities.DboSlice, error) {
+	if resolution := entities.ResolutionAuto.Pick(start, end); resolution != entities.ResolutionRaw {
+		return k.ByResolution(ctx, []int{bundleId}, resolution, start, end)
+	}
 	return k.ProducerFunc(
 		ctx,
-		"select * from keyword_tracking KEY inner join app_tracking APP on KEY.bundleid = APP.id where KEY.bundleid = $1 and KEY.date >= $2 and KEY.date <= $3",
+		"select KEY.*, "+appColumns+" from keyword_tracking KEY inner join app_tracking APP on KEY.bundleid = APP.id where KEY.bundleid = $1 and KEY.date >= $2 and KEY.date <= $3",
 		bundleId, start, end,
 	)
 }
 
+// Return DboSlice with entities.Track of several bundle ids within time range from start to end.
+// Zero start or end means that range is not bounded from this side
+func (k *KeysRepo) ByBundleIds(ctx context.Context, bundleIds []int, start, end time.Time) (entities.DboSlice, error) {
+	return k.ProducerFunc(
+		ctx,
+		"select KEY.*, "+appColumns+" from keyword_tracking KEY inner join app_tracking APP on KEY.bundleid = APP.id where KEY.bundleid = any($1) and KEY.date >= coalesce($2::timestamp, '-infinity') and KEY.date <= coalesce($3::timestamp, 'infinity') order by KEY.id",
+		bundleIds, connector.NullTime(start), connector.NullTime(end),
+	)
+}
+
+// Return DboSlice with entities.Track of several bundle ids within time range by given resolution.
+// Auto resolution is picked by width of range, day and week resolutions return the latest keyword
+// place of every bucket with start of bucket as date. Zero start or end means unbounded range
+func (k *KeysRepo) ByResolution(ctx context.Context, bundleIds []int, resolution entities.Resolution, start, end time.Time) (entities.DboSlice, error) {
+	if !resolution.Valid() {
+		return nil, fmt.Errorf("unknown resolution %q", resolution)
+	}
+	if resolution = resolution.Pick(start, end); resolution == entities.ResolutionRaw {
+		return k.ByBundleIds(ctx, bundleIds, start, end)
+	}
+	return downsample(ctx, k.Conn, k.ProducerFunc, "keyword_tracking", "keyword_rollups", bundleIds, resolution, start, end)
+}
+
+// Return page of entities.Track with given bundle id ordered by date and id. Rows are selected
+// by keyset (date, id) of cursors, so pages are stable while new rows are added
+func (k *KeysRepo) Page(ctx context.Context, bundleId int, page entities.Page) (entities.DboSlice, error) {
+	order := " order by KEY.date, KEY.id limit $8"
+	if page.Backward {
+		order = " order by KEY.date desc, KEY.id desc limit $8"
+	}
+	afterDate, afterId := page.After.Values()
+	beforeDate, beforeId := page.Before.Values()
+
+	dbo, err := k.ProducerFunc(
+		ctx,
+		"select KEY.*, "+appColumns+" from keyword_tracking KEY inner join app_tracking APP on KEY.bundleid = APP.id where KEY.bundleid = $1 and KEY.date >= coalesce($2::timestamp, '-infinity') and KEY.date <= coalesce($3::timestamp, 'infinity') and ($4::timestamp is null or (KEY.date, KEY.id) > ($4, $5)) and ($6::timestamp is null or (KEY.date, KEY.id) < ($6, $7))"+order,
+		bundleId, connector.NullTime(page.Start), connector.NullTime(page.End),
+		afterDate, afterId, beforeDate, beforeId, page.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if page.Backward {
+		dbo.Reverse()
+	}
+
+	return dbo, nil
+}
+
+// Count rows with given bundle id within time range from start to end.
+// Zero start or end means that range is not bounded from this side
+func (k *KeysRepo) Count(ctx context.Context, bundleId int, start, end time.Time) (int, error) {
+	var count int
+	err := k.Conn.QueryRow(
+		ctx,
+		"select count(*) from keyword_tracking KEY where KEY.bundleid = $1 and KEY.date >= coalesce($2::timestamp, '-infinity') and KEY.date <= coalesce($3::timestamp, 'infinity')",
+		bundleId, connector.NullTime(start), connector.NullTime(end),
+	).Scan(&count)
+
+	return count, err
+}
+
 // Get last n updates of app with bundle id equals given bundle id
 func (k *KeysRepo) LastUpdates(ctx context.Context, bundleId, count int) (entities.DboSlice, error) {
 	return k.ProducerFunc(
 		ctx,
-		"select * from keyword_tracking KEY inner join app_tracking APP on KEY.bundleid = APP.id where KEY.bundleid = $1 order by KEY.id desc limit $2",
+		"select KEY.*, "+appColumns+" from keyword_tracking KEY inner join app_tracking APP on KEY.bundleid = APP.id where KEY.bundleid = $1 order by KEY.id desc limit $2",
 		bundleId, count,
 	)
 }
+
+// Call f for every row with given bundle id within time range from start to end without
+// collecting rows. Zero start or end means unbounded range, positive last selects last rows
+func (k *KeysRepo) Stream(ctx context.Context, bundleId, last int, start, end time.Time, f func(entities.DBO) error) error {
+	return streamTracks(ctx, k.Conn, "keyword_tracking", bundleId, last, start, end, f)
+}
+
+// Return DboSlice with entities.RankStats of keyword places by time buckets within time range.
+// Empty keyword means all keyword types of bundle, zero start or end means unbounded range
+func (k *KeysRepo) RankStats(ctx context.Context, bundleId int, keyword string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	return rankStats(ctx, k.Conn, "keyword_tracking", []int{bundleId}, keyword, bucket, start, end)
+}
+
+// Return DboSlice with entities.RankStats of keyword places of every bundle by time buckets within time range.
+// All bundles are aggregated by one query, zero start or end means unbounded range
+func (k *KeysRepo) RankStatsByBundleIds(ctx context.Context, bundleIds []int, keyword string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	return rankStats(ctx, k.Conn, "keyword_tracking", bundleIds, keyword, bucket, start, end)
+}
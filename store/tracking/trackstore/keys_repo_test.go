@@ -6,6 +6,7 @@ import (
 	"Muromachi/store/testhelpers"
 	"Muromachi/store/tracking/trackstore"
 	"context"
+	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -28,7 +29,6 @@ func TestKeysRepo_ByBundleId_ShouldReturnApp_Mock(t *testing.T) {
 
 func TestKeysRepo_ByBundleId_ShouldReturnApp(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("app_tracking", "keyword_tracking")
@@ -74,7 +74,6 @@ func TestKeysRepo_TimeRange_ShouldReturnAppsInTimeRange_Mock(t *testing.T) {
 
 func TestKeysRepo_TimeRange_ShouldReturnAppsInTimeRange(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("app_tracking", "keyword_tracking")
@@ -116,7 +115,6 @@ func TestKeysRepo_LastUpdates_ShouldReturnLastNApps_Mock(t *testing.T) {
 
 func TestKeysRepo_LastUpdates_ShouldReturnLastNApps(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("app_tracking", "keyword_tracking")
@@ -156,7 +154,6 @@ func TestKeysRepo_ByBundleId_ShouldReturnErrorIfNoRows_Mock(t *testing.T) {
 
 func TestKeysRepo_ByBundleId_ShouldReturnErrorIfNoRows(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, _ := testhelpers.RealDb(cfg.Database)
 	repo := trackstore.KeysRepo{Conn: conn}
@@ -178,7 +175,6 @@ func TestKeysRepo_TimeRange_ShouldReturnErrorIfNoRows_Mock(t *testing.T) {
 
 func TestKeysRepo_TimeRange_ShouldReturnErrorIfNoRow(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, _ := testhelpers.RealDb(cfg.Database)
 	repo := trackstore.KeysRepo{Conn: conn}
@@ -201,7 +197,6 @@ func TestKeysRepo_LastUpdates_ShouldReturnErrorIfNoRows_Mock(t *testing.T) {
 
 func TestKeysRepo_LastUpdates_ShouldReturnErrorIfNoRow(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, _ := testhelpers.RealDb(cfg.Database)
 	repo := trackstore.KeysRepo{Conn: conn}
@@ -213,3 +208,238 @@ func TestKeysRepo_LastUpdates_ShouldReturnErrorIfNoRow(t *testing.T) {
 }
 
 
+
+func TestKeysRepo_ByBundleIds_ShouldReturnAppsOfAllBundles_Mock(t *testing.T) {
+	conn := mockTrackConnection{}
+	repo := trackstore.KeysRepo{Conn: conn}
+	ctx := context.Background()
+
+	dboSlice, err := repo.ByBundleIds(ctx, []int{123, 124}, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, dboSlice)
+
+	var key entities.Track
+	assert.NoError(t, dboSlice[0].To(&key))
+	assert.Equal(t, "123", key.App.Bundle)
+}
+
+func TestKeysRepo_ByBundleIds_ShouldReturnAppsOfAllBundles(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "keyword_tracking")
+	repo := trackstore.KeysRepo{Conn: conn}
+	ctx := context.Background()
+
+	var ids []int
+	for _, bundle := range []string{"123", "456"} {
+		bundleId, _ := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: bundle})
+		ids = append(ids, bundleId)
+
+		track := testhelpers.TrackStruct(bundleId, "key")
+		for i := 0; i < 4; i++ {
+			_, _ = testhelpers.AddNewTrack(conn, ctx, track, "keyword_tracking")
+			track.Date = track.Date.AddDate(0, 0, 1)
+		}
+	}
+	end := testhelpers.TrackStruct(0, "key").Date.AddDate(0, 0, 1)
+
+	// Without range
+	dboSlice, err := repo.ByBundleIds(ctx, ids, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(dboSlice))
+
+	// Range bounded only from end
+	dboSlice, err = repo.ByBundleIds(ctx, ids, time.Time{}, end)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(dboSlice))
+
+	var key entities.Track
+	for _, v := range dboSlice {
+		assert.NoError(t, v.To(&key))
+		assert.Contains(t, ids, key.BundleId)
+		assert.False(t, key.Date.After(end))
+	}
+}
+
+func TestKeysRepo_Page_ShouldReturnPageOfRows_Mock(t *testing.T) {
+	conn := mockTrackConnection{}
+	repo := trackstore.KeysRepo{Conn: conn}
+	ctx := context.Background()
+
+	dboSlice, err := repo.Page(ctx, 12, entities.Page{Limit: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(dboSlice))
+}
+
+func TestKeysRepo_Page_ShouldReturnRowsAroundCursors(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "keyword_tracking")
+	repo := trackstore.KeysRepo{Conn: conn}
+	ctx := context.Background()
+
+	bundleId, _ := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "123"})
+	track := testhelpers.TrackStruct(bundleId, "key")
+	// Rows with the same date are ordered by id
+	for i := 0; i < 6; i++ {
+		_, _ = testhelpers.AddNewTrack(conn, ctx, track, "keyword_tracking")
+		if i%2 == 1 {
+			track.Date = track.Date.AddDate(0, 0, 1)
+		}
+	}
+
+	first, err := repo.Page(ctx, bundleId, entities.Page{Limit: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(first))
+
+	cursor, _ := entities.CursorOf(first[2])
+	next, err := repo.Page(ctx, bundleId, entities.Page{Limit: 10, After: &cursor})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(next))
+
+	previous, err := repo.Page(ctx, bundleId, entities.Page{Limit: 2, Before: &cursor, Backward: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(previous))
+
+	var a, b entities.Track
+	assert.NoError(t, previous[0].To(&a))
+	assert.NoError(t, previous[1].To(&b))
+	assert.True(t, a.Id < b.Id)
+
+	count, err := repo.Count(ctx, bundleId, track.Date.AddDate(0, 0, -1), time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestKeysRepo_Count_ShouldReturnError_Mock(t *testing.T) {
+	conn := mockTrackConnectionErrors{}
+	repo := trackstore.KeysRepo{Conn: conn}
+
+	_, err := repo.Count(context.Background(), 12, time.Time{}, time.Time{})
+	assert.Error(t, err)
+}
+
+func TestKeysRepo_Stream_ShouldCallFuncForRowsInOrder(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "keyword_tracking")
+	repo := trackstore.KeysRepo{Conn: conn}
+	ctx := context.Background()
+
+	bundleId, _ := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "123"})
+	track := testhelpers.TrackStruct(bundleId, "key")
+	for i := 0; i < 4; i++ {
+		track.Place = int32(i + 1)
+		track.Date = track.Date.AddDate(0, 0, -1)
+		_, _ = testhelpers.AddNewTrack(conn, ctx, track, "keyword_tracking")
+	}
+
+	var places []int32
+	collect := func(dbo entities.DBO) error {
+		places = append(places, dbo.(entities.Track).Place)
+		return nil
+	}
+	assert.NoError(t, repo.Stream(ctx, bundleId, 0, time.Time{}, time.Time{}, collect))
+	assert.Equal(t, []int32{4, 3, 2, 1}, places)
+
+	places = nil
+	assert.NoError(t, repo.Stream(ctx, bundleId, 2, time.Time{}, time.Time{}, collect))
+	assert.Equal(t, []int32{4, 3}, places)
+
+	places = nil
+	assert.NoError(t, repo.Stream(ctx, bundleId+1, 0, time.Time{}, time.Time{}, collect))
+	assert.Empty(t, places)
+}
+
+func TestKeysRepo_ByResolution_ShouldReturnRawRows_Mock(t *testing.T) {
+	conn := mockTrackConnection{}
+	repo := trackstore.KeysRepo{Conn: conn}
+	ctx := context.Background()
+
+	t1, _ := time.Parse("2006-01-02", "2019-01-18")
+	dboSlice, err := repo.ByResolution(ctx, []int{12}, entities.ResolutionRaw, t1, t1.AddDate(2, 0, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(dboSlice))
+
+	_, err = repo.ByResolution(ctx, []int{12}, entities.Resolution("month"), t1, t1.AddDate(2, 0, 0))
+	assert.Error(t, err)
+}
+
+func TestKeysRepo_ByResolution_ShouldReturnLatestPlaceOfBuckets(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "keyword_tracking", "keyword_rollups", "watermarks")
+	cleaner("watermarks")
+	repo := trackstore.KeysRepo{Conn: conn}
+	ctx := context.Background()
+
+	bundleId, _ := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "123"})
+	track := testhelpers.TrackStruct(bundleId, "key")
+	day := track.Date
+	for _, place := range []int32{5, 3, 8} {
+		track.Place = place
+		_, _ = testhelpers.AddNewTrack(conn, ctx, track, "keyword_tracking")
+		track.Date = track.Date.Add(time.Hour * 6)
+	}
+	track.Date = day.AddDate(0, 0, 1)
+	track.Place = 2
+	_, _ = testhelpers.AddNewTrack(conn, ctx, track, "keyword_tracking")
+
+	check := func(dboSlice entities.DboSlice) {
+		assert.Equal(t, 2, len(dboSlice))
+		var key entities.Track
+		assert.NoError(t, dboSlice[0].To(&key))
+		assert.True(t, key.Date.Equal(day))
+		assert.Equal(t, int32(8), key.Place)
+		assert.Equal(t, "123", key.App.Bundle)
+	}
+
+	// Without rollups buckets are aggregated from rows
+	dboSlice, err := repo.ByResolution(ctx, []int{bundleId}, entities.ResolutionDay, day, day.AddDate(0, 0, 7))
+	assert.NoError(t, err)
+	check(dboSlice)
+
+	_, err = conn.Exec(ctx, "insert into watermarks (source, ready) values ('rollups:keyword_tracking', true)")
+	assert.NoError(t, err)
+	_, err = conn.Exec(
+		ctx,
+		"insert into keyword_rollups (resolution, bundleId, type, bucket, min, max, avg, median, first, last, count) values"+
+			" ('day', $1, 'key', $2, 3, 8, 5.3, 5, 5, 8, 3), ('day', $1, 'key', $3, 2, 2, 2, 2, 2, 2, 1)",
+		bundleId, day, day.AddDate(0, 0, 1),
+	)
+	assert.NoError(t, err)
+
+	dboSlice, err = repo.ByResolution(ctx, []int{bundleId}, entities.ResolutionDay, day, day.AddDate(0, 0, 7))
+	assert.NoError(t, err)
+	check(dboSlice)
+
+	// Bucket of end is aggregated from rows before end, not read from rollups
+	var key entities.Track
+	dboSlice, err = repo.ByResolution(ctx, []int{bundleId}, entities.ResolutionDay, day, day.Add(time.Hour*7))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(dboSlice))
+	assert.NoError(t, dboSlice[0].To(&key))
+	assert.Equal(t, int32(3), key.Place)
+
+	// Rollups are read while watermark is fresh
+	_, err = conn.Exec(ctx, "update keyword_rollups set last = 99 where bundleId = $1", bundleId)
+	assert.NoError(t, err)
+	dboSlice, err = repo.ByResolution(ctx, []int{bundleId}, entities.ResolutionDay, day, day.AddDate(0, 0, 7))
+	assert.NoError(t, err)
+	assert.NoError(t, dboSlice[0].To(&key))
+	assert.Equal(t, int32(99), key.Place)
+	_, err = conn.Exec(ctx, "update watermarks set updatedAt = updatedAt - interval '2 hours'")
+	assert.NoError(t, err)
+	dboSlice, err = repo.ByResolution(ctx, []int{bundleId}, entities.ResolutionDay, day, day.AddDate(0, 0, 7))
+	assert.NoError(t, err)
+	check(dboSlice)
+
+	// Wide range is read by weeks automatically
+	dboSlice, err = repo.TimeRange(ctx, bundleId, day.AddDate(-2, 0, 0), day.AddDate(0, 0, 7))
+	assert.Equal(t, pgx.ErrNoRows, err)
+	assert.Empty(t, dboSlice)
+}
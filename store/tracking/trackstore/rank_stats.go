@@ -0,0 +1,52 @@
+package trackstore
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/entities"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// rankStats aggregates places of table rows by time buckets. Rows of every
+// bundle and type are aggregated separately, empty typ means all types of bundles
+func rankStats(ctx context.Context, conn connector.Conn, table string, bundleIds []int, typ string, bucket entities.Bucket, start, end time.Time) (entities.DboSlice, error) {
+	if !bucket.Valid() {
+		return nil, fmt.Errorf("unknown bucket %q", bucket)
+	}
+
+	var (
+		stats entities.RankStats
+		list  []entities.DBO
+	)
+	_, err := conn.QueryFunc(
+		ctx,
+		fmt.Sprintf(
+			"select bundleid, date_trunc($3, date) as bucket, type, min(place), max(place), avg(place)::float8,"+
+				" percentile_cont(0.5) within group (order by place)::float8,"+
+				" (array_agg(place order by date, id))[1], (array_agg(place order by date desc, id desc))[1], count(*)"+
+				" from %s where bundleid = any($1) and ($2 = '' or type = $2)"+
+				" and date >= coalesce($4::timestamp, '-infinity') and date <= coalesce($5::timestamp, 'infinity')"+
+				" group by bundleid, bucket, type order by bundleid, bucket, type",
+			table,
+		),
+		[]interface{}{bundleIds, typ, string(bucket), connector.NullTime(start), connector.NullTime(end)},
+		[]interface{}{
+			&stats.BundleId, &stats.Bucket, &stats.Type, &stats.Min, &stats.Max, &stats.Avg,
+			&stats.Median, &stats.First, &stats.Last, &stats.Count,
+		},
+		func(row pgx.QueryFuncRow) error {
+			list = append(list, stats)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return list, nil
+}
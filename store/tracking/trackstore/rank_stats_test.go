@@ -0,0 +1,117 @@
+package trackstore_test
+
+import (
+	"Muromachi/config"
+	"Muromachi/store/entities"
+	"Muromachi/store/testhelpers"
+	"Muromachi/store/tracking/trackstore"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestKeysRepo_RankStats_ShouldReturnErrorForUnknownBucket_Mock(t *testing.T) {
+	repo := trackstore.KeysRepo{Conn: mockTrackConnection{}}
+
+	_, err := repo.RankStats(context.Background(), 12, "key", entities.Bucket("year"), time.Time{}, time.Time{})
+	assert.Error(t, err)
+}
+
+func TestCatRepo_RankStats_ShouldReturnErrNoRows_Mock(t *testing.T) {
+	repo := trackstore.CatRepo{Conn: mockTrackConnectionErrors{}}
+
+	_, err := repo.RankStats(context.Background(), 12, "", entities.BucketDay, time.Time{}, time.Time{})
+	assert.Equal(t, pgx.ErrNoRows, err)
+}
+
+func TestKeysRepo_RankStats_ShouldAggregatePlacesByBuckets(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "keyword_tracking")
+	repo := trackstore.KeysRepo{Conn: conn}
+	ctx := context.Background()
+
+	bundleId, _ := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: "123"})
+	day, _ := time.Parse("2006-01-02", "2021-01-18")
+	rows := []struct {
+		keyword string
+		hours   int
+		place   int32
+	}{
+		{"bank", 1, 10},
+		{"bank", 2, 4},
+		{"bank", 3, 7},
+		{"bank", 4, 1},
+		{"bank", 25, 3},
+		{"money", 1, 50},
+	}
+	for _, row := range rows {
+		track := testhelpers.TrackStruct(bundleId, row.keyword)
+		track.Date = day.Add(time.Hour * time.Duration(row.hours))
+		track.Place = row.place
+		_, err := testhelpers.AddNewTrack(conn, ctx, track, "keyword_tracking")
+		assert.NoError(t, err)
+	}
+
+	dbo, err := repo.RankStats(ctx, bundleId, "bank", entities.BucketDay, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(dbo))
+
+	var stats entities.RankStats
+	assert.NoError(t, dbo[0].To(&stats))
+	assert.True(t, day.Equal(stats.Bucket))
+	assert.Equal(t, "bank", stats.Type)
+	assert.Equal(t, int32(1), stats.Min)
+	assert.Equal(t, int32(10), stats.Max)
+	assert.Equal(t, 5.5, stats.Avg)
+	assert.Equal(t, 5.5, stats.Median)
+	assert.Equal(t, int32(10), stats.First)
+	assert.Equal(t, int32(1), stats.Last)
+	assert.Equal(t, 4, stats.Count)
+
+	// All keywords within one month
+	dbo, err = repo.RankStats(ctx, bundleId, "", entities.BucketMonth, day, day.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(dbo))
+	assert.NoError(t, dbo[1].To(&stats))
+	assert.Equal(t, "money", stats.Type)
+	assert.Equal(t, 1, stats.Count)
+}
+
+func TestCatRepo_RankStatsByBundleIds_ShouldAggregateEveryBundleByOneQuery(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("app_tracking", "category_tracking")
+	repo := trackstore.CatRepo{Conn: conn}
+	ctx := context.Background()
+
+	day, _ := time.Parse("2006-01-02", "2021-01-18")
+	var ids []int
+	for i, bundle := range []string{"first", "second", "third"} {
+		id, err := testhelpers.AddNewApp(conn, ctx, entities.App{Bundle: bundle})
+		assert.NoError(t, err)
+		ids = append(ids, id)
+
+		track := testhelpers.TrackStruct(id, "FINANCE")
+		track.Date = day.AddDate(0, 0, i)
+		track.Place = int32(i + 1)
+		_, err = testhelpers.AddNewTrack(conn, ctx, track, "category_tracking")
+		assert.NoError(t, err)
+	}
+
+	dbo, err := repo.RankStatsByBundleIds(ctx, ids[1:], "FINANCE", entities.BucketDay, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(dbo))
+
+	var stats entities.RankStats
+	for i, v := range dbo {
+		assert.NoError(t, v.To(&stats))
+		assert.Equal(t, ids[i+1], stats.BundleId)
+		assert.True(t, day.AddDate(0, 0, i+1).Equal(stats.Bucket))
+		assert.Equal(t, int32(i+2), stats.Last)
+	}
+}
@@ -0,0 +1,65 @@
+package trackstore
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/entities"
+	"Muromachi/store/tracking/worker"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Max age of rollup watermark. Rollup job updates watermark on every run, so older watermark
+// means that job is stopped and aggregates are stale
+const rollupsMaxAge = "1 hour"
+
+// Function which runs query and scans rows with app columns to entities.Track
+type producer func(ctx context.Context, sql string, params ...interface{}) (entities.DboSlice, error)
+
+// downsample returns places of table rows by buckets of resolution as entities.Track with zero id,
+// start of bucket as date and the latest place of bucket before end. Buckets which end before end
+// are read from rollups table when rollup job caught up with table and keeps running, the bucket
+// of end and all buckets without fresh rollups are aggregated from table rows
+func downsample(ctx context.Context, conn connector.Conn, produce producer, table, rollups string, bundleIds []int, resolution entities.Resolution, start, end time.Time) (entities.DboSlice, error) {
+	var ready bool
+	err := conn.QueryRow(
+		ctx,
+		"select exists (select 1 from watermarks where source = $1 and ready"+
+			" and updatedAt >= (now() at time zone 'utc') - interval '"+rollupsMaxAge+"')",
+		worker.Source("rollups", table),
+	).Scan(&ready)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows of table are aggregated from start of the first bucket until end inclusive
+	aggregate := fmt.Sprintf(
+		"select 0, T.bundleid, T.type, (array_agg(T.place order by T.date desc, T.id desc))[1], date_trunc($2::text, T.date) as bucket, %s"+
+			" from %s T inner join app_tracking APP on T.bundleid = APP.id where T.bundleid = any($1)"+
+			" and T.date >= %%s and T.date <= coalesce($4::timestamp, 'infinity')"+
+			" group by T.bundleid, T.type, bucket, APP.id",
+		appColumns, table,
+	)
+	if !ready {
+		return produce(
+			ctx,
+			fmt.Sprintf(aggregate, "date_trunc($2::text, coalesce($3::timestamp, '-infinity'))")+" order by 2, 5, 3",
+			bundleIds, string(resolution), connector.NullTime(start), connector.NullTime(end),
+		)
+	}
+
+	// The last place of rollup bucket can be observed after end, so bucket of end is aggregated from rows
+	return produce(
+		ctx,
+		fmt.Sprintf(
+			"select 0, R.bundleid, R.type, R.last, R.bucket, %s from %s R inner join app_tracking APP on R.bundleid = APP.id"+
+				" where R.resolution = $2 and R.bundleid = any($1) and R.bucket >= date_trunc($2::text, coalesce($3::timestamp, '-infinity'))"+
+				" and R.bucket + ('1 ' || $2::text)::interval <= coalesce($4::timestamp, 'infinity') union all ",
+			appColumns, rollups,
+		)+fmt.Sprintf(
+			aggregate,
+			"greatest(date_trunc($2::text, coalesce($3::timestamp, '-infinity')), date_trunc($2::text, coalesce($4::timestamp, 'infinity')))",
+		)+" order by 2, 5, 3",
+		bundleIds, string(resolution), connector.NullTime(start), connector.NullTime(end),
+	)
+}
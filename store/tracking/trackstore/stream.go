@@ -0,0 +1,47 @@
+package trackstore
+
+import (
+	"Muromachi/store/connector"
+	"Muromachi/store/entities"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// streamTracks calls f for every row of table with given bundle id without collecting
+// rows in memory. Rows within range are ordered by date and id, positive last
+// selects only last rows newest first like LastUpdates
+func streamTracks(ctx context.Context, conn connector.Conn, table string, bundleId, last int, start, end time.Time, f func(entities.DBO) error) error {
+	var (
+		track entities.Track
+		app   entities.App
+		limit *int
+	)
+	order := " order by T.date, T.id"
+	if last > 0 {
+		order, limit = " order by T.id desc", &last
+	}
+
+	_, err := conn.QueryFunc(
+		ctx,
+		fmt.Sprintf(
+			"select T.*, "+appColumns+" from %s T inner join app_tracking APP on T.bundleid = APP.id"+
+				" where T.bundleid = $1 and T.date >= coalesce($2::timestamp, '-infinity') and T.date <= coalesce($3::timestamp, 'infinity')"+
+				order+" limit $4",
+			table,
+		),
+		[]interface{}{bundleId, connector.NullTime(start), connector.NullTime(end), limit},
+		[]interface{}{
+			&track.Id, &track.BundleId, &track.Type, &track.Place, &track.Date,
+			&app.Id, &app.Bundle, &app.Category, &app.DeveloperId, &app.Developer, &app.Geo,
+			&app.StartAt, &app.Period, &app.Store,
+		},
+		func(row pgx.QueryFuncRow) error {
+			track.App = app
+			return f(track)
+		},
+	)
+
+	return err
+}
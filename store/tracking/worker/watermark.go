@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"Muromachi/store/connector"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+)
+
+// Rows of tracking table which are processed at once
+type Batch struct {
+	// Count of rows, batch is full if it is equal to limit
+	Count int
+	// Id of the last row of batch, id after which batch starts if batch is empty
+	LastId int64
+}
+
+// Watermarks of workers in watermarks table. Watermark is id of the last processed row
+// of tracking table, so workers process rows incrementally and resume where they stopped.
+// Rows of writers other than ingest can be committed after rows with greater ids, so
+// workers process lag rows behind watermark again and their processing is idempotent
+type Watermarks struct {
+	// Connection or transaction of worker
+	DB connector.Conn
+}
+
+// Source returns name of watermark of worker which processes rows of table
+func Source(worker, table string) string {
+	return worker + ":" + table
+}
+
+// Get returns id of the last processed row of source, zero if source was never processed
+func (w Watermarks) Get(ctx context.Context, source string) (int64, error) {
+	var lastId int64
+	err := w.DB.QueryRow(ctx, "select lastId from watermarks where source = $1", source).Scan(&lastId)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+
+	return lastId, err
+}
+
+// Lock locks watermark of source in transaction with FOR UPDATE SKIP LOCKED and returns
+// its id, so source which is processed by other instance is skipped. False is returned
+// if watermark is locked already
+func (w Watermarks) Lock(ctx context.Context, source string) (int64, bool, error) {
+	if _, err := w.DB.Exec(ctx, "insert into watermarks (source) values ($1) on conflict do nothing", source); err != nil {
+		return 0, false, err
+	}
+	var lastId int64
+	err := w.DB.QueryRow(ctx, "select lastId from watermarks where source = $1 for update skip locked", source).Scan(&lastId)
+	if err == pgx.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return lastId, true, nil
+}
+
+// Advance moves watermark of source forward to id, watermark never moves back. Source
+// is ready since it caught up with its table once
+func (w Watermarks) Advance(ctx context.Context, source string, id int64, caughtUp bool) error {
+	_, err := w.DB.Exec(
+		ctx,
+		"insert into watermarks (source, lastId, ready) values ($1, $2, $3) on conflict (source)"+
+			" do update set lastId = greatest(watermarks.lastId, excluded.lastId), ready = watermarks.ready or excluded.ready,"+
+			" updatedAt = now() at time zone 'utc'",
+		source, id, caughtUp,
+	)
+
+	return err
+}
+
+// Next returns batch of at most limit rows of table with ids greater than after
+func Next(ctx context.Context, conn connector.Conn, table string, after int64, limit int) (Batch, error) {
+	batch := Batch{}
+	err := conn.QueryRow(
+		ctx,
+		fmt.Sprintf("select count(*), coalesce(max(id), $1) from (select id from %s where id > $1 order by id limit $2) B", pgx.Identifier{table}.Sanitize()),
+		after, limit,
+	).Scan(&batch.Count, &batch.LastId)
+
+	return batch, err
+}
+
+// Behind returns id after which rows are processed again, it is lag rows behind watermark
+func Behind(lastId int64, lag int) int64 {
+	if after := lastId - int64(lag); after > 0 {
+		return after
+	}
+	return 0
+}
+
+// Scan processes rows from lag behind watermark by batches of size while batches are
+// full. Process handles rows with ids greater than after and returns their batch,
+// advance moves watermark to the last row of every batch
+func Scan(
+	ctx context.Context, lastId int64, size, lag int,
+	process func(ctx context.Context, after int64) (Batch, error),
+	advance func(ctx context.Context, id int64, caughtUp bool) error,
+) error {
+	after := Behind(lastId, lag)
+	for ctx.Err() == nil {
+		batch, err := process(ctx, after)
+		if err != nil {
+			return err
+		}
+		// Watermark never moves back, so batches of lag rows keep it
+		caughtUp := batch.Count < size
+		if err := advance(ctx, batch.LastId, caughtUp); err != nil {
+			return err
+		}
+		after = batch.LastId
+		if caughtUp {
+			break
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"Muromachi/logging"
+	"context"
+	"time"
+)
+
+// Clock returns current time of worker, nil Clock uses time.Now, so tests
+// can set time of workers
+type Clock func() time.Time
+
+// UTC returns current time of clock in UTC
+func (c Clock) UTC() time.Time {
+	if c != nil {
+		return c().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// Run leases work at start and then every interval until ctx is done. Lease returns
+// count of leased items, full batch means that there can be more due items, so lease
+// is called again at once. Errors are logged with name of worker and do not stop it
+func Run(ctx context.Context, name string, interval time.Duration, batchSize int, lease func(ctx context.Context) (int, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			count, err := lease(ctx)
+			if err != nil && ctx.Err() == nil {
+				logging.Errorf("%s: %v", name, err)
+			}
+			if err != nil || count < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
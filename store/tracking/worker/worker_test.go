@@ -0,0 +1,101 @@
+package worker_test
+
+import (
+	"Muromachi/store/tracking/worker"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestClock_ShouldReturnTimeInUTC(t *testing.T) {
+	date := time.Date(2021, 1, 18, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	clock := worker.Clock(func() time.Time { return date })
+
+	assert.Equal(t, time.UTC, clock.UTC().Location())
+	assert.True(t, date.Equal(clock.UTC()))
+	assert.Equal(t, time.UTC, worker.Clock(nil).UTC().Location())
+}
+
+func TestRun_ShouldLeaseWhileBatchesAreFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// Two full batches, the last one is not full
+	counts := []int{10, 10, 3}
+	calls := 0
+	err := worker.Run(ctx, "test", time.Hour, 10, func(ctx context.Context) (int, error) {
+		calls++
+		if calls == len(counts) {
+			cancel()
+		}
+		return counts[calls-1], nil
+	})
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRun_ShouldWaitForNextTickAfterError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := worker.Run(ctx, "test", time.Millisecond, 10, func(ctx context.Context) (int, error) {
+		calls++
+		if calls == 2 {
+			cancel()
+		}
+		return 10, errors.New("connection reset")
+	})
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestBehind(t *testing.T) {
+	assert.Equal(t, int64(90), worker.Behind(100, 10))
+	assert.Equal(t, int64(0), worker.Behind(5, 10))
+}
+
+func TestScan_ShouldProcessRowsFromLagBehindWatermarkWhileBatchesAreFull(t *testing.T) {
+	var (
+		afters   []int64
+		advances []int64
+		caughtUp bool
+	)
+	// Rows 1..25 by batches of 10, watermark is 15 and lag is 5
+	err := worker.Scan(context.Background(), 15, 10, 5,
+		func(ctx context.Context, after int64) (worker.Batch, error) {
+			afters = append(afters, after)
+			last := after + 10
+			if last > 25 {
+				last = 25
+			}
+			return worker.Batch{Count: int(last - after), LastId: last}, nil
+		},
+		func(ctx context.Context, id int64, ready bool) error {
+			advances = append(advances, id)
+			caughtUp = ready
+			return nil
+		},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{10, 20}, afters)
+	assert.Equal(t, []int64{20, 25}, advances)
+	assert.True(t, caughtUp)
+}
+
+func TestScan_ShouldNotAdvanceAfterError(t *testing.T) {
+	advanced := false
+	err := worker.Scan(context.Background(), 0, 10, 5,
+		func(ctx context.Context, after int64) (worker.Batch, error) {
+			return worker.Batch{}, errors.New("connection reset")
+		},
+		func(ctx context.Context, id int64, ready bool) error {
+			advanced = true
+			return nil
+		},
+	)
+
+	assert.EqualError(t, err, "connection reset")
+	assert.False(t, advanced)
+}
@@ -16,7 +16,6 @@ import (
 
 func TestRefreshRepo_New(t *testing.T) {
 	cfg := config.New("../../../../config/dev.yml")
-	cfg.Database.Schema = "../../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users")
@@ -89,7 +88,6 @@ func TestRefreshRepo_New(t *testing.T) {
 
 func TestRefreshRepo_Get(t *testing.T) {
 	cfg := config.New("../../../../config/dev.yml")
-	cfg.Database.Schema = "../../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users", "refresh_sessions")
@@ -173,7 +171,6 @@ func TestRefreshRepo_Get(t *testing.T) {
 
 func TestRefreshRepo_Remove(t *testing.T) {
 	cfg := config.New("../../../../config/dev.yml")
-	cfg.Database.Schema = "../../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users", "refresh_sessions")
@@ -250,7 +247,6 @@ func TestRefreshRepo_Remove(t *testing.T) {
 
 func TestRefreshRepo_RemoveBatch(t *testing.T) {
 	cfg := config.New("../../../../config/dev.yml")
-	cfg.Database.Schema = "../../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users", "refresh_sessions")
@@ -311,7 +307,6 @@ func TestRefreshRepo_RemoveBatch(t *testing.T) {
 
 func TestRefreshRepo_UserSessions(t *testing.T) {
 	cfg := config.New("../../../../config/dev.yml")
-	cfg.Database.Schema = "../../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users", "refresh_sessions")
@@ -135,3 +135,45 @@ func (m mockUserApproveConnectionError) QueryFunc(ctx context.Context, sql strin
 	return nil, nil
 }
 
+//
+// List, Disable and RotateSecret methods
+//
+type mockUserManageRowSuccess struct {
+}
+
+func (m mockUserManageRowSuccess) Scan(dest ...interface{}) error {
+	*dest[0].(*int) = 1
+	for _, d := range dest[1:] {
+		switch v := d.(type) {
+		case *string:
+			*v = "123"
+		case *time.Time:
+			*v = time.Now()
+		}
+	}
+
+	return nil
+}
+
+type mockUserManageConnectionSuccess struct {
+}
+
+func (m mockUserManageConnectionSuccess) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return nil, nil
+}
+
+func (m mockUserManageConnectionSuccess) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return mockUserManageRowSuccess{}
+}
+
+func (m mockUserManageConnectionSuccess) QueryFunc(ctx context.Context, sql string, args []interface{}, scans []interface{}, f func(pgx.QueryFuncRow) error) (pgconn.CommandTag, error) {
+	for i := 0; i < 3; i++ {
+		_ = mockUserManageRowSuccess{}.Scan(scans...)
+		*scans[0].(*int) = i + 1
+		if err := f(nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
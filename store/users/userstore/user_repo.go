@@ -4,6 +4,7 @@ import (
 	"Muromachi/store/connector"
 	"Muromachi/store/entities"
 	"context"
+	"github.com/jackc/pgx/v4"
 	"time"
 )
 
@@ -12,6 +13,12 @@ type UsersRepo interface {
 	Create(ctx context.Context, user entities.User) (entities.User, error)
 	// Check if user exists by clientId
 	Approve(ctx context.Context, clientId string) (entities.User, error)
+	// Get all users
+	List(ctx context.Context) ([]entities.User, error)
+	// Forbid user to authorize with his credentials
+	Disable(ctx context.Context, clientId string) (entities.User, error)
+	// Generate new client secret for user
+	RotateSecret(ctx context.Context, clientId string) (entities.User, error)
 }
 
 type UserRepo struct {
@@ -52,7 +59,7 @@ func (u *UserRepo) Create(ctx context.Context, user entities.User) (entities.Use
 func (u *UserRepo) Approve(ctx context.Context, clientId string) (entities.User, error) {
 	row := u.conn.QueryRow(
 		ctx,
-		"select * from users where clientId = $1",
+		"select id, clientId, clientSecret, company, addedAt from users where clientId = $1 and not disabled",
 		clientId,
 	)
 	var user entities.User
@@ -68,6 +75,71 @@ func (u *UserRepo) Approve(ctx context.Context, clientId string) (entities.User,
 	return user, nil
 }
 
+// Get all users ordered by id. Client secrets are not returned
+func (u *UserRepo) List(ctx context.Context) ([]entities.User, error) {
+	var (
+		user  entities.User
+		users []entities.User
+	)
+	_, err := u.conn.QueryFunc(
+		ctx,
+		"select id, clientId, company, addedAt, disabled from users order by id",
+		nil,
+		[]interface{}{&user.ID, &user.ClientId, &user.Company, &user.AddedAt, &user.Disabled},
+		func(row pgx.QueryFuncRow) error {
+			users = append(users, user)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Mark user with given clientId as disabled. Disabled user can not be approved
+func (u *UserRepo) Disable(ctx context.Context, clientId string) (entities.User, error) {
+	row := u.conn.QueryRow(
+		ctx,
+		"update users set disabled = true where clientId = $1 returning id, clientId, company, addedAt",
+		clientId,
+	)
+	user := entities.User{Disabled: true}
+	if err := row.Scan(&user.ID, &user.ClientId, &user.Company, &user.AddedAt); err != nil {
+		return entities.User{}, err
+	}
+
+	return user, nil
+}
+
+// Replace client secret of user with new generated one
+//
+// The returned user contains not hashed new client secret
+func (u *UserRepo) RotateSecret(ctx context.Context, clientId string) (entities.User, error) {
+	user := entities.User{ClientId: clientId}
+	if err := user.GenerateSecrets(); err != nil {
+		return entities.User{}, err
+	}
+	// GenerateSecrets creates new client id too, but we should keep the old one
+	user.ClientId = clientId
+	secret, err := user.SecureSecret()
+	if err != nil {
+		return entities.User{}, err
+	}
+	row := u.conn.QueryRow(
+		ctx,
+		"update users set clientSecret = $1 where clientId = $2 returning id, company, addedAt",
+		user.ClientSecret, clientId,
+	)
+	if err = row.Scan(&user.ID, &user.Company, &user.AddedAt); err != nil {
+		return entities.User{}, err
+	}
+
+	user.ClientSecret = secret
+	return user, nil
+}
+
 func NewUserRepo(conn connector.Conn) *UserRepo {
 	return &UserRepo{
 		conn: conn,
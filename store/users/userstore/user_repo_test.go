@@ -33,7 +33,6 @@ func TestUserRepo_Create_ShouldCreateNewUserAndPutItToDatabase_Mock(t *testing.T
 
 func TestUserRepo_Create_ShouldCreateNewUserAndPutItToDatabase(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users")
@@ -72,7 +71,6 @@ func TestUserRepo_Create_ShouldReturnErrorBecauseUserHasNotClientIdAndSecret_Moc
 
 func TestUserRepo_Create_ShouldReturnErrorBecauseUserHasNotClientIdAndSecret(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users")
@@ -107,7 +105,6 @@ func TestUserRepo_Create_ShouldReturnErrorIfCanNotCreateUser_Mock(t *testing.T)
 
 func TestUserRepo_Create_ShouldReturnErrorIfCanNotCreateUser(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users")
@@ -141,7 +138,6 @@ func TestUserRepo_Approve_ShouldGetUserFromDatabase_Mock(t *testing.T) {
 
 func TestUserRepo_Approve_ShouldGetUserFromDatabase(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, cleaner := testhelpers.RealDb(cfg.Database)
 	defer cleaner("users")
@@ -179,7 +175,6 @@ func TestUserRepo_Approve_ShouldReturnErrorIfClientIdDoesNotExist_Mock(t *testin
 
 func TestUserRepo_Approve_ShouldReturnErrorIfClientIdDoesNotExist(t *testing.T) {
 	cfg := config.New("../../../config/dev.yml")
-	cfg.Database.Schema = "../../../config/schema.sql"
 
 	conn, _ := testhelpers.RealDb(cfg.Database)
 	repo := user2.NewUserRepo(conn)
@@ -189,3 +184,74 @@ func TestUserRepo_Approve_ShouldReturnErrorIfClientIdDoesNotExist(t *testing.T)
 	assert.Error(t, err)
 	assert.Equal(t, pgx.ErrNoRows, err)
 }
+
+func TestUserRepo_List_ShouldReturnAllUsers_Mock(t *testing.T) {
+	conn := mockUserManageConnectionSuccess{}
+	repo := user2.NewUserRepo(conn)
+	ctx := context.Background()
+
+	users, err := repo.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(users))
+	assert.Equal(t, 3, users[2].ID)
+	assert.Empty(t, users[0].ClientSecret)
+}
+
+func TestUserRepo_Disable_ShouldMarkUserAsDisabled_Mock(t *testing.T) {
+	conn := mockUserManageConnectionSuccess{}
+	repo := user2.NewUserRepo(conn)
+	ctx := context.Background()
+
+	user, err := repo.Disable(ctx, "123")
+	assert.NoError(t, err)
+	assert.True(t, user.Disabled)
+	assert.Equal(t, 1, user.ID)
+}
+
+func TestUserRepo_Disable_ShouldReturnErrorIfClientIdDoesNotExist_Mock(t *testing.T) {
+	conn := mockUserApproveConnectionError{}
+	repo := user2.NewUserRepo(conn)
+	ctx := context.Background()
+
+	_, err := repo.Disable(ctx, "nudopustim1")
+	assert.Equal(t, pgx.ErrNoRows, err)
+}
+
+func TestUserRepo_RotateSecret_ShouldReturnNewSecret_Mock(t *testing.T) {
+	conn := mockUserManageConnectionSuccess{}
+	repo := user2.NewUserRepo(conn)
+	ctx := context.Background()
+
+	user, err := repo.RotateSecret(ctx, "client")
+	assert.NoError(t, err)
+	assert.Equal(t, "client", user.ClientId)
+	assert.NotEmpty(t, user.ClientSecret)
+}
+
+func TestUserRepo_DisableAndRotateSecret(t *testing.T) {
+	cfg := config.New("../../../config/dev.yml")
+
+	conn, cleaner := testhelpers.RealDb(cfg.Database)
+	defer cleaner("users")
+	repo := user2.NewUserRepo(conn)
+	ctx := context.Background()
+
+	user := entities.User{Company: "123"}
+	_ = user.GenerateSecrets()
+	user, err := repo.Create(ctx, user)
+	assert.NoError(t, err)
+
+	rotated, err := repo.RotateSecret(ctx, user.ClientId)
+	assert.NoError(t, err)
+	assert.NotEqual(t, user.ClientSecret, rotated.ClientSecret)
+
+	approved, err := repo.Approve(ctx, user.ClientId)
+	assert.NoError(t, err)
+	assert.NoError(t, approved.CompareSecret(rotated.ClientSecret))
+	assert.Error(t, approved.CompareSecret(user.ClientSecret))
+
+	_, err = repo.Disable(ctx, user.ClientId)
+	assert.NoError(t, err)
+	_, err = repo.Approve(ctx, user.ClientId)
+	assert.Equal(t, pgx.ErrNoRows, err)
+}
@@ -0,0 +1,52 @@
+package textdiff_test
+
+import (
+	"Muromachi/textdiff"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	var tt = []struct {
+		name     string
+		old, new string
+		expected []textdiff.Line
+	}{
+		{
+			name:     "equal texts",
+			old:      "a\nb",
+			new:      "a\r\nb",
+			expected: []textdiff.Line{{Op: textdiff.Equal, Text: "a"}, {Op: textdiff.Equal, Text: "b"}},
+		},
+		{
+			name:     "from empty text",
+			old:      "",
+			new:      "a",
+			expected: []textdiff.Line{{Op: textdiff.Insert, Text: "a"}},
+		},
+		{
+			name: "changed line in the middle",
+			old:  "Best bank\nFast payments\nSupport 24/7",
+			new:  "Best bank\nInstant payments\nCashback\nSupport 24/7",
+			expected: []textdiff.Line{
+				{Op: textdiff.Equal, Text: "Best bank"},
+				{Op: textdiff.Delete, Text: "Fast payments"},
+				{Op: textdiff.Insert, Text: "Instant payments"},
+				{Op: textdiff.Insert, Text: "Cashback"},
+				{Op: textdiff.Equal, Text: "Support 24/7"},
+			},
+		},
+		{
+			name:     "removed tail",
+			old:      "a\nb\nc",
+			new:      "a",
+			expected: []textdiff.Line{{Op: textdiff.Equal, Text: "a"}, {Op: textdiff.Delete, Text: "b"}, {Op: textdiff.Delete, Text: "c"}},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, textdiff.Lines(test.old, test.new))
+		})
+	}
+}